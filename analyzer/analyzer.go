@@ -1,257 +1,1309 @@
 package analyzer
 
 import (
+	"context"
 	"errors"
-	"flag"
 	"go/ast"
 	"go/token"
 	"go/types"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
 
 	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/buildssa"
+	"golang.org/x/tools/go/analysis/passes/ctrlflow"
 	"golang.org/x/tools/go/analysis/passes/inspect"
 	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/ssa"
 )
 
-const FlagReportErrorInDefer = "report-error-in-defer"
+const (
+	FlagReportErrorInDefer = "report-error-in-defer"
+	FlagIgnoreNolint       = "ignore-nolint"
+	FlagMinReturns         = "min-returns"
+	FlagMode               = "mode"
+	FlagErrorConvention    = "error-convention"
+	FlagErrorName          = "error-name"
+	FlagDeferErrorHandler  = "defer-error-handler"
+	FlagReportUnusedNames  = "report-unused-names"
+	FlagReportInconsistent = "report-inconsistent-names"
+	FlagBoolConvention     = "bool-convention"
+	FlagBoolNames          = "bool-names"
+	FlagInterfaceNames     = "interface-names"
+	FlagDocResults         = "doc-results"
+	FlagDisable            = "disable"
+	FlagLocale             = "locale"
+	FlagFunc               = "func"
+	FlagGroupByFunction    = "group-by-function"
+	FlagOncePerFunction    = "once-per-function"
+	FlagExcludeFiles       = "exclude-files"
+	FlagExemptResultTypes  = "exempt-result-types"
+	FlagTargets            = "targets"
+	FlagExemptCommaOK      = "exempt-comma-ok"
+	FlagMustAssignError    = "must-assign-error"
+	FlagErrorPropagation   = "error-propagation"
+	FlagPanicRecover       = "panic-recover"
+	FlagReportReordered    = "report-reordered-results"
+	FlagPreferBareReturn   = "prefer-bare-return"
+	FlagShadowedReturns    = "report-shadowed-returns"
+	FlagExemptYieldFuncs   = "exempt-yield-funcs"
+	FlagIteratorConvention = "iterator-convention"
+	FlagIteratorNames      = "iterator-names"
+	FlagExcludePresets     = "exclude-presets"
+	FlagInternalPreset     = "internal-preset"
+	FlagPublicPreset       = "public-preset"
+	FlagInternalPackages   = "internal-packages"
+	FlagExemptTestHelpers  = "exempt-test-helpers"
+)
+
+// Analyzer reports every rule, with the default configuration.
+var Analyzer = NewAnalyzer(Config{})
+
+// NewAnalyzer creates an analyzer reporting every rule, configured by cfg.
+// The configuration file and the analyzer's flags can still change settings,
+// in that order. Analyzers don't share state, so differently configured ones
+// can be used side by side.
+func NewAnalyzer(cfg Config) (a *analysis.Analyzer) {
+	a = newAnalyzer("namedreturns", "Reports functions that don't use named returns", cfg,
+		RuleUnnamedResult, RuleUnderscoreResult, RuleUnusedInReturn, RuleShadowedResult, RuleInvalidDirective, RuleErrorConvention, RuleDeferErrorHandler, RuleUnusedNames, RuleInconsistentName, RuleBoolName, RuleInterfaceName, RuleDocResult, RuleDroppedError, RuleUnrecoveredPanic, RuleReorderedResults, RulePreferBareReturn, RuleShadowedReturn, RuleIteratorName)
+	return a
+}
+
+// The sub-checks of Analyzer, each available as an analyzer of its own so they
+// can be run and configured independently, e.g. the shadowing check in one
+// pipeline and the naming checks in another. Together they report exactly
+// what Analyzer reports, along with what Propagation alone can.
+var (
+	// Unnamed reports unnamed results.
+	Unnamed = newAnalyzer("namedreturns_unnamed", "Reports function results that are unnamed", Config{},
+		RuleUnnamedResult)
+
+	// Underscore reports results named _.
+	Underscore = newAnalyzer("namedreturns_underscore", "Reports function results named _", Config{},
+		RuleUnderscoreResult)
+
+	// Naming reports, when enabled, error, boolean and iterator results
+	// breaking their conventions, names deviating from the rest of the package and from the
+	// interfaces a method implements, and names missing from doc comments.
+	Naming = newAnalyzer("namedreturns_naming", "Reports result names breaking the naming conventions", Config{},
+		RuleErrorConvention, RuleInconsistentName, RuleBoolName, RuleInterfaceName, RuleDocResult, RuleIteratorName)
+
+	// Usage reports return statements that don't return the named results,
+	// and, when enabled, names that are never used, named results returned
+	// in each other's places and final returns that could be bare.
+	Usage = newAnalyzer("namedreturns_usage", "Reports return statements that don't return the named result variables", Config{},
+		RuleUnusedInReturn, RuleUnusedNames, RuleReorderedResults, RulePreferBareReturn)
+
+	// Shadowing reports local declarations shadowing named results, and,
+	// when enabled, returns of the shadowing variables.
+	Shadowing = newAnalyzer("namedreturns_shadowing", "Reports named result variables shadowed by local declarations", Config{},
+		RuleShadowedResult, RuleShadowedReturn)
+
+	// DeferErrors reports, when enabled, named errors that deferred cleanup
+	// doesn't handle and panics no deferred recover converts into them.
+	DeferErrors = newAnalyzer("namedreturns_defer", "Reports named errors deferred cleanup and recovery don't handle", Config{},
+		RuleDeferErrorHandler, RuleUnrecoveredPanic)
+
+	// Flow reports, when enabled, bare returns leaving the named error
+	// unassigned after dropping the error of a call. Unlike Analyzer, which
+	// builds the control flow graphs itself to keep working on packages with
+	// type errors, it takes them from the ctrlflow analyzer, which knows the
+	// functions of other packages that never return.
+	Flow = require(newAnalyzer("namedreturns_flow", "Reports bare returns leaving the named error unassigned after a dropped error", Config{},
+		RuleDroppedError), ctrlflow.Analyzer)
 
-var Analyzer = &analysis.Analyzer{
-	Name:     "namedreturns",
-	Doc:      "Reports functions that don't use named returns",
-	Flags:    flags(),
-	Run:      run,
-	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	// Propagation reports, when enabled, errors of calls stored in
+	// variables that never reach a return of a function with a named error.
+	// It follows the errors through the SSA form of the functions, which
+	// takes complete type information to build, so Analyzer leaves it out.
+	Propagation = NewPropagation(Config{})
+)
+
+// NewPropagation creates an analyzer reporting what Propagation does,
+// configured by cfg like NewAnalyzer. Drivers run it along with an analyzer
+// of NewAnalyzer when error-propagation is set.
+func NewPropagation(cfg Config) (a *analysis.Analyzer) {
+	a = require(newAnalyzer("namedreturns_propagation", "Reports errors of calls that never reach a return", cfg,
+		RuleUnpropagatedError), buildssa.Analyzer)
+	return a
 }
 
-func flags() (fs flag.FlagSet) {
-	fs = flag.FlagSet{}
-	fs.Bool(FlagReportErrorInDefer, false, "report named error if it is assigned inside defer")
-	return
+// newAnalyzer creates an analyzer reporting only the given rules, starting
+// from the settings in defaults.
+func newAnalyzer(name string, doc string, defaults Config, ruleIDs ...string) (a *analysis.Analyzer) {
+	enabled := make(map[string]bool, len(ruleIDs))
+	for _, id := range ruleIDs {
+		enabled[id] = true
+	}
+
+	// Implementations are compared with the interfaces of other packages
+	// through the facts about them
+	requires := []*analysis.Analyzer{findingsAnalyzer}
+	if enabled[RuleInterfaceName] {
+		requires = append(requires, Facts)
+	}
+
+	s, fs := newSettings(defaults)
+	a = &analysis.Analyzer{
+		Name:  name,
+		Doc:   doc,
+		Flags: fs,
+		// The checks are syntactic at heart and degrade gracefully without
+		// complete type information, e.g. for unsaved editor buffers
+		RunDespiteErrors: true,
+		Requires:         requires,
+		ResultType:       reflect.TypeOf((*Result)(nil)),
+	}
+	s.run = func(ctx context.Context, pass *analysis.Pass) (result interface{}, err error) {
+		result, err = run(ctx, pass, s, enabled)
+		return result, err
+	}
+	a.Run = func(pass *analysis.Pass) (result interface{}, err error) {
+		result, err = s.run(context.Background(), pass)
+		return result, err
+	}
+	return a
 }
 
-func run(pass *analysis.Pass) (result interface{}, err error) {
-	reportErrorInDefer := pass.Analyzer.Flags.Lookup(FlagReportErrorInDefer).Value.String() == "true"
-	errorType := types.Universe.Lookup("error").Type()
+// WithContext returns a copy of a, one of the analyzers of this package,
+// that stops once ctx is done: each package it is run on fails with the
+// error of ctx, whether it is done before the package is analyzed or while
+// the functions of its files are. The copy shares the settings and flags of
+// a, which ResolveConfig and ConfigFiles take it for, but not its identity:
+// it must not be mixed with a in the same analysis. Analyzers not of
+// this package are returned as they are.
+func WithContext(ctx context.Context, a *analysis.Analyzer) (bound *analysis.Analyzer) {
+	s, ok := lookupSettings(a)
+	if !ok {
+		bound = a
+		return bound
+	}
+
+	copied := *a
+	copied.Run = func(pass *analysis.Pass) (result interface{}, err error) {
+		result, err = s.run(ctx, pass)
+		return result, err
+	}
+	bound = &copied
+	return bound
+}
+
+// require makes a depend on the given analyzers too, whose results the checks
+// use when they are available.
+func require(a *analysis.Analyzer, analyzers ...*analysis.Analyzer) (required *analysis.Analyzer) {
+	a.Requires = append(a.Requires, analyzers...)
+	required = a
+	return required
+}
+
+// Result is the result of the analyzer for one package. It counts, per file,
+// the analyzed functions that declare results and how many of them name all of
+// their results, which is what compliance statistics are computed from.
+type Result struct {
+	Files map[string]*FileStats
+}
+
+// FileStats counts the functions with results declared in one file.
+type FileStats struct {
+	Functions  int // functions and function literals with at least one result
+	FullyNamed int // of those, the ones with no unnamed or underscore results
+}
+
+func run(ctx context.Context, pass *analysis.Pass, s *settings, enabled map[string]bool) (result interface{}, err error) {
+	err = ctx.Err()
+	if err != nil {
+		return result, err
+	}
+
+	found, ok := pass.ResultOf[findingsAnalyzer].(*packageFindings)
+	if !ok {
+		err = errors.New("failed to get findings")
+		return result, err
+	}
+
+	var cfg Config
+	cfg, err = s.resolve(pass)
+	if err != nil {
+		return result, err
+	}
+
+	// The checks use the results of the other analyzers this one requires
+	var inputs checkInputs
+	inputs.interfaces, _ = pass.ResultOf[Facts].(InterfaceResults)
+	inputs.cfgs, _ = pass.ResultOf[ctrlflow.Analyzer].(*ctrlflow.CFGs)
+	inputs.ssaResult, _ = pass.ResultOf[buildssa.Analyzer].(*buildssa.SSA)
+
+	var checked *checkedPackage
+	checked, err = found.check(ctx, cfg, inputs)
+	if err != nil {
+		return result, err
+	}
+
+	// Of the findings of all rules, report those of the rules this analyzer
+	// reports that no nolint directive naming it suppresses
+	suppressed := make(suppressions)
+	if !checked.cfg.IgnoreNolint {
+		addNolint(pass, suppressed, "namedreturns", pass.Analyzer.Name)
+	}
+	fset := pass.Fset
+	keep := func(d analysis.Diagnostic) (kept bool) {
+		kept = enabled[d.Category] && !suppressed.covers(fset, d)
+		return kept
+	}
+
+	// Findings are reported in an order that doesn't depend on how they
+	// are found, grouped or limited by function once all are known
+	var reported []analysis.Diagnostic
+	var groups *findingGroups
+	if checked.cfg.GroupByFunction || checked.cfg.OncePerFunction {
+		groups = newFindingGroups(fset, keep)
+	}
+	for _, f := range checked.findings {
+		switch {
+		case groups != nil && f.funcName != "":
+			groups.add(f.funcName, f.Diagnostic)
+		case keep(f.Diagnostic):
+			reported = append(reported, f.Diagnostic)
+		}
+	}
+	if groups != nil {
+		for _, group := range groups.order {
+			if !checked.cfg.GroupByFunction {
+				reported = append(reported, groups.mostSevere(group))
+				continue
+			}
+
+			msgs := checked.msgs
+			if isTestFile(fset, group.findings[0].Pos) {
+				msgs = checked.testMsgs
+			}
+			reported = append(reported, groups.grouped(group, msgs))
+		}
+	}
+
+	sortDiagnostics(fset, reported)
+	for _, d := range reported {
+		pass.Report(d)
+	}
+
+	result = checked.stats
+	return result, err
+}
+
+// checkedPackage is what checking a package under one configuration found,
+// for every rule.
+type checkedPackage struct {
+	cfg      Config // the settings, adjusted by the package's own directives
+	msgs     catalog
+	testMsgs catalog   // in effect in test files
+	findings []finding // in the order they were found
+	stats    *Result
+}
+
+// finding is a diagnostic along with the function it is about.
+type finding struct {
+	analysis.Diagnostic
+	funcName string // empty for the findings about directives
+}
+
+// checkPackage checks the functions of the package of pass for every rule
+// under cfg, with what inputs holds. The findings the settings disable,
+// those in excluded files or outside the selected functions, and those the
+// package's directives suppress are dropped.
+func checkPackage(ctx context.Context, pass *analysis.Pass, cfg Config, inputs checkInputs) (checked *checkedPackage, err error) {
+	// The package may adjust its own settings. Their errors are in the
+	// locale in effect before
+	var msgs catalog
+	msgs, err = lookupCatalog(cfg.Locale)
+	if err != nil {
+		return checked, err
+	}
+	directiveErrors := applyPackageConfig(pass, msgs, &cfg)
+
+	var testCfg Config
+	testCfg, err = cfg.forTests()
+	if err != nil {
+		return checked, err
+	}
+
+	var testMsgs catalog
+	msgs, err = lookupCatalog(cfg.Locale)
+	if err == nil {
+		testMsgs, err = lookupCatalog(testCfg.Locale)
+	}
+	if err != nil {
+		return checked, err
+	}
+
+	sel := selectFuncs(pass, cfg, testCfg)
+
+	var templates messageTemplates
+	templates, err = parseMessages(cfg.Messages)
+	if err != nil {
+		return checked, err
+	}
+
+	// Excluded files are neither checked nor reported on
+	excluded := make(map[*token.File]bool)
+	for _, file := range pass.Files {
+		tokenFile := pass.Fset.File(file.Pos())
+		fileCfg := cfg
+		if isTestFile(pass.Fset, file.Pos()) {
+			fileCfg = testCfg
+		}
+		if tokenFile != nil && (fileCfg.ExcludesFile(tokenFile.Name()) || fileCfg.ExcludesGenerated(file)) {
+			excluded[tokenFile] = true
+		}
+	}
+
+	// Drop the findings of rules the settings disable, those in excluded
+	// files or outside the selected functions, and the suppressed ones
+	suppressed := make(suppressions)
+	fset := pass.Fset
+	keep := func(d analysis.Diagnostic) (kept bool) {
+		disabled := cfg.disabled(d.Category)
+		if isTestFile(fset, d.Pos) {
+			disabled = testCfg.disabled(d.Category)
+		}
+		kept = !disabled && !excluded[fset.File(d.Pos)] && sel.contains(d.Pos) && !suppressed.covers(fset, d)
+		return kept
+	}
+	var found []finding
+	record := func(d analysis.Diagnostic, funcName string) {
+		if keep(d) {
+			found = append(found, finding{Diagnostic: d, funcName: funcName})
+		}
+	}
+	filtered := *pass
+	filtered.Report = func(d analysis.Diagnostic) {
+		// The other rules' findings are rendered where they are found,
+		// with what they concern
+		if d.Category == RuleInvalidDirective {
+			templates.render(&d, Message{})
+		}
+		record(d, "")
+	}
+	pass = &filtered
+
+	for _, d := range directiveErrors {
+		pass.Report(d)
+	}
+	addDisableFile(pass, msgs, suppressed)
+	addIgnores(pass, msgs, suppressed)
+	options := funcOptions(pass, msgs)
 
 	inspector, ok := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
 	if !ok {
 		err = errors.New("failed to get inspector")
-		return result, err
+		return checked, err
 	}
 
-	// only filter function defintions
+	// Function declarations and literals, along with the nodes checked
+	// within their bodies
 	nodeFilter := []ast.Node{
 		(*ast.FuncDecl)(nil),
 		(*ast.FuncLit)(nil),
+		(*ast.ReturnStmt)(nil),
+		(*ast.AssignStmt)(nil),
+		(*ast.ValueSpec)(nil),
+		(*ast.RangeStmt)(nil),
+		(*ast.ForStmt)(nil),
+		(*ast.DeferStmt)(nil),
 	}
 
-	inspector.Preorder(nodeFilter, func(node ast.Node) {
-		var funcResults *ast.FieldList
-		var funcBody *ast.BlockStmt
+	c := &checker{
+		pass:       pass,
+		cfg:        cfg,
+		testCfg:    testCfg,
+		msgs:       msgs,
+		testMsgs:   testMsgs,
+		templates:  templates,
+		options:    options,
+		exemptions: registeredExemptions(),
+		selection:  sel,
+		interfaces: newInterfaceIndex(inputs.interfaces),
+		lookup:     newTypeCache(pass.TypesInfo),
+		stats:      &Result{Files: make(map[string]*FileStats)},
+		record:     record,
+		skipped:    make(map[*token.File]bool),
+		cfgs:       inputs.cfgs,
+	}
+	if cfg.ReportInconsistent {
+		c.tally = newNameTally(pass.Pkg)
+	}
+	if inputs.ssaResult != nil {
+		c.ssaFuncs = ssaFuncsBySyntax(inputs.ssaResult.SrcFuncs)
+	}
+	for _, file := range pass.Files {
+		if cgoGenerated(pass.Fset, file) {
+			c.skipped[pass.Fset.File(file.Pos())] = true
+		}
+	}
+	for tokenFile := range excluded {
+		c.skipped[tokenFile] = true
+	}
 
+	// A single traversal collects what each function needs checked, which
+	// is checked once the traversal leaves the function. Files can be huge,
+	// so the traversal stops, skipping the rest, as soon as ctx is done
+	inspector.WithStack(nodeFilter, func(node ast.Node, push bool, stack []ast.Node) (proceed bool) {
+		if err != nil {
+			return proceed
+		}
+		proceed = true
 		switch n := node.(type) {
-		case *ast.FuncLit:
-			funcResults = n.Type.Results
-			funcBody = n.Body
-		case *ast.FuncDecl:
-			funcResults = n.Type.Results
-			funcBody = n.Body
-		default:
-			return
+		case *ast.FuncDecl, *ast.FuncLit:
+			if push {
+				if _, isDecl := n.(*ast.FuncDecl); isDecl {
+					err = ctx.Err()
+					if err != nil {
+						proceed = false
+						return proceed
+					}
+				}
+				c.enter(n, stack[len(stack)-2])
+			} else {
+				c.leave()
+			}
+			return proceed
 		}
 
-		// Function without body, ex: https://github.com/golang/go/blob/master/src/internal/syscall/unix/net.go
-		if funcBody == nil {
-			return
+		if push && len(c.frames) > 0 {
+			c.collect(node, stack)
 		}
+		return proceed
+	})
 
-		// no return values - this is fine, no report needed
-		if funcResults == nil {
-			return
+	if err != nil {
+		return checked, err
+	}
+
+	// Names are only consistent or not across the whole package
+	if c.tally != nil {
+		c.tally.report(c)
+	}
+
+	checked = &checkedPackage{cfg: cfg, msgs: msgs, testMsgs: testMsgs, findings: found, stats: c.stats}
+	return checked, err
+}
+
+// sortDiagnostics orders diagnostics by file, position, rule and message.
+func sortDiagnostics(fset *token.FileSet, diagnostics []analysis.Diagnostic) {
+	sort.SliceStable(diagnostics, func(i int, j int) (less bool) {
+		a, b := fset.Position(diagnostics[i].Pos), fset.Position(diagnostics[j].Pos)
+		switch {
+		case a.Filename != b.Filename:
+			less = a.Filename < b.Filename
+		case a.Offset != b.Offset:
+			less = a.Offset < b.Offset
+		case diagnostics[i].Category != diagnostics[j].Category:
+			less = diagnostics[i].Category < diagnostics[j].Category
+		default:
+			less = diagnostics[i].Message < diagnostics[j].Message
 		}
+		return less
+	})
+}
 
-		resultsList := funcResults.List
+// checker checks the functions of a package in a single traversal, keeping a
+// frame for each function enclosing the current node.
+type checker struct {
+	pass       *analysis.Pass
+	cfg        Config
+	testCfg    Config // in effect in test files
+	msgs       catalog
+	testMsgs   catalog // in effect in test files
+	templates  messageTemplates
+	options    map[ast.Node][]setting // of the functions with an opts directive
+	exemptions []func(*ExemptionContext) bool
+	selection  selection       // of the functions checked
+	interfaces *interfaceIndex // nil without the Facts analyzer, as with RunSyntax
+	lookup     *typeCache
+	stats      *Result
+	frames     []*funcFrame
+	skipped    map[*token.File]bool       // files whose functions aren't checked
+	tally      *nameTally                 // nil unless inconsistent names are reported
+	cfgs       *ctrlflow.CFGs             // nil unless the analyzer requires ctrlflow
+	ssaFuncs   map[ast.Node]*ssa.Function // by syntax, nil unless the analyzer requires buildssa
 
-		// Collect named return variable names
-		var namedReturnNames []string
-		for _, p := range resultsList {
-			if len(p.Names) == 0 {
-				// Report this - the parameter is not named and should be
-				pass.Reportf(node.Pos(), "unnamed return with type %q found - named returns are required", types.ExprString(p.Type))
-				continue
-			}
+	// record keeps d, a finding about the function funcName, unless the
+	// settings drop it
+	record func(d analysis.Diagnostic, funcName string)
+}
 
-			// Check each name - underscore is not an acceptable return name
-			for _, n := range p.Names {
-				if n.Name == "_" {
-					// Report this - underscore is not a proper name
-					pass.Reportf(node.Pos(), "underscore as a return variable name is unacceptable for type %q", types.ExprString(p.Type))
-					continue
-				}
+// funcFrame is what the checker collects about a function while traversing
+// its body.
+type funcFrame struct {
+	node    ast.Node // the *ast.FuncDecl or *ast.FuncLit
+	cfg     *Config  // the settings in effect in the function's file
+	msgs    catalog  // the messages in the locale of those settings
+	recv    *ast.FieldList
+	typ     *ast.FuncType
+	body    *ast.BlockStmt
+	name    string
+	checked bool // false for functions without body or results, with too few results, exempted, not selected or not targeted
 
-				// Check if this is an error return that might be exempted
-				if !reportErrorInDefer &&
-					types.Identical(pass.TypesInfo.TypeOf(p.Type), errorType) &&
-					findDeferWithVariableAssignment(funcBody, pass.TypesInfo, pass.TypesInfo.ObjectOf(n)) {
-					// This is fine - error return with defer assignment
-					continue
-				}
+	typeParams *ast.FieldList // of the function, or of the one declaring a literal
+	exempted   bool           // by an exemption rule, itself or through the function declaring it
+
+	returns       []*ast.ReturnStmt // of the function itself, not of nested literals
+	defers        []*ast.DeferStmt  // of the function itself, not of nested literals
+	shadows       []shadowSite      // declarations in the body, nested literals included
+	deferAssigned []*ast.Ident      // variables assigned in deferred function literals
+}
+
+// shadowSite is a declaration that may shadow a named result.
+type shadowSite struct {
+	ident *ast.Ident
+	kind  string // ID of the message describing the declaration
+}
 
-				// Collect named return names for later analysis
-				namedReturnNames = append(namedReturnNames, n.Name)
+// enter pushes the frame of a function, a child of parent.
+func (c *checker) enter(node ast.Node, parent ast.Node) {
+	frame := &funcFrame{node: node, cfg: &c.cfg, msgs: c.msgs}
+	if isTestFile(c.pass.Fset, node.Pos()) {
+		frame.cfg = &c.testCfg
+		frame.msgs = c.testMsgs
+	}
+	switch n := node.(type) {
+	case *ast.FuncLit:
+		frame.typ = n.Type
+		frame.body = n.Body
+		frame.name = "func literal"
+		if len(c.frames) > 0 {
+			if decl, ok := c.frames[0].node.(*ast.FuncDecl); ok {
+				frame.name += " in " + describeFuncDecl(decl)
 			}
+			frame.typeParams = c.frames[len(c.frames)-1].typeParams
+
+			// Literals take the options of the enclosing function
+			frame.cfg = c.frames[len(c.frames)-1].cfg
 		}
+	case *ast.FuncDecl:
+		frame.recv = n.Recv
+		frame.typ = n.Type
+		frame.body = n.Body
+		frame.name = describeFuncDecl(n)
+		frame.typeParams = n.Type.TypeParams
+	}
 
-		// If we have named returns, check if they're used in return statements and check for shadowing
-		if len(namedReturnNames) > 0 {
-			checkNamedReturnUsage(pass, funcBody, namedReturnNames, node.Pos())
-			checkNamedReturnShadowing(pass, funcBody, namedReturnNames)
+	if settings, ok := c.options[node]; ok {
+		opts := *frame.cfg
+		for _, s := range settings {
+			// Settings were checked when collected
+			_ = opts.Set(s.name, s.value)
 		}
-	})
+		frame.cfg = &opts
+	}
 
-	return result, err
+	// Functions without body, ex: https://github.com/golang/go/blob/master/src/internal/syscall/unix/net.go,
+	// and without results need no checks
+	frame.checked = frame.body != nil && frame.typ.Results != nil &&
+		resultCount(frame.typ.Results.List) >= frame.cfg.MinReturns &&
+		!c.skipped[c.pass.Fset.File(node.Pos())] && c.selection.contains(node.Pos()) &&
+		frame.cfg.targeted(node)
+	frame.exempted = c.exemptedByRule(frame)
+	frame.checked = frame.checked && !frame.exempted && !c.exempt(frame) &&
+		!(frame.cfg.ExemptCommaOK && commaOKPassthrough(frame.body)) &&
+		!(frame.cfg.ExemptTestHelpers && c.testHelper(frame))
+	if lit, ok := node.(*ast.FuncLit); ok && frame.cfg.ExemptYieldFuncs {
+		frame.checked = frame.checked && !c.yieldFunc(lit, parent)
+	}
+	c.frames = append(c.frames, frame)
 }
 
-// checkNamedReturnUsage analyzes the function body to see if named return variables are used in return statements
-func checkNamedReturnUsage(pass *analysis.Pass, body *ast.BlockStmt, namedReturnNames []string, funcPos token.Pos) {
-	ast.Inspect(body, func(node ast.Node) (continueInspection bool) {
-		if returnStmt, ok := node.(*ast.ReturnStmt); ok {
-			// Check if this is a bare return (no expressions)
-			if len(returnStmt.Results) == 0 {
-				// Bare return is fine when using named returns
-				continueInspection = true
-				return continueInspection
-			}
-
-			// Check if the return statement uses the named return variables
-			usedNames := make(map[string]bool)
-			for _, result := range returnStmt.Results {
-				if ident, ok := result.(*ast.Ident); ok {
-					// Check if this identifier is one of our named return variables
-					for _, namedReturn := range namedReturnNames {
-						if ident.Name == namedReturn {
-							usedNames[namedReturn] = true
-							break
-						}
-					}
+// leave checks the innermost function, if the mode selects it, and pops its
+// frame.
+func (c *checker) leave() {
+	frame := c.frames[len(c.frames)-1]
+	c.frames = c.frames[:len(c.frames)-1]
+	if frame.checked && c.selected(frame) {
+		c.check(frame)
+	}
+}
+
+// selected reports whether the mode requires the function of frame to name
+// its results.
+func (c *checker) selected(frame *funcFrame) (selected bool) {
+	switch frame.cfg.Mode {
+	case ModeDefers:
+		selected = len(frame.defers) > 0
+	case ModeErrors:
+		for _, field := range frame.typ.Results.List {
+			if c.lookup.isErrorType(field.Type, frame.typeParams) {
+				selected = true
+				break
+			}
+		}
+	case ModeAmbiguous:
+		selected = c.ambiguousResults(frame.typ.Results)
+	default:
+		selected = true
+	}
+	return selected
+}
+
+// ambiguousResults reports whether two or more of results have the same
+// underlying type. Type parameters are told apart by themselves, and types
+// by their syntax when type information is missing.
+func (c *checker) ambiguousResults(results *ast.FieldList) (ambiguous bool) {
+	var seen []types.Type
+	var seenSyntax []string
+	for _, field := range results.List {
+		for range max(len(field.Names), 1) {
+			t := c.pass.TypesInfo.TypeOf(field.Type)
+			if t == nil {
+				s := types.ExprString(field.Type)
+				if slices.Contains(seenSyntax, s) {
+					ambiguous = true
+					return ambiguous
 				}
+				seenSyntax = append(seenSyntax, s)
+				continue
 			}
 
-			// Report on named return variables that are declared but not used in this return statement
-			for _, namedReturn := range namedReturnNames {
-				if !usedNames[namedReturn] {
-					pass.Reportf(funcPos, "named return variable %q is declared but not used in return statement", namedReturn)
+			if _, ok := t.(*types.TypeParam); !ok {
+				t = t.Underlying()
+			}
+			for _, other := range seen {
+				if types.Identical(t, other) {
+					ambiguous = true
+					return ambiguous
 				}
 			}
+			seen = append(seen, t)
 		}
-		continueInspection = true
-		return continueInspection
-	})
+	}
+	return ambiguous
 }
 
-// checkNamedReturnShadowing detects when named return variables are shadowed by local variables
-func checkNamedReturnShadowing(pass *analysis.Pass, body *ast.BlockStmt, namedReturnNames []string) {
-	ast.Inspect(body, func(node ast.Node) (continueInspection bool) {
-		// Check for variable declarations and assignments that might shadow named returns
-		switch n := node.(type) {
-		case *ast.AssignStmt:
-			// Check for := assignments that might shadow named returns
-			if n.Tok == token.DEFINE {
-				for _, lhs := range n.Lhs {
-					if ident, ok := lhs.(*ast.Ident); ok {
-						for _, namedReturn := range namedReturnNames {
-							if ident.Name == namedReturn {
-								pass.Reportf(ident.Pos(), "named return variable %q is shadowed by local variable declaration", namedReturn)
-							}
-						}
-					}
-				}
+// collect records node, found in the body of the innermost function, in the
+// frames it concerns.
+func (c *checker) collect(node ast.Node, stack []ast.Node) {
+	innermost := c.frames[len(c.frames)-1]
+	switch n := node.(type) {
+	case *ast.ReturnStmt:
+		innermost.returns = append(innermost.returns, n)
+	case *ast.DeferStmt:
+		innermost.defers = append(innermost.defers, n)
+	case *ast.AssignStmt:
+		// Check for := assignments that might shadow named returns,
+		// which in deferred function literals defeat the point of naming
+		// the results
+		deferred := inDeferredFuncLit(stack)
+		if n.Tok == token.DEFINE {
+			kind := MessageShadowLocalVariable
+			if deferred {
+				kind = MessageShadowDeferredVariable
 			}
-		case *ast.ValueSpec:
-			// Check for var declarations that might shadow named returns
-			for _, name := range n.Names {
-				for _, namedReturn := range namedReturnNames {
-					if name.Name == namedReturn {
-						pass.Reportf(name.Pos(), "named return variable %q is shadowed by local variable declaration", namedReturn)
-					}
+			for _, lhs := range n.Lhs {
+				if ident, ok := lhs.(*ast.Ident); ok {
+					c.addShadow(ident, kind)
 				}
 			}
-		case *ast.RangeStmt:
-			// Check for range loop variables that might shadow named returns
-			if ident, ok := n.Key.(*ast.Ident); ok {
-				for _, namedReturn := range namedReturnNames {
-					if ident.Name == namedReturn {
-						pass.Reportf(ident.Pos(), "named return variable %q is shadowed by range loop variable", namedReturn)
+		}
+
+		// Named results assigned in a deferred function literal may be
+		// exempt from the usage check in every enclosing function. The
+		// variables the literal declares are its own, though
+		if deferred && n.Tok != token.DEFINE {
+			for _, lhs := range n.Lhs {
+				if ident, ok := lhs.(*ast.Ident); ok && !c.declaredInLiteral(ident, stack) {
+					for _, frame := range c.frames {
+						frame.deferAssigned = append(frame.deferAssigned, ident)
 					}
 				}
 			}
-			if ident, ok := n.Value.(*ast.Ident); ok {
-				for _, namedReturn := range namedReturnNames {
-					if ident.Name == namedReturn {
-						pass.Reportf(ident.Pos(), "named return variable %q is shadowed by range loop variable", namedReturn)
-					}
+		}
+	case *ast.ValueSpec:
+		// Check for var declarations that might shadow named returns
+		kind := MessageShadowLocalVariable
+		if inDeferredFuncLit(stack) {
+			kind = MessageShadowDeferredVariable
+		}
+		for _, name := range n.Names {
+			c.addShadow(name, kind)
+		}
+	case *ast.RangeStmt:
+		// Check for range loop variables that might shadow named returns
+		if ident, ok := n.Key.(*ast.Ident); ok {
+			c.addShadow(ident, MessageShadowRangeVariable)
+		}
+		if ident, ok := n.Value.(*ast.Ident); ok {
+			c.addShadow(ident, MessageShadowRangeVariable)
+		}
+	case *ast.ForStmt:
+		// Check for for loop variables that might shadow named returns
+		if init, ok := n.Init.(*ast.AssignStmt); ok && init.Tok == token.DEFINE {
+			for _, lhs := range init.Lhs {
+				if ident, ok := lhs.(*ast.Ident); ok {
+					c.addShadow(ident, MessageShadowForVariable)
 				}
 			}
-		case *ast.ForStmt:
-			// Check for for loop variables that might shadow named returns
-			if forStmt, ok := n.Init.(*ast.AssignStmt); ok && forStmt.Tok == token.DEFINE {
-				for _, lhs := range forStmt.Lhs {
-					if ident, ok := lhs.(*ast.Ident); ok {
-						for _, namedReturn := range namedReturnNames {
-							if ident.Name == namedReturn {
-								pass.Reportf(ident.Pos(), "named return variable %q is shadowed by for loop variable", namedReturn)
-							}
-						}
-					}
+		}
+	}
+}
+
+// addShadow records a declaration in the frames of all enclosing functions,
+// as it may shadow the named results of any of them.
+func (c *checker) addShadow(ident *ast.Ident, kind string) {
+	for _, frame := range c.frames {
+		if frame.checked {
+			frame.shadows = append(frame.shadows, shadowSite{ident: ident, kind: kind})
+		}
+	}
+}
+
+// declaredInLiteral reports whether ident, assigned within the innermost
+// function literal of stack, refers to a variable the literal declares
+// itself. Without type information it goes by the names the literal declares
+// before ident, whatever their scope.
+func (c *checker) declaredInLiteral(ident *ast.Ident, stack []ast.Node) (declared bool) {
+	if obj := c.lookup.objectOf(ident); obj != nil {
+		for i := len(stack) - 1; i >= 0; i-- {
+			if lit, ok := stack[i].(*ast.FuncLit); ok {
+				declared = lit.Pos() <= obj.Pos() && obj.Pos() < lit.End()
+				return declared
+			}
+		}
+		return declared
+	}
+
+	var lit *ast.FuncLit
+	for i := len(stack) - 1; i >= 0 && lit == nil; i-- {
+		lit, _ = stack[i].(*ast.FuncLit)
+	}
+	if lit == nil {
+		return declared
+	}
+
+	ast.Inspect(lit, func(n ast.Node) (proceed bool) {
+		if n == nil || declared || n.Pos() >= ident.Pos() {
+			return proceed
+		}
+
+		switch n := n.(type) {
+		case *ast.Field:
+			declared = slices.ContainsFunc(n.Names, func(name *ast.Ident) (same bool) {
+				same = name.Name == ident.Name
+				return same
+			})
+		case *ast.AssignStmt:
+			for _, lhs := range n.Lhs {
+				if name, ok := lhs.(*ast.Ident); ok && n.Tok == token.DEFINE && name.Name == ident.Name {
+					declared = true
 				}
 			}
+		case *ast.ValueSpec:
+			for _, name := range n.Names {
+				declared = declared || name.Name == ident.Name
+			}
 		}
-		continueInspection = true
-		return continueInspection
+		proceed = !declared
+		return proceed
 	})
+	return declared
+}
+
+// inDeferredFuncLit reports whether the innermost node of stack lies within a
+// function literal called by a defer statement.
+func inDeferredFuncLit(stack []ast.Node) (deferred bool) {
+	for i := len(stack) - 1; i >= 2; i-- {
+		lit, ok := stack[i].(*ast.FuncLit)
+		if !ok {
+			continue
+		}
+		call, ok := stack[i-1].(*ast.CallExpr)
+		if !ok || call.Fun != lit {
+			continue
+		}
+		if d, ok := stack[i-2].(*ast.DeferStmt); ok && d.Call == call {
+			deferred = true
+			return deferred
+		}
+	}
+	return deferred
+}
+
+// check reports the findings about a function whose body was traversed.
+func (c *checker) check(frame *funcFrame) {
+	pass := c.pass
+	node := frame.node
+	funcResults := frame.typ.Results
+
+	filename := pass.Fset.Position(node.Pos()).Filename
+	fileStats, ok := c.stats.Files[filename]
+	if !ok {
+		fileStats = &FileStats{}
+		c.stats.Files[filename] = fileStats
+	}
+	fileStats.Functions++
+	fullyNamed := true
+
+	// Names suggested by fixes must not clash with each other
+	names := newNamer(frame.recv, frame.typ, frame.body)
+	var nameFix []analysis.SuggestedFix
+
+	// Collect named return variables
+	var namedReturns []*ast.Ident
+	for _, p := range funcResults.List {
+		// Results of some types may go without a name, or with _ where
+		// the others are named
+		exemptType := c.exemptResultType(frame, p.Type)
+
+		if len(p.Names) == 0 {
+			if exemptType {
+				continue
+			}
+
+			// Report this - the parameter is not named and should be.
+			// Results are either all named or all unnamed, so one fix
+			// naming them all is attached to each finding
+			if nameFix == nil {
+				nameFix = []analysis.SuggestedFix{nameResultsFix(frame.msgs, funcResults, names)}
+			}
+			d := diagnosticf(RuleUnnamedResult, node.Pos(), frame.msgs, MessageUnnamedResult, frame.name, types.ExprString(p.Type))
+			d.End = p.Type.End()
+			d.SuggestedFixes = nameFix
+			c.report(d, Message{FuncName: frame.name, Type: types.ExprString(p.Type)})
+			fullyNamed = false
+			continue
+		}
+
+		// The names of a field share its type, which is looked up once
+		isError := c.lookup.isErrorType(p.Type, frame.typeParams)
+
+		// Check each name - underscore is not an acceptable return name
+		for _, n := range p.Names {
+			if n.Name == "_" && exemptType {
+				continue
+			}
+			if n.Name == "_" {
+				// Report this - underscore is not a proper name
+				d := diagnosticf(RuleUnderscoreResult, node.Pos(), frame.msgs, MessageUnderscoreResult, frame.name, types.ExprString(p.Type))
+				d.End = n.End()
+				d.SuggestedFixes = []analysis.SuggestedFix{renameUnderscoreFix(frame.msgs, n, p.Type, names)}
+				c.report(d, Message{FuncName: frame.name, ReturnName: n.Name, Type: types.ExprString(p.Type)})
+				fullyNamed = false
+				continue
+			}
+
+			// Check if this is an error return that might be exempted
+			if !frame.cfg.ReportErrorInDefer && isError && c.lookup.assignedIn(frame.deferAssigned, n) {
+				// This is fine - error return with defer assignment
+				continue
+			}
+
+			// Collect named returns for later analysis
+			namedReturns = append(namedReturns, n)
+		}
+	}
+
+	if fullyNamed {
+		fileStats.FullyNamed++
+	}
+
+	if frame.cfg.ErrorConvention {
+		c.checkErrorConvention(frame)
+	}
+	if frame.cfg.BoolConvention {
+		c.checkBoolNames(frame)
+	}
+	if frame.cfg.IteratorConvention {
+		c.checkIteratorNames(frame)
+	}
+	if frame.cfg.InterfaceNames {
+		c.checkInterfaceNames(frame)
+	}
+	if frame.cfg.DocResults {
+		c.checkDocResults(frame)
+	}
+	if frame.cfg.DeferErrorHandler {
+		c.checkDeferErrorHandler(frame)
+	}
+	if frame.cfg.ReportUnusedNames {
+		c.checkUnusedNames(frame)
+	}
+	if frame.cfg.MustAssignError {
+		c.checkDroppedErrors(frame)
+	}
+	if frame.cfg.PanicRecover {
+		c.checkPanics(frame)
+	}
+	if frame.cfg.ReportReordered {
+		c.checkReorderedResults(frame)
+	}
+	if frame.cfg.PreferBareReturn {
+		c.checkBareReturns(frame)
+	}
+	if frame.cfg.ShadowedReturns {
+		c.checkShadowedReturns(frame)
+	}
+	if frame.cfg.ErrorPropagation && c.ssaFuncs != nil {
+		c.checkPropagation(frame)
+	}
+	if c.tally != nil {
+		c.tally.add(c.lookup, frame)
+	}
+
+	// If we have named returns, check if they're used in return statements and check for shadowing
+	if len(namedReturns) > 0 {
+		index := make(map[string]int, len(namedReturns))
+		for i, namedReturn := range namedReturns {
+			index[namedReturn.Name] = i
+		}
+
+		c.checkNamedReturnUsage(frame, namedReturns, index)
+		for _, site := range frame.shadows {
+			c.reportShadowing(frame, site, namedReturns, index, names)
+		}
+	}
+}
+
+// checkErrorConvention reports error results of a function that aren't its
+// last result, or that are named differently from the configured name.
+// Unnamed results are left to the naming check.
+func (c *checker) checkErrorConvention(frame *funcFrame) {
+	want := frame.cfg.errorName()
+	fields := frame.typ.Results.List
+	for i, p := range fields {
+		if !c.lookup.isErrorType(p.Type, frame.typeParams) {
+			continue
+		}
+
+		if len(p.Names) == 0 {
+			if i < len(fields)-1 {
+				c.report(diagnosticf(RuleErrorConvention, p.Pos(), frame.msgs, MessageErrorNotLast, frame.name),
+					Message{FuncName: frame.name, Type: types.ExprString(p.Type)})
+			}
+			continue
+		}
+
+		for j, n := range p.Names {
+			if i < len(fields)-1 || j < len(p.Names)-1 {
+				c.report(diagnosticf(RuleErrorConvention, n.Pos(), frame.msgs, MessageNamedErrorNotLast, frame.name, n.Name),
+					Message{FuncName: frame.name, ReturnName: n.Name, Type: types.ExprString(p.Type)})
+			} else if n.Name != want && n.Name != "_" {
+				c.report(diagnosticf(RuleErrorConvention, n.Pos(), frame.msgs, MessageErrorName, frame.name, n.Name, want),
+					Message{FuncName: frame.name, ReturnName: n.Name, Type: types.ExprString(p.Type)})
+			}
+		}
+	}
+}
+
+// checkBoolNames reports boolean results not named from the allowed names,
+// such as ok in the comma-ok idiom.
+func (c *checker) checkBoolNames(frame *funcFrame) {
+	allowed := frame.cfg.boolNames()
+	for _, p := range frame.typ.Results.List {
+		isBool := false
+		if t := c.lookup.info.TypeOf(p.Type); t != nil {
+			isBool = types.Identical(t, types.Typ[types.Bool])
+		} else if ident, ok := p.Type.(*ast.Ident); ok {
+			isBool = ident.Name == "bool"
+		}
+		if !isBool {
+			continue
+		}
+
+		for _, n := range p.Names {
+			if n.Name != "_" && !slices.Contains(allowed, n.Name) {
+				c.report(diagnosticf(RuleBoolName, n.Pos(), frame.msgs, MessageBoolName, frame.name, n.Name, strings.Join(allowed, ", ")),
+					Message{FuncName: frame.name, ReturnName: n.Name, Type: types.ExprString(p.Type)})
+			}
+		}
+	}
+}
+
+// releaseMethods are the methods whose deferred calls release a resource,
+// which may fail or leave the function's work half done.
+var releaseMethods = map[string]bool{
+	"Close":    true,
+	"Unlock":   true,
+	"RUnlock":  true,
+	"Rollback": true,
+	"Release":  true,
+	"Flush":    true,
+	"Sync":     true,
+	"Stop":     true,
+}
+
+// checkDeferErrorHandler reports exported functions with a named error
+// result that defer releasing a resource, but have no deferred code that
+// could assign or wrap the error: neither a deferred function literal
+// assigning it nor a deferred call given its address.
+func (c *checker) checkDeferErrorHandler(frame *funcFrame) {
+	decl, ok := frame.node.(*ast.FuncDecl)
+	if !ok || !decl.Name.IsExported() {
+		return
+	}
+
+	errName := c.namedError(frame)
+	if errName == nil {
+		return
+	}
+
+	var release *ast.DeferStmt
+	for _, d := range frame.defers {
+		if sel, isSel := d.Call.Fun.(*ast.SelectorExpr); isSel && releaseMethods[sel.Sel.Name] && release == nil {
+			release = d
+		}
+	}
+	if release == nil || c.deferHandles(frame, errName) {
+		return
+	}
+
+	c.report(diagnosticf(RuleDeferErrorHandler, release.Pos(), frame.msgs, MessageDeferErrorHandler, frame.name, types.ExprString(release.Call), errName.Name),
+		Message{FuncName: frame.name, ReturnName: errName.Name, Type: frame.resultType(errName)})
 }
 
-func findDeferWithVariableAssignment(body *ast.BlockStmt, info *types.Info, variable types.Object) (found bool) {
-	ast.Inspect(body, func(node ast.Node) (continueInspection bool) {
-		if found {
-			return // stop inspection
+// namedError returns the name of the last named error result of the function
+// of frame, or nil if it has none.
+func (c *checker) namedError(frame *funcFrame) (errName *ast.Ident) {
+	for _, p := range frame.typ.Results.List {
+		if c.lookup.isErrorType(p.Type, frame.typeParams) {
+			for _, n := range p.Names {
+				if n.Name != "_" {
+					errName = n
+				}
+			}
 		}
+	}
+	return errName
+}
 
-		if d, ok := node.(*ast.DeferStmt); ok {
-			if fn, ok2 := d.Call.Fun.(*ast.FuncLit); ok2 {
-				if findVariableAssignment(fn.Body, info, variable) {
-					found = true
-					return
+// deferHandles reports whether deferred code of the function of frame may
+// assign the named error errName: a deferred function literal assigning it,
+// or a deferred call given its address.
+func (c *checker) deferHandles(frame *funcFrame, errName *ast.Ident) (handled bool) {
+	for _, d := range frame.defers {
+		for _, arg := range d.Call.Args {
+			if addr, isAddr := arg.(*ast.UnaryExpr); isAddr && addr.Op == token.AND {
+				if ident, isIdent := addr.X.(*ast.Ident); isIdent && c.lookup.assignedIn([]*ast.Ident{ident}, errName) {
+					handled = true
+					return handled
 				}
 			}
 		}
+	}
 
-		continueInspection = true
+	handled = c.lookup.assignedIn(frame.deferAssigned, errName)
+	return handled
+}
+
+// checkUnusedNames reports functions whose results are named for show: the
+// body neither returns them with a bare return nor refers to them anywhere.
+func (c *checker) checkUnusedNames(frame *funcFrame) {
+	for _, ret := range frame.returns {
+		if len(ret.Results) == 0 {
+			return
+		}
+	}
+
+	var named []*ast.Ident
+	for _, p := range frame.typ.Results.List {
+		for _, n := range p.Names {
+			if n.Name != "_" {
+				named = append(named, n)
+			}
+		}
+	}
+	if len(named) == 0 {
 		return
+	}
+
+	referenced := false
+	ast.Inspect(frame.body, func(node ast.Node) (descend bool) {
+		if ident, ok := node.(*ast.Ident); ok && !referenced {
+			for _, n := range named {
+				if c.lookup.assignedIn([]*ast.Ident{ident}, n) {
+					referenced = true
+				}
+			}
+		}
+		descend = !referenced
+		return descend
 	})
+	if referenced {
+		return
+	}
 
-	return
+	c.report(diagnosticf(RuleUnusedNames, frame.node.Pos(), frame.msgs, MessageUnusedNames, frame.name),
+		Message{FuncName: frame.name})
 }
 
-func findVariableAssignment(body *ast.BlockStmt, info *types.Info, variable types.Object) (found bool) {
-	ast.Inspect(body, func(node ast.Node) (continueInspection bool) {
-		if found {
-			return // stop inspection
+// resultCount counts the results declared by fields.
+func resultCount(fields []*ast.Field) (count int) {
+	for _, field := range fields {
+		count += max(len(field.Names), 1)
+	}
+	return count
+}
+
+// checkNamedReturnUsage checks that the return statements of the function of
+// frame use its named return variables, reporting each variable once, with
+// the return statements not using it. index maps their names to their
+// position in namedReturns.
+func (c *checker) checkNamedReturnUsage(frame *funcFrame, namedReturns []*ast.Ident, index map[string]int) {
+	unused := make([][]*ast.ReturnStmt, len(namedReturns))
+	used := make([]bool, len(namedReturns))
+	for _, returnStmt := range frame.returns {
+		// Bare return is fine when using named returns, and a return
+		// that should be bare is reported, and fixed, as such
+		if len(returnStmt.Results) == 0 || frame.cfg.PreferBareReturn && c.restatesNil(frame, returnStmt) {
+			continue
 		}
 
-		if a, ok := node.(*ast.AssignStmt); ok {
-			for _, lh := range a.Lhs {
-				if i, ok2 := lh.(*ast.Ident); ok2 {
-					if info.ObjectOf(i) == variable {
-						found = true
-						return
-					}
+		// Check if the return statement uses the named return variables
+		clear(used)
+		for _, result := range returnStmt.Results {
+			if ident, ok := result.(*ast.Ident); ok {
+				if i, named := index[ident.Name]; named {
+					used[i] = true
 				}
 			}
 		}
+		for i := range namedReturns {
+			if !used[i] {
+				unused[i] = append(unused[i], returnStmt)
+			}
+		}
+	}
 
-		continueInspection = true
+	// The fixes rewrite whole statements, so a statement not using several
+	// named return variables gets the same edits in each of their findings
+	fixes := make(map[*ast.ReturnStmt]analysis.SuggestedFix)
+	for _, returnStmts := range unused {
+		for _, returnStmt := range returnStmts {
+			if _, done := fixes[returnStmt]; done {
+				continue
+			}
+			if fix, ok := returnFix(frame.msgs, c.pass, c.lookup, returnStmt, namedReturns); ok {
+				fixes[returnStmt] = fix
+			}
+		}
+	}
+
+	// Report on named return variables that are declared but not used in
+	// some return statements, pointing at each of the offending ones
+	for i, namedReturn := range namedReturns {
+		if len(unused[i]) == 0 {
+			continue
+		}
+
+		d := diagnosticf(RuleUnusedInReturn, frame.node.Pos(), frame.msgs, MessageUnusedInReturn, frame.name, namedReturn.Name)
+		d.End = namedReturn.End()
+		var edits []analysis.TextEdit
+		for _, returnStmt := range unused[i] {
+			d.Related = append(d.Related, analysis.RelatedInformation{
+				Pos:     returnStmt.Pos(),
+				End:     returnStmt.End(),
+				Message: frame.msgs.sprintf(MessageUnusedInReturnRelated, namedReturn.Name),
+			})
+			if fix, ok := fixes[returnStmt]; ok {
+				edits = append(edits, fix.TextEdits...)
+			}
+		}
+		if len(edits) > 0 {
+			d.SuggestedFixes = []analysis.SuggestedFix{{Message: frame.msgs.sprintf(MessageFixReturnNamed), TextEdits: edits}}
+		}
+		c.report(d, Message{FuncName: frame.name, ReturnName: namedReturn.Name, Type: frame.resultType(namedReturn)})
+	}
+}
+
+// reportShadowing reports the declaration of site if it redeclares one of the
+// named returns of the function of frame, pointing back at the declaration
+// in the signature. index maps the names of the named returns to their
+// position in namedReturns.
+func (c *checker) reportShadowing(frame *funcFrame, site shadowSite, namedReturns []*ast.Ident, index map[string]int, names *namer) {
+	ident := site.ident
+	i, named := index[ident.Name]
+	if !named {
 		return
-	})
+	}
+
+	namedReturn := namedReturns[i]
+	d := diagnosticf(RuleShadowedResult, ident.Pos(), frame.msgs, MessageShadowedResult, frame.name, namedReturn.Name, frame.msgs.sprintf(site.kind))
+	d.Related = []analysis.RelatedInformation{{
+		Pos:     namedReturn.Pos(),
+		End:     namedReturn.End(),
+		Message: frame.msgs.sprintf(MessageShadowedResultRelated, namedReturn.Name),
+	}}
+	if fix, ok := renameShadowFix(frame.msgs, c.pass, c.lookup, ident, names); ok {
+		d.SuggestedFixes = []analysis.SuggestedFix{fix}
+	}
+	c.report(d, Message{FuncName: frame.name, ReturnName: namedReturn.Name, Type: frame.resultType(namedReturn)})
+}
+
+// report records d, a finding about the function msg.FuncName, rendering
+// its message with the template for its rule from the data of msg. The
+// analyzers report the findings of their rules once the package is checked.
+func (c *checker) report(d analysis.Diagnostic, msg Message) {
+	c.templates.render(&d, msg)
+	c.record(d, msg.FuncName)
+}
+
+// resultType returns the type of the named result of the function of frame,
+// as written in the signature.
+func (frame *funcFrame) resultType(name *ast.Ident) (typ string) {
+	for _, field := range frame.typ.Results.List {
+		if slices.Contains(field.Names, name) {
+			typ = types.ExprString(field.Type)
+			return typ
+		}
+	}
+	return typ
+}
+
+// isTestFile reports whether pos lies in a _test.go file.
+func isTestFile(fset *token.FileSet, pos token.Pos) (isTest bool) {
+	if file := fset.File(pos); file != nil {
+		isTest = strings.HasSuffix(file.Name(), "_test.go")
+	}
+	return isTest
+}
+
+// cgoGenerated reports whether file was generated by cgo, other than from
+// the package's own code. When a package uses cgo, the files analyzed are
+// those cgo generates: the package's files, rewritten, with line directives
+// pointing back at the originals, along with helpers of its own, which live
+// in the build cache and can't be changed.
+func cgoGenerated(fset *token.FileSet, file *ast.File) (generated bool) {
+	if !ast.IsGenerated(file) {
+		return generated
+	}
+
+	cgo := false
+	for _, group := range file.Comments {
+		if group.Pos() > file.Package {
+			break
+		}
+		for _, c := range group.List {
+			cgo = cgo || c.Text == "// Code generated by cmd/cgo; DO NOT EDIT."
+		}
+	}
+
+	// A line directive before the package clause maps a rewritten file to
+	// its original
+	generated = cgo && fset.PositionFor(file.Package, true).Filename == fset.PositionFor(file.Package, false).Filename
+	return generated
+}
+
+// describeFuncDecl names a function declaration the way it reads in Go code,
+// e.g. "func Start" or "method (*Server).Start".
+func describeFuncDecl(decl *ast.FuncDecl) (description string) {
+	if decl.Recv == nil || len(decl.Recv.List) == 0 {
+		description = "func " + decl.Name.Name
+		return description
+	}
+
+	recv := types.ExprString(decl.Recv.List[0].Type)
+	if _, ok := decl.Recv.List[0].Type.(*ast.StarExpr); ok {
+		recv = "(" + recv + ")"
+	}
 
-	return
+	description = "method " + recv + "." + decl.Name.Name
+	return description
 }
@@ -1,11 +1,23 @@
 package analyzer
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 
+	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/analysistest"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
 )
 
 func TestAll(t *testing.T) {
@@ -17,9 +29,1270 @@ func TestAll(t *testing.T) {
 	testdata := filepath.Join(filepath.Dir(wd), "testdata")
 	analysistest.Run(t, testdata, Analyzer, "default-config")
 
-	err = Analyzer.Flags.Set(FlagReportErrorInDefer, "true")
+	analysistest.Run(t, testdata, NewAnalyzer(Config{ReportErrorInDefer: true}), "report-error-in-defer")
+
+	analysistest.Run(t, testdata, Analyzer, "nested-literals")
+}
+
+func TestNewAnalyzerIndependent(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	// Instances don't share settings, whether given as Config or flags
+	reporting := NewAnalyzer(Config{})
+	err = reporting.Flags.Set(FlagReportErrorInDefer, "true")
+	if err != nil {
+		t.Fatalf("Failed to set flag: %s", err)
+	}
+
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	analysistest.Run(t, testdata, NewAnalyzer(Config{}), "default-config")
+	analysistest.Run(t, testdata, reporting, "report-error-in-defer")
+}
+
+func TestCategories(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	expected := map[string]string{
+		"unnamed return":        RuleUnnamedResult,
+		"underscore as":         RuleUnderscoreResult,
+		"declared but not used": RuleUnusedInReturn,
+		"shadowed by":           RuleShadowedResult,
+	}
+
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	results := analysistest.Run(t, testdata, Analyzer, "default-config")
+	for _, result := range results {
+		for _, d := range result.Diagnostics {
+			if _, ok := LookupRule(d.Category); !ok {
+				t.Errorf("diagnostic %q has unknown category %q", d.Message, d.Category)
+			}
+			for fragment, rule := range expected {
+				if strings.Contains(d.Message, fragment) && d.Category != rule {
+					t.Errorf("diagnostic %q has category %q, want %q", d.Message, d.Category, rule)
+				}
+			}
+		}
+	}
+}
+
+func TestRelatedInformation(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	results := analysistest.Run(t, testdata, Analyzer, "default-config")
+	for _, result := range results {
+		for _, d := range result.Diagnostics {
+			switch d.Category {
+			case RuleShadowedResult:
+				// The declaration in the signature always precedes the shadowing site
+				if len(d.Related) != 1 || d.Related[0].Pos >= d.Pos {
+					t.Errorf("shadowing diagnostic %q should point back at the named return declaration, got %+v", d.Message, d.Related)
+				}
+			case RuleUnusedInReturn:
+				// The offending return statements are always inside the
+				// function body
+				if len(d.Related) == 0 {
+					t.Errorf("unused-in-return diagnostic %q should point at the return statements, got none", d.Message)
+				}
+				for _, related := range d.Related {
+					if related.Pos <= d.Pos {
+						t.Errorf("unused-in-return diagnostic %q should point at the return statements, got %+v", d.Message, d.Related)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestConfigFile(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	analysistest.Run(t, testdata, Analyzer, "config-file")
+
+	path := filepath.Join(t.TempDir(), ".namedreturns.yaml")
+	err = os.WriteFile(path, []byte("report-errors-in-defer: true\n"), 0o600)
+	if err != nil {
+		t.Fatalf("Failed to write config: %s", err)
+	}
+
+	var cfg Config
+	err = LoadConfigFile(path, &cfg)
+	if err == nil || !strings.Contains(err.Error(), `unknown setting "report-errors-in-defer"`) {
+		t.Errorf("expected an unknown setting error, got %v", err)
+	}
+
+	err = cfg.Set(FlagReportErrorInDefer, "maybe")
+	if err == nil {
+		t.Errorf("expected an error for an invalid boolean")
+	}
+}
+
+func TestNestedConfigFiles(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	// Each directory's file applies over those of its parents, unless it
+	// sets root
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	analysistest.Run(t, testdata, Analyzer, "nested-config/...")
+
+	dir := filepath.Join(testdata, "src", "nested-config")
+	paths := FindConfigFiles(filepath.Join(dir, "relaxed"))
+	expected := []string{filepath.Join(dir, ".namedreturns.yaml"), filepath.Join(dir, "relaxed", ".namedreturns.yaml")}
+	if !slices.Equal(paths, expected) {
+		t.Errorf("expected the files outermost first, got %v", paths)
+	}
+
+	var cfg Config
+	err = ParseConfigJSON([]byte(`{"root": "yes"}`), &cfg)
+	if err == nil {
+		t.Errorf("expected an error for a root that isn't a boolean")
+	}
+}
+
+func TestConfigConflicts(t *testing.T) {
+	cfg := Config{
+		BoolNames: []string{"ok"},
+		Tests:     map[string]string{FlagGroupByFunction: "true", FlagDocResults: "true", FlagDisable: "doc-result"},
+	}
+	expected := []string{
+		"bool-names has no effect without bool-convention",
+		"tests: group-by-function applies to whole packages and can't be changed for test files",
+		"tests: doc-results is set, but its rule NR012 is disabled",
+	}
+	if conflicts := cfg.Conflicts(); !slices.Equal(conflicts, expected) {
+		t.Errorf("expected %q, got %q", expected, conflicts)
+	}
+
+	if conflicts := (Config{ErrorConvention: true, ErrorName: "e"}).Conflicts(); len(conflicts) != 0 {
+		t.Errorf("expected no conflicts, got %q", conflicts)
+	}
+}
+
+func TestTestsProfile(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	analysistest.Run(t, testdata, Analyzer, "test-profile")
+
+	var cfg Config
+	err = ParseConfigJSON([]byte(`{"tests": {"disable": "NR042"}}`), &cfg)
+	if err == nil || !strings.Contains(err.Error(), `unknown rule "NR042"`) {
+		t.Errorf("expected an unknown rule error, got %v", err)
+	}
+
+	err = ParseConfigJSON([]byte(`{"tests": true}`), &cfg)
+	if err == nil {
+		t.Errorf("expected an error for tests without settings")
+	}
+}
+
+func TestMessageTemplates(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	a := NewAnalyzer(Config{})
+	err = a.Flags.Set(FlagConfigJSON, `{"messages": {
+		"unnamed-result": "{{.FuncName}} must name its {{.Type}} result, see https://style.example.com/results ({{.Rule}} {{.RuleName}})",
+		"NR005": "{{.Text}}, see https://style.example.com/directives"
+	}}`)
+	if err != nil {
+		t.Fatalf("Failed to set flag: %s", err)
+	}
+
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	analysistest.Run(t, testdata, a, "message-templates")
+
+	for messages, expected := range map[string]string{
+		`{"messages": {"NR042": "x"}}`:                `unknown rule "NR042"`,
+		`{"messages": {"NR001": "{{.Func"}}`:          "template for NR001",
+		`{"messages": {"NR001": "{{.Function}}"}}`:    "can't evaluate field Function",
+		`{"messages": ["NR001"]}`:                     "messages must hold templates by rule",
+		`{"tests": {"messages": {"NR001": "tests"}}}`: `unknown setting "messages"`,
+	} {
+		var cfg Config
+		err = ParseConfigJSON([]byte(messages), &cfg)
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("%s: expected an error containing %q, got %v", messages, expected, err)
+		}
+	}
+}
+
+func TestLocale(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	// A regional locale falls back to the catalog of its language
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	analysistest.Run(t, testdata, NewAnalyzer(Config{Locale: "de-AT"}), "locale")
+
+	var cfg Config
+	err = cfg.Set(FlagLocale, "tlh")
+	if err == nil || !strings.Contains(err.Error(), `unknown locale "tlh"`) {
+		t.Errorf("expected an unknown locale error, got %v", err)
+	}
+
+	err = RegisterCatalog("tlh", map[string]string{MessageUnnamedResult: "%s: %q Daq pong Hutlh"})
+	if err != nil {
+		t.Fatalf("RegisterCatalog failed: %s", err)
+	}
+	err = cfg.Set(FlagLocale, "tlh")
+	if err != nil {
+		t.Fatalf("Failed to set a registered locale: %s", err)
+	}
+
+	msgs, err := lookupCatalog("tlh")
+	if err != nil {
+		t.Fatalf("lookupCatalog failed: %s", err)
+	}
+	if msgs.sprintf(MessageUnnamedResult, "func f", "int") != `func f: "int" Daq pong Hutlh` || msgs[MessageIgnoreNoRule] != catalogs[DefaultLocale][MessageIgnoreNoRule] {
+		t.Errorf("expected the registered message with the others in the default locale, got %v", msgs)
+	}
+
+	err = RegisterCatalog("tlh", map[string]string{"no-such-message": "x"})
+	if err == nil {
+		t.Errorf("expected an error for an unknown message")
+	}
+
+	// Every message of a built-in catalog takes the arguments of the
+	// default one
+	for _, locale := range Locales() {
+		for id := range catalogs[locale] {
+			if _, ok := catalogs[DefaultLocale][id]; !ok {
+				t.Errorf("%s: unknown message %q", locale, id)
+			}
+			if strings.Count(catalogs[locale][id], "%") != strings.Count(catalogs[DefaultLocale][id], "%") {
+				t.Errorf("%s: message %q doesn't take the arguments of the default one", locale, id)
+			}
+		}
+	}
+}
+
+func TestConfigJSON(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	// As nogo passes settings, on an instance of its own
+	a := NewAnalyzer(Config{})
+	err = a.Flags.Set(FlagConfigJSON, `{"report-error-in-defer": true}`)
+	if err != nil {
+		t.Fatalf("Failed to set flag: %s", err)
+	}
+
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	analysistest.Run(t, testdata, a, "report-error-in-defer")
+
+	var cfg Config
+	err = ParseConfigJSON([]byte(`{"report-error-in-defer": "yes please"}`), &cfg)
+	if err == nil {
+		t.Errorf("expected an error for an invalid boolean")
+	}
+
+	err = ParseConfigJSON([]byte(`[true]`), &cfg)
+	if err == nil {
+		t.Errorf("expected an error for a JSON array")
+	}
+}
+
+func TestEnvOverrides(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	t.Setenv(EnvName(FlagReportErrorInDefer), "true")
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	analysistest.Run(t, testdata, NewAnalyzer(Config{}), "report-error-in-defer")
+
+	// The environment overrides the configuration file, and explicit flags
+	// override the environment
+	dir := t.TempDir()
+	err = os.WriteFile(filepath.Join(dir, ".namedreturns.yaml"), []byte("min-returns: 4\nerror-convention: true\n"), 0o600)
+	if err != nil {
+		t.Fatalf("Failed to write config: %s", err)
+	}
+	t.Setenv(EnvName(FlagMinReturns), "3")
+	t.Setenv(EnvName(FlagErrorConvention), "false")
+	t.Setenv(EnvName(FlagDisable), "NR003,shadowed-result")
+
+	a := NewAnalyzer(Config{})
+	err = a.Flags.Set(FlagMinReturns, "2")
 	if err != nil {
 		t.Fatalf("Failed to set flag: %s", err)
 	}
-	analysistest.Run(t, testdata, Analyzer, "report-error-in-defer")
+	cfg, err := ResolveConfig(a, dir)
+	if err != nil {
+		t.Fatalf("ResolveConfig failed: %s", err)
+	}
+	if cfg.MinReturns != 2 || cfg.ErrorConvention || !slices.Equal(cfg.Disable, []string{RuleUnusedInReturn, RuleShadowedResult}) {
+		t.Errorf("unexpected settings: %+v", cfg)
+	}
+
+	t.Setenv(EnvName(FlagMinReturns), "many")
+	_, err = ResolveConfig(a, dir)
+	if err == nil || !strings.Contains(err.Error(), "NAMEDRETURNS_MIN_RETURNS") {
+		t.Errorf("expected an error naming the variable, got %v", err)
+	}
+}
+
+func TestNolint(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	analysistest.Run(t, testdata, Analyzer, "nolint")
+
+	// With the directives ignored, every function is reported
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filepath.Join(testdata, "src", "nolint", "nolint.go"), nil, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("Failed to parse: %s", err)
+	}
+	diagnostics, _, err := RunSyntax(NewAnalyzer(Config{IgnoreNolint: true}), fset, []*ast.File{file})
+	if err != nil {
+		t.Fatalf("RunSyntax failed: %s", err)
+	}
+	if len(diagnostics) != 11 {
+		t.Errorf("expected 11 findings with nolint ignored, got %d", len(diagnostics))
+	}
+}
+
+func TestIgnoreDirective(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	analysistest.Run(t, testdata, Analyzer, "ignore-directive")
+}
+
+func TestDisableFileDirective(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	analysistest.Run(t, testdata, Analyzer, "disable-file")
+}
+
+func TestPackageConfigDirective(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	analysistest.Run(t, testdata, Analyzer, "package-config", "package-config-mode")
+}
+
+func TestFuncOptions(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	analysistest.Run(t, testdata, Analyzer, "func-options")
+}
+
+func TestRegisterExemption(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	// Exemptions apply to all analyzers, so this one only considers the
+	// package of its fixture
+	RegisterExemption(func(ctx *ExemptionContext) (exempt bool) {
+		if ctx.Pass.Pkg.Path() != "exemptions" || ctx.Signature == nil || ctx.Signature.Params().Len() == 0 {
+			return exempt
+		}
+		exempt = types.TypeString(ctx.Signature.Params().At(0).Type(), nil) == "*exemptions.Request"
+		return exempt
+	})
+
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	analysistest.Run(t, testdata, Analyzer, "exemptions")
+}
+
+func TestExemptionRules(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	analysistest.Run(t, testdata, Analyzer, "exempt-rules", "exempt-rules/gen")
+
+	for _, tc := range []struct {
+		pattern    string
+		importPath string
+		matched    bool
+	}{
+		{".../gen/...", "example.com/api/gen/v1", true},
+		{".../gen/...", "example.com/api/gen", true},
+		{".../gen/...", "example.com/api/generated", false},
+		{"example.com/...", "example.com", true},
+		{"example.com/api", "example.com/api/gen", false},
+	} {
+		if matchPackage(tc.pattern, tc.importPath) != tc.matched {
+			t.Errorf("matchPackage(%q, %q) = %t, expected %t", tc.pattern, tc.importPath, !tc.matched, tc.matched)
+		}
+	}
+
+	var cfg Config
+	for _, bad := range []string{`{"exempt": {"receiver": "*Mock"}}`, `{"exempt": [{}]}`, `{"exempt": [{"name": "Get"}]}`, `{"exempt": [{"returns": ["[int"]}]}`} {
+		err = ParseConfigJSON([]byte(bad), &cfg)
+		if err == nil {
+			t.Errorf("expected an error for %s", bad)
+		}
+	}
+}
+
+func TestFuncSelector(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	a := NewAnalyzer(Config{Funcs: []string{"func-selector.(*Server).Start", "(*Box).Parse", "selector.parse"}})
+	analysistest.Run(t, testdata, a, "func-selector")
+}
+
+func TestGroupByFunction(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	results := analysistest.Run(t, testdata, NewAnalyzer(Config{GroupByFunction: true}), "group-by-function")
+
+	for _, d := range results[0].Diagnostics {
+		if strings.HasPrefix(d.Message, "func load:") && (d.Category != RuleUnderscoreResult || len(d.Related) < 4 || len(d.SuggestedFixes) == 0) {
+			t.Errorf("expected the grouped finding under %s with the findings as related information and their fixes, got %s with %d and %d", RuleUnderscoreResult, d.Category, len(d.Related), len(d.SuggestedFixes))
+		}
+	}
+}
+
+func TestOncePerFunction(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	analysistest.Run(t, testdata, NewAnalyzer(Config{OncePerFunction: true}), "once-per-function")
+}
+
+func TestDefersMode(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	analysistest.Run(t, testdata, NewAnalyzer(Config{Mode: ModeDefers}), "defers-mode")
+
+	var cfg Config
+	err = cfg.Set(FlagMode, "sometimes")
+	if err == nil || !strings.Contains(err.Error(), "unknown mode") {
+		t.Errorf("expected an unknown mode error, got %v", err)
+	}
+}
+
+func TestErrorsMode(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	analysistest.Run(t, testdata, NewAnalyzer(Config{Mode: ModeErrors}), "errors-mode")
+}
+
+func TestAmbiguousMode(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	analysistest.Run(t, testdata, NewAnalyzer(Config{Mode: ModeAmbiguous}), "ambiguous-mode")
+}
+
+func TestErrorConvention(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	analysistest.Run(t, testdata, NewAnalyzer(Config{ErrorConvention: true}), "error-convention", "error-convention/custom")
+
+	// The rule is opt-in
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filepath.Join(testdata, "src", "error-convention", "convention.go"), nil, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("Failed to parse: %s", err)
+	}
+	diagnostics, _, err := RunSyntax(NewAnalyzer(Config{}), fset, []*ast.File{file})
+	if err != nil {
+		t.Fatalf("RunSyntax failed: %s", err)
+	}
+	for _, d := range diagnostics {
+		if d.Category == RuleErrorConvention {
+			t.Errorf("unexpected %s finding without error-convention: %s", RuleErrorConvention, d.Message)
+		}
+	}
+}
+
+func TestDeferErrorHandler(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	analysistest.Run(t, testdata, NewAnalyzer(Config{DeferErrorHandler: true}), "defer-handler")
+}
+
+func TestReportUnusedNames(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	analysistest.Run(t, testdata, NewAnalyzer(Config{ReportUnusedNames: true}), "unused-names")
+}
+
+func TestReportInconsistentNames(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	analysistest.Run(t, testdata, NewAnalyzer(Config{ReportInconsistent: true}), "inconsistent-names")
+}
+
+func TestBoolConvention(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	analysistest.Run(t, testdata, NewAnalyzer(Config{BoolConvention: true}), "bool-names")
+
+	cfg := Config{BoolConvention: true}
+	err = cfg.Set(FlagBoolNames, "ok, found,exists,done,result,b")
+	if err != nil {
+		t.Fatalf("Failed to set %s: %s", FlagBoolNames, err)
+	}
+	analysistest.Run(t, testdata, NewAnalyzer(cfg), "bool-names/allowed")
+}
+
+func TestInterfaceNames(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	analysistest.Run(t, testdata, NewAnalyzer(Config{InterfaceNames: true}), "interface-names")
+
+	// Without the facts, as with RunSyntax, the check is skipped
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filepath.Join(testdata, "src", "interface-names", "impl.go"), nil, 0)
+	if err != nil {
+		t.Fatalf("Failed to parse: %s", err)
+	}
+	diagnostics, _, err := RunSyntax(NewAnalyzer(Config{InterfaceNames: true}), fset, []*ast.File{file})
+	if err != nil {
+		t.Fatalf("Failed to run: %s", err)
+	}
+	for _, d := range diagnostics {
+		if d.Category == RuleInterfaceName {
+			t.Errorf("unexpected finding without facts: %s", d.Message)
+		}
+	}
+}
+
+func TestDocResults(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	analysistest.Run(t, testdata, NewAnalyzer(Config{DocResults: true}), "doc-results")
+}
+
+func TestSubAnalyzers(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	analysistest.Run(t, testdata, Shadowing, "suite")
+}
+
+func TestPresetAnalyzers(t *testing.T) {
+	analyzers := map[string]*analysis.Analyzer{PresetStrict: Strict, PresetRelaxed: Relaxed, PresetErrorsOnly: ErrorsOnly}
+
+	err := analysis.Validate([]*analysis.Analyzer{Analyzer, Strict, Relaxed, ErrorsOnly})
+	if err != nil {
+		t.Fatalf("expected the preset analyzers to run side by side: %s", err)
+	}
+
+	dir := t.TempDir()
+	for preset, a := range analyzers {
+		want, _ := LookupPreset(preset)
+		got, err := ResolveConfig(a, dir)
+		if err != nil {
+			t.Fatalf("%s: ResolveConfig failed: %s", a.Name, err)
+		}
+		if fmt.Sprint(got.WithDefaults()) != fmt.Sprint(want.WithDefaults()) {
+			t.Errorf("%s: expected the settings of the %s preset, got %+v", a.Name, preset, got)
+		}
+	}
+}
+
+func TestSubAnalyzersPartition(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filepath.Join(filepath.Dir(wd), "testdata", "src", "default-config", "default_config.go"), nil, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Between them, the sub-checks needing no other analyzer's results
+	// report what Analyzer does, each finding once
+	report := func(a *analysis.Analyzer) (found []string) {
+		diagnostics, _, err := RunSyntax(a, fset, []*ast.File{file})
+		if err != nil {
+			t.Fatalf("%s: %s", a.Name, err)
+		}
+		for _, d := range diagnostics {
+			found = append(found, fmt.Sprintf("%s %s %s", fset.Position(d.Pos), d.Category, d.Message))
+		}
+		return found
+	}
+
+	var split []string
+	for _, a := range []*analysis.Analyzer{Unnamed, Underscore, Naming, Usage, Shadowing, DeferErrors} {
+		split = append(split, report(a)...)
+	}
+	whole := report(Analyzer)
+	slices.Sort(split)
+	slices.Sort(whole)
+	if len(whole) == 0 || !slices.Equal(split, whole) {
+		t.Errorf("expected the sub-checks to report\n%s\ngot\n%s", strings.Join(whole, "\n"), strings.Join(split, "\n"))
+	}
+}
+
+func TestSharedFindings(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filepath.Join(filepath.Dir(wd), "testdata", "src", "default-config", "default_config.go"), nil, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pass := &analysis.Pass{
+		Analyzer:  findingsAnalyzer,
+		Fset:      fset,
+		Files:     []*ast.File{file},
+		Pkg:       types.NewPackage("config", "config"),
+		TypesInfo: &types.Info{},
+		ResultOf:  map[*analysis.Analyzer]interface{}{inspect.Analyzer: inspector.New([]*ast.File{file})},
+	}
+	r, err := runFindings(pass)
+	if err != nil {
+		t.Fatalf("runFindings failed: %s", err)
+	}
+	found, ok := r.(*packageFindings)
+	if !ok {
+		t.Fatalf("unexpected result %T", r)
+	}
+
+	// Analyzers configured alike share the findings, checked once
+	ctx := context.Background()
+	first, err := found.check(ctx, Config{}, checkInputs{})
+	if err != nil || len(first.findings) == 0 {
+		t.Fatalf("expected findings, got %v", err)
+	}
+	second, _ := found.check(ctx, Config{}, checkInputs{})
+	other, _ := found.check(ctx, Config{ErrorConvention: true}, checkInputs{})
+	if second != first || other == first {
+		t.Errorf("expected the findings to be shared by configuration")
+	}
+
+	// A check stopped by its context is done again
+	_, err = found.check(&countdownContext{Context: ctx}, Config{MinReturns: 2}, checkInputs{})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the check to stop, got %v", err)
+	}
+	retried, err := found.check(ctx, Config{MinReturns: 2}, checkInputs{})
+	if err != nil || retried == nil {
+		t.Errorf("expected the check to be done again, got %v", err)
+	}
+}
+
+// countdownContext is done once its Err method has been called a given
+// number of times.
+type countdownContext struct {
+	context.Context
+	left int
+}
+
+func (ctx *countdownContext) Err() (err error) {
+	ctx.left--
+	if ctx.left < 0 {
+		err = context.Canceled
+	}
+	return err
+}
+
+func TestWithContext(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filepath.Join(filepath.Dir(wd), "testdata", "src", "default-config", "default_config.go"), nil, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	diagnostics, _, err := RunSyntax(WithContext(context.Background(), Analyzer), fset, []*ast.File{file})
+	if err != nil || len(diagnostics) == 0 {
+		t.Fatalf("expected findings with a live context, got %d and %v", len(diagnostics), err)
+	}
+
+	// Done before the package, and once a few functions are checked
+	for _, checks := range []int{0, 4} {
+		_, _, err = RunSyntax(WithContext(&countdownContext{Context: context.Background(), left: checks}, Analyzer), fset, []*ast.File{file})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected the run to stop after %d checks, got %v", checks, err)
+		}
+	}
+
+	if WithContext(context.Background(), Facts) != Facts {
+		t.Errorf("expected analyzers of other packages to be returned as they are")
+	}
+
+	// The copy shares the settings of the analyzer
+	a := NewAnalyzer(Config{MinReturns: 3})
+	cfg, err := ResolveConfig(WithContext(context.Background(), a), "")
+	if err != nil || cfg.MinReturns != 3 {
+		t.Errorf("expected the settings of the analyzer, got %+v and %v", cfg, err)
+	}
+	_, err = ResolveConfig(Facts, "")
+	if err == nil {
+		t.Errorf("expected an error for an analyzer of another package")
+	}
+}
+
+func TestDiagnosticOrder(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	// Findings made when functions are left, across the package or by
+	// group come out in order of position, then rule, with the files of a
+	// package in any order
+	a := NewAnalyzer(Config{ReportUnusedNames: true, ReportInconsistent: true, ErrorConvention: true})
+	for _, fixture := range []string{"default-config", "inconsistent-names", "group-by-function", "nested-literals"} {
+		dir := filepath.Join(filepath.Dir(wd), "testdata", "src", fixture)
+		paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var runs [][]string
+		for _, reverse := range []bool{false, true} {
+			fset := token.NewFileSet()
+			var files []*ast.File
+			for _, path := range paths {
+				file, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+				if err != nil {
+					t.Fatal(err)
+				}
+				files = append(files, file)
+			}
+			if reverse {
+				slices.Reverse(files)
+			}
+
+			diagnostics, _, err := RunSyntax(a, fset, files)
+			if err != nil {
+				t.Fatalf("%s: %s", fixture, err)
+			}
+
+			var run []string
+			for i, d := range diagnostics {
+				position := fset.Position(d.Pos)
+				run = append(run, fmt.Sprintf("%s:%d:%d %s %s", filepath.Base(position.Filename), position.Line, position.Column, d.Category, d.Message))
+				if i == 0 {
+					continue
+				}
+				previous := fset.Position(diagnostics[i-1].Pos)
+				if previous.Filename > position.Filename || previous.Filename == position.Filename && (previous.Offset > position.Offset ||
+					previous.Offset == position.Offset && diagnostics[i-1].Category > d.Category) {
+					t.Errorf("%s: %s reported after %s", fixture, run[i], run[i-1])
+				}
+			}
+			runs = append(runs, run)
+		}
+
+		if len(runs[0]) == 0 || !slices.Equal(runs[0], runs[1]) {
+			t.Errorf("%s: expected the same findings in the same order whatever the order of the files, got\n%s\nand\n%s", fixture, strings.Join(runs[0], "\n"), strings.Join(runs[1], "\n"))
+		}
+	}
+}
+
+func TestSuggestedFixes(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	results := analysistest.RunWithSuggestedFixes(t, testdata, NewAnalyzer(Config{}), "suggested-fixes")
+
+	// A named result is reported once, with the return statements not
+	// using it and a fix for all of them
+	found := false
+	for _, d := range results[0].Diagnostics {
+		if d.Message != `func literals: named return variable "result" is declared but not used in return statement` {
+			continue
+		}
+		found = true
+		if len(d.Related) != 2 || len(d.SuggestedFixes) != 1 || len(d.SuggestedFixes[0].TextEdits) != 2 {
+			t.Errorf("expected 2 return statements and a fix editing both, got %+v", d)
+		}
+	}
+	if !found {
+		t.Errorf("expected an unused-in-return diagnostic for result")
+	}
+}
+
+func TestFacts(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	results := analysistest.Run(t, testdata, Facts, "facts/a", "facts/b")
+
+	// The imported interface is known to the dependent package
+	found := false
+	for _, result := range results {
+		interfaces, ok := result.Result.(InterfaceResults)
+		if !ok {
+			t.Fatalf("unexpected result type %T", result.Result)
+		}
+		for typeName := range interfaces {
+			if typeName.Pkg().Path() == "facts/a" && typeName.Name() == "Reader" {
+				names, _ := interfaces.Lookup(typeName, "Read")
+				found = len(names) == 2 && names[0] == "n" && names[1] == "err"
+			}
+		}
+	}
+	if !found {
+		t.Error("expected the result names of facts/a.Reader.Read to be known in facts/b")
+	}
+}
+
+func TestGenerics(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	results := analysistest.Run(t, testdata, Analyzer, "generics")
+
+	// Without type information, the same findings are made from the syntax
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filepath.Join(testdata, "src", "generics", "generics.go"), nil, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("Failed to parse: %s", err)
+	}
+	diagnostics, _, err := RunSyntax(NewAnalyzer(Config{}), fset, []*ast.File{file})
+	if err != nil {
+		t.Fatalf("RunSyntax failed: %s", err)
+	}
+
+	typed := make(map[string]bool)
+	for _, result := range results {
+		for _, d := range result.Diagnostics {
+			typed[d.Message] = true
+		}
+	}
+	if len(diagnostics) != len(typed) {
+		t.Errorf("expected %d findings without type information, got %d", len(typed), len(diagnostics))
+	}
+	for _, d := range diagnostics {
+		if !typed[d.Message] {
+			t.Errorf("unexpected finding without type information: %s", d.Message)
+		}
+	}
+}
+
+func TestCgoGenerated(t *testing.T) {
+	const helpers = `// Code generated by cmd/cgo; DO NOT EDIT.
+
+package p
+
+func _Cgo_use(v interface{}) interface{} { return v }
+`
+	const rewritten = `// Code generated by cmd/cgo; DO NOT EDIT.
+
+//line /src/p/c.go:1:1
+package p
+
+func Add(a, b int) int { return a + b }
+`
+
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for i, src := range []string{helpers, rewritten} {
+		file, err := parser.ParseFile(fset, fmt.Sprintf("/cache/%d-d", i), src, parser.ParseComments)
+		if err != nil {
+			t.Fatalf("Failed to parse: %s", err)
+		}
+		files = append(files, file)
+	}
+
+	// Only the package's own code, rewritten, is checked
+	diagnostics, _, err := RunSyntax(Analyzer, fset, files)
+	if err != nil {
+		t.Fatalf("RunSyntax failed: %s", err)
+	}
+	if len(diagnostics) != 1 || fset.Position(diagnostics[0].Pos).Filename != "/src/p/c.go" {
+		t.Errorf("expected a single finding in c.go, got %v", diagnostics)
+	}
+}
+
+func TestExcludeFiles(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	analysistest.Run(t, testdata, NewAnalyzer(Config{ExcludeFiles: []string{"**/*.pb.go", "testdata/src/*/zz_generated*.go"}}), "exclude-files")
+}
+
+func TestMatchFileGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		matched bool
+	}{
+		{"**/*.pb.go", "api.pb.go", true},
+		{"**/*.pb.go", "proto/v1/api.pb.go", true},
+		{"**/*.pb.go", "proto/v1/api.go", false},
+		{"**/zz_generated*.go", "apis/zz_generated.deepcopy.go", true},
+		{"internal/legacy/**", "internal/legacy/store/db.go", true},
+		{"internal/legacy/**", "internal/legacyx/db.go", false},
+		{"internal/*/mocks/**", "internal/store/mocks/store.go", true},
+		{"internal/*/mocks/**", "internal/a/b/mocks/store.go", false},
+		{"main.go", "cmd/main.go", false},
+	}
+	for _, test := range tests {
+		if got := matchFileGlob(test.pattern, test.name); got != test.matched {
+			t.Errorf("matchFileGlob(%q, %q) = %v, want %v", test.pattern, test.name, got, test.matched)
+		}
+	}
+}
+
+func TestExemptResultTypes(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	a := NewAnalyzer(Config{ExemptResultTypes: []string{CategoryFunc, CategoryChan, CategoryEmptyStruct, CategoryIterator, CategorySingleMethod}})
+	analysistest.Run(t, testdata, a, "exempt-result-types")
+}
+
+func TestTargets(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	a := NewAnalyzer(Config{Targets: []string{TargetFuncs, TargetMethods}})
+	analysistest.Run(t, testdata, a, "targets")
+}
+
+func TestExemptCommaOK(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	a := NewAnalyzer(Config{ExemptCommaOK: true})
+	analysistest.Run(t, testdata, a, "exempt-comma-ok")
+}
+
+func TestMustAssignError(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	// The settings come from the fixture's configuration file. Analyzer
+	// builds the control flow graphs itself, while Flow takes them from
+	// ctrlflow
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	analysistest.Run(t, testdata, NewAnalyzer(Config{}), "must-assign-error")
+	analysistest.Run(t, testdata, Flow, "must-assign-error")
+}
+
+func TestErrorPropagation(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	// The settings come from the fixture's configuration file
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	analysistest.Run(t, testdata, Propagation, "error-propagation")
+}
+
+func TestPanicRecover(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	// The settings come from the fixture's configuration file
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	analysistest.Run(t, testdata, Analyzer, "panic-recover")
+}
+
+func TestDeferRedeclare(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	results := analysistest.Run(t, testdata, Analyzer, "defer-redeclare")
+
+	// Without type information, the variables deferred functions declare
+	// are told apart by name
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filepath.Join(testdata, "src", "defer-redeclare", "redeclare.go"), nil, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("Failed to parse: %s", err)
+	}
+	diagnostics, _, err := RunSyntax(NewAnalyzer(Config{}), fset, []*ast.File{file})
+	if err != nil {
+		t.Fatalf("RunSyntax failed: %s", err)
+	}
+
+	typed := make(map[string]bool)
+	for _, result := range results {
+		for _, d := range result.Diagnostics {
+			typed[d.Message] = true
+		}
+	}
+	if len(diagnostics) != len(typed) {
+		t.Errorf("expected %d findings without type information, got %d", len(typed), len(diagnostics))
+	}
+	for _, d := range diagnostics {
+		if !typed[d.Message] {
+			t.Errorf("unexpected finding without type information: %s", d.Message)
+		}
+	}
+}
+
+func TestReorderedResults(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	// The settings come from the fixture's configuration file
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	analysistest.Run(t, testdata, Analyzer, "reordered-results")
+}
+
+func TestPreferBareReturn(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	// The settings come from the fixture's configuration file
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	analysistest.RunWithSuggestedFixes(t, testdata, Analyzer, "prefer-bare-return")
+}
+
+func TestShadowedReturns(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	// The settings come from the fixture's configuration file
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	analysistest.Run(t, testdata, Analyzer, "shadowed-returns")
+}
+
+func TestIterators(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	// The settings come from the fixture's configuration file
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	analysistest.Run(t, testdata, Analyzer, "iterators")
+}
+
+func TestExcludePresets(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	// The settings come from the fixture's configuration file
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	analysistest.Run(t, testdata, Analyzer, "exclude-presets")
+
+	// Mocks are also known by their package names
+	cfg := Config{ExcludePresets: []string{GeneratorMockery, GeneratorEnt}}
+	tests := []struct {
+		src      string
+		excluded bool
+	}{
+		{"package mocks\n", true},
+		{"package mock_store\n", false},
+		{"// Code generated by entc, DO NOT EDIT.\n\npackage ent\n", true},
+		{"// Code generated by sqlc. DO NOT EDIT.\n\npackage db\n", false},
+		{"package store\n\n// Code generated by mockery v2.20.0. DO NOT EDIT.\n", false},
+	}
+	for _, test := range tests {
+		file, parseErr := parser.ParseFile(token.NewFileSet(), "file.go", test.src, parser.ParseComments)
+		if parseErr != nil {
+			t.Fatalf("Failed to parse %q: %s", test.src, parseErr)
+		}
+		if got := cfg.ExcludesGenerated(file); got != test.excluded {
+			t.Errorf("ExcludesGenerated(%q) = %v, want %v", test.src, got, test.excluded)
+		}
+	}
+
+	if !cfg.ExcludesFile("ent/user_create.go") || cfg.ExcludesFile("ent/schema/user.go") {
+		t.Errorf("expected the ent preset to exclude the generated files, not the schema")
+	}
+
+	err = cfg.Set(FlagExcludePresets, "mockery,wire")
+	if err == nil {
+		t.Errorf("expected an error for an unknown exclude preset")
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	// The settings come from the fixture's configuration file
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	analysistest.Run(t, testdata, Analyzer, "profiles/...")
+
+	dir := filepath.Join(testdata, "src", "profiles")
+	for sub, expected := range map[string]string{"api": ModeAll, "internal/helpers": ModeAmbiguous} {
+		cfg, resolveErr := ResolveConfig(Analyzer, filepath.Join(dir, sub))
+		if resolveErr != nil {
+			t.Fatalf("Failed to resolve the settings of %s: %s", sub, resolveErr)
+		}
+		if cfg.Mode != expected {
+			t.Errorf("expected mode %s in %s, got %q", expected, sub, cfg.Mode)
+		}
+	}
+
+	var cfg Config
+	err = cfg.Set(FlagInternalPreset, "lenient")
+	if err == nil {
+		t.Errorf("expected an error for an unknown preset")
+	}
+}
+
+func TestExemptTestHelpers(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	// The settings come from the fixture's configuration file
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	analysistest.Run(t, testdata, Analyzer, "test-helpers")
+
+	// Without type information the helpers are known by their signatures
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filepath.Join(testdata, "src", "test-helpers", "testutil.go"), nil, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("Failed to parse: %s", err)
+	}
+	diagnostics, _, err := RunSyntax(NewAnalyzer(Config{ExemptTestHelpers: true}), fset, []*ast.File{file})
+	if err != nil {
+		t.Fatalf("Failed to run: %s", err)
+	}
+	if len(diagnostics) != 3 {
+		t.Errorf("expected the 3 findings about Compare and Load, got %d: %v", len(diagnostics), diagnostics)
+	}
 }
@@ -0,0 +1,86 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// restatesNil reports whether ret, the last statement of the function of
+// frame, returns its named results as they are, but for nil in the place of
+// a named error the function never assigns, so that a bare return returns
+// the same. Results not all named, or named _, take the values returned.
+func (c *checker) restatesNil(frame *funcFrame, ret *ast.ReturnStmt) (restates bool) {
+	body := frame.body.List
+	if len(body) == 0 || body[len(body)-1] != ret {
+		return restates
+	}
+
+	errName := c.namedError(frame)
+	if errName == nil || c.assignsNamed(frame.body, errName) {
+		return restates
+	}
+
+	i := 0
+	for _, p := range frame.typ.Results.List {
+		if len(p.Names) == 0 {
+			return restates
+		}
+		for _, n := range p.Names {
+			if n.Name == "_" || i >= len(ret.Results) {
+				return restates
+			}
+			ident, ok := ast.Unparen(ret.Results[i]).(*ast.Ident)
+			i++
+			switch {
+			case !ok:
+				return restates
+			case n == errName:
+				if !c.isNil(ident) {
+					return restates
+				}
+			case !c.lookup.assignedIn([]*ast.Ident{ident}, n):
+				return restates
+			}
+		}
+	}
+	restates = i == len(ret.Results)
+	return restates
+}
+
+// isNil reports whether ident is the predeclared nil. Without type
+// information it is taken for it.
+func (c *checker) isNil(ident *ast.Ident) (is bool) {
+	if ident.Name != "nil" {
+		return is
+	}
+
+	obj := c.pass.TypesInfo.Uses[ident]
+	_, isNil := obj.(*types.Nil)
+	is = obj == nil || isNil
+	return is
+}
+
+// checkBareReturns reports the last statement of a function restating its
+// named results with nil for the named error, as in return result, nil,
+// suggesting a bare return instead.
+func (c *checker) checkBareReturns(frame *funcFrame) {
+	body := frame.body.List
+	if len(body) == 0 {
+		return
+	}
+	ret, ok := body[len(body)-1].(*ast.ReturnStmt)
+	if !ok || !c.restatesNil(frame, ret) {
+		return
+	}
+
+	errName := c.namedError(frame)
+	d := diagnosticf(RulePreferBareReturn, ret.Pos(), frame.msgs, MessagePreferBareReturn, frame.name, errName.Name)
+	d.End = ret.End()
+	d.SuggestedFixes = []analysis.SuggestedFix{{
+		Message:   frame.msgs.sprintf(MessageFixBareReturn),
+		TextEdits: []analysis.TextEdit{{Pos: ret.Pos(), End: ret.End(), NewText: []byte("return")}},
+	}}
+	c.report(d, Message{FuncName: frame.name, ReturnName: errName.Name, Type: frame.resultType(errName)})
+}
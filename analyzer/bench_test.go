@@ -0,0 +1,117 @@
+package analyzer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+// wideResultsSource generates a package of functions with width named
+// results each, returning and redeclaring them in many places.
+func wideResultsSource(functions int, width int) (src string) {
+	var b strings.Builder
+	b.WriteString("package wide\n")
+
+	names := make([]string, width)
+	for i := range names {
+		names[i] = fmt.Sprintf("r%d", i)
+	}
+	results := strings.Join(names, ", ")
+
+	for f := 0; f < functions; f++ {
+		fmt.Fprintf(&b, "\nfunc f%d(x int) (%s int) {\n", f, results)
+		for i := 0; i < width; i++ {
+			fmt.Fprintf(&b, "\tif x == %d {\n\t\t%s := x\n\t\t_ = %s\n\t\treturn %s\n\t}\n", i, names[i], names[i], results)
+		}
+		fmt.Fprintf(&b, "\treturn %s\n}\n", strings.Repeat("0, ", width-1)+"0")
+	}
+	src = b.String()
+	return src
+}
+
+func benchmarkWideResults(b *testing.B, width int) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "wide.go", wideResultsSource(50, width), parser.ParseComments)
+	if err != nil {
+		b.Fatalf("Failed to parse: %s", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _, err = RunSyntax(Analyzer, fset, []*ast.File{file})
+		if err != nil {
+			b.Fatalf("RunSyntax failed: %s", err)
+		}
+	}
+}
+
+func BenchmarkWideResults4(b *testing.B)  { benchmarkWideResults(b, 4) }
+func BenchmarkWideResults16(b *testing.B) { benchmarkWideResults(b, 16) }
+func BenchmarkWideResults64(b *testing.B) { benchmarkWideResults(b, 64) }
+
+// genericSource generates a package of generic functions whose results
+// are shadowed, so that fixes renaming the shadowing variables are built.
+func genericSource(functions int) (src string) {
+	var b strings.Builder
+	b.WriteString("package generic\n\ntype Pair[K comparable, V any] struct {\n\tKey K\n\tValue V\n}\n")
+	for f := 0; f < functions; f++ {
+		fmt.Fprintf(&b, `
+func f%d[K comparable, V any](m map[K]V) (pairs []Pair[K, V], err error) {
+	for key, value := range m {
+		pairs := append(pairs, Pair[K, V]{key, value})
+		_ = pairs
+	}
+	defer func() {
+		err = nil
+	}()
+	return pairs, err
+}
+`, f)
+	}
+	src = b.String()
+	return src
+}
+
+func BenchmarkGenerics(b *testing.B) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "generic.go", genericSource(200), parser.ParseComments)
+	if err != nil {
+		b.Fatalf("Failed to parse: %s", err)
+	}
+
+	info := &types.Info{
+		Types: make(map[ast.Expr]types.TypeAndValue),
+		Defs:  make(map[*ast.Ident]types.Object),
+		Uses:  make(map[*ast.Ident]types.Object),
+	}
+	pkg, err := new(types.Config).Check("generic", fset, []*ast.File{file}, info)
+	if err != nil {
+		b.Fatalf("Failed to type check: %s", err)
+	}
+
+	files := []*ast.File{file}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pass := &analysis.Pass{
+			Analyzer:  Analyzer,
+			Fset:      fset,
+			Files:     files,
+			Pkg:       pkg,
+			TypesInfo: info,
+			ResultOf:  map[*analysis.Analyzer]interface{}{inspect.Analyzer: inspector.New(files)},
+			Report:    func(analysis.Diagnostic) {},
+		}
+		_, err = Analyzer.Run(pass)
+		if err != nil {
+			b.Fatalf("Run failed: %s", err)
+		}
+	}
+}
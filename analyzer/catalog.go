@@ -0,0 +1,274 @@
+package analyzer
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultLocale is the locale of the messages unless the locale setting says
+// otherwise. Its catalog holds every message.
+const DefaultLocale = "en"
+
+// Message IDs identify the messages of the catalogs. Like rule IDs, they
+// never change once released, so translations keep working across
+// versions.
+const (
+	MessageUnnamedResult          = "unnamed-result"
+	MessageUnderscoreResult       = "underscore-result"
+	MessageUnusedInReturn         = "unused-in-return"
+	MessageUnusedInReturnRelated  = "unused-in-return.related"
+	MessageShadowedResult         = "shadowed-result"
+	MessageShadowedResultRelated  = "shadowed-result.related"
+	MessageShadowLocalVariable    = "shadowed-result.local-variable"
+	MessageShadowRangeVariable    = "shadowed-result.range-variable"
+	MessageShadowForVariable      = "shadowed-result.for-variable"
+	MessageShadowDeferredVariable = "shadowed-result.deferred-variable"
+	MessageErrorNotLast           = "error-convention.not-last"
+	MessageNamedErrorNotLast      = "error-convention.named-not-last"
+	MessageErrorName              = "error-convention.name"
+	MessageDeferErrorHandler      = "defer-error-handler"
+	MessageUnusedNames            = "unused-names"
+	MessageInconsistentName       = "inconsistent-name"
+	MessageBoolName               = "bool-name"
+	MessageInterfaceName          = "interface-name"
+	MessageInterfaceUnnamed       = "interface-name.unnamed"
+	MessageInterfaceRelated       = "interface-name.related"
+	MessageDocResult              = "doc-result"
+	MessageDocError               = "doc-result.error"
+	MessageDroppedError           = "dropped-error"
+	MessageDroppedErrorRelated    = "dropped-error.related"
+	MessageUnpropagatedError      = "unpropagated-error"
+	MessageUnrecoveredPanic       = "unrecovered-panic"
+	MessageReorderedResults       = "reordered-results"
+	MessagePreferBareReturn       = "prefer-bare-return"
+	MessageShadowedReturn         = "shadowed-return"
+	MessageShadowedReturnLocal    = "shadowed-return.local"
+	MessageIteratorName           = "iterator-name"
+	MessageIgnoreNotAttached      = "invalid-directive.ignore-not-attached"
+	MessageIgnoreNoReason         = "invalid-directive.ignore-no-reason"
+	MessageIgnoreNoRule           = "invalid-directive.ignore-no-rule"
+	MessageDisableFilePlacement   = "invalid-directive.disable-file-placement"
+	MessageConfigPlacement        = "invalid-directive.config-placement"
+	MessageConfigNoSetting        = "invalid-directive.config-no-setting"
+	MessageConfigInvalid          = "invalid-directive.config-invalid"
+	MessageUnknownRule            = "invalid-directive.unknown-rule"
+	MessageUnknownAttribute       = "invalid-directive.unknown-attribute"
+	MessageInvalidExpiry          = "invalid-directive.invalid-expiry"
+	MessageExpired                = "invalid-directive.expired"
+	MessageOptsNotAttached        = "invalid-directive.opts-not-attached"
+	MessageOptsNoSetting          = "invalid-directive.opts-no-setting"
+	MessageOptsInvalid            = "invalid-directive.opts-invalid"
+	MessageOptsPackageSetting     = "invalid-directive.opts-package-setting"
+	MessageGrouped                = "grouped"
+	MessageGroupedAtLine          = "grouped.at-line"
+	MessageFixNameResults         = "fix.name-results"
+	MessageFixNameResult          = "fix.name-result"
+	MessageFixReturnNamed         = "fix.return-named"
+	MessageFixRenameShadow        = "fix.rename-shadow"
+	MessageFixBareReturn          = "fix.bare-return"
+)
+
+// catalogs holds the registered messages, by locale and message ID. The
+// messages are fmt format strings, which may use explicit argument indexes,
+// such as %[2]q, to order the arguments as the language requires.
+var catalogs = map[string]map[string]string{
+	DefaultLocale: {
+		MessageUnnamedResult:          "%s: unnamed return with type %q found - named returns are required",
+		MessageUnderscoreResult:       "%s: underscore as a return variable name is unacceptable for type %q",
+		MessageUnusedInReturn:         "%s: named return variable %q is declared but not used in return statement",
+		MessageUnusedInReturnRelated:  "return statement does not use %q",
+		MessageShadowedResult:         "%s: named return variable %q is shadowed by %s",
+		MessageShadowedResultRelated:  "named return variable %q declared here",
+		MessageShadowLocalVariable:    "local variable declaration",
+		MessageShadowRangeVariable:    "range loop variable",
+		MessageShadowForVariable:      "for loop variable",
+		MessageShadowDeferredVariable: "variable declared in a deferred function, which leaves the named result unchanged",
+		MessageErrorNotLast:           "%s: error result must be the last result",
+		MessageNamedErrorNotLast:      "%s: error result %q must be the last result",
+		MessageErrorName:              "%s: error result %q should be named %q",
+		MessageDeferErrorHandler:      "%s: defers %s but no deferred function handles the named error %q",
+		MessageUnusedNames:            "%s: named results are never used - assign and return them, or use a bare return",
+		MessageInconsistentName:       "%s: result %q of type %q is usually named %q, as %d of %d results of the type in the package are",
+		MessageBoolName:               "%s: boolean result %q should be named one of: %s",
+		MessageInterfaceName:          "%s: result %q should be named %q, as in %s",
+		MessageInterfaceUnnamed:       "%s: result of type %q should be named %q, as in %s",
+		MessageInterfaceRelated:       "%s names the results here",
+		MessageDocResult:              "%s: the doc comment doesn't mention result %q",
+		MessageDocError:               "%s: the doc comment mentions neither result %q nor the errors returned",
+		MessageDroppedError:           "%s: bare return may leave %q unassigned after the error of %s is dropped",
+		MessageDroppedErrorRelated:    "the error of this call is not assigned to %q",
+		MessageUnpropagatedError:      "%s: the error of %s is stored but never reaches a return, leaving %q unset on failure",
+		MessageUnrecoveredPanic:       "%s: panics without a deferred recover converting the panic into %q",
+		MessageReorderedResults:       "%s: returns %s in the place of %s, unlike the order the results are declared in",
+		MessagePreferBareReturn:       "%s: restates the named results with nil for %q, which is never assigned, rather than returning bare",
+		MessageShadowedReturn:         "%s: returns the local variable %q shadowing the named result, not the result itself",
+		MessageShadowedReturnLocal:    "the local variable %q returned is declared here",
+		MessageIteratorName:           "%s: iterator result %q should be named one of: %s",
+		MessageIgnoreNotAttached:      "namedreturns:ignore directive is not attached to a function",
+		MessageIgnoreNoReason:         "namedreturns:ignore directive requires a reason, e.g. //namedreturns:ignore NR003 -- reason",
+		MessageIgnoreNoRule:           "namedreturns:ignore directive names no rule",
+		MessageDisableFilePlacement:   "namedreturns:disable-file directive must precede the package clause",
+		MessageConfigPlacement:        "namedreturns:config directive must be part of the package doc comment",
+		MessageConfigNoSetting:        "namedreturns:config directive names no setting",
+		MessageConfigInvalid:          "namedreturns:config directive: %s",
+		MessageUnknownRule:            "namedreturns:%s directive names unknown rule %q",
+		MessageUnknownAttribute:       "namedreturns:%s directive has unknown attribute %q",
+		MessageInvalidExpiry:          "namedreturns:%s directive has invalid expiry date %q, expected YYYY-MM-DD",
+		MessageExpired:                "namedreturns:%s directive expired on %s",
+		MessageOptsNotAttached:        "namedreturns:opts directive is not attached to a function",
+		MessageOptsNoSetting:          "namedreturns:opts directive names no setting",
+		MessageOptsInvalid:            "namedreturns:opts directive: %s",
+		MessageOptsPackageSetting:     "namedreturns:opts directive can't change %s, which applies to the whole package",
+		MessageGrouped:                "%s: %d problems with the results: %s",
+		MessageGroupedAtLine:          "%s at line %d",
+		MessageFixNameResults:         "Name the results",
+		MessageFixNameResult:          "Name the result %s",
+		MessageFixReturnNamed:         "Assign the named results and return them",
+		MessageFixRenameShadow:        "Rename the shadowing variable to %s",
+		MessageFixBareReturn:          "Return bare",
+	},
+	"de": {
+		MessageUnnamedResult:          "%s: unbenanntes Ergebnis vom Typ %q gefunden - Ergebnisse müssen benannt sein",
+		MessageUnderscoreResult:       "%s: _ ist kein zulässiger Name für das Ergebnis vom Typ %q",
+		MessageUnusedInReturn:         "%s: das benannte Ergebnis %q wird in der return-Anweisung nicht verwendet",
+		MessageUnusedInReturnRelated:  "die return-Anweisung verwendet %q nicht",
+		MessageShadowedResult:         "%s: das benannte Ergebnis %q wird durch eine %s verdeckt",
+		MessageShadowedResultRelated:  "das benannte Ergebnis %q wird hier deklariert",
+		MessageShadowLocalVariable:    "lokale Variablendeklaration",
+		MessageShadowRangeVariable:    "range-Schleifenvariable",
+		MessageShadowForVariable:      "for-Schleifenvariable",
+		MessageShadowDeferredVariable: "in einer verzögerten Funktion deklarierte Variable, die das benannte Ergebnis unverändert lässt",
+		MessageErrorNotLast:           "%s: das error-Ergebnis muss das letzte Ergebnis sein",
+		MessageNamedErrorNotLast:      "%s: das error-Ergebnis %q muss das letzte Ergebnis sein",
+		MessageErrorName:              "%s: das error-Ergebnis %q sollte %q heißen",
+		MessageDeferErrorHandler:      "%s: verzögert %s, aber keine verzögerte Funktion behandelt den benannten Fehler %q",
+		MessageUnusedNames:            "%s: die benannten Ergebnisse werden nie verwendet - weisen Sie sie zu und geben Sie sie zurück, oder verwenden Sie ein leeres return",
+		MessageInconsistentName:       "%s: das Ergebnis %q vom Typ %q heißt im Paket üblicherweise %q, wie %d von %d Ergebnissen dieses Typs",
+		MessageBoolName:               "%s: das boolesche Ergebnis %q sollte einen dieser Namen haben: %s",
+		MessageInterfaceName:          "%s: das Ergebnis %q sollte wie in %[4]s %[3]q heißen",
+		MessageInterfaceUnnamed:       "%s: das Ergebnis vom Typ %q sollte wie in %[4]s %[3]q heißen",
+		MessageInterfaceRelated:       "%s benennt die Ergebnisse hier",
+		MessageDocResult:              "%s: der Doc-Kommentar erwähnt das Ergebnis %q nicht",
+		MessageDocError:               "%s: der Doc-Kommentar erwähnt weder das Ergebnis %q noch die zurückgegebenen Fehler",
+		MessageDroppedError:           "%s: das leere return lässt %q möglicherweise unzugewiesen, nachdem der Fehler von %s verworfen wurde",
+		MessageDroppedErrorRelated:    "der Fehler dieses Aufrufs wird %q nicht zugewiesen",
+		MessageUnpropagatedError:      "%s: der Fehler von %s wird gespeichert, erreicht aber nie ein return, sodass %q bei einem Fehlschlag nicht gesetzt wird",
+		MessageUnrecoveredPanic:       "%s: löst eine Panik aus, ohne dass ein verzögertes recover sie in %q umwandelt",
+		MessageReorderedResults:       "%s: gibt %s an der Stelle von %s zurück, anders als die Ergebnisse deklariert sind",
+		MessagePreferBareReturn:       "%s: wiederholt die benannten Ergebnisse mit nil für das nie zugewiesene %q, statt leer zurückzukehren",
+		MessageShadowedReturn:         "%s: gibt die lokale Variable %q zurück, die das benannte Ergebnis verdeckt, nicht das Ergebnis selbst",
+		MessageShadowedReturnLocal:    "die zurückgegebene lokale Variable %q wird hier deklariert",
+		MessageIteratorName:           "%s: das Iterator-Ergebnis %q sollte einen dieser Namen haben: %s",
+		MessageIgnoreNotAttached:      "die namedreturns:ignore-Direktive gehört zu keiner Funktion",
+		MessageIgnoreNoReason:         "die namedreturns:ignore-Direktive braucht eine Begründung, z. B. //namedreturns:ignore NR003 -- Begründung",
+		MessageIgnoreNoRule:           "die namedreturns:ignore-Direktive nennt keine Regel",
+		MessageDisableFilePlacement:   "die namedreturns:disable-file-Direktive muss vor der package-Klausel stehen",
+		MessageConfigPlacement:        "die namedreturns:config-Direktive muss Teil des Paketkommentars sein",
+		MessageConfigNoSetting:        "die namedreturns:config-Direktive nennt keine Einstellung",
+		MessageConfigInvalid:          "namedreturns:config-Direktive: %s",
+		MessageUnknownRule:            "die namedreturns:%s-Direktive nennt die unbekannte Regel %q",
+		MessageUnknownAttribute:       "die namedreturns:%s-Direktive hat das unbekannte Attribut %q",
+		MessageInvalidExpiry:          "die namedreturns:%s-Direktive hat das ungültige Ablaufdatum %q, erwartet wird JJJJ-MM-TT",
+		MessageExpired:                "die namedreturns:%s-Direktive ist am %s abgelaufen",
+		MessageOptsNotAttached:        "die namedreturns:opts-Direktive gehört zu keiner Funktion",
+		MessageOptsNoSetting:          "die namedreturns:opts-Direktive nennt keine Einstellung",
+		MessageOptsInvalid:            "namedreturns:opts-Direktive: %s",
+		MessageOptsPackageSetting:     "die namedreturns:opts-Direktive kann %s nicht ändern, da es für das ganze Paket gilt",
+		MessageGrouped:                "%s: %d Probleme mit den Ergebnissen: %s",
+		MessageGroupedAtLine:          "%s in Zeile %d",
+		MessageFixNameResults:         "Ergebnisse benennen",
+		MessageFixNameResult:          "Ergebnis %s nennen",
+		MessageFixReturnNamed:         "Benannte Ergebnisse zuweisen und zurückgeben",
+		MessageFixRenameShadow:        "Verdeckende Variable in %s umbenennen",
+		MessageFixBareReturn:          "Leer zurückkehren",
+	},
+}
+
+// catalogsMu guards catalogs, which programs may extend while analyzers run.
+var catalogsMu sync.RWMutex
+
+// RegisterCatalog adds the messages of a locale, by message ID, replacing
+// those registered before. Messages missing from a catalog are taken from
+// the catalog of the locale's language, e.g. "pt" for "pt-BR", then from
+// the default one. Programs embedding the analyzer call it to add a
+// language, or to reword messages of an existing one.
+func RegisterCatalog(locale string, messages map[string]string) (err error) {
+	if locale == "" {
+		err = errors.New("locale must not be empty")
+		return err
+	}
+
+	catalogsMu.Lock()
+	defer catalogsMu.Unlock()
+
+	for id := range messages {
+		if _, ok := catalogs[DefaultLocale][id]; !ok {
+			err = fmt.Errorf("unknown message %q", id)
+			return err
+		}
+	}
+
+	merged := make(map[string]string, len(catalogs[locale])+len(messages))
+	for id, text := range catalogs[locale] {
+		merged[id] = text
+	}
+	for id, text := range messages {
+		merged[id] = text
+	}
+	catalogs[locale] = merged
+	return err
+}
+
+// Locales returns the locales with a registered catalog, sorted.
+func Locales() (locales []string) {
+	catalogsMu.RLock()
+	defer catalogsMu.RUnlock()
+
+	for locale := range catalogs {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// catalog holds the messages of one locale, by message ID, completed from
+// the locales it falls back to.
+type catalog map[string]string
+
+// lookupCatalog returns the messages of locale, which is registered either
+// itself or through its language. An empty locale is the default one.
+func lookupCatalog(locale string) (cat catalog, err error) {
+	catalogsMu.RLock()
+	defer catalogsMu.RUnlock()
+
+	_, known := catalogs[locale]
+	chain := []string{DefaultLocale}
+	if language, _, found := strings.Cut(locale, "-"); found {
+		_, hasLanguage := catalogs[language]
+		known = known || hasLanguage
+		chain = append(chain, language)
+	}
+	chain = append(chain, locale)
+
+	// Later locales in the chain are more specific
+	cat = make(catalog, len(catalogs[DefaultLocale]))
+	for _, l := range chain {
+		for id, text := range catalogs[l] {
+			cat[id] = text
+		}
+	}
+
+	if !known && locale != "" {
+		err = fmt.Errorf("unknown locale %q", locale)
+	}
+	return cat, err
+}
+
+// sprintf formats the message with the given ID.
+func (cat catalog) sprintf(id string, args ...interface{}) (message string) {
+	message = fmt.Sprintf(cat[id], args...)
+	return message
+}
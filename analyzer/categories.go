@@ -0,0 +1,187 @@
+package analyzer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"slices"
+	"strings"
+)
+
+// Type categories of results the exempt-result-types setting exempts from
+// being named.
+const (
+	// CategoryFunc is function types, iterators included.
+	CategoryFunc = "func"
+
+	// CategoryChan is channel types.
+	CategoryChan = "chan"
+
+	// CategoryEmptyStruct is struct types without fields, like struct{}.
+	CategoryEmptyStruct = "empty-struct"
+
+	// CategoryIterator is iter.Seq, iter.Seq2 and function types of the
+	// same shape.
+	CategoryIterator = "iterator"
+
+	// CategorySingleMethod is interfaces with a single method, other than
+	// error.
+	CategorySingleMethod = "single-method-interface"
+)
+
+// categories are the valid values of the exempt-result-types setting.
+var categories = []string{CategoryFunc, CategoryChan, CategoryEmptyStruct, CategoryIterator, CategorySingleMethod}
+
+// categoryListValue is the flag value of a list of type categories, given as
+// comma separated names.
+type categoryListValue struct {
+	list *[]string
+}
+
+func (v categoryListValue) String() (s string) {
+	if v.list != nil {
+		s = strings.Join(*v.list, ",")
+	}
+	return s
+}
+
+func (v categoryListValue) Set(value string) (err error) {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		if !slices.Contains(categories, item) {
+			err = fmt.Errorf("unknown type category %q, expected one of: %s", item, strings.Join(categories, ", "))
+			return err
+		}
+		items = append(items, item)
+	}
+	*v.list = items
+	return err
+}
+
+// exemptResultType reports whether the results of type expr, in the function
+// of frame, need no name because their type is of a category the settings
+// exempt. Without type information it goes by the syntax.
+func (c *checker) exemptResultType(frame *funcFrame, expr ast.Expr) (exempt bool) {
+	if len(frame.cfg.ExemptResultTypes) == 0 {
+		return exempt
+	}
+
+	for _, category := range c.resultCategories(expr) {
+		if slices.Contains(frame.cfg.ExemptResultTypes, category) {
+			exempt = true
+			return exempt
+		}
+	}
+	return exempt
+}
+
+// resultCategories returns the categories the type expr denotes belongs to.
+// Without type information it goes by the syntax.
+func (c *checker) resultCategories(expr ast.Expr) (matches []string) {
+	if t := c.pass.TypesInfo.TypeOf(expr); t != nil {
+		matches = typeCategories(t)
+	} else {
+		matches = syntaxCategories(expr)
+	}
+	return matches
+}
+
+// typeCategories returns the categories t belongs to.
+func typeCategories(t types.Type) (matches []string) {
+	if named, ok := t.(*types.Named); ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "iter" &&
+		(named.Obj().Name() == "Seq" || named.Obj().Name() == "Seq2") {
+		matches = append(matches, CategoryIterator)
+	}
+
+	switch u := t.Underlying().(type) {
+	case *types.Signature:
+		matches = append(matches, CategoryFunc)
+		if iteratorSignature(u) && !slices.Contains(matches, CategoryIterator) {
+			matches = append(matches, CategoryIterator)
+		}
+	case *types.Chan:
+		matches = append(matches, CategoryChan)
+	case *types.Struct:
+		if u.NumFields() == 0 {
+			matches = append(matches, CategoryEmptyStruct)
+		}
+	case *types.Interface:
+		if u.IsMethodSet() && u.NumMethods() == 1 && !types.Identical(t, types.Universe.Lookup("error").Type()) {
+			matches = append(matches, CategorySingleMethod)
+		}
+	}
+	return matches
+}
+
+// iteratorSignature reports whether sig is shaped like an iterator: it takes
+// a yield function returning bool and returns nothing.
+func iteratorSignature(sig *types.Signature) (iterator bool) {
+	if sig.Params().Len() != 1 || sig.Results().Len() != 0 {
+		return iterator
+	}
+
+	yield, ok := sig.Params().At(0).Type().Underlying().(*types.Signature)
+	if !ok || yield.Params().Len() > 2 || yield.Results().Len() != 1 {
+		return iterator
+	}
+	basic, ok := yield.Results().At(0).Type().Underlying().(*types.Basic)
+	iterator = ok && basic.Kind() == types.Bool
+	return iterator
+}
+
+// syntaxCategories returns the categories the type expr spells out belongs
+// to.
+func syntaxCategories(expr ast.Expr) (matches []string) {
+	if index, ok := expr.(*ast.IndexExpr); ok {
+		expr = index.X
+	}
+	if index, ok := expr.(*ast.IndexListExpr); ok {
+		expr = index.X
+	}
+
+	switch e := expr.(type) {
+	case *ast.ParenExpr:
+		matches = syntaxCategories(e.X)
+	case *ast.SelectorExpr:
+		if pkg, ok := e.X.(*ast.Ident); ok && pkg.Name == "iter" && (e.Sel.Name == "Seq" || e.Sel.Name == "Seq2") {
+			matches = []string{CategoryIterator, CategoryFunc}
+		}
+	case *ast.FuncType:
+		matches = []string{CategoryFunc}
+		if iteratorSyntax(e) {
+			matches = append(matches, CategoryIterator)
+		}
+	case *ast.ChanType:
+		matches = []string{CategoryChan}
+	case *ast.StructType:
+		if e.Fields == nil || len(e.Fields.List) == 0 {
+			matches = []string{CategoryEmptyStruct}
+		}
+	case *ast.InterfaceType:
+		if e.Methods != nil && len(e.Methods.List) == 1 && len(e.Methods.List[0].Names) == 1 {
+			matches = []string{CategorySingleMethod}
+		}
+	}
+	return matches
+}
+
+// iteratorSyntax reports whether the function type spelled out by fn is
+// shaped like an iterator.
+func iteratorSyntax(fn *ast.FuncType) (iterator bool) {
+	if fn.Params == nil || len(fn.Params.List) != 1 || len(fn.Params.List[0].Names) > 1 || fn.Results != nil && len(fn.Results.List) > 0 {
+		return iterator
+	}
+
+	yield, ok := fn.Params.List[0].Type.(*ast.FuncType)
+	if !ok || yield.Results == nil || len(yield.Results.List) != 1 {
+		return iterator
+	}
+	result, ok := yield.Results.List[0].Type.(*ast.Ident)
+	iterator = ok && result.Name == "bool" && len(yield.Results.List[0].Names) <= 1
+	return iterator
+}
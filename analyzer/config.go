@@ -0,0 +1,810 @@
+package analyzer
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// FlagConfig names the flag holding the path of the configuration file.
+	FlagConfig = "config"
+
+	// FlagConfigJSON names the flag holding settings as a JSON object, keyed
+	// by setting name, as Bazel's nogo passes analyzer configuration.
+	FlagConfigJSON = "config-json"
+
+	// EnvConfig names the environment variable holding the path of the
+	// configuration file. It is consulted when the config flag is not set,
+	// which is the convenient way to configure the go vet tool.
+	EnvConfig = "NAMEDRETURNS_CONFIG"
+
+	// EnvPrefix starts the names of the environment variables overriding
+	// settings, which EnvName gives.
+	EnvPrefix = "NAMEDRETURNS_"
+)
+
+// configFileNames are the configuration files looked up next to the analyzed
+// package and in its parent directories.
+var configFileNames = []string{".namedreturns.yaml", ".namedreturns.yml"}
+
+// Config holds the settings of the analyzer. Every setting can be given as a
+// flag or as a key of the configuration file, under the same name, and
+// embedding programs can pass a Config to NewAnalyzer.
+type Config struct {
+	ReportErrorInDefer bool     `json:"report-error-in-defer" yaml:"report-error-in-defer"`
+	IgnoreNolint       bool     `json:"ignore-nolint" yaml:"ignore-nolint"`
+	MinReturns         int      `json:"min-returns" yaml:"min-returns"`
+	Mode               string   `json:"mode" yaml:"mode"` // one of the Mode constants, empty for ModeAll
+	ErrorConvention    bool     `json:"error-convention" yaml:"error-convention"`
+	ErrorName          string   `json:"error-name" yaml:"error-name"` // empty for DefaultErrorName
+	DeferErrorHandler  bool     `json:"defer-error-handler" yaml:"defer-error-handler"`
+	ReportUnusedNames  bool     `json:"report-unused-names" yaml:"report-unused-names"`
+	ReportInconsistent bool     `json:"report-inconsistent-names" yaml:"report-inconsistent-names"`
+	BoolConvention     bool     `json:"bool-convention" yaml:"bool-convention"`
+	BoolNames          []string `json:"bool-names" yaml:"bool-names"` // empty for DefaultBoolNames
+	InterfaceNames     bool     `json:"interface-names" yaml:"interface-names"`
+	DocResults         bool     `json:"doc-results" yaml:"doc-results"`
+	Disable            []string `json:"disable" yaml:"disable"` // IDs of the rules not reported
+	Locale             string   `json:"locale" yaml:"locale"`   // language of the messages, empty for DefaultLocale
+	Funcs              []string `json:"func" yaml:"func"`       // names of the only functions checked, empty for all
+	GroupByFunction    bool     `json:"group-by-function" yaml:"group-by-function"`
+	OncePerFunction    bool     `json:"once-per-function" yaml:"once-per-function"`
+	ExcludeFiles       []string `json:"exclude-files" yaml:"exclude-files"`             // globs of the files not analyzed, e.g. **/*.pb.go
+	ExcludePresets     []string `json:"exclude-presets" yaml:"exclude-presets"`         // Generator constants of the code generators whose output is not analyzed
+	ExemptResultTypes  []string `json:"exempt-result-types" yaml:"exempt-result-types"` // Category constants of results that need no name
+	Targets            []string `json:"targets" yaml:"targets"`                         // Target constants of the functions checked, empty for all
+	ExemptCommaOK      bool     `json:"exempt-comma-ok" yaml:"exempt-comma-ok"`
+	ExemptTestHelpers  bool     `json:"exempt-test-helpers" yaml:"exempt-test-helpers"`
+	MustAssignError    bool     `json:"must-assign-error" yaml:"must-assign-error"`
+	ErrorPropagation   bool     `json:"error-propagation" yaml:"error-propagation"`
+	PanicRecover       bool     `json:"panic-recover" yaml:"panic-recover"`
+	ReportReordered    bool     `json:"report-reordered-results" yaml:"report-reordered-results"`
+	PreferBareReturn   bool     `json:"prefer-bare-return" yaml:"prefer-bare-return"`
+	ShadowedReturns    bool     `json:"report-shadowed-returns" yaml:"report-shadowed-returns"`
+	ExemptYieldFuncs   bool     `json:"exempt-yield-funcs" yaml:"exempt-yield-funcs"`
+	IteratorConvention bool     `json:"iterator-convention" yaml:"iterator-convention"`
+	IteratorNames      []string `json:"iterator-names" yaml:"iterator-names"`       // empty for DefaultIteratorNames
+	InternalPreset     string   `json:"internal-preset" yaml:"internal-preset"`     // preset overriding the settings of internal packages, empty for none
+	PublicPreset       string   `json:"public-preset" yaml:"public-preset"`         // preset overriding the settings of the other packages, empty for none
+	InternalPackages   []string `json:"internal-packages" yaml:"internal-packages"` // globs of the internal packages, empty for DefaultInternalPackages
+
+	// Tests holds the settings, by name, that override the others for
+	// the functions declared in _test.go files.
+	Tests map[string]string `json:"tests" yaml:"tests"`
+
+	// Messages holds text/template templates, by rule ID or name,
+	// replacing the messages of the rule's findings. They are executed
+	// with a Message.
+	Messages map[string]string `json:"messages" yaml:"messages"`
+
+	// Exempt holds the rules exempting functions from all checks.
+	Exempt []Exemption `json:"exempt" yaml:"exempt"`
+}
+
+// settingTests is the key of the configuration documents holding the
+// settings for test files. It names no flag, as its value is itself a
+// document of settings.
+const settingTests = "tests"
+
+// settingRoot is the key of configuration files marking them as the root of
+// the configuration, so the files of parent directories are not applied
+// before them.
+const settingRoot = "root"
+
+// DefaultErrorName is the name the error convention requires of error
+// results unless the error-name setting says otherwise.
+const DefaultErrorName = "err"
+
+// DefaultBoolNames are the names the boolean convention allows for boolean
+// results unless the bool-names setting says otherwise.
+var DefaultBoolNames = []string{"ok", "found", "exists", "done"}
+
+// boolNames returns the names allowed for boolean results.
+func (cfg Config) boolNames() (names []string) {
+	names = cfg.BoolNames
+	if len(names) == 0 {
+		names = DefaultBoolNames
+	}
+	return names
+}
+
+// DefaultIteratorNames are the names the iterator convention allows for
+// iterator results unless the iterator-names setting says otherwise.
+var DefaultIteratorNames = []string{"seq"}
+
+// iteratorNames returns the names allowed for iterator results.
+func (cfg Config) iteratorNames() (names []string) {
+	names = cfg.IteratorNames
+	if len(names) == 0 {
+		names = DefaultIteratorNames
+	}
+	return names
+}
+
+// disabled reports whether the findings of the rule with the given ID are
+// not reported.
+func (cfg Config) disabled(rule string) (disabled bool) {
+	disabled = slices.Contains(cfg.Disable, rule)
+	return disabled
+}
+
+// forTests returns the settings in effect in test files: cfg, overridden by
+// its test settings.
+func (cfg Config) forTests() (tests Config, err error) {
+	tests = cfg
+	tests.Tests = nil
+
+	names := make([]string, 0, len(cfg.Tests))
+	for name := range cfg.Tests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		err = tests.Set(name, cfg.Tests[name])
+		if err != nil {
+			err = fmt.Errorf("%s: %w", settingTests, err)
+			return tests, err
+		}
+	}
+	return tests, err
+}
+
+// errorName returns the name required of error results.
+func (cfg Config) errorName() (name string) {
+	name = cfg.ErrorName
+	if name == "" {
+		name = DefaultErrorName
+	}
+	return name
+}
+
+// Modes select the functions whose results must be named.
+const (
+	// ModeAll checks every function with results.
+	ModeAll = "all"
+
+	// ModeDefers checks only the functions containing a defer statement,
+	// where named results matter most: they are the only way for deferred
+	// code to change what the function returns.
+	ModeDefers = "defers"
+
+	// ModeErrors checks only the functions returning an error among their
+	// results, following the common rule to name the results of those.
+	ModeErrors = "errors"
+
+	// ModeAmbiguous checks only the functions with two or more results of
+	// the same underlying type, as (int, int, error), which names tell
+	// apart.
+	ModeAmbiguous = "ambiguous"
+)
+
+// modes are the valid values of the mode setting.
+var modes = []string{ModeAll, ModeDefers, ModeErrors, ModeAmbiguous}
+
+// modeAliases are the other names the mode setting accepts for modes.
+var modeAliases = map[string]string{
+	"error-only": ModeErrors,
+}
+
+// modeValue is the flag value of the mode setting, which accepts only the
+// known modes.
+type modeValue struct {
+	mode *string
+}
+
+func (v modeValue) String() (s string) {
+	s = ModeAll
+	if v.mode != nil && *v.mode != "" {
+		s = *v.mode
+	}
+	return s
+}
+
+func (v modeValue) Set(value string) (err error) {
+	if mode, ok := modeAliases[value]; ok {
+		value = mode
+	}
+	for _, mode := range modes {
+		if value == mode {
+			*v.mode = value
+			return err
+		}
+	}
+
+	err = fmt.Errorf("unknown mode, expected one of: %s", strings.Join(modes, ", "))
+	return err
+}
+
+// listValue is the flag value of a list setting, given as comma separated
+// items.
+type listValue struct {
+	list *[]string
+}
+
+func (v listValue) String() (s string) {
+	if v.list != nil {
+		s = strings.Join(*v.list, ",")
+	}
+	return s
+}
+
+func (v listValue) Set(value string) (err error) {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	*v.list = items
+	return err
+}
+
+// localeValue is the flag value of the locale setting, which accepts only
+// locales with a registered catalog, themselves or through their language.
+type localeValue struct {
+	locale *string
+}
+
+func (v localeValue) String() (s string) {
+	if v.locale != nil {
+		s = *v.locale
+	}
+	return s
+}
+
+func (v localeValue) Set(value string) (err error) {
+	_, err = lookupCatalog(value)
+	if err != nil {
+		err = fmt.Errorf("%w, expected one of: %s", err, strings.Join(Locales(), ", "))
+		return err
+	}
+	*v.locale = value
+	return err
+}
+
+// ruleListValue is the flag value of a list of rules, given as comma
+// separated IDs or names and stored as IDs.
+type ruleListValue struct {
+	list *[]string
+}
+
+func (v ruleListValue) String() (s string) {
+	if v.list != nil {
+		s = strings.Join(*v.list, ",")
+	}
+	return s
+}
+
+func (v ruleListValue) Set(value string) (err error) {
+	var ids []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		rule, ok := LookupRule(item)
+		if !ok {
+			err = fmt.Errorf("unknown rule %q", item)
+			return err
+		}
+		ids = append(ids, rule.ID)
+	}
+	*v.list = ids
+	return err
+}
+
+// bind registers a flag for every setting in fs, storing the values in cfg.
+func (cfg *Config) bind(fs *flag.FlagSet) {
+	fs.BoolVar(&cfg.ReportErrorInDefer, FlagReportErrorInDefer, cfg.ReportErrorInDefer, "report named error if it is assigned inside defer")
+	fs.BoolVar(&cfg.IgnoreNolint, FlagIgnoreNolint, cfg.IgnoreNolint, "don't honor //nolint comments, e.g. when golangci-lint handles them")
+	fs.IntVar(&cfg.MinReturns, FlagMinReturns, cfg.MinReturns, "check only functions with at least this many results")
+	fs.Var(modeValue{&cfg.Mode}, FlagMode, fmt.Sprintf("which functions must name their results, one of: %s", strings.Join(modes, ", ")))
+	fs.BoolVar(&cfg.ErrorConvention, FlagErrorConvention, cfg.ErrorConvention, "require error results to come last and be named as error-name says")
+	fs.StringVar(&cfg.ErrorName, FlagErrorName, cfg.errorName(), "the name error results must have when error-convention is set")
+	fs.BoolVar(&cfg.DeferErrorHandler, FlagDeferErrorHandler, cfg.DeferErrorHandler, "require exported functions deferring cleanup, such as Close, to handle the named error in a deferred function")
+	fs.BoolVar(&cfg.ReportUnusedNames, FlagReportUnusedNames, cfg.ReportUnusedNames, "report functions naming results that the body never uses")
+	fs.BoolVar(&cfg.BoolConvention, FlagBoolConvention, cfg.BoolConvention, "require boolean results to be named from bool-names")
+	fs.Var(listValue{&cfg.BoolNames}, FlagBoolNames, fmt.Sprintf("comma separated names allowed for boolean results when bool-convention is set (default %s)", strings.Join(DefaultBoolNames, ",")))
+	fs.BoolVar(&cfg.InterfaceNames, FlagInterfaceNames, cfg.InterfaceNames, "require methods implementing an interface to name their results as the interface does")
+	fs.BoolVar(&cfg.DocResults, FlagDocResults, cfg.DocResults, "require the doc comments of exported functions to mention their named results, or the errors returned")
+	fs.BoolVar(&cfg.ReportInconsistent, FlagReportInconsistent, cfg.ReportInconsistent, "report results named differently from most results of their type in the package")
+	fs.Var(ruleListValue{&cfg.Disable}, FlagDisable, "comma separated IDs or names of rules not to report")
+	fs.Var(localeValue{&cfg.Locale}, FlagLocale, fmt.Sprintf("language of the messages, one of: %s (default %s)", strings.Join(Locales(), ", "), DefaultLocale))
+	fs.Var(listValue{&cfg.Funcs}, FlagFunc, "check and report only the functions with these comma separated names, e.g. server.(*Server).Start, qualified by package name or import path or not at all")
+	fs.BoolVar(&cfg.GroupByFunction, FlagGroupByFunction, cfg.GroupByFunction, "report the findings about a function as one, listing them all")
+	fs.BoolVar(&cfg.OncePerFunction, FlagOncePerFunction, cfg.OncePerFunction, "report only the most severe finding about each function")
+	fs.Var(categoryListValue{&cfg.ExemptResultTypes}, FlagExemptResultTypes, fmt.Sprintf("comma separated categories of result types that may be left unnamed, or named _, among: %s", strings.Join(categories, ", ")))
+	fs.Var(targetListValue{&cfg.Targets}, FlagTargets, fmt.Sprintf("comma separated kinds of functions checked, among: %s (default all)", strings.Join(targets, ", ")))
+	fs.BoolVar(&cfg.ExemptCommaOK, FlagExemptCommaOK, cfg.ExemptCommaOK, "exempt functions only passing on a comma-ok map index, type assertion or channel receive, as in v, ok := m[k]; return v, ok")
+	fs.BoolVar(&cfg.ExemptTestHelpers, FlagExemptTestHelpers, cfg.ExemptTestHelpers, "exempt functions taking a *testing.T, *testing.B or testing.TB first, test helpers in _test.go files or not")
+	fs.BoolVar(&cfg.MustAssignError, FlagMustAssignError, cfg.MustAssignError, "report bare returns that may leave the named error unassigned after dropping the error of a call")
+	fs.BoolVar(&cfg.ErrorPropagation, FlagErrorPropagation, cfg.ErrorPropagation, "report errors of calls stored in variables that never reach a return, in the namedreturns_propagation analyzer")
+	fs.BoolVar(&cfg.PanicRecover, FlagPanicRecover, cfg.PanicRecover, "require functions with a named error that panic to defer a recover converting the panic into the error")
+	fs.BoolVar(&cfg.ReportReordered, FlagReportReordered, cfg.ReportReordered, "report return statements returning named results of identical types in each other's places, as in return b, a for results (a, b int)")
+	fs.BoolVar(&cfg.PreferBareReturn, FlagPreferBareReturn, cfg.PreferBareReturn, "report functions ending with a return restating the named results with nil for a named error never assigned, as in return result, nil, rather than returning bare")
+	fs.BoolVar(&cfg.ShadowedReturns, FlagShadowedReturns, cfg.ShadowedReturns, "report return statements returning a local variable shadowing the named result of the same name, which takes type information")
+	fs.BoolVar(&cfg.ExemptYieldFuncs, FlagExemptYieldFuncs, cfg.ExemptYieldFuncs, "exempt function literals passed to iterators as their yield function, as in seq(func(v int) bool { ... })")
+	fs.BoolVar(&cfg.IteratorConvention, FlagIteratorConvention, cfg.IteratorConvention, "require iterator results, iter.Seq, iter.Seq2 and functions of their shape, to be named from iterator-names")
+	fs.Var(listValue{&cfg.IteratorNames}, FlagIteratorNames, fmt.Sprintf("comma separated names allowed for iterator results when iterator-convention is set (default %s)", strings.Join(DefaultIteratorNames, ",")))
+	fs.Var(presetValue{&cfg.InternalPreset}, FlagInternalPreset, fmt.Sprintf("preset whose settings override the others in internal packages, one of: %s (default none)", strings.Join(presetNames, ", ")))
+	fs.Var(presetValue{&cfg.PublicPreset}, FlagPublicPreset, fmt.Sprintf("preset whose settings override the others in the packages that aren't internal, one of: %s (default none)", strings.Join(presetNames, ", ")))
+	fs.Var(globListValue{&cfg.InternalPackages}, FlagInternalPackages, fmt.Sprintf("comma separated globs of the directories of internal packages, relative to the module root, for internal-preset and public-preset (default %s)", strings.Join(DefaultInternalPackages, ",")))
+	fs.Var(globListValue{&cfg.ExcludeFiles}, FlagExcludeFiles, "comma separated globs of files not to analyze, relative to the module root, in which ** matches any number of directories, e.g. **/*.pb.go,internal/legacy/**")
+	fs.Var(generatorListValue{&cfg.ExcludePresets}, FlagExcludePresets, fmt.Sprintf("comma separated code generators whose output is not analyzed, by file name, package name or header, among: %s", strings.Join(generators, ", ")))
+}
+
+// EnvName returns the name of the environment variable overriding the
+// setting with the given name: EnvPrefix followed by the name in upper case,
+// with dashes as underscores, e.g. NAMEDRETURNS_ERROR_CONVENTION for
+// error-convention.
+func EnvName(setting string) (name string) {
+	name = EnvPrefix + strings.ToUpper(strings.ReplaceAll(setting, "-", "_"))
+	return name
+}
+
+// applyEnv sets the settings given by environment variables, parsing their
+// values the way the corresponding flags would.
+func (cfg *Config) applyEnv() (err error) {
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	cfg.bind(fs)
+
+	fs.VisitAll(func(f *flag.Flag) {
+		value, set := os.LookupEnv(EnvName(f.Name))
+		if !set || err != nil {
+			return
+		}
+
+		err = cfg.Set(f.Name, value)
+		if err != nil {
+			err = fmt.Errorf("%s: %w", EnvName(f.Name), err)
+		}
+	})
+	return err
+}
+
+// Set changes the setting with the given name, parsing value the way the
+// corresponding flag would.
+func (cfg *Config) Set(name string, value string) (err error) {
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	cfg.bind(fs)
+
+	f := fs.Lookup(name)
+	if f == nil {
+		err = fmt.Errorf("unknown setting %q", name)
+		return err
+	}
+
+	err = f.Value.Set(value)
+	if err != nil {
+		err = fmt.Errorf("invalid value %q for setting %q: %w", value, name, err)
+		return err
+	}
+	return err
+}
+
+// LoadConfigFile applies the settings of the YAML configuration file at path
+// to cfg. Keys are setting names, e.g.
+//
+//	report-error-in-defer: true
+//	tests:
+//	  mode: errors
+//	  disable: [shadowed-result]
+//
+// Lists are accepted wherever a flag takes a comma separated value. The tests
+// key holds the settings overriding the others in test files, the messages
+// key the message templates by rule, and the exempt key the exemption rules.
+func LoadConfigFile(path string, cfg *Config) (err error) {
+	var data []byte
+	data, err = os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var values map[string]interface{}
+	err = yaml.Unmarshal(data, &values)
+	if err != nil {
+		err = fmt.Errorf("parsing %s: %w", path, err)
+		return err
+	}
+
+	err = cfg.apply(values)
+	if err != nil {
+		err = fmt.Errorf("%s: %w", path, err)
+		return err
+	}
+	return err
+}
+
+// ParseConfigJSON applies the settings of a JSON object keyed by setting
+// name to cfg, e.g.
+//
+//	{"report-error-in-defer": true}
+func ParseConfigJSON(data []byte, cfg *Config) (err error) {
+	var values map[string]interface{}
+	err = json.Unmarshal(data, &values)
+	if err != nil {
+		err = fmt.Errorf("parsing JSON settings: %w", err)
+		return err
+	}
+
+	err = cfg.apply(values)
+	return err
+}
+
+// apply sets the settings of a decoded configuration document.
+func (cfg *Config) apply(values map[string]interface{}) (err error) {
+	// Apply settings in a stable order so errors are reproducible
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		err = cfg.applySetting(name, values[name])
+		if err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// applySetting sets the setting with the given name to its value in a
+// decoded configuration document.
+func (cfg *Config) applySetting(name string, value interface{}) (err error) {
+	switch name {
+	case settingTests:
+		err = cfg.applyTests(value)
+	case settingMessages:
+		err = cfg.applyMessages(value)
+	case settingExempt:
+		err = cfg.applyExempt(value)
+	case settingRoot:
+		if _, ok := value.(bool); !ok {
+			err = fmt.Errorf("%s must be true or false", settingRoot)
+		}
+	default:
+		err = cfg.Set(name, settingString(value))
+	}
+	return err
+}
+
+// applyTests merges the settings for test files of a decoded configuration
+// document into those of cfg, checking them as it goes.
+func (cfg *Config) applyTests(value interface{}) (err error) {
+	values, ok := value.(map[string]interface{})
+	if !ok {
+		err = fmt.Errorf("%s must hold settings by name", settingTests)
+		return err
+	}
+
+	// The map may be shared with the defaults of the analyzer, so it is
+	// copied rather than changed
+	tests := make(map[string]string, len(cfg.Tests)+len(values))
+	for name, v := range cfg.Tests {
+		tests[name] = v
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	probe := *cfg
+	for _, name := range names {
+		tests[name] = settingString(values[name])
+		err = probe.Set(name, tests[name])
+		if err != nil {
+			err = fmt.Errorf("%s: %w", settingTests, err)
+			return err
+		}
+	}
+
+	cfg.Tests = tests
+	return err
+}
+
+// settingString renders a decoded YAML value the way it would be given as a flag.
+func settingString(value interface{}) (s string) {
+	switch v := value.(type) {
+	case nil:
+		s = ""
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, settingString(item))
+		}
+		s = strings.Join(items, ",")
+	default:
+		s = fmt.Sprint(v)
+	}
+	return s
+}
+
+// FindConfigFiles looks for the configuration files in dir and its parents,
+// up to the first directory containing a go.mod file, or to the first file
+// setting root to true. They are returned outermost first, the order in
+// which they apply: settings of files nearer to dir override those of files
+// further up, lists included, while the tests, messages and exempt
+// settings add to them.
+func FindConfigFiles(dir string) (paths []string) {
+	for {
+		path, found := FindConfigFile(dir)
+		if !found {
+			break
+		}
+		paths = append(paths, path)
+
+		dir = filepath.Dir(path)
+		parent := filepath.Dir(dir)
+		if isRootConfig(path) || parent == dir {
+			break
+		}
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			break
+		}
+		dir = parent
+	}
+
+	slices.Reverse(paths)
+	return paths
+}
+
+// isRootConfig reports whether the configuration file at path sets root to
+// true. Files that can't be read or parsed fail when loaded instead.
+func isRootConfig(path string) (root bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return root
+	}
+
+	var values map[string]interface{}
+	if yaml.Unmarshal(data, &values) == nil {
+		root, _ = values[settingRoot].(bool)
+	}
+	return root
+}
+
+// FindConfigFile looks for a configuration file in dir and its parents,
+// stopping at the first directory containing a go.mod file.
+func FindConfigFile(dir string) (path string, found bool) {
+	for {
+		for _, name := range configFileNames {
+			candidate := filepath.Join(dir, name)
+			if _, err := os.Stat(candidate); err == nil {
+				path = candidate
+				found = true
+				return path, found
+			}
+		}
+
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return path, found
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return path, found
+		}
+		dir = parent
+	}
+}
+
+// setFlag wraps a flag value and remembers whether it was set explicitly, so
+// that explicit flags take precedence over the configuration file.
+type setFlag struct {
+	flag.Value
+	set bool
+}
+
+func (f *setFlag) Set(value string) (err error) {
+	err = f.Value.Set(value)
+	if err == nil {
+		f.set = true
+	}
+	return err
+}
+
+func (f *setFlag) String() (s string) {
+	// The flag package calls this on a zero value to find the default
+	if f != nil && f.Value != nil {
+		s = f.Value.String()
+	}
+	return s
+}
+
+func (f *setFlag) IsBoolFlag() (isBool bool) {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	isBool = ok && b.IsBoolFlag()
+	return isBool
+}
+
+// settings is the configuration state of one analyzer: the defaults it was
+// created with and the values of its flags. It is kept with the analyzer,
+// rather than in package variables, and reached through the value of its
+// config flag, so drivers can set the flags of any number of instances.
+type settings struct {
+	defaults   Config
+	flags      map[string]*setFlag // the flags of the settings, by name
+	configPath string
+	configJSON string
+
+	// run runs the analyzer, stopping once the context is done
+	run func(context.Context, *analysis.Pass) (interface{}, error)
+}
+
+// configFlag is the value of the config flag, holding the path of the
+// configuration file in the settings it leads to.
+type configFlag struct {
+	s *settings
+}
+
+func (f configFlag) String() (path string) {
+	if f.s != nil {
+		path = f.s.configPath
+	}
+	return path
+}
+
+func (f configFlag) Set(value string) (err error) {
+	f.s.configPath = value
+	return err
+}
+
+// lookupSettings returns the settings of a, if it is one of the analyzers
+// of this package, or a copy of one.
+func lookupSettings(a *analysis.Analyzer) (s *settings, ok bool) {
+	f := a.Flags.Lookup(FlagConfig)
+	if f == nil {
+		return s, ok
+	}
+
+	var value configFlag
+	value, ok = f.Value.(configFlag)
+	s = value.s
+	ok = ok && s != nil
+	return s, ok
+}
+
+// ResolveConfig returns the settings a, one of the analyzers of this
+// package, applies to the package in dir, from its defaults, configuration
+// file, JSON settings and flags, but not from directives in the package.
+// Drivers use it to skip work the analyzer would discard, such as loading
+// excluded files.
+func ResolveConfig(a *analysis.Analyzer, dir string) (cfg Config, err error) {
+	s, ok := lookupSettings(a)
+	if !ok {
+		err = fmt.Errorf("analyzer %s is not one of namedreturns", a.Name)
+		return cfg, err
+	}
+
+	cfg, err = s.resolveDir(dir)
+	return cfg, err
+}
+
+// ConfigFiles returns the configuration files a, one of the analyzers of
+// this package, applies to the package in dir, in the order they apply: the
+// file of its config flag or of EnvConfig, or else those FindConfigFiles
+// finds from dir.
+func ConfigFiles(a *analysis.Analyzer, dir string) (paths []string, err error) {
+	s, ok := lookupSettings(a)
+	if !ok {
+		err = fmt.Errorf("analyzer %s is not one of namedreturns", a.Name)
+		return paths, err
+	}
+
+	paths = s.configFiles(dir)
+	return paths, err
+}
+
+// configFiles returns the configuration files applying to the package in
+// dir, none when neither a file is given nor dir.
+func (s *settings) configFiles(dir string) (paths []string) {
+	switch path := s.configPath; {
+	case path != "":
+		paths = []string{path}
+	case os.Getenv(EnvConfig) != "":
+		paths = []string{os.Getenv(EnvConfig)}
+	case dir != "":
+		paths = FindConfigFiles(dir)
+	}
+	return paths
+}
+
+// newSettings creates the settings of an analyzer along with its flags.
+func newSettings(defaults Config) (s *settings, fs flag.FlagSet) {
+	s = &settings{defaults: defaults, flags: make(map[string]*setFlag)}
+
+	// Each analyzer gets its own storage for the flag values
+	values := defaults
+	bound := flag.NewFlagSet("", flag.ContinueOnError)
+	values.bind(bound)
+
+	// Remember which flags are set so they can override the configuration file
+	bound.VisitAll(func(f *flag.Flag) {
+		s.flags[f.Name] = &setFlag{Value: f.Value}
+		fs.Var(s.flags[f.Name], f.Name, f.Usage)
+	})
+
+	fs.Var(configFlag{s}, FlagConfig, "path of the YAML configuration file (default $"+EnvConfig+", then the .namedreturns.yaml files in the package directory and its parents up to the module root, nearer ones overriding)")
+	fs.StringVar(&s.configJSON, FlagConfigJSON, "", "settings as a JSON object keyed by setting name, applied over the configuration file")
+	return s, fs
+}
+
+// resolve computes the settings in effect for pass, before its package's
+// own directives.
+func (s *settings) resolve(pass *analysis.Pass) (cfg Config, err error) {
+	var dir string
+	if len(pass.Files) > 0 {
+		dir = filepath.Dir(pass.Fset.File(pass.Files[0].Pos()).Name())
+	}
+	cfg, err = s.resolveDir(dir)
+	return cfg, err
+}
+
+// resolveDir computes the settings in effect for the package in dir: the
+// defaults, overridden by the configuration file, or the files found from
+// dir, then by the JSON settings, then by the environment, then by the
+// flags that were set explicitly. Without a dir, no configuration file is
+// looked up.
+func (s *settings) resolveDir(dir string) (cfg Config, err error) {
+	cfg = s.defaults
+
+	for _, path := range s.configFiles(dir) {
+		err = LoadConfigFile(path, &cfg)
+		if err != nil {
+			return cfg, err
+		}
+	}
+
+	configJSON := s.configJSON
+	if configJSON == "" {
+		configJSON = os.Getenv(EnvName(FlagConfigJSON))
+	}
+	if configJSON != "" {
+		err = ParseConfigJSON([]byte(configJSON), &cfg)
+		if err != nil {
+			return cfg, err
+		}
+	}
+
+	err = cfg.applyEnv()
+	if err != nil {
+		return cfg, err
+	}
+
+	names := make([]string, 0, len(s.flags))
+	for name := range s.flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if f := s.flags[name]; f.set {
+			err = cfg.Set(name, f.Value.String())
+			if err != nil {
+				return cfg, err
+			}
+		}
+	}
+
+	if dir != "" {
+		cfg, err = cfg.withProfile(dir)
+	}
+	return cfg, err
+}
@@ -0,0 +1,92 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/types"
+	"sort"
+)
+
+// minUsualNameCount is how many results of a type must share a name before
+// other names for the type count as outliers.
+const minUsualNameCount = 3
+
+// outlierRatio is how many times more often the usual name of a type must be
+// used than another name for the other name to be reported.
+const outlierRatio = 3
+
+// nameTally accumulates the names given to results of each type across a
+// package, so the names deviating from the package's usual one can be
+// reported once every function has been seen.
+type nameTally struct {
+	qualifier types.Qualifier
+	names     map[string]map[string][]*ast.Ident // result names by type, then name
+	funcNames map[*ast.Ident]string              // the function declaring each name
+}
+
+// newNameTally creates an empty tally for the package pkg, which may be nil
+// without type information.
+func newNameTally(pkg *types.Package) (t *nameTally) {
+	t = &nameTally{
+		qualifier: types.RelativeTo(pkg),
+		names:     make(map[string]map[string][]*ast.Ident),
+		funcNames: make(map[*ast.Ident]string),
+	}
+	return t
+}
+
+// add records the names of the results of the function of frame.
+func (t *nameTally) add(lookup *typeCache, frame *funcFrame) {
+	for _, field := range frame.typ.Results.List {
+		typ := types.ExprString(field.Type)
+		if tv := lookup.info.TypeOf(field.Type); tv != nil {
+			typ = types.TypeString(tv, t.qualifier)
+		}
+
+		for _, n := range field.Names {
+			if n.Name == "_" {
+				continue
+			}
+			if t.names[typ] == nil {
+				t.names[typ] = make(map[string][]*ast.Ident)
+			}
+			t.names[typ][n.Name] = append(t.names[typ][n.Name], n)
+			t.funcNames[n] = frame.name
+		}
+	}
+}
+
+// report reports the results named differently from the usual name of their
+// type in the package, when the usual name is used at least
+// minUsualNameCount times and outlierRatio times as often as theirs.
+func (t *nameTally) report(c *checker) {
+	typeNames := make([]string, 0, len(t.names))
+	for typ := range t.names {
+		typeNames = append(typeNames, typ)
+	}
+	sort.Strings(typeNames)
+
+	for _, typ := range typeNames {
+		byName := t.names[typ]
+		usual, total := "", 0
+		for name, idents := range byName {
+			total += len(idents)
+			if len(idents) > len(byName[usual]) || len(idents) == len(byName[usual]) && name < usual {
+				usual = name
+			}
+		}
+		usualCount := len(byName[usual])
+		if usualCount < minUsualNameCount {
+			continue
+		}
+
+		for name, idents := range byName {
+			if name == usual || len(idents)*outlierRatio > usualCount {
+				continue
+			}
+			for _, n := range idents {
+				c.report(diagnosticf(RuleInconsistentName, n.Pos(), c.msgs, MessageInconsistentName, t.funcNames[n], name, typ, usual, usualCount, total),
+					Message{FuncName: t.funcNames[n], ReturnName: name, Type: typ})
+			}
+		}
+	}
+}
@@ -0,0 +1,386 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// ignorePattern matches an ignore directive, capturing its arguments:
+//
+//	//namedreturns:ignore NR003,shadowed-result -- reason
+//	//namedreturns:ignore NR001 expires=2025-12-31 -- reason
+var ignorePattern = regexp.MustCompile(`^//namedreturns:ignore(?:\s+(.*))?$`)
+
+// disableFilePattern matches a disable-file directive, capturing its
+// arguments:
+//
+//	//namedreturns:disable-file
+//	//namedreturns:disable-file NR001,NR003 -- reason
+var disableFilePattern = regexp.MustCompile(`^//namedreturns:disable-file(?:\s+(.*))?$`)
+
+// packageConfigPattern matches a package configuration directive, capturing
+// its settings:
+//
+//	//namedreturns:config min-returns=2 report-error-in-defer
+var packageConfigPattern = regexp.MustCompile(`^//namedreturns:config(?:\s+(.*))?$`)
+
+// funcOptionsPattern matches a function options directive, capturing its
+// settings:
+//
+//	//namedreturns:opts min-returns=2 mode=errors
+var funcOptionsPattern = regexp.MustCompile(`^//namedreturns:opts(?:\s+(.*))?$`)
+
+// packageSettings are the settings applying to a whole package, which
+// function options can't change.
+var packageSettings = map[string]bool{
+	FlagIgnoreNolint:       true,
+	FlagReportInconsistent: true,
+	FlagDisable:            true,
+	FlagLocale:             true,
+	FlagFunc:               true,
+	FlagGroupByFunction:    true,
+	FlagOncePerFunction:    true,
+	FlagExcludeFiles:       true,
+	FlagExcludePresets:     true,
+	FlagInternalPreset:     true,
+	FlagPublicPreset:       true,
+	FlagInternalPackages:   true,
+}
+
+// trailingComment matches the start of a comment following a directive.
+var trailingComment = regexp.MustCompile(`(^|\s)//`)
+
+// lineRange is a range of lines, inclusive.
+type lineRange struct {
+	from, to int
+}
+
+// suppression silences the findings of some rules in a range of lines.
+type suppression struct {
+	lines lineRange
+	rules map[string]bool // by ID, nil for all rules
+}
+
+// suppressions maps file names to the suppressions in them.
+type suppressions map[string][]suppression
+
+// add suppresses the findings of rules, or of all rules when rules is nil,
+// in the given lines of file.
+func (s suppressions) add(file string, lines lineRange, rules map[string]bool) {
+	s[file] = append(s[file], suppression{lines: lines, rules: rules})
+}
+
+// covers reports whether d is suppressed.
+func (s suppressions) covers(fset *token.FileSet, d analysis.Diagnostic) (covered bool) {
+	position := fset.Position(d.Pos)
+	for _, sup := range s[position.Filename] {
+		if sup.lines.from <= position.Line && position.Line <= sup.lines.to && (sup.rules == nil || sup.rules[d.Category]) {
+			covered = true
+			return covered
+		}
+	}
+	return covered
+}
+
+// addIgnores adds the suppressions of the ignore directives in the files of
+// pass to s. A directive applies to the function declared or starting on the
+// line after the comment it is part of, such as a doc comment, and must name
+// the rules it suppresses and give a reason after --. Directives that don't
+// are reported and have no effect.
+func addIgnores(pass *analysis.Pass, msgs catalog, s suppressions) {
+	for _, file := range pass.Files {
+		tokFile := pass.Fset.File(file.Pos())
+		if tokFile == nil {
+			continue
+		}
+
+		unattached := forAttached(pass.Fset, file, ignorePattern, func(node ast.Node, directives []*ast.Comment) {
+			lines := lineRange{pass.Fset.Position(node.Pos()).Line, pass.Fset.Position(node.End()).Line}
+			for _, c := range directives {
+				rules, valid := parseIgnore(pass, msgs, c)
+				if valid {
+					s.add(tokFile.Name(), lines, rules)
+				}
+			}
+		})
+		for _, c := range unattached {
+			reportf(pass, RuleInvalidDirective, c.Slash, msgs, MessageIgnoreNotAttached)
+		}
+	}
+}
+
+// forAttached calls attach with each function of file, declared or literal,
+// that directives matching pattern are attached to: they are part of a
+// comment, such as a doc comment, ending on the line before the function
+// starts. It returns the directives attached to no function.
+func forAttached(fset *token.FileSet, file *ast.File, pattern *regexp.Regexp, attach func(node ast.Node, directives []*ast.Comment)) (unattached []*ast.Comment) {
+	// Comment groups with directives, by the line they end on
+	groups := make(map[int][]*ast.Comment)
+	for _, group := range file.Comments {
+		for _, c := range group.List {
+			if pattern.MatchString(c.Text) {
+				line := fset.Position(group.End()).Line
+				groups[line] = append(groups[line], c)
+			}
+		}
+	}
+	if len(groups) == 0 {
+		return unattached
+	}
+
+	ast.Inspect(file, func(node ast.Node) (continueInspection bool) {
+		continueInspection = true
+		switch node.(type) {
+		case *ast.FuncDecl, *ast.FuncLit:
+		default:
+			return continueInspection
+		}
+
+		// A doc comment ends on the line before the func keyword
+		start := fset.Position(node.Pos()).Line
+		directives, ok := groups[start-1]
+		if !ok {
+			return continueInspection
+		}
+		delete(groups, start-1)
+
+		attach(node, directives)
+		return continueInspection
+	})
+
+	for _, directives := range groups {
+		unattached = append(unattached, directives...)
+	}
+	return unattached
+}
+
+// setting is a setting given by a directive.
+type setting struct {
+	name, value string
+}
+
+// funcOptions collects the settings of the opts directives attached to
+// functions, by function. They change the settings in effect for the
+// function and its function literals. Invalid directives and settings, and
+// the package wide settings, which can't be changed for a function, are
+// reported and left out.
+func funcOptions(pass *analysis.Pass, msgs catalog) (options map[ast.Node][]setting) {
+	options = make(map[ast.Node][]setting)
+	for _, file := range pass.Files {
+		unattached := forAttached(pass.Fset, file, funcOptionsPattern, func(node ast.Node, directives []*ast.Comment) {
+			for _, c := range directives {
+				settings := strings.Fields(stripTrailingComment(funcOptionsPattern.FindStringSubmatch(c.Text)[1]))
+				if len(settings) == 0 {
+					reportf(pass, RuleInvalidDirective, c.Slash, msgs, MessageOptsNoSetting)
+					continue
+				}
+
+				for _, s := range settings {
+					name, value := parseSetting(s)
+					if packageSettings[name] {
+						reportf(pass, RuleInvalidDirective, c.Slash, msgs, MessageOptsPackageSetting, name)
+						continue
+					}
+
+					// The settings are applied once the function is
+					// checked, so they are checked on their own here
+					var probe Config
+					err := probe.Set(name, value)
+					if err != nil {
+						reportf(pass, RuleInvalidDirective, c.Slash, msgs, MessageOptsInvalid, err)
+						continue
+					}
+					options[node] = append(options[node], setting{name: name, value: value})
+				}
+			}
+		})
+		for _, c := range unattached {
+			reportf(pass, RuleInvalidDirective, c.Slash, msgs, MessageOptsNotAttached)
+		}
+	}
+	return options
+}
+
+// parseIgnore parses an ignore directive into the IDs of the rules it
+// suppresses, reporting it when it is malformed.
+func parseIgnore(pass *analysis.Pass, msgs catalog, c *ast.Comment) (rules map[string]bool, valid bool) {
+	args := stripTrailingComment(ignorePattern.FindStringSubmatch(c.Text)[1])
+	names, reason, found := strings.Cut(args, "--")
+	if !found || strings.TrimSpace(reason) == "" {
+		reportf(pass, RuleInvalidDirective, c.Slash, msgs, MessageIgnoreNoReason)
+		return rules, valid
+	}
+
+	rules, valid = parseRules(pass, msgs, c, "ignore", names)
+	if valid && len(rules) == 0 {
+		reportf(pass, RuleInvalidDirective, c.Slash, msgs, MessageIgnoreNoRule)
+		rules = nil
+		valid = false
+		return rules, valid
+	}
+	return rules, valid
+}
+
+// addDisableFile adds the suppressions of the disable-file directives in the
+// files of pass to s. A directive suppresses the findings of the rules it
+// names, or of all rules, in the whole file, and must precede the package
+// clause.
+func addDisableFile(pass *analysis.Pass, msgs catalog, s suppressions) {
+	for _, file := range pass.Files {
+		tokFile := pass.Fset.File(file.Pos())
+		if tokFile == nil {
+			continue
+		}
+
+		for _, group := range file.Comments {
+			for _, c := range group.List {
+				match := disableFilePattern.FindStringSubmatch(c.Text)
+				if match == nil {
+					continue
+				}
+				if c.Pos() > file.Package {
+					reportf(pass, RuleInvalidDirective, c.Slash, msgs, MessageDisableFilePlacement)
+					continue
+				}
+
+				// The reason is optional, as the file header usually explains it
+				names, _, _ := strings.Cut(stripTrailingComment(match[1]), "--")
+				rules, valid := parseRules(pass, msgs, c, "disable-file", names)
+				if !valid {
+					continue
+				}
+				if len(rules) == 0 {
+					rules = nil
+				}
+				s.add(tokFile.Name(), lineRange{1, tokFile.LineCount()}, rules)
+			}
+		}
+	}
+}
+
+// applyPackageConfig applies the settings of the config directives in the
+// package doc comments of pass's files to cfg, in file order. A setting
+// without a value is set to true. The invalid directives are returned as
+// diagnostics, to be reported once the settings, which control reporting,
+// are known.
+func applyPackageConfig(pass *analysis.Pass, msgs catalog, cfg *Config) (diagnostics []analysis.Diagnostic) {
+	for _, file := range pass.Files {
+		for _, group := range file.Comments {
+			for _, c := range group.List {
+				match := packageConfigPattern.FindStringSubmatch(c.Text)
+				if match == nil {
+					continue
+				}
+				if group != file.Doc {
+					diagnostics = append(diagnostics, diagnosticf(RuleInvalidDirective, c.Slash, msgs, MessageConfigPlacement))
+					continue
+				}
+
+				settings := strings.Fields(stripTrailingComment(match[1]))
+				if len(settings) == 0 {
+					diagnostics = append(diagnostics, diagnosticf(RuleInvalidDirective, c.Slash, msgs, MessageConfigNoSetting))
+					continue
+				}
+				for _, setting := range settings {
+					name, value := parseSetting(setting)
+					err := cfg.Set(name, value)
+					if err != nil {
+						diagnostics = append(diagnostics, diagnosticf(RuleInvalidDirective, c.Slash, msgs, MessageConfigInvalid, err))
+					}
+				}
+			}
+		}
+	}
+	return diagnostics
+}
+
+// parseRules parses the rule IDs or names of a directive, separated by commas
+// or spaces, into rule IDs, reporting unknown ones. The rules may be mixed
+// with attributes of the form name=value. A directive whose expires
+// attribute is a past date is reported and not valid.
+func parseRules(pass *analysis.Pass, msgs catalog, c *ast.Comment, directive string, names string) (rules map[string]bool, valid bool) {
+	fields := strings.FieldsFunc(names, func(r rune) (isSeparator bool) {
+		isSeparator = r == ',' || r == ' ' || r == '\t'
+		return isSeparator
+	})
+
+	rules = make(map[string]bool, len(fields))
+	for _, name := range fields {
+		if attribute, value, isAttribute := strings.Cut(name, "="); isAttribute {
+			if !checkAttribute(pass, msgs, c, directive, attribute, value) {
+				rules = nil
+				return rules, valid
+			}
+			continue
+		}
+
+		rule, ok := LookupRule(name)
+		if !ok {
+			reportf(pass, RuleInvalidDirective, c.Slash, msgs, MessageUnknownRule, directive, name)
+			rules = nil
+			return rules, valid
+		}
+		rules[rule.ID] = true
+	}
+
+	valid = true
+	return rules, valid
+}
+
+// checkAttribute checks an attribute of a directive, reporting it when it is
+// unknown or malformed, or when it makes the directive lapse.
+func checkAttribute(pass *analysis.Pass, msgs catalog, c *ast.Comment, directive string, name string, value string) (valid bool) {
+	if name != "expires" {
+		reportf(pass, RuleInvalidDirective, c.Slash, msgs, MessageUnknownAttribute, directive, name)
+		return valid
+	}
+
+	expires, err := time.ParseInLocation(time.DateOnly, value, time.Local)
+	if err != nil {
+		reportf(pass, RuleInvalidDirective, c.Slash, msgs, MessageInvalidExpiry, directive, value)
+		return valid
+	}
+
+	// The directive holds through the day it expires
+	if !time.Now().Before(expires.AddDate(0, 0, 1)) {
+		reportf(pass, RuleInvalidDirective, c.Slash, msgs, MessageExpired, directive, value)
+		return valid
+	}
+
+	valid = true
+	return valid
+}
+
+// parseSetting parses a setting of a config or opts directive, of the form
+// name=value. A name alone sets a boolean setting, while a mode alone, such as
+// errors or error-only, is short for mode=mode.
+func parseSetting(s string) (name string, value string) {
+	var found bool
+	name, value, found = strings.Cut(s, "=")
+	if found {
+		return name, value
+	}
+
+	value = "true"
+	if _, alias := modeAliases[name]; alias || slices.Contains(modes, name) {
+		value = name
+		name = FlagMode
+	}
+	return name, value
+}
+
+// stripTrailingComment drops a comment following the arguments of a
+// directive, which is not part of it, as with nolint.
+func stripTrailingComment(args string) (stripped string) {
+	stripped = args
+	if loc := trailingComment.FindStringIndex(args); loc != nil {
+		stripped = args[:loc[0]]
+	}
+	return stripped
+}
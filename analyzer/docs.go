@@ -0,0 +1,80 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/types"
+	"strings"
+	"unicode"
+)
+
+// errorWords are the words by which a doc comment may describe the error
+// behavior of a function instead of naming its error result.
+var errorWords = []string{"error", "errors", "fails", "fail"}
+
+// checkDocResults reports the named results of an exported function that
+// its doc comment doesn't mention, which is how names a rename left stale in
+// the doc show. Error results also count as mentioned when the doc describes
+// the errors returned. Functions without a doc comment are left alone.
+func (c *checker) checkDocResults(frame *funcFrame) {
+	decl, ok := frame.node.(*ast.FuncDecl)
+	if !ok || decl.Doc == nil || !decl.Name.IsExported() {
+		return
+	}
+	if decl.Recv != nil && len(decl.Recv.List) > 0 && !exportedReceiver(decl.Recv.List[0].Type) {
+		return
+	}
+
+	// Words are matched ignoring case, except for the name of the function
+	// the doc starts with, which mentions no result named alike
+	words := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(decl.Doc.Text(), func(r rune) (separator bool) {
+		separator = !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+		return separator
+	}) {
+		if word != decl.Name.Name {
+			words[strings.ToLower(word)] = true
+		}
+	}
+
+	for _, p := range frame.typ.Results.List {
+		isError := c.lookup.isErrorType(p.Type, frame.typeParams)
+		for _, n := range p.Names {
+			if n.Name == "_" || words[strings.ToLower(n.Name)] {
+				continue
+			}
+
+			if !isError {
+				c.report(diagnosticf(RuleDocResult, n.Pos(), frame.msgs, MessageDocResult, frame.name, n.Name),
+					Message{FuncName: frame.name, ReturnName: n.Name, Type: types.ExprString(p.Type)})
+				continue
+			}
+
+			described := false
+			for _, word := range errorWords {
+				described = described || words[word]
+			}
+			if !described {
+				c.report(diagnosticf(RuleDocResult, n.Pos(), frame.msgs, MessageDocError, frame.name, n.Name),
+					Message{FuncName: frame.name, ReturnName: n.Name, Type: types.ExprString(p.Type)})
+			}
+		}
+	}
+}
+
+// exportedReceiver reports whether the receiver type expr names an exported
+// type, whose methods are documented.
+func exportedReceiver(expr ast.Expr) (exported bool) {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		exported = exportedReceiver(e.X)
+	case *ast.ParenExpr:
+		exported = exportedReceiver(e.X)
+	case *ast.IndexExpr:
+		exported = exportedReceiver(e.X)
+	case *ast.IndexListExpr:
+		exported = exportedReceiver(e.X)
+	case *ast.Ident:
+		exported = e.IsExported()
+	}
+	return exported
+}
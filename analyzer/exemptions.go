@@ -0,0 +1,374 @@
+package analyzer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// ExemptionContext describes a function to the exemptions, which decide
+// whether it is checked.
+type ExemptionContext struct {
+	Pass *analysis.Pass
+	File *ast.File     // the file declaring the function
+	Node ast.Node      // the *ast.FuncDecl or *ast.FuncLit
+	Decl *ast.FuncDecl // the function, or the one declaring a literal, nil for literals outside functions
+	Name string        // e.g. "func Start" or "method (*Server).Start"
+
+	// Signature is the type of the function, nil when type information is
+	// missing, as with RunSyntax
+	Signature *types.Signature
+}
+
+// exemptions holds the registered exemptions, in order of registration.
+var exemptions []func(*ExemptionContext) bool
+
+// exemptionsMu guards exemptions, which programs may extend while analyzers
+// run.
+var exemptionsMu sync.RWMutex
+
+// RegisterExemption adds an exemption, which is called for every function
+// that would otherwise be checked, and exempts it from all checks by
+// returning true. Programs embedding the analyzer call it to exempt
+// functions on grounds settings can't express, e.g. generated wrappers
+// recognized by their signature. Exemptions apply to every analyzer of this
+// package, so they must be safe for concurrent use.
+func RegisterExemption(exempt func(*ExemptionContext) bool) {
+	exemptionsMu.Lock()
+	defer exemptionsMu.Unlock()
+
+	exemptions = append(exemptions, exempt)
+}
+
+// registeredExemptions returns the exemptions registered so far.
+func registeredExemptions() (registered []func(*ExemptionContext) bool) {
+	exemptionsMu.RLock()
+	defer exemptionsMu.RUnlock()
+
+	registered = exemptions
+	return registered
+}
+
+// exempt reports whether a registered exemption exempts the function of
+// frame.
+func (c *checker) exempt(frame *funcFrame) (exempt bool) {
+	if len(c.exemptions) == 0 {
+		return exempt
+	}
+
+	ctx := &ExemptionContext{
+		Pass: c.pass,
+		Node: frame.node,
+		Name: frame.name,
+	}
+	for _, file := range c.pass.Files {
+		if file.FileStart <= frame.node.Pos() && frame.node.Pos() < file.FileEnd {
+			ctx.File = file
+			break
+		}
+	}
+	switch n := frame.node.(type) {
+	case *ast.FuncDecl:
+		ctx.Decl = n
+		if fn, ok := c.pass.TypesInfo.Defs[n.Name].(*types.Func); ok {
+			ctx.Signature, _ = fn.Type().(*types.Signature)
+		}
+	case *ast.FuncLit:
+		if len(c.frames) > 0 {
+			ctx.Decl, _ = c.frames[0].node.(*ast.FuncDecl)
+		}
+		ctx.Signature, _ = c.pass.TypesInfo.TypeOf(n).(*types.Signature)
+	}
+
+	for _, e := range c.exemptions {
+		if e(ctx) {
+			exempt = true
+			break
+		}
+	}
+	return exempt
+}
+
+// settingExempt is the key of the configuration documents holding the
+// exemption rules. Like tests, it names no flag.
+const settingExempt = "exempt"
+
+// Exemption is a rule of the exempt setting, exempting the functions that
+// match all of its fields. Empty fields match every function.
+type Exemption struct {
+	// Receiver is a path.Match pattern for the receiver type of methods,
+	// e.g. "*Mock*". It matches no function that isn't a method.
+	Receiver string `json:"receiver,omitempty" yaml:"receiver,omitempty"`
+
+	// Returns are path.Match patterns for the result types, one for each
+	// result, e.g. ["func()"] or ["*", "error"].
+	Returns []string `json:"returns,omitempty" yaml:"returns,omitempty"`
+
+	// Package is a pattern for the import path of the package, where ...
+	// matches any string as in go package patterns, e.g. ".../gen/...".
+	Package string `json:"package,omitempty" yaml:"package,omitempty"`
+}
+
+// exemptionFields are the fields of an exemption rule in configuration
+// documents.
+var exemptionFields = []string{"package", "receiver", "returns"}
+
+// applyExempt adds the exemption rules of a decoded configuration document
+// to those of cfg, checking them as it goes.
+func (cfg *Config) applyExempt(value interface{}) (err error) {
+	values, ok := value.([]interface{})
+	if !ok {
+		err = fmt.Errorf("%s must hold a list of rules", settingExempt)
+		return err
+	}
+
+	// The slice may be shared with the defaults of the analyzer, so it is
+	// copied rather than appended to
+	rules := make([]Exemption, 0, len(cfg.Exempt)+len(values))
+	rules = append(rules, cfg.Exempt...)
+	for i, v := range values {
+		var rule Exemption
+		rule, err = decodeExemption(v)
+		if err != nil {
+			err = fmt.Errorf("%s: rule %d: %w", settingExempt, i+1, err)
+			return err
+		}
+		rules = append(rules, rule)
+	}
+
+	cfg.Exempt = rules
+	return err
+}
+
+// decodeExemption decodes and checks an exemption rule of a configuration
+// document.
+func decodeExemption(value interface{}) (rule Exemption, err error) {
+	fields, ok := value.(map[string]interface{})
+	if !ok || len(fields) == 0 {
+		err = fmt.Errorf("must set one of %s", strings.Join(exemptionFields, ", "))
+		return rule, err
+	}
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		switch name {
+		case "receiver":
+			rule.Receiver = settingString(fields[name])
+		case "returns":
+			rule.Returns = []string{}
+			if items, isList := fields[name].([]interface{}); isList {
+				for _, item := range items {
+					rule.Returns = append(rule.Returns, settingString(item))
+				}
+			} else {
+				rule.Returns = append(rule.Returns, settingString(fields[name]))
+			}
+		case "package":
+			rule.Package = settingString(fields[name])
+		default:
+			err = fmt.Errorf("unknown field %q, expected one of %s", name, strings.Join(exemptionFields, ", "))
+			return rule, err
+		}
+	}
+
+	for _, pattern := range append([]string{rule.Receiver}, rule.Returns...) {
+		if _, err = path.Match(pattern, ""); err != nil {
+			err = fmt.Errorf("invalid pattern %q: %w", pattern, err)
+			return rule, err
+		}
+	}
+	return rule, err
+}
+
+// exemptedByRule reports whether an exemption rule of the settings of frame
+// exempts its function. Function literals are exempted along with the
+// function declaring them.
+func (c *checker) exemptedByRule(frame *funcFrame) (exempt bool) {
+	decl, ok := frame.node.(*ast.FuncDecl)
+	if !ok {
+		if len(c.frames) > 0 {
+			exempt = c.frames[len(c.frames)-1].exempted
+		}
+		return exempt
+	}
+
+	for _, rule := range frame.cfg.Exempt {
+		if c.matches(rule, decl) {
+			exempt = true
+			break
+		}
+	}
+	return exempt
+}
+
+// matches reports whether decl matches all the fields of rule. Types are
+// written as in the source of the package, e.g. "*Server" or "sql.DB", and
+// taken from the syntax when type information is missing.
+func (c *checker) matches(rule Exemption, decl *ast.FuncDecl) (matched bool) {
+	if rule.Package != "" && !matchPackage(rule.Package, c.pass.Pkg.Path()) {
+		return matched
+	}
+
+	var sig *types.Signature
+	if fn, ok := c.pass.TypesInfo.Defs[decl.Name].(*types.Func); ok {
+		sig, _ = fn.Type().(*types.Signature)
+	}
+	qualifier := func(p *types.Package) (name string) {
+		if p != c.pass.Pkg {
+			name = p.Name()
+		}
+		return name
+	}
+
+	if rule.Receiver != "" {
+		if decl.Recv == nil || len(decl.Recv.List) == 0 {
+			return matched
+		}
+		recv := types.ExprString(decl.Recv.List[0].Type)
+		if sig != nil && sig.Recv() != nil {
+			recv = types.TypeString(sig.Recv().Type(), qualifier)
+		}
+		if ok, _ := path.Match(rule.Receiver, recv); !ok {
+			return matched
+		}
+	}
+
+	if rule.Returns != nil {
+		var results []string
+		if sig != nil {
+			for i := 0; i < sig.Results().Len(); i++ {
+				results = append(results, types.TypeString(sig.Results().At(i).Type(), qualifier))
+			}
+		} else if decl.Type.Results != nil {
+			for _, field := range decl.Type.Results.List {
+				for range max(len(field.Names), 1) {
+					results = append(results, types.ExprString(field.Type))
+				}
+			}
+		}
+
+		if len(results) != len(rule.Returns) {
+			return matched
+		}
+		for i, pattern := range rule.Returns {
+			if ok, _ := path.Match(pattern, results[i]); !ok {
+				return matched
+			}
+		}
+	}
+
+	matched = true
+	return matched
+}
+
+// matchPackage reports whether the import path matches pattern, where ...
+// matches any string, and a trailing /... also the path before it, as in go
+// package patterns.
+func matchPackage(pattern string, importPath string) (matched bool) {
+	re := strings.ReplaceAll(regexp.QuoteMeta(pattern), `\.\.\.`, `.*`)
+	if strings.HasSuffix(re, `/.*`) {
+		re = strings.TrimSuffix(re, `/.*`) + `(/.*)?`
+	}
+	matched = regexp.MustCompile(`^` + re + `$`).MatchString(importPath)
+	return matched
+}
+
+// commaOKPassthrough reports whether body only passes on the result of a
+// comma-ok map index, type assertion or channel receive:
+//
+//	v, ok := m[k]
+//	return v, ok
+func commaOKPassthrough(body *ast.BlockStmt) (passthrough bool) {
+	if len(body.List) != 2 {
+		return passthrough
+	}
+
+	assign, ok := body.List[0].(*ast.AssignStmt)
+	if !ok || len(assign.Lhs) != 2 || len(assign.Rhs) != 1 {
+		return passthrough
+	}
+	switch rhs := ast.Unparen(assign.Rhs[0]).(type) {
+	case *ast.IndexExpr, *ast.TypeAssertExpr:
+	case *ast.UnaryExpr:
+		if rhs.Op != token.ARROW {
+			return passthrough
+		}
+	default:
+		return passthrough
+	}
+
+	ret, ok := body.List[1].(*ast.ReturnStmt)
+	if !ok || len(ret.Results) != 2 {
+		return passthrough
+	}
+	for i, lhs := range assign.Lhs {
+		assigned, isIdent := lhs.(*ast.Ident)
+		returned, returnsIdent := ret.Results[i].(*ast.Ident)
+		if !isIdent || !returnsIdent || assigned.Name == "_" || assigned.Name != returned.Name {
+			return passthrough
+		}
+	}
+
+	passthrough = true
+	return passthrough
+}
+
+// testingHelperTypes are the types of the testing package whose values test
+// helpers take first, by whether they are taken by pointer.
+var testingHelperTypes = map[string]bool{
+	"T":  true,
+	"B":  true,
+	"TB": false,
+}
+
+// testHelper reports whether the first parameter of the function of frame
+// is a *testing.T, a *testing.B or a testing.TB, as test helpers take, in
+// _test.go files or not. Without type information any package imported as
+// testing is taken for the standard one.
+func (c *checker) testHelper(frame *funcFrame) (helper bool) {
+	params := frame.typ.Params
+	if params == nil || len(params.List) == 0 {
+		return helper
+	}
+	typ := params.List[0].Type
+
+	if t := c.pass.TypesInfo.TypeOf(typ); t != nil {
+		pointer := false
+		if ptr, isPtr := t.(*types.Pointer); isPtr {
+			t, pointer = ptr.Elem(), true
+		}
+		named, isNamed := t.(*types.Named)
+		if !isNamed || named.Obj().Pkg() == nil || named.Obj().Pkg().Path() != "testing" {
+			return helper
+		}
+		byPointer, known := testingHelperTypes[named.Obj().Name()]
+		helper = known && byPointer == pointer
+		return helper
+	}
+
+	pointer := false
+	if star, isStar := typ.(*ast.StarExpr); isStar {
+		typ, pointer = star.X, true
+	}
+	sel, isSel := typ.(*ast.SelectorExpr)
+	if !isSel {
+		return helper
+	}
+	pkg, isIdent := sel.X.(*ast.Ident)
+	byPointer, known := testingHelperTypes[sel.Sel.Name]
+	helper = isIdent && pkg.Name == "testing" && known && byPointer == pointer
+	return helper
+}
@@ -0,0 +1,131 @@
+package analyzer
+
+import (
+	"go/types"
+	"reflect"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// ResultNames is a fact recording the result names of the methods of an
+// exported interface type, so the analysis of a dependent package can compare
+// implementations against the interface as declared. A method whose results
+// are unnamed has empty names.
+type ResultNames struct {
+	Methods map[string][]string
+}
+
+// AFact marks ResultNames as an analysis fact.
+func (*ResultNames) AFact() {}
+
+func (f *ResultNames) String() (s string) {
+	methods := make([]string, 0, len(f.Methods))
+	for method := range f.Methods {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	parts := make([]string, 0, len(methods))
+	for _, method := range methods {
+		names := make([]string, 0, len(f.Methods[method]))
+		for _, name := range f.Methods[method] {
+			if name == "" {
+				name = "-"
+			}
+			names = append(names, name)
+		}
+		parts = append(parts, method+"("+strings.Join(names, ", ")+")")
+	}
+	s = "results " + strings.Join(parts, " ")
+	return s
+}
+
+// InterfaceResults maps the interface types visible to a package, declared in
+// it or imported from the packages it depends on, to the result names of
+// their methods. It is the result of the Facts analyzer.
+type InterfaceResults map[*types.TypeName]*ResultNames
+
+// Facts exports a ResultNames fact for every exported interface type and
+// provides the InterfaceResults of each package to the analyzers requiring it.
+var Facts = &analysis.Analyzer{
+	Name:             "namedreturns_facts",
+	Doc:              "Records the result names of interface methods for use across packages",
+	Run:              runFacts,
+	RunDespiteErrors: true,
+	FactTypes:        []analysis.Fact{new(ResultNames)},
+	ResultType:       reflect.TypeOf(InterfaceResults(nil)),
+}
+
+func runFacts(pass *analysis.Pass) (result interface{}, err error) {
+	interfaces := make(InterfaceResults)
+
+	// Interfaces imported from dependencies
+	for _, fact := range pass.AllObjectFacts() {
+		typeName, ok := fact.Object.(*types.TypeName)
+		names, isNames := fact.Fact.(*ResultNames)
+		if ok && isNames {
+			interfaces[typeName] = names
+		}
+	}
+
+	// Interfaces declared in this package, of which only the exported ones
+	// can be referenced by other packages
+	scope := pass.Pkg.Scope()
+	for _, name := range scope.Names() {
+		typeName, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok {
+			continue
+		}
+		iface, ok := typeName.Type().Underlying().(*types.Interface)
+		if !ok {
+			continue
+		}
+
+		names := interfaceResultNames(iface)
+		interfaces[typeName] = names
+		if typeName.Exported() {
+			pass.ExportObjectFact(typeName, names)
+		}
+	}
+
+	result = interfaces
+	return result, err
+}
+
+// interfaceResultNames collects the result names of the methods of iface,
+// including embedded ones.
+func interfaceResultNames(iface *types.Interface) (names *ResultNames) {
+	names = &ResultNames{Methods: make(map[string][]string, iface.NumMethods())}
+	for i := 0; i < iface.NumMethods(); i++ {
+		method := iface.Method(i)
+		sig, ok := method.Type().(*types.Signature)
+		if !ok {
+			continue
+		}
+
+		results := make([]string, sig.Results().Len())
+		for j := range results {
+			results[j] = sig.Results().At(j).Name()
+		}
+		names.Methods[method.Name()] = results
+	}
+	return names
+}
+
+// Lookup returns the result names of the method of the interface type named
+// typeName, and whether they are known.
+func (r InterfaceResults) Lookup(typeName *types.TypeName, method string) (names []string, ok bool) {
+	var resultNames *ResultNames
+	resultNames, ok = r[typeName]
+	if !ok {
+		return names, ok
+	}
+
+	names, ok = resultNames.Methods[method]
+	if ok {
+		names = append([]string(nil), names...)
+	}
+	return names, ok
+}
@@ -0,0 +1,127 @@
+package analyzer
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// moduleRoots memoizes the module root of directories, by directory.
+var moduleRoots sync.Map
+
+// moduleRoot returns the closest directory to dir, itself included,
+// holding a go.mod file, or "" when there is none.
+func moduleRoot(dir string) (root string) {
+	if cached, ok := moduleRoots.Load(dir); ok {
+		root, _ = cached.(string)
+		return root
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+		root = dir
+	} else if parent := filepath.Dir(dir); parent != dir {
+		root = moduleRoot(parent)
+	}
+	moduleRoots.Store(dir, root)
+	return root
+}
+
+// ExcludesFile reports whether the exclude-files setting, or the file
+// patterns of the exclude-presets setting, excludes the file at filename.
+// The patterns match the path of the file relative to the root of its
+// module, with forward slashes, or its absolute path outside any module.
+func (cfg Config) ExcludesFile(filename string) (excluded bool) {
+	patterns := append(slices.Clip(cfg.ExcludeFiles), cfg.presetFiles()...)
+	if len(patterns) == 0 {
+		return excluded
+	}
+
+	name := filepath.ToSlash(filename)
+	if abs, err := filepath.Abs(filename); err == nil {
+		name = filepath.ToSlash(abs)
+		if root := moduleRoot(filepath.Dir(abs)); root != "" {
+			if rel, relErr := filepath.Rel(root, abs); relErr == nil {
+				name = filepath.ToSlash(rel)
+			}
+		}
+	}
+
+	for _, pattern := range patterns {
+		if matchFileGlob(pattern, name) {
+			excluded = true
+			return excluded
+		}
+	}
+	return excluded
+}
+
+// matchFileGlob reports whether the slash separated path name matches
+// pattern, whose ** elements match any number of path elements, and whose
+// other elements are path.Match patterns matching one.
+func matchFileGlob(pattern string, name string) (matched bool) {
+	matched = matchElems(strings.Split(pattern, "/"), strings.Split(strings.TrimPrefix(name, "/"), "/"))
+	return matched
+}
+
+// matchElems matches the elements of a path against those of a pattern.
+func matchElems(pattern []string, elems []string) (matched bool) {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(elems); i++ {
+				if matchElems(pattern[1:], elems[i:]) {
+					matched = true
+					return matched
+				}
+			}
+			return matched
+		}
+
+		if len(elems) == 0 {
+			return matched
+		}
+		if ok, _ := path.Match(pattern[0], elems[0]); !ok {
+			return matched
+		}
+		pattern, elems = pattern[1:], elems[1:]
+	}
+
+	matched = len(elems) == 0
+	return matched
+}
+
+// globListValue is the flag value of a list of file globs, given as comma
+// separated patterns.
+type globListValue struct {
+	list *[]string
+}
+
+func (v globListValue) String() (s string) {
+	if v.list != nil {
+		s = strings.Join(*v.list, ",")
+	}
+	return s
+}
+
+func (v globListValue) Set(value string) (err error) {
+	var globs []string
+	for _, glob := range strings.Split(value, ",") {
+		glob = strings.TrimSpace(glob)
+		if glob == "" {
+			continue
+		}
+
+		for _, elem := range strings.Split(glob, "/") {
+			if _, err = path.Match(elem, ""); err != nil {
+				err = fmt.Errorf("invalid glob %q: %w", glob, err)
+				return err
+			}
+		}
+		globs = append(globs, glob)
+	}
+	*v.list = globs
+	return err
+}
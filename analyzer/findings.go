@@ -0,0 +1,93 @@
+package analyzer
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sync"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/buildssa"
+	"golang.org/x/tools/go/analysis/passes/ctrlflow"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+)
+
+// findingsAnalyzer finds what every rule reports in a package, for the
+// analyzers of this package to report the findings of their own rules, so
+// running several of them checks the package once. It uses no facts, which
+// would have drivers run it on every dependency too.
+var findingsAnalyzer = &analysis.Analyzer{
+	Name:             "namedreturns_findings",
+	Doc:              "Finds what the rules of the namedreturns analyzers report",
+	Run:              runFindings,
+	RunDespiteErrors: true,
+	Requires:         []*analysis.Analyzer{inspect.Analyzer},
+	ResultType:       reflect.TypeOf((*packageFindings)(nil)),
+}
+
+func runFindings(pass *analysis.Pass) (result interface{}, err error) {
+	result = &packageFindings{pass: pass, checked: make(map[findingsKey]*findingsEntry)}
+	return result, err
+}
+
+// checkInputs are the results of the other analyzers some analyzers of this
+// package require, which the checks use when they are given.
+type checkInputs struct {
+	interfaces InterfaceResults // of Facts
+	cfgs       *ctrlflow.CFGs
+	ssaResult  *buildssa.SSA
+}
+
+// packageFindings holds the findings of every rule in a package. Analyzers
+// configured alike share them, while each configuration, which every
+// analyzer resolves from its own flags, and each set of inputs get their
+// own, checked on first use.
+type packageFindings struct {
+	pass    *analysis.Pass
+	mu      sync.Mutex
+	checked map[findingsKey]*findingsEntry
+}
+
+// findingsKey identifies what a package is checked with. The package has a
+// single result of Facts, so whether it is given tells them apart.
+type findingsKey struct {
+	config     string // the settings, as JSON
+	interfaces bool
+	cfgs       *ctrlflow.CFGs
+	ssaResult  *buildssa.SSA
+}
+
+// findingsEntry holds the findings of the package under one key, once
+// checked.
+type findingsEntry struct {
+	mu      sync.Mutex
+	checked *checkedPackage
+}
+
+// check returns the findings of the package under cfg, with inputs, checking
+// it unless that was done already. A failed check, such as one stopped by
+// ctx, is tried again by the next analyzer.
+func (f *packageFindings) check(ctx context.Context, cfg Config, inputs checkInputs) (checked *checkedPackage, err error) {
+	var config []byte
+	config, err = json.Marshal(cfg)
+	if err != nil {
+		return checked, err
+	}
+	key := findingsKey{config: string(config), interfaces: inputs.interfaces != nil, cfgs: inputs.cfgs, ssaResult: inputs.ssaResult}
+
+	f.mu.Lock()
+	entry, ok := f.checked[key]
+	if !ok {
+		entry = &findingsEntry{}
+		f.checked[key] = entry
+	}
+	f.mu.Unlock()
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	if entry.checked == nil {
+		entry.checked, err = checkPackage(ctx, f.pass, cfg, inputs)
+	}
+	checked = entry.checked
+	return checked, err
+}
@@ -0,0 +1,315 @@
+package analyzer
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"go/types"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// namer picks names for results that don't clash with any identifier used in
+// a function, so naming a result never changes what the function refers to.
+// The identifiers are collected on first use, as most functions need no names.
+type namer struct {
+	recv     *ast.FieldList // nil for functions
+	funcType *ast.FuncType
+	body     *ast.BlockStmt
+	taken    map[string]bool
+	renamed  map[*ast.Ident]string // new names of the identifiers renamed so far
+}
+
+// newNamer creates a namer for the function with the given receiver, which
+// is nil for functions, type and body.
+func newNamer(recv *ast.FieldList, funcType *ast.FuncType, body *ast.BlockStmt) (n *namer) {
+	n = &namer{recv: recv, funcType: funcType, body: body}
+	return n
+}
+
+// name returns an unused name for a result of the type expr.
+func (n *namer) name(expr ast.Expr) (name string) {
+	name = n.unique(typeBasedName(expr))
+	return name
+}
+
+// unique returns base, or base followed by a number, whichever is unused
+// first, and reserves it.
+func (n *namer) unique(base string) (name string) {
+	if n.taken == nil {
+		n.taken = make(map[string]bool)
+		nodes := []ast.Node{n.funcType, n.body}
+		if n.recv != nil {
+			nodes = append(nodes, n.recv)
+		}
+		for _, node := range nodes {
+			ast.Inspect(node, func(node ast.Node) (continueInspection bool) {
+				if ident, ok := node.(*ast.Ident); ok {
+					n.taken[ident.Name] = true
+				}
+				continueInspection = true
+				return continueInspection
+			})
+		}
+	}
+
+	name = base
+	for i := 2; n.taken[name] || token.IsKeyword(name); i++ {
+		name = base + strconv.Itoa(i)
+	}
+	n.taken[name] = true
+	return name
+}
+
+// conventionalNames are the customary variable names for common types.
+var conventionalNames = map[string]string{
+	"error": "err", "bool": "ok", "string": "s", "byte": "b", "rune": "r",
+	"int": "n", "int8": "n", "int16": "n", "int32": "n", "int64": "n",
+	"uint": "n", "uint8": "n", "uint16": "n", "uint32": "n", "uint64": "n", "uintptr": "n",
+	"float32": "f", "float64": "f", "complex64": "c", "complex128": "c",
+	"any": "v", "Context": "ctx",
+}
+
+// typeBasedName derives a variable name from a type expression, e.g. err for
+// error, config for *Config and readers for []io.Reader.
+func typeBasedName(expr ast.Expr) (name string) {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if conventional, ok := conventionalNames[t.Name]; ok {
+			name = conventional
+			return name
+		}
+		name = lowerInitialism(t.Name)
+	case *ast.SelectorExpr:
+		name = typeBasedName(t.Sel)
+	case *ast.StarExpr:
+		name = typeBasedName(t.X)
+	case *ast.ParenExpr:
+		name = typeBasedName(t.X)
+	case *ast.IndexExpr:
+		name = typeBasedName(t.X)
+	case *ast.IndexListExpr:
+		name = typeBasedName(t.X)
+	case *ast.ArrayType:
+		if ident, ok := t.Elt.(*ast.Ident); ok && ident.Name == "byte" {
+			name = "data"
+			return name
+		}
+		elem := typeBasedName(t.Elt)
+		if len(elem) <= 3 {
+			name = "values"
+			return name
+		}
+		name = elem + "s"
+	case *ast.MapType:
+		name = "m"
+	case *ast.ChanType:
+		name = "ch"
+	case *ast.FuncType:
+		name = "fn"
+	default:
+		name = "v"
+	}
+	return name
+}
+
+// lowerInitialism lowers the leading capitals of an exported name, keeping
+// the capital that starts the next word: URL becomes url and HTTPClient
+// becomes httpClient.
+func lowerInitialism(s string) (lowered string) {
+	runes := []rune(s)
+	i := 0
+	for i < len(runes) && unicode.IsUpper(runes[i]) {
+		i++
+	}
+	if i > 1 && i < len(runes) {
+		i--
+	}
+	for j := 0; j < i; j++ {
+		runes[j] = unicode.ToLower(runes[j])
+	}
+	lowered = string(runes)
+	return lowered
+}
+
+// nameResultsFix names all results of a function whose results are unnamed.
+// Go doesn't allow mixing named and unnamed results, so they are named
+// together.
+func nameResultsFix(msgs catalog, results *ast.FieldList, n *namer) (fix analysis.SuggestedFix) {
+	fix.Message = msgs.sprintf(MessageFixNameResults)
+
+	// A single unnamed result may lack parentheses
+	if !results.Opening.IsValid() {
+		field := results.List[0]
+		fix.TextEdits = []analysis.TextEdit{
+			{Pos: field.Type.Pos(), End: field.Type.Pos(), NewText: []byte("(" + n.name(field.Type) + " ")},
+			{Pos: field.Type.End(), End: field.Type.End(), NewText: []byte(")")},
+		}
+		return fix
+	}
+
+	for _, field := range results.List {
+		fix.TextEdits = append(fix.TextEdits, analysis.TextEdit{
+			Pos:     field.Type.Pos(),
+			End:     field.Type.Pos(),
+			NewText: []byte(n.name(field.Type) + " "),
+		})
+	}
+	return fix
+}
+
+// renameUnderscoreFix names a result declared as _.
+func renameUnderscoreFix(msgs catalog, ident *ast.Ident, typ ast.Expr, n *namer) (fix analysis.SuggestedFix) {
+	name := n.name(typ)
+	fix = analysis.SuggestedFix{
+		Message:   msgs.sprintf(MessageFixNameResult, name),
+		TextEdits: []analysis.TextEdit{{Pos: ident.Pos(), End: ident.End(), NewText: []byte(name)}},
+	}
+	return fix
+}
+
+// returnFix rewrites a return statement not using the named results into an
+// assignment to them followed by a return of them, which behaves the same:
+//
+//	return 42, nil
+//
+// becomes
+//
+//	result, err = 42, nil
+//	return result, err
+//
+// ok is false when the statement can't be rewritten safely, such as when a
+// named result is shadowed at the return statement.
+func returnFix(msgs catalog, pass *analysis.Pass, lookup *typeCache, ret *ast.ReturnStmt, names []*ast.Ident) (fix analysis.SuggestedFix, ok bool) {
+	if !resolvesTo(pass, lookup, names, ret.Pos()) {
+		return fix, ok
+	}
+
+	var lhs, rhs []string
+	switch {
+	case len(ret.Results) == len(names):
+		for i, result := range ret.Results {
+			if ident, isIdent := result.(*ast.Ident); isIdent && ident.Name == names[i].Name {
+				continue
+			}
+			lhs = append(lhs, names[i].Name)
+			rhs = append(rhs, exprString(pass.Fset, result))
+		}
+	case len(ret.Results) == 1 && len(names) > 1:
+		// A call returning several values
+		if _, isCall := ret.Results[0].(*ast.CallExpr); !isCall {
+			return fix, ok
+		}
+		for _, name := range names {
+			lhs = append(lhs, name.Name)
+		}
+		rhs = []string{exprString(pass.Fset, ret.Results[0])}
+	default:
+		return fix, ok
+	}
+
+	returned := make([]string, 0, len(names))
+	for _, name := range names {
+		returned = append(returned, name.Name)
+	}
+
+	// gofmt indents with tabs, so the column gives the indentation
+	indent := strings.Repeat("\t", pass.Fset.Position(ret.Pos()).Column-1)
+	text := fmt.Sprintf("%s = %s\n%sreturn %s", strings.Join(lhs, ", "), strings.Join(rhs, ", "), indent, strings.Join(returned, ", "))
+
+	fix = analysis.SuggestedFix{
+		Message:   msgs.sprintf(MessageFixReturnNamed),
+		TextEdits: []analysis.TextEdit{{Pos: ret.Pos(), End: ret.End(), NewText: []byte(text)}},
+	}
+	ok = true
+	return fix, ok
+}
+
+// resolvesTo reports whether the names of the results declared by decls
+// refer to those results at pos. Without type information they are assumed
+// to.
+func resolvesTo(pass *analysis.Pass, lookup *typeCache, decls []*ast.Ident, pos token.Pos) (resolves bool) {
+	resolves = true
+	if pass.Pkg == nil {
+		return resolves
+	}
+
+	// The scope is looked up lazily, as it isn't needed without type
+	// information
+	var scope *types.Scope
+	for _, decl := range decls {
+		obj := lookup.objectOf(decl)
+		if obj == nil {
+			continue
+		}
+		if scope == nil {
+			scope = pass.Pkg.Scope().Innermost(pos)
+			if scope == nil {
+				return resolves
+			}
+		}
+
+		if _, found := scope.LookupParent(decl.Name, pos); found != obj {
+			resolves = false
+			return resolves
+		}
+	}
+	return resolves
+}
+
+// renameShadowFix renames a variable shadowing a named result, along with its
+// uses, which keeps the code's behavior. It needs type information to find
+// the uses, so ok is false without it.
+func renameShadowFix(msgs catalog, pass *analysis.Pass, lookup *typeCache, ident *ast.Ident, n *namer) (fix analysis.SuggestedFix, ok bool) {
+	obj := pass.TypesInfo.Defs[ident]
+	if obj == nil {
+		return fix, ok
+	}
+
+	// A declaration may be reported more than once, e.g. as a for loop
+	// variable and as a local variable, and must be renamed alike
+	name, renamed := n.renamed[ident]
+	if !renamed {
+		first, size := utf8.DecodeRuneInString(ident.Name)
+		name = n.unique("inner" + string(unicode.ToUpper(first)) + ident.Name[size:])
+		if n.renamed == nil {
+			n.renamed = make(map[*ast.Ident]string)
+		}
+		n.renamed[ident] = name
+	}
+
+	fix.Message = msgs.sprintf(MessageFixRenameShadow, name)
+	fix.TextEdits = []analysis.TextEdit{{Pos: ident.Pos(), End: ident.End(), NewText: []byte(name)}}
+	for _, use := range lookup.usesOf(obj) {
+		fix.TextEdits = append(fix.TextEdits, analysis.TextEdit{Pos: use.Pos(), End: use.End(), NewText: []byte(name)})
+	}
+
+	// Tools merging fixes expect the edits ordered by position
+	edits := fix.TextEdits
+	sort.Slice(edits, func(i, j int) (less bool) {
+		less = edits[i].Pos < edits[j].Pos
+		return less
+	})
+
+	ok = true
+	return fix, ok
+}
+
+// exprString prints expr as it appears in the source, modulo formatting.
+func exprString(fset *token.FileSet, expr ast.Expr) (s string) {
+	var buf bytes.Buffer
+	err := format.Node(&buf, fset, expr)
+	if err != nil {
+		s = types.ExprString(expr)
+		return s
+	}
+	s = buf.String()
+	return s
+}
@@ -0,0 +1,307 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/cfg"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// noReturnFuncs are the functions of the standard library known never to
+// return, by full name, for the control flow graphs built without ctrlflow.
+var noReturnFuncs = map[string]bool{
+	"os.Exit":                   true,
+	"log.Fatal":                 true,
+	"log.Fatalf":                true,
+	"log.Fatalln":               true,
+	"(*log.Logger).Fatal":       true,
+	"(*log.Logger).Fatalf":      true,
+	"(*log.Logger).Fatalln":     true,
+	"runtime.Goexit":            true,
+	"syscall.Exit":              true,
+	"(*testing.common).Fatal":   true,
+	"(*testing.common).Fatalf":  true,
+	"(*testing.common).FailNow": true,
+}
+
+// flowGraph returns the control flow graph of the function of frame, from
+// ctrlflow when the analyzer requires it, or built from its body.
+func (c *checker) flowGraph(frame *funcFrame) (g *cfg.CFG) {
+	if c.cfgs != nil {
+		switch n := frame.node.(type) {
+		case *ast.FuncDecl:
+			g = c.cfgs.FuncDecl(n)
+		case *ast.FuncLit:
+			g = c.cfgs.FuncLit(n)
+		}
+		if g != nil {
+			return g
+		}
+	}
+
+	g = cfg.New(frame.body, c.mayReturn)
+	return g
+}
+
+// mayReturn reports whether call may return, which calls to panic and to
+// noReturnFuncs don't. Without type information any call to panic is taken
+// for the builtin.
+func (c *checker) mayReturn(call *ast.CallExpr) (mayReturn bool) {
+	if c.callsBuiltin(call, "panic") {
+		return mayReturn
+	}
+
+	fn := typeutil.StaticCallee(c.pass.TypesInfo, call)
+	mayReturn = fn == nil || !noReturnFuncs[fn.FullName()]
+	return mayReturn
+}
+
+// droppedErrors maps the local variables holding the error of a call to the
+// call, for the errors dropped, on some path, without assigning the named
+// error since.
+type droppedErrors map[types.Object]*ast.CallExpr
+
+// merge adds the dropped errors of other, reporting whether any was new.
+func (d droppedErrors) merge(other droppedErrors) (changed bool) {
+	for v, call := range other {
+		if _, ok := d[v]; !ok {
+			d[v] = call
+			changed = true
+		}
+	}
+	return changed
+}
+
+// checkDroppedErrors reports bare returns reached, on some path, with the
+// named error left unassigned after the error of a call was stored in
+// another variable and found not to be nil. Assigning the named error, nil
+// included, on the way settles it. Without type information the errors of
+// calls are unknown, so nothing is reported.
+func (c *checker) checkDroppedErrors(frame *funcFrame) {
+	errName := c.namedError(frame)
+	if errName == nil || c.lookup.objectOf(errName) == nil || c.deferHandles(frame, errName) {
+		return
+	}
+
+	bare := false
+	for _, ret := range frame.returns {
+		bare = bare || len(ret.Results) == 0
+	}
+	if !bare {
+		return
+	}
+
+	// The dropped errors at the start of each block grow until they settle
+	g := c.flowGraph(frame)
+	entry := make(map[*cfg.Block]droppedErrors, len(g.Blocks))
+	entry[g.Blocks[0]] = droppedErrors{}
+	work := []*cfg.Block{g.Blocks[0]}
+	for len(work) > 0 {
+		b := work[0]
+		work = work[1:]
+
+		dropped := droppedErrors{}
+		dropped.merge(entry[b])
+		for _, n := range b.Nodes {
+			c.transfer(dropped, n, errName)
+		}
+
+		for i, succ := range b.Succs {
+			out := dropped
+			if len(b.Succs) == 2 && len(b.Nodes) > 0 {
+				if cond, ok := b.Nodes[len(b.Nodes)-1].(ast.Expr); ok {
+					out = c.assumeCondition(dropped, cond, i == 0)
+				}
+			}
+
+			if _, seen := entry[succ]; !seen {
+				entry[succ] = droppedErrors{}
+				entry[succ].merge(out)
+				work = append(work, succ)
+			} else if entry[succ].merge(out) {
+				work = append(work, succ)
+			}
+		}
+	}
+
+	for _, b := range g.Blocks {
+		if _, reached := entry[b]; !reached {
+			continue
+		}
+
+		dropped := droppedErrors{}
+		dropped.merge(entry[b])
+		for _, n := range b.Nodes {
+			if ret, ok := n.(*ast.ReturnStmt); ok && len(ret.Results) == 0 && len(dropped) > 0 {
+				c.reportDroppedError(frame, ret, errName, dropped)
+			}
+			c.transfer(dropped, n, errName)
+		}
+	}
+}
+
+// transfer updates dropped with the effect of n, a node of a control flow
+// graph: assigning the named error settles every dropped error, and storing
+// the error of a call in another variable drops it.
+func (c *checker) transfer(dropped droppedErrors, n ast.Node, errName *ast.Ident) {
+	if c.assignsNamed(n, errName) {
+		clear(dropped)
+		return
+	}
+
+	var lhs []ast.Expr
+	var rhs []ast.Expr
+	switch n := n.(type) {
+	case *ast.AssignStmt:
+		lhs, rhs = n.Lhs, n.Rhs
+	case *ast.ValueSpec:
+		for _, name := range n.Names {
+			lhs = append(lhs, name)
+		}
+		rhs = n.Values
+	default:
+		return
+	}
+
+	for _, e := range lhs {
+		if ident, ok := e.(*ast.Ident); ok {
+			delete(dropped, c.lookup.objectOf(ident))
+		}
+	}
+
+	for i, e := range lhs {
+		ident, ok := e.(*ast.Ident)
+		if !ok || ident.Name == "_" {
+			continue
+		}
+
+		// The error is either one of the results of a single call, or
+		// the result of the call assigned to the variable alone
+		var call *ast.CallExpr
+		var t types.Type
+		switch {
+		case len(rhs) == 1 && len(lhs) > 1:
+			call, _ = ast.Unparen(rhs[0]).(*ast.CallExpr)
+			if call != nil {
+				if tuple, isTuple := c.pass.TypesInfo.TypeOf(call).(*types.Tuple); isTuple && i < tuple.Len() {
+					t = tuple.At(i).Type()
+				}
+			}
+		case i < len(rhs):
+			call, _ = ast.Unparen(rhs[i]).(*ast.CallExpr)
+			if call != nil {
+				t = c.pass.TypesInfo.TypeOf(call)
+			}
+		}
+
+		if v := c.lookup.objectOf(ident); v != nil && t != nil && types.Identical(t, c.lookup.errorType) {
+			dropped[v] = call
+		}
+	}
+}
+
+// assignsNamed reports whether n assigns the named error errName, itself or
+// in a function literal, or takes its address.
+func (c *checker) assignsNamed(n ast.Node, errName *ast.Ident) (assigns bool) {
+	named := func(e ast.Expr) (is bool) {
+		ident, ok := ast.Unparen(e).(*ast.Ident)
+		is = ok && c.lookup.assignedIn([]*ast.Ident{ident}, errName)
+		return is
+	}
+
+	ast.Inspect(n, func(node ast.Node) (proceed bool) {
+		switch node := node.(type) {
+		case *ast.AssignStmt:
+			for _, lhs := range node.Lhs {
+				assigns = assigns || named(lhs)
+			}
+		case *ast.UnaryExpr:
+			assigns = assigns || node.Op == token.AND && named(node.X)
+		}
+		proceed = !assigns
+		return proceed
+	})
+	return assigns
+}
+
+// assumeCondition returns the dropped errors left when cond evaluates to
+// outcome: those compared to nil are not dropped where they are nil.
+func (c *checker) assumeCondition(dropped droppedErrors, cond ast.Expr, outcome bool) (left droppedErrors) {
+	nilVars := c.nilWhen(cond, outcome)
+	if len(nilVars) == 0 {
+		left = dropped
+		return left
+	}
+
+	left = droppedErrors{}
+	for v, call := range dropped {
+		if !nilVars[v] {
+			left[v] = call
+		}
+	}
+	return left
+}
+
+// nilWhen returns the variables cond implies are nil when it evaluates to
+// outcome, such as e in e == nil.
+func (c *checker) nilWhen(cond ast.Expr, outcome bool) (nilVars map[types.Object]bool) {
+	nilVars = make(map[types.Object]bool)
+	binary, ok := ast.Unparen(cond).(*ast.BinaryExpr)
+	if !ok {
+		return nilVars
+	}
+
+	switch binary.Op {
+	case token.LAND, token.LOR:
+		// Both operands hold when a conjunction holds or a disjunction
+		// doesn't
+		if binary.Op == token.LAND == outcome {
+			for v := range c.nilWhen(binary.X, outcome) {
+				nilVars[v] = true
+			}
+			for v := range c.nilWhen(binary.Y, outcome) {
+				nilVars[v] = true
+			}
+		}
+	case token.EQL, token.NEQ:
+		if binary.Op == token.EQL != outcome {
+			return nilVars
+		}
+		for _, pair := range [][2]ast.Expr{{binary.X, binary.Y}, {binary.Y, binary.X}} {
+			ident, isIdent := ast.Unparen(pair[0]).(*ast.Ident)
+			other, isNil := ast.Unparen(pair[1]).(*ast.Ident)
+			if isIdent && isNil && other.Name == "nil" {
+				if v := c.lookup.objectOf(ident); v != nil {
+					nilVars[v] = true
+				}
+			}
+		}
+	}
+	return nilVars
+}
+
+// reportDroppedError reports the bare return ret, reached with the named
+// error errName unassigned after the errors of dropped were dropped, at the
+// first of which the finding points.
+func (c *checker) reportDroppedError(frame *funcFrame, ret *ast.ReturnStmt, errName *ast.Ident, dropped droppedErrors) {
+	calls := make([]*ast.CallExpr, 0, len(dropped))
+	for _, call := range dropped {
+		calls = append(calls, call)
+	}
+	sort.Slice(calls, func(i, j int) (less bool) {
+		less = calls[i].Pos() < calls[j].Pos()
+		return less
+	})
+
+	d := diagnosticf(RuleDroppedError, ret.Pos(), frame.msgs, MessageDroppedError, frame.name, errName.Name, types.ExprString(calls[0].Fun))
+	d.Related = []analysis.RelatedInformation{{
+		Pos:     calls[0].Pos(),
+		End:     calls[0].End(),
+		Message: frame.msgs.sprintf(MessageDroppedErrorRelated, errName.Name),
+	}}
+	c.report(d, Message{FuncName: frame.name, ReturnName: errName.Name, Type: frame.resultType(errName)})
+}
@@ -0,0 +1,146 @@
+package analyzer
+
+import (
+	"fmt"
+	"go/ast"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Code generators whose output the exclude-presets setting excludes.
+const (
+	GeneratorMockery     = "mockery"
+	GeneratorGomock      = "gomock"
+	GeneratorProtocGenGo = "protoc-gen-go"
+	GeneratorStringer    = "stringer"
+	GeneratorEnt         = "ent"
+	GeneratorSqlc        = "sqlc"
+)
+
+// generators are the valid values of the exclude-presets setting.
+var generators = []string{GeneratorMockery, GeneratorGomock, GeneratorProtocGenGo, GeneratorStringer, GeneratorEnt, GeneratorSqlc}
+
+// generatorOutput describes the files a code generator produces, any of
+// which identifies them.
+type generatorOutput struct {
+	files    []string         // globs of their paths, as in exclude-files
+	packages []string         // path.Match patterns of their package names
+	headers  []*regexp.Regexp // matching a line of a comment above their package clause
+}
+
+// generatorOutputs holds the output of each generator, covering the header
+// variants of their older versions, which don't all follow the
+// "Code generated ... DO NOT EDIT." convention.
+var generatorOutputs = map[string]generatorOutput{
+	GeneratorMockery: {
+		files:    []string{"**/mock_*.go", "**/mocks/**"},
+		packages: []string{"mocks"},
+		headers:  []*regexp.Regexp{regexp.MustCompile(`^// Code generated by mockery\b`)},
+	},
+	GeneratorGomock: {
+		files:    []string{"**/mock_*.go", "**/*_mock.go", "**/mocks/**"},
+		packages: []string{"mock_*"},
+		headers: []*regexp.Regexp{
+			regexp.MustCompile(`^// Code generated by MockGen\b`),
+			regexp.MustCompile(`^// Automatically generated by MockGen\b`),
+		},
+	},
+	GeneratorProtocGenGo: {
+		files: []string{"**/*.pb.go", "**/*.pb.gw.go", "**/*.pb.validate.go"},
+		headers: []*regexp.Regexp{
+			regexp.MustCompile(`^// Code generated by protoc-gen-go[\w-]*\b`),
+			regexp.MustCompile(`^// Code generated by protoc-gen-grpc-gateway\b`),
+		},
+	},
+	GeneratorStringer: {
+		files:   []string{"**/*_string.go"},
+		headers: []*regexp.Regexp{regexp.MustCompile(`^// Code generated by "stringer\b`)},
+	},
+	GeneratorEnt: {
+		// The schema package is written by hand
+		files: []string{
+			"**/ent/*_create.go", "**/ent/*_delete.go", "**/ent/*_query.go", "**/ent/*_update.go",
+			"**/ent/client.go", "**/ent/ent.go", "**/ent/mutation.go", "**/ent/runtime.go", "**/ent/tx.go",
+			"**/ent/enttest/**", "**/ent/hook/**", "**/ent/migrate/**", "**/ent/predicate/**", "**/ent/runtime/**",
+		},
+		headers: []*regexp.Regexp{regexp.MustCompile(`^// Code generated by entc?\b`)},
+	},
+	GeneratorSqlc: {
+		files:   []string{"**/*.sql.go"},
+		headers: []*regexp.Regexp{regexp.MustCompile(`^// Code generated by sqlc\b`)},
+	},
+}
+
+// generatorListValue is the flag value of a list of generators, given as
+// comma separated names.
+type generatorListValue struct {
+	list *[]string
+}
+
+func (v generatorListValue) String() (s string) {
+	if v.list != nil {
+		s = strings.Join(*v.list, ",")
+	}
+	return s
+}
+
+func (v generatorListValue) Set(value string) (err error) {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		if !slices.Contains(generators, item) {
+			err = fmt.Errorf("unknown exclude preset %q, expected one of: %s", item, strings.Join(generators, ", "))
+			return err
+		}
+		items = append(items, item)
+	}
+	*v.list = items
+	return err
+}
+
+// presetFiles returns the globs of the files the exclude-presets setting
+// excludes by path.
+func (cfg Config) presetFiles() (globs []string) {
+	for _, generator := range cfg.ExcludePresets {
+		globs = append(globs, generatorOutputs[generator].files...)
+	}
+	return globs
+}
+
+// ExcludesGenerated reports whether the exclude-presets setting excludes
+// file, by its package name or by the header above its package clause. The
+// paths of the files are left to ExcludesFile.
+func (cfg Config) ExcludesGenerated(file *ast.File) (excluded bool) {
+	for _, generator := range cfg.ExcludePresets {
+		output := generatorOutputs[generator]
+		for _, pattern := range output.packages {
+			if matched, _ := path.Match(pattern, file.Name.Name); matched {
+				excluded = true
+				return excluded
+			}
+		}
+
+		for _, group := range file.Comments {
+			if group.Pos() > file.Package {
+				break
+			}
+			for _, c := range group.List {
+				for _, line := range strings.Split(c.Text, "\n") {
+					for _, header := range output.headers {
+						if header.MatchString(line) {
+							excluded = true
+							return excluded
+						}
+					}
+				}
+			}
+		}
+	}
+	return excluded
+}
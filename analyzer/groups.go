@@ -0,0 +1,134 @@
+package analyzer
+
+import (
+	"go/token"
+	"slices"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// severityRank orders severities from least to most severe.
+var severityRank = map[string]int{
+	SeverityInfo:    1,
+	SeverityWarning: 2,
+	SeverityError:   3,
+}
+
+// findingGroup holds the findings about one function.
+type findingGroup struct {
+	funcName string
+	findings []analysis.Diagnostic
+}
+
+// groupKey identifies a function by file and description. Function literals
+// of the same function share their description, and so a group.
+type groupKey struct {
+	file     *token.File
+	funcName string
+}
+
+// findingGroups collects the findings of the functions of a package, which
+// are reported one per function once the package is checked.
+type findingGroups struct {
+	fset   *token.FileSet
+	keep   func(analysis.Diagnostic) bool // whether a finding is reported at all
+	groups map[groupKey]*findingGroup
+	order  []*findingGroup
+}
+
+func newFindingGroups(fset *token.FileSet, keep func(analysis.Diagnostic) bool) (g *findingGroups) {
+	g = &findingGroups{fset: fset, keep: keep, groups: make(map[groupKey]*findingGroup)}
+	return g
+}
+
+// add adds d, a finding about the function funcName, unless it isn't
+// reported at all.
+func (g *findingGroups) add(funcName string, d analysis.Diagnostic) {
+	if !g.keep(d) {
+		return
+	}
+
+	key := groupKey{file: g.fset.File(d.Pos), funcName: funcName}
+	group, ok := g.groups[key]
+	if !ok {
+		group = &findingGroup{funcName: funcName}
+		g.groups[key] = group
+		g.order = append(g.order, group)
+	}
+	group.findings = append(group.findings, d)
+}
+
+// grouped returns the findings of group as one, in the locale of msgs. It is
+// reported at the first finding, under the rule of the most severe one, and
+// lists all of them, with their related information and distinct suggested
+// fixes.
+func (g *findingGroups) grouped(group *findingGroup, msgs catalog) (d analysis.Diagnostic) {
+	findings := group.findings
+	sort.SliceStable(findings, func(i, j int) (less bool) {
+		less = findings[i].Pos < findings[j].Pos
+		return less
+	})
+	if len(findings) == 1 {
+		d = findings[0]
+		return d
+	}
+
+	d = analysis.Diagnostic{Pos: findings[0].Pos, End: findings[0].End, Category: findings[0].Category}
+	firstLine := g.fset.Position(findings[0].Pos).Line
+	problems := make([]string, 0, len(findings))
+	for _, f := range findings {
+		if severityRank[ruleSeverity(f.Category)] > severityRank[ruleSeverity(d.Category)] {
+			d.Category = f.Category
+		}
+
+		problem := strings.TrimPrefix(f.Message, group.funcName+": ")
+		if line := g.fset.Position(f.Pos).Line; line != firstLine {
+			problem = msgs.sprintf(MessageGroupedAtLine, problem, line)
+		}
+		problems = append(problems, problem)
+
+		d.Related = append(d.Related, analysis.RelatedInformation{Pos: f.Pos, End: f.End, Message: f.Message})
+		d.Related = append(d.Related, f.Related...)
+		for _, fix := range f.SuggestedFixes {
+			// Fixes of several findings may be the same, e.g. naming all
+			// results, and drivers require distinct ones
+			duplicate := slices.ContainsFunc(d.SuggestedFixes, func(other analysis.SuggestedFix) (same bool) {
+				same = other.Message == fix.Message
+				return same
+			})
+			if !duplicate {
+				d.SuggestedFixes = append(d.SuggestedFixes, fix)
+			}
+		}
+	}
+	d.Message = msgs.sprintf(MessageGrouped, group.funcName, len(findings), strings.Join(problems, "; "))
+	return d
+}
+
+// mostSevere returns the most severe finding of group, the first of them on
+// a tie.
+func (g *findingGroups) mostSevere(group *findingGroup) (d analysis.Diagnostic) {
+	findings := group.findings
+	sort.SliceStable(findings, func(i, j int) (less bool) {
+		less = findings[i].Pos < findings[j].Pos
+		return less
+	})
+
+	d = findings[0]
+	for _, f := range findings[1:] {
+		if severityRank[ruleSeverity(f.Category)] > severityRank[ruleSeverity(d.Category)] {
+			d = f
+		}
+	}
+	return d
+}
+
+// ruleSeverity returns the severity of the rule with the given ID.
+func ruleSeverity(id string) (severity string) {
+	if rule, ok := LookupRule(id); ok {
+		severity = rule.Severity
+	}
+	return severity
+}
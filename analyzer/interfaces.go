@@ -0,0 +1,176 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/types"
+	"slices"
+	"sort"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// interfaceIndex finds the interfaces visible to a package that may be
+// implemented by a method, by the method's name.
+type interfaceIndex struct {
+	results  InterfaceResults
+	byMethod map[string][]*types.TypeName // built on first use, ordered by package path and name
+}
+
+// newInterfaceIndex returns the index of the interfaces the Facts analyzer
+// found, or nil when it didn't run.
+func newInterfaceIndex(results InterfaceResults) (index *interfaceIndex) {
+	if results == nil {
+		return index
+	}
+
+	index = &interfaceIndex{results: results}
+	return index
+}
+
+// declaring returns the interfaces declaring a method with the given name
+// whose results they name.
+func (index *interfaceIndex) declaring(method string) (typeNames []*types.TypeName) {
+	if index.byMethod == nil {
+		index.byMethod = make(map[string][]*types.TypeName)
+		for typeName, names := range index.results {
+			// Generic interfaces and constraints aren't implemented as
+			// declared
+			iface, ok := typeName.Type().Underlying().(*types.Interface)
+			if !ok || !iface.IsMethodSet() {
+				continue
+			}
+			if named, isNamed := typeName.Type().(*types.Named); isNamed && named.TypeParams().Len() > 0 {
+				continue
+			}
+
+			for name, results := range names.Methods {
+				if slices.ContainsFunc(results, func(r string) (named bool) {
+					named = r != ""
+					return named
+				}) {
+					index.byMethod[name] = append(index.byMethod[name], typeName)
+				}
+			}
+		}
+		for _, declared := range index.byMethod {
+			sort.Slice(declared, func(i, j int) (less bool) {
+				if declared[i].Pkg().Path() != declared[j].Pkg().Path() {
+					less = declared[i].Pkg().Path() < declared[j].Pkg().Path()
+					return less
+				}
+				less = declared[i].Name() < declared[j].Name()
+				return less
+			})
+		}
+	}
+
+	typeNames = index.byMethod[method]
+	return typeNames
+}
+
+// checkInterfaceNames reports the results of a method named differently
+// from the results of an interface it implements, or left unnamed where the
+// interface names them. A method agreeing with one of the interfaces it
+// implements is left alone, as interfaces may disagree among themselves.
+func (c *checker) checkInterfaceNames(frame *funcFrame) {
+	decl, ok := frame.node.(*ast.FuncDecl)
+	if !ok || decl.Recv == nil || c.interfaces == nil {
+		return
+	}
+	fn, ok := c.pass.TypesInfo.Defs[decl.Name].(*types.Func)
+	if !ok {
+		return
+	}
+	sig, ok := fn.Type().(*types.Signature)
+	if !ok || sig.Recv() == nil {
+		return
+	}
+
+	// The method set of the pointer includes every method of the type
+	recv := sig.Recv().Type()
+	if ptr, isPtr := recv.(*types.Pointer); isPtr {
+		recv = ptr.Elem()
+	}
+	named, ok := recv.(*types.Named)
+	if !ok || named.TypeParams().Len() > 0 {
+		return
+	}
+	ptr := types.NewPointer(named)
+
+	// The names the method gives its results, "" for unnamed ones
+	var got []string
+	for _, field := range frame.typ.Results.List {
+		if len(field.Names) == 0 {
+			got = append(got, "")
+		}
+		for _, n := range field.Names {
+			got = append(got, n.Name)
+		}
+	}
+
+	var mismatched *types.TypeName
+	var want []string
+	for _, typeName := range c.interfaces.declaring(decl.Name.Name) {
+		iface, isIface := typeName.Type().Underlying().(*types.Interface)
+		if !isIface || !types.Implements(ptr, iface) {
+			continue
+		}
+
+		names, _ := c.interfaces.results.Lookup(typeName, decl.Name.Name)
+		if len(names) != len(got) {
+			continue
+		}
+		agrees := true
+		for i, name := range names {
+			if name != "" && got[i] != name {
+				agrees = false
+			}
+		}
+		if agrees {
+			return
+		}
+		if mismatched == nil {
+			mismatched, want = typeName, names
+		}
+	}
+	if mismatched == nil {
+		return
+	}
+
+	qualifier := func(p *types.Package) (name string) {
+		if p != c.pass.Pkg {
+			name = p.Name()
+		}
+		return name
+	}
+	ifaceName := types.TypeString(mismatched.Type(), qualifier)
+
+	var related []analysis.RelatedInformation
+	method, _, _ := types.LookupFieldOrMethod(mismatched.Type(), false, mismatched.Pkg(), decl.Name.Name)
+	if method != nil && method.Pos().IsValid() {
+		related = []analysis.RelatedInformation{{Pos: method.Pos(), Message: frame.msgs.sprintf(MessageInterfaceRelated, ifaceName+"."+decl.Name.Name)}}
+	}
+
+	i := 0
+	for _, field := range frame.typ.Results.List {
+		typ := types.ExprString(field.Type)
+		if len(field.Names) == 0 {
+			if want[i] != "" {
+				d := diagnosticf(RuleInterfaceName, field.Pos(), frame.msgs, MessageInterfaceUnnamed, frame.name, typ, want[i], ifaceName)
+				d.Related = related
+				c.report(d, Message{FuncName: frame.name, Type: typ})
+			}
+			i++
+			continue
+		}
+
+		for _, n := range field.Names {
+			if want[i] != "" && n.Name != want[i] {
+				d := diagnosticf(RuleInterfaceName, n.Pos(), frame.msgs, MessageInterfaceName, frame.name, n.Name, want[i], ifaceName)
+				d.Related = related
+				c.report(d, Message{FuncName: frame.name, ReturnName: n.Name, Type: typ})
+			}
+			i++
+		}
+	}
+}
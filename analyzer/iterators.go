@@ -0,0 +1,51 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/types"
+	"slices"
+	"strings"
+)
+
+// yieldFunc reports whether lit, a child of parent, is a yield function: the
+// only argument of a call to an iterator, taking up to two values and
+// returning an unnamed bool, as in seq(func(v int) bool { ... }). Without
+// type information the callee is taken for an iterator.
+func (c *checker) yieldFunc(lit *ast.FuncLit, parent ast.Node) (yield bool) {
+	call, ok := parent.(*ast.CallExpr)
+	if !ok || len(call.Args) != 1 || call.Args[0] != lit {
+		return yield
+	}
+
+	params, results := lit.Type.Params, lit.Type.Results
+	if params != nil && resultCount(params.List) > 2 || results == nil || len(results.List) != 1 || len(results.List[0].Names) > 0 {
+		return yield
+	}
+
+	if t := c.pass.TypesInfo.TypeOf(call.Fun); t != nil {
+		sig, isFunc := t.Underlying().(*types.Signature)
+		yield = isFunc && iteratorSignature(sig)
+		return yield
+	}
+	result, isIdent := results.List[0].Type.(*ast.Ident)
+	yield = isIdent && result.Name == "bool"
+	return yield
+}
+
+// checkIteratorNames reports iterator results, iter.Seq, iter.Seq2 and
+// functions of their shape, not named from the allowed names.
+func (c *checker) checkIteratorNames(frame *funcFrame) {
+	allowed := frame.cfg.iteratorNames()
+	for _, p := range frame.typ.Results.List {
+		if !slices.Contains(c.resultCategories(p.Type), CategoryIterator) {
+			continue
+		}
+
+		for _, n := range p.Names {
+			if n.Name != "_" && !slices.Contains(allowed, n.Name) {
+				c.report(diagnosticf(RuleIteratorName, n.Pos(), frame.msgs, MessageIteratorName, frame.name, n.Name, strings.Join(allowed, ", ")),
+					Message{FuncName: frame.name, ReturnName: n.Name, Type: types.ExprString(p.Type)})
+			}
+		}
+	}
+}
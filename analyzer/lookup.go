@@ -0,0 +1,122 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/types"
+)
+
+// typeCache memoizes the type information the checks of a package look up
+// repeatedly, such as the objects of named results, which are resolved for
+// every return statement and shadowing declaration. Without type information
+// the lookups find nothing and the checks fall back to the syntax.
+type typeCache struct {
+	info      *types.Info
+	errorType types.Type
+	objects   map[*ast.Ident]types.Object
+	isError   map[ast.Expr]bool
+	uses      map[types.Object][]*ast.Ident // built on first use
+}
+
+// newTypeCache creates an empty cache for info.
+func newTypeCache(info *types.Info) (tc *typeCache) {
+	tc = &typeCache{
+		info:      info,
+		errorType: types.Universe.Lookup("error").Type(),
+		objects:   make(map[*ast.Ident]types.Object),
+		isError:   make(map[ast.Expr]bool),
+	}
+	return tc
+}
+
+// objectOf returns the object ident denotes, or nil if it is unknown.
+func (tc *typeCache) objectOf(ident *ast.Ident) (obj types.Object) {
+	var cached bool
+	obj, cached = tc.objects[ident]
+	if cached {
+		return obj
+	}
+
+	obj = tc.info.ObjectOf(ident)
+	tc.objects[ident] = obj
+	return obj
+}
+
+// isErrorType reports whether expr denotes the error type, or a type
+// parameter whose constraint implies error. Without type information, as when
+// the package has type errors, it goes by the syntax, looking up type
+// parameters in typeParams.
+func (tc *typeCache) isErrorType(expr ast.Expr, typeParams *ast.FieldList) (isError bool) {
+	var cached bool
+	isError, cached = tc.isError[expr]
+	if cached {
+		return isError
+	}
+
+	if t := tc.info.TypeOf(expr); t != nil {
+		isError = types.Identical(t, tc.errorType)
+		if tp, ok := t.(*types.TypeParam); ok {
+			iface, isInterface := tc.errorType.Underlying().(*types.Interface)
+			isError = isInterface && types.Implements(tp, iface)
+		}
+	} else if ident, ok := expr.(*ast.Ident); ok {
+		isError = ident.Name == "error" || errorConstraint(typeParams, ident.Name)
+	}
+	tc.isError[expr] = isError
+	return isError
+}
+
+// errorConstraint reports whether the type parameter called name, among
+// typeParams, is constrained by error or by an interface embedding it.
+func errorConstraint(typeParams *ast.FieldList, name string) (isError bool) {
+	if typeParams == nil {
+		return isError
+	}
+
+	for _, field := range typeParams.List {
+		for _, param := range field.Names {
+			if param.Name != name {
+				continue
+			}
+
+			switch constraint := field.Type.(type) {
+			case *ast.Ident:
+				isError = constraint.Name == "error"
+			case *ast.InterfaceType:
+				for _, embedded := range constraint.Methods.List {
+					if ident, ok := embedded.Type.(*ast.Ident); ok && len(embedded.Names) == 0 && ident.Name == "error" {
+						isError = true
+					}
+				}
+			}
+			return isError
+		}
+	}
+	return isError
+}
+
+// assignedIn reports whether any of assigned refers to the variable declared
+// by decl. Without type information it compares the names.
+func (tc *typeCache) assignedIn(assigned []*ast.Ident, decl *ast.Ident) (found bool) {
+	variable := tc.objectOf(decl)
+	for _, ident := range assigned {
+		if variable == nil && ident.Name == decl.Name || variable != nil && tc.objectOf(ident) == variable {
+			found = true
+			return found
+		}
+	}
+	return found
+}
+
+// usesOf returns the identifiers referring to obj. The index of all uses is
+// built once, rather than scanning them for every renamed variable.
+func (tc *typeCache) usesOf(obj types.Object) (uses []*ast.Ident) {
+	if tc.uses == nil {
+		tc.uses = make(map[types.Object][]*ast.Ident)
+		for use, used := range tc.info.Uses {
+			tc.uses[used] = append(tc.uses[used], use)
+		}
+	}
+
+	uses = tc.uses[obj]
+	return uses
+}
@@ -0,0 +1,115 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// settingMessages is the key of the configuration documents holding the
+// message templates, by rule ID or name. Like tests, it names no flag.
+const settingMessages = "messages"
+
+// Message is what a message template is executed with. Fields that don't
+// apply to a finding are empty.
+type Message struct {
+	Rule       string // ID of the rule, e.g. "NR001"
+	RuleName   string // name of the rule, e.g. "unnamed-result"
+	FuncName   string // the function, e.g. "func Start" or "method (*Server).Start"
+	ReturnName string // the result concerned, if it is named
+	Type       string // the type of the result concerned
+	Text       string // the default message
+}
+
+// messageTemplates are the parsed message templates, by rule ID.
+type messageTemplates map[string]*template.Template
+
+// parseMessages parses message templates given by rule ID or name, checking
+// they only use the fields of Message.
+func parseMessages(messages map[string]string) (templates messageTemplates, err error) {
+	templates = make(messageTemplates, len(messages))
+
+	// Parse in a stable order so errors are reproducible
+	names := make([]string, 0, len(messages))
+	for name := range messages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		rule, ok := LookupRule(name)
+		if !ok {
+			err = fmt.Errorf("%s: unknown rule %q", settingMessages, name)
+			return templates, err
+		}
+
+		var t *template.Template
+		t, err = template.New(rule.ID).Option("missingkey=error").Parse(messages[name])
+		if err == nil {
+			err = t.Execute(&strings.Builder{}, Message{})
+		}
+		if err != nil {
+			err = fmt.Errorf("%s: template for %s: %w", settingMessages, name, err)
+			return templates, err
+		}
+		templates[rule.ID] = t
+	}
+	return templates, err
+}
+
+// render replaces the message of d by the template for its rule, if there
+// is one, executed with msg. The default message is kept if the template
+// fails.
+func (templates messageTemplates) render(d *analysis.Diagnostic, msg Message) {
+	t, ok := templates[d.Category]
+	if !ok {
+		return
+	}
+
+	msg.Rule = d.Category
+	if rule, found := LookupRule(d.Category); found {
+		msg.RuleName = rule.Name
+	}
+	msg.Text = d.Message
+
+	var b strings.Builder
+	if t.Execute(&b, msg) == nil {
+		d.Message = b.String()
+	}
+}
+
+// applyMessages merges the message templates of a decoded configuration
+// document into those of cfg, checking them as it goes.
+func (cfg *Config) applyMessages(value interface{}) (err error) {
+	values, ok := value.(map[string]interface{})
+	if !ok {
+		err = fmt.Errorf("%s must hold templates by rule", settingMessages)
+		return err
+	}
+
+	// The map may be shared with the defaults of the analyzer, so it is
+	// copied rather than changed
+	messages := make(map[string]string, len(cfg.Messages)+len(values))
+	for name, text := range cfg.Messages {
+		messages[name] = text
+	}
+	for name, text := range values {
+		// Rules are keyed by ID, so a rule given by name replaces the
+		// template given by ID
+		if rule, found := LookupRule(name); found {
+			name = rule.ID
+		}
+		messages[name] = settingString(text)
+	}
+
+	_, err = parseMessages(messages)
+	if err != nil {
+		return err
+	}
+
+	cfg.Messages = messages
+	return err
+}
@@ -0,0 +1,90 @@
+package analyzer
+
+import (
+	"go/ast"
+	"regexp"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// nolintPattern matches a nolint directive the way golangci-lint does,
+// capturing the linters it names, if any:
+//
+//	//nolint
+//	//nolint:namedreturns,errcheck // reason
+var nolintPattern = regexp.MustCompile(`^//\s*nolint(?::([\w-]+(?:\s*,\s*[\w-]+)*))?(?:\s|//|$)`)
+
+// addNolint adds the suppressions of the nolint directives naming any of
+// linters to s. As in golangci-lint, a directive applies to its own line, and
+// to the whole node starting on the next line at the same column, so a
+// directive above a function, or at the end of its doc comment, covers the
+// function.
+func addNolint(pass *analysis.Pass, s suppressions, linters ...string) {
+	for _, file := range pass.Files {
+		tokFile := pass.Fset.File(file.Pos())
+		if tokFile == nil {
+			continue
+		}
+
+		// Directive lines to their column
+		directives := make(map[int]int)
+		for _, group := range file.Comments {
+			for _, c := range group.List {
+				if nolintApplies(c.Text, linters) {
+					pos := pass.Fset.Position(c.Slash)
+					directives[pos.Line] = pos.Column
+					s.add(tokFile.Name(), lineRange{pos.Line, pos.Line}, nil)
+				}
+			}
+		}
+		if len(directives) == 0 {
+			continue
+		}
+
+		ast.Inspect(file, func(node ast.Node) (continueInspection bool) {
+			continueInspection = true
+			if node == nil {
+				return continueInspection
+			}
+			if _, isComment := node.(*ast.CommentGroup); isComment {
+				return continueInspection
+			}
+
+			start := pass.Fset.Position(node.Pos())
+			if column, ok := directives[start.Line-1]; ok && column == start.Column {
+				end := pass.Fset.Position(node.End())
+				s.add(tokFile.Name(), lineRange{start.Line, end.Line}, nil)
+			}
+			return continueInspection
+		})
+	}
+}
+
+// nolintApplies reports whether the comment text is a nolint directive
+// naming any of linters, or naming none, which suppresses all of them.
+func nolintApplies(text string, linters []string) (applies bool) {
+	match := nolintPattern.FindStringSubmatch(text)
+	if match == nil {
+		return applies
+	}
+	if match[1] == "" {
+		applies = true
+		return applies
+	}
+
+	for _, name := range strings.Split(match[1], ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "all" {
+			applies = true
+			return applies
+		}
+		for _, linter := range linters {
+			if name == linter {
+				applies = true
+				return applies
+			}
+		}
+	}
+	return applies
+}
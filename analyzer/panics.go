@@ -0,0 +1,74 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+)
+
+// callsBuiltin reports whether call calls the builtin function called name.
+// Without type information any function called so is taken for the builtin.
+func (c *checker) callsBuiltin(call *ast.CallExpr, name string) (calls bool) {
+	ident, ok := ast.Unparen(call.Fun).(*ast.Ident)
+	if !ok || ident.Name != name {
+		return calls
+	}
+
+	obj := c.pass.TypesInfo.Uses[ident]
+	_, isBuiltin := obj.(*types.Builtin)
+	calls = obj == nil || isBuiltin
+	return calls
+}
+
+// checkPanics reports the calls to panic of a function with a named error
+// that no deferred code converts into the error: neither a deferred function
+// literal both calling recover and assigning the error, nor a deferred call
+// given the error's address, as helpers doing the conversion are. Panics in
+// nested function literals are left to those.
+func (c *checker) checkPanics(frame *funcFrame) {
+	errName := c.namedError(frame)
+	if errName == nil {
+		return
+	}
+
+	for _, d := range frame.defers {
+		if lit, ok := d.Call.Fun.(*ast.FuncLit); ok && c.recovers(lit) && c.assignsNamed(lit.Body, errName) {
+			return
+		}
+		for _, arg := range d.Call.Args {
+			if addr, ok := arg.(*ast.UnaryExpr); ok && addr.Op == token.AND && c.assignsNamed(addr, errName) {
+				return
+			}
+		}
+	}
+
+	ast.Inspect(frame.body, func(n ast.Node) (proceed bool) {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return proceed
+		case *ast.CallExpr:
+			if c.callsBuiltin(n, "panic") {
+				c.report(diagnosticf(RuleUnrecoveredPanic, n.Pos(), frame.msgs, MessageUnrecoveredPanic, frame.name, errName.Name),
+					Message{FuncName: frame.name, ReturnName: errName.Name, Type: frame.resultType(errName)})
+			}
+		}
+		proceed = true
+		return proceed
+	})
+}
+
+// recovers reports whether lit calls recover, itself rather than in nested
+// function literals, where it would recover nothing.
+func (c *checker) recovers(lit *ast.FuncLit) (recovers bool) {
+	ast.Inspect(lit.Body, func(n ast.Node) (proceed bool) {
+		if _, isLit := n.(*ast.FuncLit); isLit || recovers {
+			return proceed
+		}
+		if call, ok := n.(*ast.CallExpr); ok && c.callsBuiltin(call, "recover") {
+			recovers = true
+		}
+		proceed = true
+		return proceed
+	})
+	return recovers
+}
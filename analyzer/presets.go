@@ -0,0 +1,199 @@
+package analyzer
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"gopkg.in/yaml.v3"
+)
+
+// Presets name ready-made configurations for common levels of strictness.
+const (
+	// PresetStrict requires every result to be named and enables the
+	// conventions and checks of how the names are used.
+	PresetStrict = "strict"
+
+	// PresetRelaxed requires names only where results share a type, and
+	// exempts the results whose types say enough.
+	PresetRelaxed = "relaxed"
+
+	// PresetErrorsOnly requires names only of functions returning errors,
+	// named by the error convention.
+	PresetErrorsOnly = "errors-only"
+)
+
+// presetNames are the names of the presets, in order of strictness.
+var presetNames = []string{PresetStrict, PresetRelaxed, PresetErrorsOnly}
+
+var presets = map[string]Config{
+	PresetStrict: {
+		Mode:               ModeAll,
+		ErrorConvention:    true,
+		DeferErrorHandler:  true,
+		ReportUnusedNames:  true,
+		BoolConvention:     true,
+		InterfaceNames:     true,
+		DocResults:         true,
+		MustAssignError:    true,
+		ErrorPropagation:   true,
+		PanicRecover:       true,
+		ReportReordered:    true,
+		PreferBareReturn:   true,
+		ShadowedReturns:    true,
+		IteratorConvention: true,
+	},
+	PresetRelaxed: {
+		Mode:              ModeAmbiguous,
+		ExemptResultTypes: []string{CategoryFunc, CategoryChan, CategoryEmptyStruct, CategoryIterator, CategorySingleMethod},
+		ExemptCommaOK:     true,
+		ExemptYieldFuncs:  true,
+		ExemptTestHelpers: true,
+		Tests:             map[string]string{FlagDisable: RuleUnusedInReturn},
+	},
+	PresetErrorsOnly: {
+		Mode:            ModeErrors,
+		ErrorConvention: true,
+	},
+}
+
+// Analyzers configured by the presets. Like Analyzer they report every rule,
+// and the configuration file and their own flags can still change settings.
+// Their names differ, so they can be registered side by side, e.g. with a
+// multichecker, and each honors nolint directives naming it as well as those
+// naming namedreturns.
+var (
+	// Strict is configured by PresetStrict.
+	Strict = newPresetAnalyzer("namedreturns_strict", PresetStrict)
+
+	// Relaxed is configured by PresetRelaxed.
+	Relaxed = newPresetAnalyzer("namedreturns_relaxed", PresetRelaxed)
+
+	// ErrorsOnly is configured by PresetErrorsOnly.
+	ErrorsOnly = newPresetAnalyzer("namedreturns_errorsonly", PresetErrorsOnly)
+)
+
+// newPresetAnalyzer creates an analyzer reporting every rule, configured by
+// the preset with the given name.
+func newPresetAnalyzer(name string, preset string) (a *analysis.Analyzer) {
+	// The presets are known, so looking them up can't fail
+	cfg, _ := LookupPreset(preset)
+	a = NewAnalyzer(cfg)
+	a.Name = name
+	a.Doc = fmt.Sprintf("%s, configured by the %s preset", a.Doc, preset)
+	return a
+}
+
+// Presets returns the names of the presets, from the strictest.
+func Presets() (names []string) {
+	names = append([]string(nil), presetNames...)
+	return names
+}
+
+// LookupPreset returns the configuration of the preset with the given name.
+func LookupPreset(name string) (cfg Config, err error) {
+	preset, ok := presets[name]
+	if !ok {
+		err = fmt.Errorf("unknown preset %q, expected one of: %s", name, strings.Join(presetNames, ", "))
+		return cfg, err
+	}
+
+	// The lists and maps of the preset are shared, so they are copied
+	cfg = preset
+	cfg.ExemptResultTypes = slices.Clone(preset.ExemptResultTypes)
+	cfg.Tests = maps.Clone(preset.Tests)
+	return cfg, err
+}
+
+// documentSettings describe the settings of configuration documents that
+// name no flag.
+var documentSettings = map[string]string{
+	settingTests:    "settings overriding the others in _test.go files, by name",
+	settingMessages: "text/template templates replacing the messages of rules, by rule ID or name",
+	settingExempt:   "rules exempting the functions matching their receiver, returns and package patterns from all checks",
+}
+
+// StarterConfig renders cfg as a configuration file listing every setting
+// under its description, with those left at their defaults commented out.
+func StarterConfig(cfg Config) (data []byte, err error) {
+	var doc, defaults yaml.Node
+	err = doc.Encode(cfg.WithDefaults())
+	if err != nil {
+		return data, err
+	}
+	err = defaults.Encode(Config{}.WithDefaults())
+	if err != nil {
+		return data, err
+	}
+
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	var usage Config
+	usage.bind(fs)
+
+	var b bytes.Buffer
+	b.WriteString("# namedreturns configuration, see https://github.com/nikogura/namedreturns#configuration\n")
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		key := doc.Content[i].Value
+
+		var text, defaultText string
+		text, err = settingYAML(doc.Content[i], doc.Content[i+1])
+		if err != nil {
+			return data, err
+		}
+		defaultText, err = settingYAML(defaults.Content[i], defaults.Content[i+1])
+		if err != nil {
+			return data, err
+		}
+
+		description := documentSettings[key]
+		if f := fs.Lookup(key); f != nil {
+			description = f.Usage
+		}
+		b.WriteString("\n")
+		for _, line := range wrap(description, 76) {
+			b.WriteString("# " + line + "\n")
+		}
+
+		if text == defaultText {
+			text = "# " + strings.ReplaceAll(strings.TrimSuffix(text, "\n"), "\n", "\n# ") + "\n"
+		}
+		b.WriteString(text)
+	}
+
+	data = b.Bytes()
+	return data, err
+}
+
+// settingYAML renders one setting of a configuration document, lists and
+// maps on a single line.
+func settingYAML(key *yaml.Node, value *yaml.Node) (text string, err error) {
+	if value.Kind == yaml.SequenceNode || value.Kind == yaml.MappingNode {
+		value.Style = yaml.FlowStyle
+	}
+
+	var data []byte
+	data, err = yaml.Marshal(&yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{key, value}})
+	text = string(data)
+	return text, err
+}
+
+// wrap splits text into lines of at most width bytes, between words.
+func wrap(text string, width int) (lines []string) {
+	line := ""
+	for _, word := range strings.Fields(text) {
+		if line != "" && len(line)+1+len(word) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	lines = append(lines, line)
+	return lines
+}
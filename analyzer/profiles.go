@@ -0,0 +1,124 @@
+package analyzer
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// DefaultInternalPackages are the globs of the internal packages unless the
+// internal-packages setting says otherwise: those under an internal
+// directory, which other modules can't import.
+var DefaultInternalPackages = []string{"**/internal", "**/internal/**"}
+
+// internalPackages returns the globs of the internal packages.
+func (cfg Config) internalPackages() (globs []string) {
+	globs = cfg.InternalPackages
+	if len(globs) == 0 {
+		globs = DefaultInternalPackages
+	}
+	return globs
+}
+
+// presetValue is the flag value of the name of a preset, empty for none.
+type presetValue struct {
+	name *string
+}
+
+func (v presetValue) String() (s string) {
+	if v.name != nil {
+		s = *v.name
+	}
+	return s
+}
+
+func (v presetValue) Set(value string) (err error) {
+	value = strings.TrimSpace(value)
+	if value != "" {
+		_, err = LookupPreset(value)
+		if err != nil {
+			return err
+		}
+	}
+	*v.name = value
+	return err
+}
+
+// isInternal reports whether the package in dir is one of the internal
+// packages. The globs match the path of dir relative to the root of its
+// module, with forward slashes, or its absolute path outside any module.
+func (cfg Config) isInternal(dir string) (internal bool) {
+	name := filepath.ToSlash(dir)
+	if abs, err := filepath.Abs(dir); err == nil {
+		name = filepath.ToSlash(abs)
+		if root := moduleRoot(abs); root != "" {
+			if rel, relErr := filepath.Rel(root, abs); relErr == nil {
+				name = filepath.ToSlash(rel)
+			}
+		}
+	}
+
+	for _, pattern := range cfg.internalPackages() {
+		if matchFileGlob(pattern, name) {
+			internal = true
+			return internal
+		}
+	}
+	return internal
+}
+
+// withProfile returns the settings in effect for the package in dir: cfg,
+// overridden by the settings of the internal-preset if the package is one
+// of the internal packages, or else of the public-preset. The presets'
+// test settings are added to those of cfg, and override them too.
+func (cfg Config) withProfile(dir string) (profiled Config, err error) {
+	profiled = cfg
+	preset := cfg.PublicPreset
+	if cfg.isInternal(dir) {
+		preset = cfg.InternalPreset
+	}
+	if preset == "" {
+		return profiled, err
+	}
+
+	var p Config
+	p, err = LookupPreset(preset)
+	if err != nil {
+		return profiled, err
+	}
+
+	// The settings the preset leaves empty are not its own, though some
+	// render as their defaults
+	values := p.values()
+	var names []string
+	fields := reflect.ValueOf(p)
+	for i := 0; i < fields.NumField(); i++ {
+		name, _, _ := strings.Cut(fields.Type().Field(i).Tag.Get("json"), ",")
+		if _, isSetting := values[name]; isSetting && !fields.Field(i).IsZero() {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		err = profiled.Set(name, values[name])
+		if err != nil {
+			err = fmt.Errorf("preset %s: %w", preset, err)
+			return profiled, err
+		}
+	}
+
+	if len(p.Tests) > 0 {
+		tests := make(map[string]string, len(cfg.Tests)+len(p.Tests))
+		for name, value := range cfg.Tests {
+			tests[name] = value
+		}
+		for name, value := range p.Tests {
+			tests[name] = value
+		}
+		profiled.Tests = tests
+	}
+	return profiled, err
+}
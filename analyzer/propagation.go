@@ -0,0 +1,151 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// ssaFuncsBySyntax maps the functions of funcs, and the function literals
+// within them, by their syntax.
+func ssaFuncsBySyntax(funcs []*ssa.Function) (bySyntax map[ast.Node]*ssa.Function) {
+	bySyntax = make(map[ast.Node]*ssa.Function)
+	for len(funcs) > 0 {
+		fn := funcs[0]
+		funcs = append(funcs[1:], fn.AnonFuncs...)
+		if syntax := fn.Syntax(); syntax != nil {
+			bySyntax[syntax] = fn
+		}
+	}
+	return bySyntax
+}
+
+// checkPropagation reports the calls of a function with a named error whose
+// error is stored in a variable but never reaches a return, through any
+// number of assignments, wrapping calls, appends and conversions. Errors
+// only compared, logged or otherwise consumed are dropped, while those
+// stored where the SSA form doesn't follow them, or panicked with, are taken
+// for handled.
+func (c *checker) checkPropagation(frame *funcFrame) {
+	errName := c.namedError(frame)
+	fn := c.ssaFuncs[frame.node]
+	if errName == nil || fn == nil {
+		return
+	}
+
+	// The calls of the function itself, not of nested literals, by the
+	// position of their opening parenthesis, which SSA calls have
+	calls := make(map[token.Pos]*ast.CallExpr)
+	ast.Inspect(frame.body, func(n ast.Node) (proceed bool) {
+		if call, ok := n.(*ast.CallExpr); ok {
+			calls[call.Lparen] = call
+		}
+		_, isLit := n.(*ast.FuncLit)
+		proceed = !isLit
+		return proceed
+	})
+
+	for _, b := range fn.Blocks {
+		for _, instr := range b.Instrs {
+			call, ok := instr.(*ssa.Call)
+			if !ok || calls[call.Pos()] == nil {
+				continue
+			}
+
+			for _, v := range errorResults(call) {
+				// Errors not stored anywhere are discarded on purpose
+				refs := v.Referrers()
+				if refs == nil || len(*refs) == 0 || reachesReturn(v, make(map[ssa.Value]bool)) {
+					continue
+				}
+
+				expr := calls[call.Pos()]
+				c.report(diagnosticf(RuleUnpropagatedError, expr.Pos(), frame.msgs, MessageUnpropagatedError, frame.name, types.ExprString(expr.Fun), errName.Name),
+					Message{FuncName: frame.name, ReturnName: errName.Name, Type: frame.resultType(errName)})
+			}
+		}
+	}
+}
+
+// errorResults returns the values of the error results of call.
+func errorResults(call *ssa.Call) (values []ssa.Value) {
+	errorType := types.Universe.Lookup("error").Type()
+	if types.Identical(call.Type(), errorType) {
+		values = []ssa.Value{call}
+		return values
+	}
+	if _, isTuple := call.Type().(*types.Tuple); !isTuple || call.Referrers() == nil {
+		return values
+	}
+
+	for _, instr := range *call.Referrers() {
+		if extract, ok := instr.(*ssa.Extract); ok && types.Identical(extract.Type(), errorType) {
+			values = append(values, extract)
+		}
+	}
+	return values
+}
+
+// reachesReturn reports whether v flows into a return, or a panic, or where
+// it can't be followed.
+func reachesReturn(v ssa.Value, seen map[ssa.Value]bool) (reaches bool) {
+	if seen[v] || v.Referrers() == nil {
+		return reaches
+	}
+	seen[v] = true
+
+	for _, instr := range *v.Referrers() {
+		switch instr := instr.(type) {
+		case *ssa.Return, *ssa.Panic, *ssa.Send, *ssa.MapUpdate, *ssa.MakeClosure, *ssa.Go, *ssa.Defer:
+			reaches = true
+		case *ssa.Store:
+			reaches = instr.Val == v && storedReachesReturn(instr.Addr, seen)
+		case *ssa.Call:
+			// Wrapping calls return a new error, and append a slice
+			// which may be joined
+			if builtin, ok := instr.Call.Value.(*ssa.Builtin); ok && builtin.Name() == "append" {
+				reaches = reachesReturn(instr, seen)
+				break
+			}
+			for _, result := range errorResults(instr) {
+				reaches = reaches || reachesReturn(result, seen)
+			}
+		case *ssa.BinOp, *ssa.DebugRef:
+		case ssa.Value:
+			reaches = reachesReturn(instr, seen)
+		}
+		if reaches {
+			return reaches
+		}
+	}
+	return reaches
+}
+
+// storedReachesReturn reports whether a value stored at addr may flow into a
+// return: when addr is within a variable of the function, such as the array
+// holding variadic arguments, through the uses of that variable, and
+// otherwise always, as the value escapes.
+func storedReachesReturn(addr ssa.Value, seen map[ssa.Value]bool) (reaches bool) {
+	base := addr
+	for {
+		switch a := base.(type) {
+		case *ssa.IndexAddr:
+			base = a.X
+			continue
+		case *ssa.FieldAddr:
+			base = a.X
+			continue
+		}
+		break
+	}
+
+	alloc, ok := base.(*ssa.Alloc)
+	if !ok {
+		reaches = true
+		return reaches
+	}
+	reaches = reachesReturn(alloc, seen)
+	return reaches
+}
@@ -0,0 +1,73 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/types"
+	"strings"
+)
+
+// checkReorderedResults reports the return statements returning named
+// results in the place of others, as return b, a does for results (a, b int).
+// Results of different types can't be swapped without a conversion, or an
+// interface taking either on purpose, so only those of identical types are
+// reported, once for each statement.
+func (c *checker) checkReorderedResults(frame *funcFrame) {
+	var results []*ast.Ident
+	var resultTypes []ast.Expr
+	for _, p := range frame.typ.Results.List {
+		if len(p.Names) == 0 {
+			return
+		}
+		for _, n := range p.Names {
+			results = append(results, n)
+			resultTypes = append(resultTypes, p.Type)
+		}
+	}
+
+	index := make(map[string]int, len(results))
+	for i, n := range results {
+		if n.Name != "_" {
+			index[n.Name] = i
+		}
+	}
+
+	for _, returnStmt := range frame.returns {
+		// Returns of a single call spread its results
+		if len(returnStmt.Results) != len(results) {
+			continue
+		}
+
+		var returned, declared []string
+		for i, result := range returnStmt.Results {
+			ident, ok := ast.Unparen(result).(*ast.Ident)
+			if !ok {
+				continue
+			}
+			j, named := index[ident.Name]
+			if !named || j == i || !c.lookup.assignedIn([]*ast.Ident{ident}, results[j]) || !c.identicalTypes(resultTypes[i], resultTypes[j]) {
+				continue
+			}
+			returned = append(returned, ident.Name)
+			declared = append(declared, results[i].Name)
+		}
+		if len(returned) == 0 {
+			continue
+		}
+
+		d := diagnosticf(RuleReorderedResults, returnStmt.Pos(), frame.msgs, MessageReorderedResults, frame.name, strings.Join(returned, ", "), strings.Join(declared, ", "))
+		d.End = returnStmt.End()
+		c.report(d, Message{FuncName: frame.name, ReturnName: returned[0], Type: frame.resultType(results[index[returned[0]]])})
+	}
+}
+
+// identicalTypes reports whether the type expressions x and y denote
+// identical types. Without type information it compares them as written.
+func (c *checker) identicalTypes(x ast.Expr, y ast.Expr) (identical bool) {
+	tx, ty := c.lookup.info.TypeOf(x), c.lookup.info.TypeOf(y)
+	if tx != nil && ty != nil {
+		identical = types.Identical(tx, ty)
+		return identical
+	}
+	identical = types.ExprString(x) == types.ExprString(y)
+	return identical
+}
@@ -0,0 +1,104 @@
+package analyzer
+
+import (
+	"go/token"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// Rule IDs identify each kind of finding. They are set as the Category of
+// every diagnostic so downstream tooling can filter and route by rule, and
+// they never change once released.
+const (
+	RuleUnnamedResult     = "NR001"
+	RuleUnderscoreResult  = "NR002"
+	RuleUnusedInReturn    = "NR003"
+	RuleShadowedResult    = "NR004"
+	RuleInvalidDirective  = "NR005"
+	RuleErrorConvention   = "NR006"
+	RuleDeferErrorHandler = "NR007"
+	RuleUnusedNames       = "NR008"
+	RuleInconsistentName  = "NR009"
+	RuleBoolName          = "NR010"
+	RuleInterfaceName     = "NR011"
+	RuleDocResult         = "NR012"
+	RuleDroppedError      = "NR013"
+	RuleUnpropagatedError = "NR014"
+	RuleUnrecoveredPanic  = "NR015"
+	RuleReorderedResults  = "NR016"
+	RulePreferBareReturn  = "NR017"
+	RuleShadowedReturn    = "NR018"
+	RuleIteratorName      = "NR019"
+)
+
+// Severities a rule can be reported with.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// Rule describes one kind of finding reported by the analyzer.
+type Rule struct {
+	ID       string // stable identifier, e.g. "NR001"
+	Name     string // short human readable slug, e.g. "unnamed-result"
+	Doc      string // one line description
+	Severity string // default severity of findings, one of the Severity constants
+}
+
+var rules = []Rule{
+	{ID: RuleUnnamedResult, Name: "unnamed-result", Doc: "function results must be named", Severity: SeverityError},
+	{ID: RuleUnderscoreResult, Name: "underscore-result", Doc: "function results must not be named _", Severity: SeverityError},
+	{ID: RuleUnusedInReturn, Name: "unused-in-return", Doc: "return statements must return the named result variables", Severity: SeverityWarning},
+	{ID: RuleShadowedResult, Name: "shadowed-result", Doc: "named result variables must not be shadowed", Severity: SeverityError},
+	{ID: RuleInvalidDirective, Name: "invalid-directive", Doc: "namedreturns directives must be well formed, attached and give a reason", Severity: SeverityError},
+	{ID: RuleErrorConvention, Name: "error-convention", Doc: "error results must come last and be named err, when the error-convention setting is on", Severity: SeverityWarning},
+	{ID: RuleDeferErrorHandler, Name: "defer-error-handler", Doc: "exported functions deferring cleanup must handle the named error in a deferred function, when the defer-error-handler setting is on", Severity: SeverityWarning},
+	{ID: RuleUnusedNames, Name: "unused-names", Doc: "named results must be used by the body or a bare return, when the report-unused-names setting is on", Severity: SeverityWarning},
+	{ID: RuleInconsistentName, Name: "inconsistent-name", Doc: "results of a type must be named as they usually are in the package, when the report-inconsistent-names setting is on", Severity: SeverityInfo},
+	{ID: RuleBoolName, Name: "bool-name", Doc: "boolean results must be named from the bool-names list, when the bool-convention setting is on", Severity: SeverityWarning},
+	{ID: RuleInterfaceName, Name: "interface-name", Doc: "methods implementing an interface must name their results as the interface does, when the interface-names setting is on", Severity: SeverityWarning},
+	{ID: RuleDocResult, Name: "doc-result", Doc: "doc comments of exported functions must mention their named results, or the errors returned, when the doc-results setting is on", Severity: SeverityInfo},
+	{ID: RuleDroppedError, Name: "dropped-error", Doc: "bare returns must not leave the named error unassigned after dropping the error of a call, when the must-assign-error setting is on", Severity: SeverityWarning},
+	{ID: RuleUnpropagatedError, Name: "unpropagated-error", Doc: "errors of calls stored in variables must reach a return, when the error-propagation setting is on, as reported by the namedreturns_propagation analyzer", Severity: SeverityWarning},
+	{ID: RuleUnrecoveredPanic, Name: "unrecovered-panic", Doc: "functions with a named error that panic must defer a recover converting the panic into the error, when the panic-recover setting is on", Severity: SeverityWarning},
+	{ID: RuleReorderedResults, Name: "reordered-results", Doc: "return statements must not return named results of identical types in each other's places, when the report-reordered-results setting is on", Severity: SeverityError},
+	{ID: RulePreferBareReturn, Name: "prefer-bare-return", Doc: "functions must end with a bare return rather than restating the named results with nil for an untouched named error, when the prefer-bare-return setting is on", Severity: SeverityInfo},
+	{ID: RuleShadowedReturn, Name: "shadowed-return", Doc: "return statements must not return a local variable shadowing the named result of the same name, when the report-shadowed-returns setting is on", Severity: SeverityError},
+	{ID: RuleIteratorName, Name: "iterator-name", Doc: "iterator results must be named from the iterator-names list, when the iterator-convention setting is on", Severity: SeverityWarning},
+}
+
+// Rules returns the metadata of every rule the analyzer can report, ordered by ID.
+func Rules() (all []Rule) {
+	all = append([]Rule(nil), rules...)
+	return all
+}
+
+// LookupRule finds a rule by its ID or its name.
+func LookupRule(idOrName string) (rule Rule, ok bool) {
+	for _, r := range rules {
+		if r.ID == idOrName || r.Name == idOrName {
+			rule = r
+			ok = true
+			return rule, ok
+		}
+	}
+	return rule, ok
+}
+
+// diagnosticf builds a diagnostic at pos categorized under the given rule ID,
+// with the message of msgs with the given ID.
+func diagnosticf(rule string, pos token.Pos, msgs catalog, id string, args ...interface{}) (d analysis.Diagnostic) {
+	d = analysis.Diagnostic{
+		Pos:      pos,
+		Category: rule,
+		Message:  msgs.sprintf(id, args...),
+	}
+	return d
+}
+
+// reportf reports a diagnostic at pos categorized under the given rule ID,
+// with the message of msgs with the given ID.
+func reportf(pass *analysis.Pass, rule string, pos token.Pos, msgs catalog, id string, args ...interface{}) {
+	pass.Report(diagnosticf(rule, pos, msgs, id, args...))
+}
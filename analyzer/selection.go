@@ -0,0 +1,167 @@
+package analyzer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"slices"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// selection holds the ranges of the functions selected by the func setting,
+// doc comments included. A nil selection selects every function.
+type selection [][2]token.Pos
+
+// selectFuncs returns the selection of the func settings in effect in the
+// files of pass, cfg in most of them and testCfg in test files.
+func selectFuncs(pass *analysis.Pass, cfg Config, testCfg Config) (sel selection) {
+	if len(cfg.Funcs) == 0 && len(testCfg.Funcs) == 0 {
+		return sel
+	}
+
+	sel = selection{}
+	for _, file := range pass.Files {
+		funcs := cfg.Funcs
+		if isTestFile(pass.Fset, file.Pos()) {
+			funcs = testCfg.Funcs
+		}
+		if len(funcs) == 0 {
+			sel = append(sel, [2]token.Pos{file.FileStart, file.FileEnd})
+			continue
+		}
+
+		for _, d := range file.Decls {
+			decl, ok := d.(*ast.FuncDecl)
+			if !ok || !selects(funcs, pass, decl) {
+				continue
+			}
+
+			start := decl.Pos()
+			if decl.Doc != nil {
+				start = decl.Doc.Pos()
+			}
+			sel = append(sel, [2]token.Pos{start, decl.End()})
+		}
+	}
+	return sel
+}
+
+// contains reports whether pos lies within a selected function.
+func (sel selection) contains(pos token.Pos) (contained bool) {
+	if sel == nil {
+		contained = true
+		return contained
+	}
+
+	for _, r := range sel {
+		if r[0] <= pos && pos < r[1] {
+			contained = true
+			break
+		}
+	}
+	return contained
+}
+
+// selects reports whether one of the selectors names decl, qualified by the
+// import path or the name of its package, or not at all, e.g.
+// "example.com/server.(*Server).Start", "server.(*Server).Start" or
+// "(*Server).Start". Type parameters of receivers are left out.
+func selects(selectors []string, pass *analysis.Pass, decl *ast.FuncDecl) (selected bool) {
+	name := decl.Name.Name
+	if decl.Recv != nil && len(decl.Recv.List) > 0 {
+		name = receiverName(decl.Recv.List[0].Type) + "." + name
+	}
+
+	for _, qualified := range []string{name, pass.Pkg.Name() + "." + name, pass.Pkg.Path() + "." + name} {
+		if slices.Contains(selectors, qualified) {
+			selected = true
+			break
+		}
+	}
+	return selected
+}
+
+// receiverName returns the name of the receiver type expr, as it qualifies
+// the names of methods: "T" or "(*T)".
+func receiverName(expr ast.Expr) (name string) {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		name = "(*" + receiverName(e.X) + ")"
+	case *ast.ParenExpr:
+		name = receiverName(e.X)
+	case *ast.IndexExpr:
+		name = receiverName(e.X)
+	case *ast.IndexListExpr:
+		name = receiverName(e.X)
+	case *ast.Ident:
+		name = e.Name
+	}
+	return name
+}
+
+// Kinds of functions the targets setting selects.
+const (
+	// TargetFuncs is functions declared without a receiver.
+	TargetFuncs = "funcs"
+
+	// TargetMethods is methods.
+	TargetMethods = "methods"
+
+	// TargetClosures is function literals.
+	TargetClosures = "closures"
+)
+
+// targets are the valid values of the targets setting.
+var targets = []string{TargetFuncs, TargetMethods, TargetClosures}
+
+// targeted reports whether the targets setting selects node, a *ast.FuncDecl
+// or *ast.FuncLit.
+func (cfg Config) targeted(node ast.Node) (targeted bool) {
+	if len(cfg.Targets) == 0 {
+		targeted = true
+		return targeted
+	}
+
+	target := TargetClosures
+	if decl, ok := node.(*ast.FuncDecl); ok {
+		target = TargetFuncs
+		if decl.Recv != nil && len(decl.Recv.List) > 0 {
+			target = TargetMethods
+		}
+	}
+	targeted = slices.Contains(cfg.Targets, target)
+	return targeted
+}
+
+// targetListValue is the flag value of a list of kinds of functions, given
+// as comma separated names.
+type targetListValue struct {
+	list *[]string
+}
+
+func (v targetListValue) String() (s string) {
+	if v.list != nil {
+		s = strings.Join(*v.list, ",")
+	}
+	return s
+}
+
+func (v targetListValue) Set(value string) (err error) {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		if !slices.Contains(targets, item) {
+			err = fmt.Errorf("unknown target %q, expected one of: %s", item, strings.Join(targets, ", "))
+			return err
+		}
+		items = append(items, item)
+	}
+	*v.list = items
+	return err
+}
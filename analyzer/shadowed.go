@@ -0,0 +1,55 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// checkShadowedReturns reports the results of return statements named like
+// a named result but resolving to another variable, a local shadowing it, as
+// err does in return err within if err := f(); err != nil. Unlike the
+// declarations reported as shadowing, it looks at what the returns actually
+// return, which takes type information: without it nothing is reported.
+func (c *checker) checkShadowedReturns(frame *funcFrame) {
+	named := make(map[string]*ast.Ident)
+	for _, p := range frame.typ.Results.List {
+		for _, n := range p.Names {
+			if n.Name != "_" {
+				named[n.Name] = n
+			}
+		}
+	}
+	if len(named) == 0 {
+		return
+	}
+
+	for _, returnStmt := range frame.returns {
+		for _, result := range returnStmt.Results {
+			ident, ok := ast.Unparen(result).(*ast.Ident)
+			if !ok {
+				continue
+			}
+			namedReturn, isNamed := named[ident.Name]
+			if !isNamed {
+				continue
+			}
+
+			// Unresolved names, without type information or in code with
+			// type errors, prove nothing
+			obj, resultObj := c.pass.TypesInfo.Uses[ident], c.lookup.objectOf(namedReturn)
+			if obj == nil || resultObj == nil || obj == resultObj {
+				continue
+			}
+
+			d := diagnosticf(RuleShadowedReturn, ident.Pos(), frame.msgs, MessageShadowedReturn, frame.name, ident.Name)
+			d.End = ident.End()
+			d.Related = []analysis.RelatedInformation{
+				{Pos: obj.Pos(), End: obj.Pos() + token.Pos(len(ident.Name)), Message: frame.msgs.sprintf(MessageShadowedReturnLocal, ident.Name)},
+				{Pos: namedReturn.Pos(), End: namedReturn.End(), Message: frame.msgs.sprintf(MessageShadowedResultRelated, namedReturn.Name)},
+			}
+			c.report(d, Message{FuncName: frame.name, ReturnName: namedReturn.Name, Type: frame.resultType(namedReturn)})
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// Kinds of suppressions.
+const (
+	SuppressionNolint      = "nolint"
+	SuppressionIgnore      = "ignore"
+	SuppressionDisableFile = "disable-file"
+)
+
+// Suppression is a directive in a source file silencing findings.
+type Suppression struct {
+	Kind    string         // one of the Suppression* kinds
+	Pos     token.Position // of the directive
+	Rules   []string       // IDs of the rules suppressed, none for all
+	Reason  string         // empty when none is given
+	Expires string         // date the directive lapses after, if any
+}
+
+// expiresPattern matches the expires attribute of a directive.
+var expiresPattern = regexp.MustCompile(`(?:^|\s)expires=(\S+)`)
+
+// FileSuppressions returns the directives of file in effect, in order:
+// the nolint directives naming namedreturns or all linters, and the
+// well formed ignore and disable-file directives that haven't expired,
+// attached where they apply. Whether nolint directives are honored depends
+// on the ignore-nolint setting.
+func FileSuppressions(fset *token.FileSet, file *ast.File) (found []Suppression) {
+	// The directives are checked as the analyzer checks them, without
+	// reporting the invalid ones
+	quiet := &analysis.Pass{Fset: fset, Files: []*ast.File{file}, Report: func(analysis.Diagnostic) {}}
+
+	for _, group := range file.Comments {
+		for _, c := range group.List {
+			if nolintApplies(c.Text, []string{"namedreturns"}) {
+				_, reason, _ := strings.Cut(strings.TrimPrefix(c.Text, "//"), "//")
+				found = append(found, Suppression{
+					Kind:   SuppressionNolint,
+					Pos:    fset.Position(c.Slash),
+					Reason: strings.TrimSpace(reason),
+				})
+				continue
+			}
+
+			match := disableFilePattern.FindStringSubmatch(c.Text)
+			if match == nil || c.Pos() > file.Package {
+				continue
+			}
+			names, reason, _ := strings.Cut(stripTrailingComment(match[1]), "--")
+			rules, valid := parseRules(quiet, nil, c, "disable-file", names)
+			if valid {
+				found = append(found, directiveSuppression(fset, SuppressionDisableFile, c, rules, names, reason))
+			}
+		}
+	}
+
+	forAttached(fset, file, ignorePattern, func(_ ast.Node, directives []*ast.Comment) {
+		for _, c := range directives {
+			rules, valid := parseIgnore(quiet, nil, c)
+			if valid {
+				names, reason, _ := strings.Cut(stripTrailingComment(ignorePattern.FindStringSubmatch(c.Text)[1]), "--")
+				found = append(found, directiveSuppression(fset, SuppressionIgnore, c, rules, names, reason))
+			}
+		}
+	})
+
+	sort.SliceStable(found, func(i int, j int) (less bool) {
+		less = found[i].Pos.Offset < found[j].Pos.Offset
+		return less
+	})
+	return found
+}
+
+// directiveSuppression describes a valid namedreturns directive suppressing
+// rules, given its arguments before and after the --.
+func directiveSuppression(fset *token.FileSet, kind string, c *ast.Comment, rules map[string]bool, names string, reason string) (s Suppression) {
+	s = Suppression{
+		Kind:   kind,
+		Pos:    fset.Position(c.Slash),
+		Reason: strings.TrimSpace(reason),
+	}
+	for id := range rules {
+		s.Rules = append(s.Rules, id)
+	}
+	sort.Strings(s.Rules)
+	if match := expiresPattern.FindStringSubmatch(names); match != nil {
+		s.Expires = match[1]
+	}
+	return s
+}
@@ -0,0 +1,53 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+// RunSyntax runs a, one of the analyzers of this package, on the parsed files
+// of a single package without loading or type checking anything. Checks that
+// rely on type information fall back to the syntax, so this is a fast
+// approximation of a full run.
+func RunSyntax(a *analysis.Analyzer, fset *token.FileSet, files []*ast.File) (diagnostics []analysis.Diagnostic, result *Result, err error) {
+	var name string
+	if len(files) > 0 {
+		name = files[0].Name.Name
+	}
+
+	pass := &analysis.Pass{
+		Analyzer:  a,
+		Fset:      fset,
+		Files:     files,
+		Pkg:       types.NewPackage(name, name),
+		TypesInfo: &types.Info{},
+		ResultOf:  map[*analysis.Analyzer]interface{}{inspect.Analyzer: inspector.New(files)},
+		Report: func(d analysis.Diagnostic) {
+			diagnostics = append(diagnostics, d)
+		},
+	}
+
+	// The analyzer reports its own of the findings of every rule, found
+	// here without the facts of other packages
+	findingsPass := *pass
+	findingsPass.Analyzer = findingsAnalyzer
+	var r interface{}
+	r, err = findingsAnalyzer.Run(&findingsPass)
+	if err != nil {
+		return diagnostics, result, err
+	}
+	pass.ResultOf[findingsAnalyzer] = r
+
+	r, err = a.Run(pass)
+	if err != nil {
+		return diagnostics, result, err
+	}
+
+	result, _ = r.(*Result)
+	return diagnostics, result, err
+}
@@ -0,0 +1,155 @@
+package analyzer
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ruleSettings are the settings enabling each rule that is off by default.
+var ruleSettings = map[string]string{
+	FlagErrorConvention:    RuleErrorConvention,
+	FlagDeferErrorHandler:  RuleDeferErrorHandler,
+	FlagReportUnusedNames:  RuleUnusedNames,
+	FlagReportInconsistent: RuleInconsistentName,
+	FlagBoolConvention:     RuleBoolName,
+	FlagInterfaceNames:     RuleInterfaceName,
+	FlagDocResults:         RuleDocResult,
+	FlagMustAssignError:    RuleDroppedError,
+	FlagErrorPropagation:   RuleUnpropagatedError,
+	FlagPanicRecover:       RuleUnrecoveredPanic,
+	FlagReportReordered:    RuleReorderedResults,
+	FlagPreferBareReturn:   RulePreferBareReturn,
+	FlagShadowedReturns:    RuleShadowedReturn,
+	FlagIteratorConvention: RuleIteratorName,
+}
+
+// testsIgnoredSettings are the settings applying to whole packages, which
+// the tests settings can't change.
+var testsIgnoredSettings = []string{FlagReportInconsistent, FlagIgnoreNolint, FlagGroupByFunction, FlagOncePerFunction, FlagInternalPreset, FlagPublicPreset, FlagInternalPackages}
+
+// ValidateConfigFile checks every setting of the YAML configuration file at
+// path, returning the problems found with each, such as unknown settings or
+// invalid values, where LoadConfigFile stops at the first.
+func ValidateConfigFile(path string) (problems []error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		problems = append(problems, err)
+		return problems
+	}
+
+	var values map[string]interface{}
+	err = yaml.Unmarshal(data, &values)
+	if err != nil {
+		problems = append(problems, fmt.Errorf("parsing %s: %w", path, err))
+		return problems
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Each setting is checked on its own, so one problem doesn't hide
+	// the others
+	for _, name := range names {
+		var probe Config
+		err = probe.applySetting(name, values[name])
+		if err != nil {
+			problems = append(problems, fmt.Errorf("%s: %w", path, err))
+		}
+	}
+	return problems
+}
+
+// Conflicts returns the settings of cfg that contradict each other or have
+// no effect, such as a rule enabled by one setting and disabled by another.
+func (cfg Config) Conflicts() (conflicts []string) {
+	conflicts = cfg.conflicts("")
+
+	tests, err := cfg.forTests()
+	if err != nil {
+		conflicts = append(conflicts, err.Error())
+		return conflicts
+	}
+	for _, name := range testsIgnoredSettings {
+		if _, set := cfg.Tests[name]; set {
+			conflicts = append(conflicts, fmt.Sprintf("%s: %s applies to whole packages and can't be changed for test files", settingTests, name))
+		}
+	}
+	for _, conflict := range tests.conflicts(settingTests + ": ") {
+		if !slices.Contains(conflicts, conflict) && !slices.Contains(conflicts, conflict[len(settingTests)+2:]) {
+			conflicts = append(conflicts, conflict)
+		}
+	}
+	return conflicts
+}
+
+// conflicts returns the conflicts of cfg, ignoring its tests settings, each
+// starting with prefix.
+func (cfg Config) conflicts(prefix string) (conflicts []string) {
+	settings := make([]string, 0, len(ruleSettings))
+	for setting := range ruleSettings {
+		settings = append(settings, setting)
+	}
+	sort.Strings(settings)
+
+	values := cfg.values()
+	for _, setting := range settings {
+		if rule := ruleSettings[setting]; values[setting] == "true" && cfg.disabled(rule) {
+			conflicts = append(conflicts, fmt.Sprintf("%s%s is set, but its rule %s is disabled", prefix, setting, rule))
+		}
+	}
+
+	if cfg.ErrorName != "" && cfg.ErrorName != DefaultErrorName && !cfg.ErrorConvention {
+		conflicts = append(conflicts, fmt.Sprintf("%s%s has no effect without %s", prefix, FlagErrorName, FlagErrorConvention))
+	}
+	if len(cfg.BoolNames) > 0 && !cfg.BoolConvention {
+		conflicts = append(conflicts, fmt.Sprintf("%s%s has no effect without %s", prefix, FlagBoolNames, FlagBoolConvention))
+	}
+	if len(cfg.IteratorNames) > 0 && !cfg.IteratorConvention {
+		conflicts = append(conflicts, fmt.Sprintf("%s%s has no effect without %s", prefix, FlagIteratorNames, FlagIteratorConvention))
+	}
+	if len(cfg.InternalPackages) > 0 && cfg.InternalPreset == "" && cfg.PublicPreset == "" {
+		conflicts = append(conflicts, fmt.Sprintf("%s%s has no effect without %s or %s", prefix, FlagInternalPackages, FlagInternalPreset, FlagPublicPreset))
+	}
+	if cfg.MinReturns < 0 {
+		conflicts = append(conflicts, fmt.Sprintf("%s%s is negative", prefix, FlagMinReturns))
+	}
+	return conflicts
+}
+
+// values returns the settings of cfg, by name, rendered as their flags
+// render them.
+func (cfg Config) values() (values map[string]string) {
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	cfg.bind(fs)
+
+	values = make(map[string]string)
+	fs.VisitAll(func(f *flag.Flag) {
+		values[f.Name] = f.Value.String()
+	})
+	return values
+}
+
+// WithDefaults returns cfg with the settings left empty to mean a default
+// set to that default, as they take effect.
+func (cfg Config) WithDefaults() (resolved Config) {
+	resolved = cfg
+	resolved.ErrorName = cfg.errorName()
+	resolved.BoolNames = cfg.boolNames()
+	resolved.IteratorNames = cfg.iteratorNames()
+	resolved.InternalPackages = cfg.internalPackages()
+	if resolved.Mode == "" {
+		resolved.Mode = ModeAll
+	}
+	if resolved.Locale == "" {
+		resolved.Locale = DefaultLocale
+	}
+	return resolved
+}
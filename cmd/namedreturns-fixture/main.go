@@ -0,0 +1,53 @@
+// Command namedreturns-fixture turns a plain Go file into an analysistest
+// fixture: it analyzes the file and prints it with a // want comment on each
+// line the analyzer reports on.
+//
+//	namedreturns-fixture -report-error-in-defer plain.go > testdata/src/p/p.go
+//
+// It accepts the analyzer's flags, so fixtures can be generated for any
+// configuration. Review the output before committing it: it records what the
+// analyzer does, not what it should do.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/nikogura/namedreturns/analyzer"
+	"github.com/nikogura/namedreturns/namedreturnstest"
+)
+
+func main() {
+	a := analyzer.NewAnalyzer(analyzer.Config{})
+	output := flag.String("o", "", "write the fixture to this file instead of standard output")
+	a.Flags.VisitAll(func(f *flag.Flag) {
+		flag.Var(f.Value, f.Name, f.Usage)
+	})
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: namedreturns-fixture [flags] file.go\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	annotated, err := namedreturnstest.Annotate(a, flag.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "namedreturns-fixture: %s\n", err)
+		os.Exit(1)
+	}
+
+	if *output == "" {
+		_, err = os.Stdout.Write(annotated)
+	} else {
+		err = os.WriteFile(*output, annotated, 0o600)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "namedreturns-fixture: %s\n", err)
+		os.Exit(1)
+	}
+}
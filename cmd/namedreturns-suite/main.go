@@ -0,0 +1,27 @@
+// Command namedreturns-suite runs the sub-checks of namedreturns as separate
+// analyzers, so each can be selected and configured on its own:
+//
+//	namedreturns-suite -namedreturns_shadowing ./...
+//	namedreturns-suite -namedreturns_usage.report-error-in-defer ./...
+//
+// Without selection flags all of them run, which reports the same findings as
+// the namedreturns command.
+package main
+
+import (
+	"github.com/nikogura/namedreturns/analyzer"
+	"golang.org/x/tools/go/analysis/multichecker"
+)
+
+func main() {
+	multichecker.Main(
+		analyzer.Unnamed,
+		analyzer.Underscore,
+		analyzer.Naming,
+		analyzer.Usage,
+		analyzer.Shadowing,
+		analyzer.DeferErrors,
+		analyzer.Flow,
+		analyzer.Propagation,
+	)
+}
@@ -0,0 +1,16 @@
+// Command namedreturns-vet runs the namedreturns analyzer as a go vet tool:
+//
+//	go vet -vettool=$(which namedreturns-vet) ./...
+//
+// go vet makes passing analyzer flags awkward, so the configuration file is
+// taken from $NAMEDRETURNS_CONFIG or discovered next to each package.
+package main
+
+import (
+	"github.com/nikogura/namedreturns/analyzer"
+	"golang.org/x/tools/go/analysis/unitchecker"
+)
+
+func main() {
+	unitchecker.Main(analyzer.Analyzer)
+}
@@ -4,7 +4,11 @@ go 1.23.0
 
 toolchain go1.24.7
 
-require golang.org/x/tools v0.35.0
+require (
+	github.com/golangci/plugin-module-register v0.1.2
+	golang.org/x/tools v0.35.0
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
 	golang.org/x/mod v0.27.0 // indirect
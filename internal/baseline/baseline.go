@@ -0,0 +1,185 @@
+// Package baseline records the findings present in a code base, so that a
+// later run reports only the findings that are new since.
+package baseline
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/nikogura/namedreturns/report"
+)
+
+const (
+	// DefaultFile is the baseline file used when none is given.
+	DefaultFile = ".namedreturns-baseline.json"
+
+	// version is the format version written to baseline files.
+	version = 1
+)
+
+// Baseline is the set of recorded findings. Files are recorded relative to
+// the directory of the baseline file, so it stays valid wherever the command
+// is run from.
+type Baseline struct {
+	Version int     `json:"version"`
+	Entries []Entry `json:"entries"`
+}
+
+// Entry is a recorded finding. Findings are matched by fingerprint, which
+// ignores their position; Count is the number of identical findings recorded.
+type Entry struct {
+	File        string `json:"file"`
+	Rule        string `json:"rule"`
+	Message     string `json:"message"`
+	Fingerprint string `json:"fingerprint"`
+	Count       int    `json:"count"`
+}
+
+// New records issues in a baseline whose files are relative to root.
+func New(root string, issues []report.Issue) (b Baseline) {
+	b = Baseline{Version: version, Entries: []Entry{}}
+
+	index := make(map[string]int)
+	for _, issue := range issues {
+		fp := report.Fingerprint(root, issue)
+		if i, ok := index[fp]; ok {
+			b.Entries[i].Count++
+			continue
+		}
+
+		index[fp] = len(b.Entries)
+		b.Entries = append(b.Entries, Entry{
+			File:        report.DisplayPath(root, issue.File),
+			Rule:        issue.Rule,
+			Message:     issue.Message,
+			Fingerprint: fp,
+			Count:       1,
+		})
+	}
+
+	// Keep the file stable across runs so it diffs cleanly under version control
+	sort.Stable(byFinding(b.Entries))
+	return b
+}
+
+// byFinding orders entries by file, rule and message.
+type byFinding []Entry
+
+func (e byFinding) Len() (n int) {
+	n = len(e)
+	return n
+}
+
+func (e byFinding) Swap(i int, j int) {
+	e[i], e[j] = e[j], e[i]
+}
+
+func (e byFinding) Less(i int, j int) (less bool) {
+	a, b := e[i], e[j]
+	switch {
+	case a.File != b.File:
+		less = a.File < b.File
+	case a.Rule != b.Rule:
+		less = a.Rule < b.Rule
+	default:
+		less = a.Message < b.Message
+	}
+	return less
+}
+
+// Filter returns the issues not covered by the baseline, and how many were
+// suppressed. Each entry covers at most Count findings, so a function that
+// gains another copy of a recorded finding is still reported.
+func (b Baseline) Filter(root string, issues []report.Issue) (fresh []report.Issue, suppressed int) {
+	remaining := make(map[string]int, len(b.Entries))
+	for _, entry := range b.Entries {
+		remaining[entry.Fingerprint] += entry.Count
+	}
+
+	for _, issue := range issues {
+		fp := report.Fingerprint(root, issue)
+		if remaining[fp] > 0 {
+			remaining[fp]--
+			suppressed++
+			continue
+		}
+		fresh = append(fresh, issue)
+	}
+	return fresh, suppressed
+}
+
+// Findings returns the number of findings recorded.
+func (b Baseline) Findings() (n int) {
+	for _, entry := range b.Entries {
+		n += entry.Count
+	}
+	return n
+}
+
+// Prune returns the baseline without the findings that no longer occur in
+// issues, how many findings it dropped and how many of issues it doesn't
+// cover. The findings it doesn't cover are not added.
+func (b Baseline) Prune(root string, issues []report.Issue) (pruned Baseline, removed int, uncovered int) {
+	occurrences := make(map[string]int)
+	for _, issue := range issues {
+		occurrences[report.Fingerprint(root, issue)]++
+	}
+
+	pruned = Baseline{Version: version, Entries: []Entry{}}
+	for _, entry := range b.Entries {
+		kept := min(entry.Count, occurrences[entry.Fingerprint])
+		occurrences[entry.Fingerprint] -= kept
+		removed += entry.Count - kept
+		if kept > 0 {
+			entry.Count = kept
+			pruned.Entries = append(pruned.Entries, entry)
+		}
+	}
+
+	for _, n := range occurrences {
+		uncovered += n
+	}
+	return pruned, removed, uncovered
+}
+
+// Load reads the baseline file at path.
+func Load(path string) (b Baseline, err error) {
+	var data []byte
+	data, err = os.ReadFile(path)
+	if err != nil {
+		return b, err
+	}
+
+	err = json.Unmarshal(data, &b)
+	if err != nil {
+		err = fmt.Errorf("parsing baseline %s: %w", path, err)
+		return b, err
+	}
+
+	if b.Version != version {
+		err = fmt.Errorf("baseline %s has unsupported version %d, expected %d", path, b.Version, version)
+		return b, err
+	}
+	return b, err
+}
+
+// Save writes the baseline to path.
+func (b Baseline) Save(path string) (err error) {
+	var data []byte
+	data, err = json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(path, append(data, '\n'), 0o644)
+	return err
+}
+
+// Root returns the directory the files of the baseline at path are relative to.
+func Root(path string) (root string, err error) {
+	root, err = filepath.Abs(filepath.Dir(path))
+	return root, err
+}
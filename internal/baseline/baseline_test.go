@@ -0,0 +1,83 @@
+package baseline
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/nikogura/namedreturns/report"
+)
+
+func TestFilter(t *testing.T) {
+	root := "/src"
+	recorded := []report.Issue{
+		{File: "/src/a.go", Line: 3, Column: 1, Rule: "NR001", Message: "func a: unnamed return"},
+		{File: "/src/a.go", Line: 9, Column: 2, Rule: "NR003", Message: "func b: named return variable \"n\" is declared but not used"},
+		{File: "/src/a.go", Line: 12, Column: 2, Rule: "NR003", Message: "func b: named return variable \"n\" is declared but not used"},
+	}
+	b := New(root, recorded)
+
+	if len(b.Entries) != 2 || b.Entries[1].Count != 2 || b.Entries[0].File != "a.go" {
+		t.Fatalf("unexpected entries: %+v", b.Entries)
+	}
+
+	// The same findings after lines were inserted above them, plus new ones
+	current := []report.Issue{
+		{File: "/src/a.go", Line: 5, Column: 1, Rule: "NR001", Message: "func a: unnamed return"},
+		{File: "/src/a.go", Line: 11, Column: 2, Rule: "NR003", Message: "func b: named return variable \"n\" is declared but not used"},
+		{File: "/src/a.go", Line: 14, Column: 2, Rule: "NR003", Message: "func b: named return variable \"n\" is declared but not used"},
+		{File: "/src/a.go", Line: 16, Column: 2, Rule: "NR003", Message: "func b: named return variable \"n\" is declared but not used"},
+		{File: "/src/c.go", Line: 3, Column: 1, Rule: "NR001", Message: "func a: unnamed return"},
+	}
+
+	fresh, suppressed := b.Filter(root, current)
+	if suppressed != 3 {
+		t.Errorf("expected 3 suppressed findings, got %d", suppressed)
+	}
+	if len(fresh) != 2 || fresh[0].Line != 16 || fresh[1].File != "/src/c.go" {
+		t.Errorf("unexpected fresh findings: %+v", fresh)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	root := "/src"
+	b := New(root, []report.Issue{
+		{File: "/src/a.go", Line: 3, Column: 1, Rule: "NR001", Message: "func a: unnamed return"},
+		{File: "/src/a.go", Line: 9, Column: 2, Rule: "NR003", Message: "func b: named return variable \"n\" is declared but not used"},
+		{File: "/src/a.go", Line: 12, Column: 2, Rule: "NR003", Message: "func b: named return variable \"n\" is declared but not used"},
+		{File: "/src/b.go", Line: 3, Column: 1, Rule: "NR001", Message: "func c: unnamed return"},
+	})
+
+	// func a was fixed, one of the findings of func b too, and a new
+	// finding appeared
+	current := []report.Issue{
+		{File: "/src/a.go", Line: 9, Column: 2, Rule: "NR003", Message: "func b: named return variable \"n\" is declared but not used"},
+		{File: "/src/b.go", Line: 5, Column: 1, Rule: "NR001", Message: "func c: unnamed return"},
+		{File: "/src/c.go", Line: 3, Column: 1, Rule: "NR001", Message: "func d: unnamed return"},
+	}
+
+	pruned, removed, uncovered := b.Prune(root, current)
+	if removed != 2 || uncovered != 1 {
+		t.Errorf("expected 2 removed and 1 uncovered findings, got %d and %d", removed, uncovered)
+	}
+	if len(pruned.Entries) != 2 || pruned.Entries[0].Count != 1 || pruned.Entries[1].File != "b.go" {
+		t.Errorf("unexpected entries: %+v", pruned.Entries)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DefaultFile)
+	b := New("/src", []report.Issue{{File: "/src/a.go", Line: 3, Column: 1, Rule: "NR001", Message: "func a: unnamed return"}})
+
+	err := b.Save(path)
+	if err != nil {
+		t.Fatalf("saving baseline: %s", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("loading baseline: %s", err)
+	}
+	if len(loaded.Entries) != 1 || loaded.Entries[0] != b.Entries[0] {
+		t.Errorf("loaded %+v, saved %+v", loaded.Entries, b.Entries)
+	}
+}
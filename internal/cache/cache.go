@@ -0,0 +1,192 @@
+// Package cache stores the results of analyzing packages on disk, keyed by
+// a hash of everything the results depend on, so that a later run can reuse
+// them for packages that didn't change.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// trimInterval is how often unused entries are removed.
+	trimInterval = 24 * time.Hour
+
+	// trimAge is how long an entry is kept without being used.
+	trimAge = 7 * 24 * time.Hour
+
+	// touchInterval bounds how often reading an entry updates its
+	// modification time, which records when it was last used.
+	touchInterval = time.Hour
+
+	// trimFile records when the cache was last trimmed.
+	trimFile = "trim.txt"
+)
+
+// Key identifies an entry. It is the hash of the inputs the entry depends
+// on.
+type Key [sha256.Size]byte
+
+// String returns the key in hexadecimal.
+func (k Key) String() (s string) {
+	s = hex.EncodeToString(k[:])
+	return s
+}
+
+// Hash accumulates the inputs of a key.
+type Hash struct {
+	h hash.Hash
+}
+
+// NewHash creates an empty hash.
+func NewHash() (h *Hash) {
+	h = &Hash{h: sha256.New()}
+	return h
+}
+
+// Add adds the named input. Inputs are delimited, so different sequences of
+// inputs give different keys.
+func (h *Hash) Add(name string, value []byte) {
+	fmt.Fprintf(h.h, "%s %d\n", name, len(value))
+	h.h.Write(value)
+}
+
+// AddString adds the named input.
+func (h *Hash) AddString(name string, value string) {
+	h.Add(name, []byte(value))
+}
+
+// Sum returns the key of the inputs added so far.
+func (h *Hash) Sum() (k Key) {
+	copy(k[:], h.h.Sum(nil))
+	return k
+}
+
+// Cache is a directory of entries.
+type Cache struct {
+	dir string
+}
+
+// DefaultDir returns the directory used when none is given, in the user's
+// cache directory.
+func DefaultDir() (dir string, err error) {
+	dir, err = os.UserCacheDir()
+	if err != nil {
+		return dir, err
+	}
+	dir = filepath.Join(dir, "namedreturns")
+	return dir, err
+}
+
+// Open opens the cache in dir, creating it if needed, and removes the entries
+// not used lately if that wasn't done recently.
+func Open(dir string) (c *Cache, err error) {
+	err = os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return c, err
+	}
+
+	c = &Cache{dir: dir}
+	c.trim(time.Now())
+	return c, err
+}
+
+// path returns the file of the entry for key.
+func (c *Cache) path(key Key) (path string) {
+	name := key.String()
+	path = filepath.Join(c.dir, name[:2], name+".json")
+	return path
+}
+
+// Get decodes the entry for key into v and reports whether it was found.
+// Unreadable entries count as missing.
+func (c *Cache) Get(key Key, v interface{}) (found bool) {
+	path := c.path(key)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return found
+	}
+
+	err = json.Unmarshal(data, v)
+	if err != nil {
+		return found
+	}
+
+	// Record the use, for trimming
+	now := time.Now()
+	if info, statErr := os.Stat(path); statErr == nil && now.Sub(info.ModTime()) > touchInterval {
+		_ = os.Chtimes(path, now, now)
+	}
+
+	found = true
+	return found
+}
+
+// Put stores v as the entry for key. The entry is written to a temporary
+// file first, so concurrent runs never read a partial entry.
+func (c *Cache) Put(key Key, v interface{}) (err error) {
+	var data []byte
+	data, err = json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	path := c.path(key)
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return err
+	}
+
+	var tmp *os.File
+	tmp, err = os.CreateTemp(filepath.Dir(path), "tmp-*")
+	if err != nil {
+		return err
+	}
+
+	_, err = tmp.Write(data)
+	closeErr := tmp.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// trim removes the entries not used for trimAge, at most once per
+// trimInterval. Failing to trim is harmless, so errors are ignored.
+func (c *Cache) trim(now time.Time) {
+	marker := filepath.Join(c.dir, trimFile)
+	if data, err := os.ReadFile(marker); err == nil {
+		last, parseErr := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+		if parseErr == nil && now.Sub(time.Unix(last, 0)) < trimInterval {
+			return
+		}
+	}
+
+	_ = filepath.WalkDir(c.dir, func(path string, d fs.DirEntry, err error) (walkErr error) {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".json") {
+			return walkErr
+		}
+		info, infoErr := d.Info()
+		if infoErr == nil && now.Sub(info.ModTime()) > trimAge {
+			_ = os.Remove(path)
+		}
+		return walkErr
+	})
+
+	_ = os.WriteFile(marker, []byte(strconv.FormatInt(now.Unix(), 10)), 0o644)
+}
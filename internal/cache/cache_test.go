@@ -0,0 +1,86 @@
+package cache
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestPutGet(t *testing.T) {
+	c, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("Open failed: %s", err)
+	}
+
+	h := NewHash()
+	h.AddString("file", "a.go")
+	key := h.Sum()
+
+	var got []string
+	if c.Get(key, &got) {
+		t.Fatalf("expected no entry in an empty cache")
+	}
+
+	err = c.Put(key, []string{"x", "y"})
+	if err != nil {
+		t.Fatalf("Put failed: %s", err)
+	}
+	if !c.Get(key, &got) || len(got) != 2 || got[1] != "y" {
+		t.Errorf("expected the stored entry, got %v", got)
+	}
+}
+
+func TestHashDelimitsInputs(t *testing.T) {
+	a := NewHash()
+	a.AddString("x", "ab")
+	a.AddString("y", "c")
+
+	b := NewHash()
+	b.AddString("x", "a")
+	b.AddString("y", "bc")
+
+	if a.Sum() == b.Sum() {
+		t.Errorf("expected different inputs to give different keys")
+	}
+}
+
+func TestTrim(t *testing.T) {
+	dir := t.TempDir()
+	c, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open failed: %s", err)
+	}
+
+	var stale, fresh Key
+	fresh[0] = 1
+	for _, key := range []Key{stale, fresh} {
+		err = c.Put(key, true)
+		if err != nil {
+			t.Fatalf("Put failed: %s", err)
+		}
+	}
+	old := time.Now().Add(-2 * trimAge)
+	err = os.Chtimes(c.path(stale), old, old)
+	if err != nil {
+		t.Fatalf("Chtimes failed: %s", err)
+	}
+
+	// Trimmed once a day at most
+	c.trim(time.Now())
+	var found bool
+	if !c.Get(stale, &found) {
+		t.Fatalf("expected no trimming right after opening")
+	}
+
+	err = os.Chtimes(c.path(stale), old, old)
+	if err != nil {
+		t.Fatalf("Chtimes failed: %s", err)
+	}
+	c.trim(time.Now().Add(2 * trimInterval))
+	if c.Get(stale, &found) {
+		t.Errorf("expected the stale entry to be removed")
+	}
+	if !c.Get(fresh, &found) {
+		t.Errorf("expected the fresh entry to be kept")
+	}
+}
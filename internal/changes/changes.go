@@ -0,0 +1,289 @@
+// Package changes reads unified diffs to find the lines a change added or
+// modified, and lists the files a change touched, so findings can be
+// restricted to new code.
+package changes
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nikogura/namedreturns/report"
+)
+
+// Lines holds, per file, the line numbers added or modified by a diff. Files
+// are given relative to the root of the diff, using forward slashes.
+type Lines map[string]map[int]bool
+
+// hunkHeader matches the header of a hunk, capturing the start and length of
+// both sides, e.g. "@@ -10,3 +12,4 @@".
+var hunkHeader = regexp.MustCompile(`^@@ -\d+(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// Parse reads a unified diff, as produced by git diff or diff -u.
+func Parse(r io.Reader) (lines Lines, err error) {
+	lines = make(Lines)
+
+	var file string
+	var line, oldLeft, newLeft int // position in the current hunk
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		text := scanner.Text()
+
+		// Headers only appear between hunks, so an added line starting
+		// with "++" is not mistaken for one
+		if oldLeft == 0 && newLeft == 0 {
+			switch {
+			case strings.HasPrefix(text, "+++ "):
+				file = newFileName(strings.TrimPrefix(text, "+++ "))
+			case strings.HasPrefix(text, "@@ "):
+				m := hunkHeader.FindStringSubmatch(text)
+				if m == nil {
+					err = fmt.Errorf("malformed hunk header %q", text)
+					return lines, err
+				}
+				oldLeft = hunkLength(m[1])
+				line, _ = strconv.Atoi(m[2])
+				newLeft = hunkLength(m[3])
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(text, "+"):
+			if file != "" {
+				if lines[file] == nil {
+					lines[file] = make(map[int]bool)
+				}
+				lines[file][line] = true
+			}
+			line++
+			newLeft--
+		case strings.HasPrefix(text, "-"):
+			oldLeft--
+		case strings.HasPrefix(text, "\\"):
+			// "\ No newline at end of file"
+		default:
+			line++
+			oldLeft--
+			newLeft--
+		}
+	}
+
+	err = scanner.Err()
+	return lines, err
+}
+
+// hunkLength parses the optional length of one side of a hunk, which
+// defaults to 1.
+func hunkLength(s string) (n int) {
+	n = 1
+	if s != "" {
+		n, _ = strconv.Atoi(s)
+	}
+	return n
+}
+
+// newFileName extracts the path from the "+++" line of a diff, dropping the
+// timestamp diff -u appends and the "b/" prefix git adds.
+func newFileName(header string) (name string) {
+	if i := strings.IndexByte(header, '\t'); i >= 0 {
+		header = header[:i]
+	}
+	if header == "/dev/null" {
+		return name
+	}
+
+	name = strings.TrimPrefix(header, "b/")
+	name = filepath.ToSlash(filepath.Clean(name))
+	return name
+}
+
+// Read parses the diff in the file at path, or on stdin when path is "-".
+func Read(path string, stdin io.Reader) (lines Lines, err error) {
+	r := stdin
+	if path != "-" {
+		var f *os.File
+		f, err = os.Open(path)
+		if err != nil {
+			return lines, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	lines, err = Parse(r)
+	if err != nil {
+		err = fmt.Errorf("parsing diff: %w", err)
+		return lines, err
+	}
+	return lines, err
+}
+
+// GitDiff returns the lines changed between ref and the working tree.
+func GitDiff(ref string) (lines Lines, err error) {
+	var rev string
+	rev, err = resolveRef(ref)
+	if err != nil {
+		return lines, err
+	}
+
+	var out []byte
+	out, err = exec.Command("git", "diff", "--no-color", "--no-ext-diff", "-U0", rev, "--").Output()
+	if err != nil {
+		err = fmt.Errorf("running git diff %s: %w", ref, gitError(err))
+		return lines, err
+	}
+
+	lines, err = Parse(bytes.NewReader(out))
+	return lines, err
+}
+
+// GitFiles returns the files changed between ref and the working tree, along
+// with the untracked files that aren't ignored, relative to the working
+// directory and limited to those below it.
+func GitFiles(ref string) (files []string, err error) {
+	var rev string
+	rev, err = resolveRef(ref)
+	if err != nil {
+		return files, err
+	}
+
+	var out []byte
+	out, err = exec.Command("git", "diff", "--no-color", "--no-ext-diff", "--name-only", "--relative", rev, "--").Output()
+	if err != nil {
+		err = fmt.Errorf("running git diff %s: %w", ref, gitError(err))
+		return files, err
+	}
+	files = nonEmptyLines(out)
+
+	out, err = exec.Command("git", "ls-files", "--others", "--exclude-standard").Output()
+	if err != nil {
+		err = fmt.Errorf("running git ls-files: %w", gitError(err))
+		return files, err
+	}
+	files = append(files, nonEmptyLines(out)...)
+	return files, err
+}
+
+// resolveRef returns the object name of the git revision ref, which, unlike
+// ref, git can't take for an option, such as --output=file, when given it.
+func resolveRef(ref string) (rev string, err error) {
+	if strings.HasPrefix(ref, "-") {
+		err = fmt.Errorf("invalid git revision %q", ref)
+		return rev, err
+	}
+
+	var out []byte
+	out, err = exec.Command("git", "rev-parse", "--verify", "--end-of-options", ref).Output()
+	if err != nil {
+		err = fmt.Errorf("resolving git revision %s: %w", ref, gitError(err))
+		return rev, err
+	}
+	rev = strings.TrimSpace(string(out))
+	return rev, err
+}
+
+// Blame returns the time each line of the file at path was last committed,
+// by line number. Lines not committed yet are left out.
+func Blame(path string) (times map[int]time.Time, err error) {
+	cmd := exec.Command("git", "blame", "--line-porcelain", "--", filepath.Base(path))
+	cmd.Dir = filepath.Dir(path)
+
+	var out []byte
+	out, err = cmd.Output()
+	if err != nil {
+		err = fmt.Errorf("running git blame %s: %w", path, gitError(err))
+		return times, err
+	}
+
+	// Each line is described by a header naming its commit and line
+	// number, followed by fields, the author time among them
+	times = make(map[int]time.Time)
+	var line int
+	var committed bool
+	for _, text := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(text)
+		switch {
+		case strings.HasPrefix(text, "\t") || len(fields) == 0:
+		case len(fields) >= 3 && len(fields[0]) >= 40 && strings.Trim(fields[0], "0123456789abcdef") == "":
+			line, _ = strconv.Atoi(fields[2])
+			committed = strings.Trim(fields[0], "0") != ""
+		case fields[0] == "author-time" && committed && len(fields) == 2:
+			if seconds, parseErr := strconv.ParseInt(fields[1], 10, 64); parseErr == nil {
+				times[line] = time.Unix(seconds, 0)
+			}
+		}
+	}
+	return times, err
+}
+
+// ReadFiles reads a list of files, one per line, from the file at path, or
+// from stdin when path is "-".
+func ReadFiles(path string, stdin io.Reader) (files []string, err error) {
+	var data []byte
+	if path == "-" {
+		data, err = io.ReadAll(stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return files, err
+	}
+
+	files = nonEmptyLines(data)
+	return files, err
+}
+
+// nonEmptyLines splits data into lines, trimmed of surrounding white space,
+// dropping the empty ones.
+func nonEmptyLines(data []byte) (lines []string) {
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// Root returns the directory diff paths are relative to: the top level of the
+// enclosing git repository, or the working directory outside of one.
+func Root() (root string, err error) {
+	var out []byte
+	out, err = exec.Command("git", "rev-parse", "--show-toplevel").Output()
+	if err == nil {
+		root = strings.TrimSpace(string(out))
+		return root, err
+	}
+
+	root, err = os.Getwd()
+	return root, err
+}
+
+// Filter returns the issues located on changed lines. Files of issues are
+// matched relative to root.
+func (l Lines) Filter(root string, issues []report.Issue) (changed []report.Issue) {
+	for _, issue := range issues {
+		if l[report.DisplayPath(root, issue.File)][issue.Line] {
+			changed = append(changed, issue)
+		}
+	}
+	return changed
+}
+
+// gitError includes the output git wrote to stderr in err.
+func gitError(err error) (wrapped error) {
+	wrapped = err
+	if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+		wrapped = fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return wrapped
+}
@@ -0,0 +1,92 @@
+package changes
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nikogura/namedreturns/report"
+)
+
+const testDiff = `diff --git a/a.go b/a.go
+index 1111111..2222222 100644
+--- a/a.go
++++ b/a.go
+@@ -3,2 +3,3 @@ package a
+ func a() {
+-	return
++	x := 1
++++y
+@@ -20 +21 @@ func b() {
+-old
++new
+diff --git a/gone.go b/gone.go
+deleted file mode 100644
+--- a/gone.go
++++ /dev/null
+@@ -1 +0,0 @@
+-package a
+--- old/c.go	2024-01-01 00:00:00
++++ new/c.go	2024-01-01 00:00:00
+@@ -0,0 +1 @@
++package c
+`
+
+func TestParse(t *testing.T) {
+	lines, err := Parse(strings.NewReader(testDiff))
+	if err != nil {
+		t.Fatalf("parsing diff: %s", err)
+	}
+
+	expected := map[string][]int{
+		"a.go":     {4, 5, 21},
+		"new/c.go": {1},
+	}
+	if len(lines) != len(expected) {
+		t.Errorf("expected changes in %d files, got %v", len(expected), lines)
+	}
+	for file, numbers := range expected {
+		if len(lines[file]) != len(numbers) {
+			t.Errorf("%s: expected lines %v, got %v", file, numbers, lines[file])
+		}
+		for _, n := range numbers {
+			if !lines[file][n] {
+				t.Errorf("%s: expected line %d to be changed", file, n)
+			}
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	lines := Lines{"pkg/a.go": {4: true}}
+	issues := []report.Issue{
+		{File: "/src/pkg/a.go", Line: 3},
+		{File: "/src/pkg/a.go", Line: 4},
+		{File: "/src/pkg/b.go", Line: 4},
+	}
+
+	changed := lines.Filter("/src", issues)
+	if len(changed) != 1 || changed[0].Line != 4 || changed[0].File != "/src/pkg/a.go" {
+		t.Errorf("unexpected issues on changed lines: %+v", changed)
+	}
+}
+
+func TestGitOptionRef(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "diff")
+	ref := "--output=" + output
+
+	_, err := GitDiff(ref)
+	if err == nil {
+		t.Errorf("expected GitDiff to reject %s", ref)
+	}
+	_, err = GitFiles(ref)
+	if err == nil {
+		t.Errorf("expected GitFiles to reject %s", ref)
+	}
+
+	_, err = os.Stat(output)
+	if err == nil {
+		t.Errorf("expected %s to be taken for a revision, not an option writing %s", ref, output)
+	}
+}
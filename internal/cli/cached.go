@@ -0,0 +1,323 @@
+package cli
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nikogura/namedreturns/analyzer"
+	"github.com/nikogura/namedreturns/internal/cache"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/packages"
+)
+
+// EnvCache names the environment variable giving the cache directory when
+// -cache-dir isn't set.
+const EnvCache = "NAMEDRETURNS_CACHE"
+
+// metadataMode loads what cache keys are computed from, which doesn't
+// involve parsing or type checking.
+const metadataMode = packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles |
+	packages.NeedImports | packages.NeedDeps | packages.NeedModule
+
+// openCache opens the cache of results, or returns nil when it is disabled.
+// Results for buffers given on stdin are not cached, and neither are they
+// when there is nowhere to keep them, e.g. without a home directory.
+func openCache(opts options) (c *cache.Cache, err error) {
+	if opts.noCache || opts.overlay != nil {
+		return c, err
+	}
+	if _, ok := toolVersion(); !ok {
+		return c, err
+	}
+
+	dir := opts.cacheDir
+	if dir == "" {
+		dir = os.Getenv(EnvCache)
+	}
+	if dir == "" {
+		var dirErr error
+		dir, dirErr = cache.DefaultDir()
+		if dirErr != nil {
+			return c, err
+		}
+	}
+
+	c, err = cache.Open(dir)
+	if err != nil {
+		err = fmt.Errorf("opening cache: %w", err)
+	}
+	return c, err
+}
+
+// analyzeCached returns the results of the packages matching opts.patterns
+// for platform p, from the cache when they are in it. Only the packages that
+// aren't are type checked and analyzed, and their results cached.
+func analyzeCached(opts options, p platform, c *cache.Cache) (results []packageResult, err error) {
+	var pkgs []*packages.Package
+	pkgs, err = load(opts, p, metadataMode, opts.patterns)
+	if err != nil {
+		return results, err
+	}
+
+	k := newKeyer(opts, p)
+	keys := make(map[string]cache.Key) // of the packages to analyze, by ID
+	var missing []string
+	var dirs []string
+	seenDirs := make(map[string]bool)
+	for _, pkg := range pkgs {
+		key, ok := k.key(pkg)
+		if ok {
+			var result packageResult
+			if c.Get(key, &result) {
+				results = append(results, result)
+				continue
+			}
+			keys[pkg.ID] = key
+		}
+
+		// The generated test main packages, in the build cache, are loaded
+		// from the directory of the package they test
+		missing = append(missing, pkg.ID)
+		if strings.HasSuffix(pkg.ID, ".test") {
+			continue
+		}
+		if dir := packageDir(pkg); dir != "" && !seenDirs[dir] {
+			seenDirs[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	if len(missing) == 0 {
+		return results, err
+	}
+
+	// The directories of the missing packages load them along with their
+	// test variants, except for packages named by their files
+	var loaded []*packages.Package
+	loaded, err = load(opts, p, packages.LoadAllSyntax, dirs)
+	if err != nil {
+		return results, err
+	}
+	toAnalyze := withIDs(loaded, missing)
+	if len(toAnalyze) < len(missing) {
+		loaded, err = load(opts, p, packages.LoadAllSyntax, opts.patterns)
+		if err != nil {
+			return results, err
+		}
+		toAnalyze = withIDs(loaded, missing)
+	}
+
+	var analyzed []packageResult
+	analyzed, err = analyzePackages(opts, toAnalyze)
+	if err != nil {
+		return results, err
+	}
+
+	// Failing to cache a result only costs analyzing the package again
+	for i, pkg := range toAnalyze {
+		if key, ok := keys[pkg.ID]; ok {
+			_ = c.Put(key, analyzed[i])
+		}
+	}
+
+	results = append(results, analyzed...)
+	return results, err
+}
+
+// withIDs returns the packages of pkgs with the given IDs.
+func withIDs(pkgs []*packages.Package, ids []string) (kept []*packages.Package) {
+	wanted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	for _, pkg := range pkgs {
+		if wanted[pkg.ID] {
+			kept = append(kept, pkg)
+			wanted[pkg.ID] = false
+		}
+	}
+	return kept
+}
+
+// keyer computes the cache keys of packages. A key covers the analyzer, the
+// effective configuration of the package and the content of the package and
+// of its dependencies, whose types the analysis depends on.
+type keyer struct {
+	analyzer *analysis.Analyzer               // whose settings the results depend on
+	settings string                           // the analyzer's flags, the platform and build tags
+	today    string                           // for directives that expire
+	configs  map[string][]byte                // config file contents by path
+	contents map[*packages.Package]contentKey // memoized content hashes
+}
+
+// contentKey is the hash of the content of a package and its dependencies.
+type contentKey struct {
+	key     cache.Key
+	expires bool // whether the package's own files may hold expiring directives
+	ok      bool // false if a file couldn't be read
+}
+
+// newKeyer creates a keyer for the current settings and platform p.
+func newKeyer(opts options, p platform) (k *keyer) {
+	var settings strings.Builder
+	opts.analyzer.Flags.VisitAll(func(f *flag.Flag) {
+		fmt.Fprintf(&settings, "%s=%s\n", f.Name, f.Value.String())
+		if value, set := os.LookupEnv(analyzer.EnvName(f.Name)); set {
+			fmt.Fprintf(&settings, "%s=%s\n", analyzer.EnvName(f.Name), value)
+		}
+	})
+	fmt.Fprintf(&settings, "platform=%s\ntags=%s\n", p, strings.Join(opts.tags, ","))
+
+	k = &keyer{
+		analyzer: opts.analyzer,
+		settings: settings.String(),
+		today:    time.Now().Format(time.DateOnly),
+		configs:  make(map[string][]byte),
+		contents: make(map[*packages.Package]contentKey),
+	}
+	return k
+}
+
+// key returns the cache key of pkg. ok is false if it can't be computed, in
+// which case the package's results aren't cached.
+func (k *keyer) key(pkg *packages.Package) (key cache.Key, ok bool) {
+	content := k.content(pkg)
+	if !content.ok {
+		return key, ok
+	}
+
+	version, _ := toolVersion()
+	h := cache.NewHash()
+	h.AddString("version", version)
+	h.AddString("settings", k.settings)
+	h.AddString("config", k.config(pkg))
+	h.Add("content", content.key[:])
+	if content.expires {
+		h.AddString("today", k.today)
+	}
+
+	key = h.Sum()
+	ok = true
+	return key, ok
+}
+
+// config returns the paths and contents of the config files applying to
+// pkg, found the way the analyzer finds them.
+func (k *keyer) config(pkg *packages.Package) (config string) {
+	paths, _ := analyzer.ConfigFiles(k.analyzer, packageDir(pkg))
+
+	var b strings.Builder
+	for _, path := range paths {
+		data, cached := k.configs[path]
+		if !cached {
+			// A config file that can't be read fails the analysis anyway
+			data, _ = os.ReadFile(path)
+			k.configs[path] = data
+		}
+		b.WriteString(path + "\n" + string(data) + "\n")
+	}
+	config = b.String()
+	return config
+}
+
+// content returns the hash of the files of pkg and, recursively, of its
+// dependencies. For packages in the module cache, the module version stands
+// for the content of the files.
+func (k *keyer) content(pkg *packages.Package) (content contentKey) {
+	if cached, found := k.contents[pkg]; found {
+		content = cached
+		return content
+	}
+
+	h := cache.NewHash()
+	h.AddString("id", pkg.ID)
+	if pkg.Module != nil {
+		h.AddString("go", pkg.Module.GoVersion)
+	}
+
+	// Packages in the module cache are immutable
+	m := pkg.Module
+	immutable := m != nil && !m.Main && m.Replace == nil && m.Version != ""
+	if immutable {
+		h.AddString("module", m.Path+"@"+m.Version)
+	}
+
+	for _, file := range pkg.CompiledGoFiles {
+		h.AddString("file", file)
+		if immutable {
+			continue
+		}
+
+		data, err := os.ReadFile(file)
+		if err != nil {
+			k.contents[pkg] = content
+			return content
+		}
+		h.Add("data", data)
+		content.expires = content.expires || bytes.Contains(data, []byte("expires="))
+	}
+
+	// Imports are keyed by path, in a stable order
+	paths := make([]string, 0, len(pkg.Imports))
+	for path := range pkg.Imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		dep := k.content(pkg.Imports[path])
+		if !dep.ok {
+			k.contents[pkg] = content
+			return content
+		}
+		h.Add("import "+path, dep.key[:])
+	}
+
+	content.key = h.Sum()
+	content.ok = true
+	k.contents[pkg] = content
+	return content
+}
+
+var (
+	versionOnce sync.Once
+	version     string
+	versionOK   bool
+)
+
+// toolVersion identifies the running build of namedreturns by the hash of
+// its executable, so results are never reused by a different build. ok is
+// false if the executable can't be read.
+func toolVersion() (v string, ok bool) {
+	versionOnce.Do(func() {
+		path, err := os.Executable()
+		if err != nil {
+			return
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return
+		}
+		defer f.Close()
+
+		h := sha256.New()
+		_, err = io.Copy(h, f)
+		if err != nil {
+			return
+		}
+		version = hex.EncodeToString(h.Sum(nil))
+		versionOK = true
+	})
+
+	v, ok = version, versionOK
+	return v, ok
+}
@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nikogura/namedreturns/analyzer"
+)
+
+func TestMainCache(t *testing.T) {
+	dir := t.TempDir()
+	run := func(args ...string) (output string) {
+		var stdout, stderr bytes.Buffer
+		code := Main(append(args, fixture), nil, &stdout, &stderr)
+		if code != exitIssues {
+			t.Fatalf("expected exit code %d, got %d (stderr: %s)", exitIssues, code, stderr.String())
+		}
+		output = stdout.String()
+		return output
+	}
+	entries := func() (n int) {
+		matches, _ := filepath.Glob(filepath.Join(dir, "*", "*.json"))
+		n = len(matches)
+		return n
+	}
+
+	uncached := run("-no-cache", "-cache-dir", dir)
+	if entries() != 0 {
+		t.Fatalf("expected -no-cache not to write the cache")
+	}
+
+	first := run("-cache-dir", dir)
+	written := entries()
+	if written == 0 {
+		t.Fatalf("expected the results to be cached")
+	}
+
+	// Corrupting the entries shows whether they are read
+	matches, _ := filepath.Glob(filepath.Join(dir, "*", "*.json"))
+	for _, path := range matches {
+		err := os.WriteFile(path, []byte(`{"issues": []}`), 0o600)
+		if err != nil {
+			t.Fatalf("Failed to write entry: %s", err)
+		}
+	}
+	var stdout, stderr bytes.Buffer
+	code := Main([]string{"-cache-dir", dir, fixture}, nil, &stdout, &stderr)
+	if code != exitOK {
+		t.Errorf("expected the cached results to be used, got exit code %d and:\n%s", code, stdout.String())
+	}
+
+	if first != uncached {
+		t.Errorf("expected the same findings with and without the cache, got:\n%s\nand:\n%s", first, uncached)
+	}
+
+	// Changing the configuration invalidates the entries
+	config := filepath.Join(t.TempDir(), "config.yaml")
+	t.Setenv(analyzer.EnvConfig, config)
+	for i, content := range []string{"min-returns: 1\n", "min-returns: 2\n"} {
+		err := os.WriteFile(config, []byte(content), 0o600)
+		if err != nil {
+			t.Fatalf("Failed to write config: %s", err)
+		}
+		run("-cache-dir", dir)
+		if n := entries(); n != written*(i+2) {
+			t.Errorf("expected config %q to miss the cache, got %d entries", content, n)
+		}
+	}
+}
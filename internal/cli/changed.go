@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/nikogura/namedreturns/internal/changes"
+)
+
+// changedPackages returns the directories of the packages to analyze, and
+// the files to report on, for the changed files given in opts: those
+// changed since the -changed-since revision, or those listed by
+// -changed-files. Whole files are reported on, unlike with -diff.
+//
+// Files found by git are filtered as a ./... pattern would be, skipping
+// vendor and testdata directories, while listed files are analyzed as named.
+// Excluded directories are skipped either way.
+func changedPackages(opts options, stdin io.Reader) (dirs []string, reported map[string]bool, err error) {
+	var files []string
+	if opts.changedSince != "" {
+		files, err = changes.GitFiles(opts.changedSince)
+	} else {
+		files, err = changes.ReadFiles(opts.changedFiles, stdin)
+	}
+	if err != nil {
+		return dirs, reported, err
+	}
+
+	var root string
+	root, err = os.Getwd()
+	if err != nil {
+		return dirs, reported, err
+	}
+
+	bases := []string{"."}
+	if opts.changedSince == "" {
+		bases = nil
+		for _, file := range files {
+			bases = append(bases, filepath.Dir(file))
+		}
+	}
+	filter := newDirFilter(root, bases, opts.excludeDirs)
+
+	var kept []string
+	for _, file := range files {
+		if !filter.excluded(filepath.Dir(file)) {
+			kept = append(kept, file)
+		}
+	}
+
+	dirs, reported, err = hookPackages(kept)
+	return dirs, reported, err
+}
@@ -0,0 +1,1029 @@
+// Package cli implements the namedreturns command line interface.
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nikogura/namedreturns/analyzer"
+	"github.com/nikogura/namedreturns/internal/baseline"
+	"github.com/nikogura/namedreturns/internal/cache"
+	"github.com/nikogura/namedreturns/internal/changes"
+	"github.com/nikogura/namedreturns/report"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/checker"
+	"golang.org/x/tools/go/packages"
+)
+
+// Exit codes, matching those of the x/tools analysis drivers.
+const (
+	exitOK     = 0
+	exitError  = 1
+	exitIssues = 3
+)
+
+// options holds the parsed command line.
+type options struct {
+	format    string
+	tests     bool
+	stats     bool
+	statsOnly bool
+	summary   bool // with the markdown format, only the counts per rule
+	relative  bool // write the paths of files relative to the working directory
+	source    bool // with the text format, show the source line of each finding
+	policy    policy
+	patterns  []string
+
+	metricsPush string // push gateway URL the metrics of the run are sent to
+	ratchet     string // file recording the number of findings, which must not go up
+
+	excludeDirs []string   // globs of directories not to analyze, relative to the working directory
+	paths       pathFilter // import path patterns of the packages to analyze
+
+	tags      []string   // build tags
+	platforms []platform // analyzed one after the other, none for the environment's
+	variants  bool       // analyze every build variant the build constraints of the files select
+
+	baseline     string // "write", "check", "update" or empty
+	baselineFile string
+	failOnGrowth bool // with baseline update, fail when findings are not in the baseline
+
+	listSuppressions bool // list the suppressions in effect instead of the findings
+
+	ctx context.Context // loading and analyzing packages stop once it is done
+
+	analyzer    *analysis.Analyzer // created for the run, holding the settings its flags set
+	propagation *analysis.Analyzer // run along with analyzer, under the same flags
+
+	diff    string // unified diff file, "-" for stdin
+	diffRef string // git revision to diff the working tree against
+
+	changedSince string // git revision, only the packages of files changed since are analyzed
+	changedFiles string // file listing the changed files, "-" for stdin
+
+	fast bool // parse only, without loading packages or type checking
+	jobs int  // packages analyzed concurrently
+
+	noCache  bool   // analyze every package, neither reading nor writing the cache
+	cacheDir string // directory of the cache, empty for the default
+
+	stdin         bool
+	stdinFilename string            // absolute path the stdin content stands for
+	overlay       map[string][]byte // file contents replacing those on disk
+
+	watch         bool
+	watchInterval time.Duration
+
+	fix         bool            // apply the suggested fixes
+	migrate     bool            // apply only the fixes converting functions to named returns
+	bareReturns bool            // with migrate, turn trailing returns restating the named results into bare ones
+	dryRun      bool            // with fix or migrate, print the diffs of the fixes instead of applying them
+	hook        bool            // run as a pre-commit hook on the files in patterns
+	lsp         bool            // serve the findings in the documents an editor opens over the language server protocol
+	files       map[string]bool // absolute paths of the only files to report on, nil for all
+}
+
+// Baseline modes.
+const (
+	baselineWrite  = "write"
+	baselineCheck  = "check"
+	baselineUpdate = "update"
+)
+
+// outcome is what one run of the analyzer over the requested packages produced.
+type outcome struct {
+	issues     []report.Issue
+	functions  int      // functions with results
+	fullyNamed int      // functions whose results are all named
+	files      []string // files declaring functions with results, in order
+}
+
+// Main runs the command with args, which exclude the program name, and
+// returns the process exit code.
+func Main(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) (code int) {
+	code = MainContext(context.Background(), args, stdin, stdout, stderr)
+	return code
+}
+
+// MainContext is Main for programs embedding the command, such as servers
+// that abort slow runs: loading and analyzing packages stop once ctx is
+// done, and the run fails with its error.
+func MainContext(ctx context.Context, args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) (code int) {
+	if len(args) > 0 && args[0] == "config" {
+		code = configCommand(args[1:], stdout, stderr)
+		return code
+	}
+
+	opts, err := parseArgs(args, stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		code = exitOK
+		return code
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "namedreturns: %s\n", err)
+		code = exitError
+		return code
+	}
+
+	// The hook may be given only deleted or non-Go files
+	if opts.hook && len(opts.patterns) == 0 {
+		code = exitOK
+		return code
+	}
+	opts.ctx = ctx
+
+	if opts.changed() {
+		opts.patterns, opts.files, err = changedPackages(opts, stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "namedreturns: finding changed files: %s\n", err)
+			code = exitError
+			return code
+		}
+	}
+
+	if opts.stdin {
+		opts.overlay, err = stdinOverlay(opts.stdinFilename, stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "namedreturns: reading stdin: %s\n", err)
+			code = exitError
+			return code
+		}
+	}
+
+	if opts.variants && len(opts.patterns) > 0 {
+		opts.platforms, err = buildVariants(opts)
+		if err != nil {
+			fmt.Fprintf(stderr, "namedreturns: finding build variants: %s\n", err)
+			code = exitError
+			return code
+		}
+	}
+
+	formatter, err := report.Lookup(opts.format)
+	if err != nil {
+		fmt.Fprintf(stderr, "namedreturns: %s\n", err)
+		code = exitError
+		return code
+	}
+	if opts.summary {
+		formatter = report.WriteMarkdownSummary
+	}
+	if opts.source {
+		formatter = report.WriteTextWithSource
+	}
+	if opts.relative {
+		formatter = relativeFormatter(formatter)
+	}
+
+	if opts.listSuppressions {
+		code = listSuppressions(opts, stdout, stderr)
+		return code
+	}
+
+	if opts.fix {
+		code = fix(opts, formatter, stdin, stdout, stderr)
+		return code
+	}
+
+	if opts.lsp {
+		code = serveLSP(opts, stdin, stdout, stderr)
+		return code
+	}
+
+	if opts.watch {
+		ctx, stop := signal.NotifyContext(opts.ctx, os.Interrupt)
+		defer stop()
+
+		err = watch(ctx, opts, formatter, stdout, stderr)
+		if err != nil {
+			fmt.Fprintf(stderr, "namedreturns: %s\n", err)
+			code = exitError
+			return code
+		}
+		code = exitOK
+		return code
+	}
+
+	start := time.Now()
+	var out outcome
+	switch {
+	case opts.changed() && len(opts.patterns) == 0:
+		// No Go file changed, so there is nothing to analyze
+	case opts.fast:
+		out, err = analyzeSyntax(opts)
+	default:
+		out, err = analyze(opts)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "namedreturns: %s\n", err)
+		code = exitError
+		return code
+	}
+
+	if opts.baseline == baselineUpdate {
+		code = updateBaseline(opts.baselineFile, opts.failOnGrowth, out.issues, stderr)
+		return code
+	}
+
+	if opts.baseline == baselineWrite {
+		err = writeBaseline(opts.baselineFile, out.issues, stderr)
+		if err != nil {
+			fmt.Fprintf(stderr, "namedreturns: %s\n", err)
+			code = exitError
+			return code
+		}
+		code = exitOK
+		return code
+	}
+
+	out.issues, err = narrow(opts, out.issues, stdin, stderr)
+	if err != nil {
+		fmt.Fprintf(stderr, "namedreturns: %s\n", err)
+		code = exitError
+		return code
+	}
+
+	if !opts.statsOnly {
+		err = formatter(stdout, out.issues)
+		if err != nil {
+			fmt.Fprintf(stderr, "namedreturns: writing output: %s\n", err)
+			code = exitError
+			return code
+		}
+	}
+
+	if opts.stats || opts.statsOnly {
+		// Keep machine readable output parseable by writing the summary
+		// to stderr unless it is the only thing written
+		statsOut := stderr
+		if opts.format == "text" || opts.statsOnly {
+			statsOut = stdout
+		}
+
+		err = report.WriteStats(statsOut, report.NewStats(out.issues, out.functions, out.fullyNamed))
+		if err != nil {
+			fmt.Fprintf(stderr, "namedreturns: writing statistics: %s\n", err)
+			code = exitError
+			return code
+		}
+	}
+
+	if opts.metricsPush != "" {
+		err = pushMetrics(opts.metricsPush, report.NewStats(out.issues, out.functions, out.fullyNamed), time.Since(start))
+		if err != nil {
+			fmt.Fprintf(stderr, "namedreturns: pushing metrics: %s\n", err)
+			code = exitError
+			return code
+		}
+	}
+
+	code = exitOK
+	if opts.ratchet != "" {
+		total, _ := opts.policy.count(out.issues)
+		var failed bool
+		failed, err = ratchet(opts.ratchet, total, stderr)
+		if err != nil {
+			fmt.Fprintf(stderr, "namedreturns: ratchet: %s\n", err)
+			code = exitError
+			return code
+		}
+		if failed {
+			code = exitIssues
+		}
+		return code
+	}
+	if opts.policy.fails(out.issues) {
+		code = exitIssues
+	}
+	return code
+}
+
+// parseArgs parses the command line, exposing the analyzer's own flags
+// alongside the driver flags.
+func parseArgs(args []string, stderr io.Writer) (opts options, err error) {
+	usage := "namedreturns [flags] [packages]"
+	var update bool
+	if len(args) > 0 {
+		switch args[0] {
+		case "baseline":
+			if len(args) < 2 || args[1] != baselineUpdate {
+				err = errors.New("baseline needs a command: update")
+				return opts, err
+			}
+			update = true
+			args = args[2:]
+			usage = "namedreturns baseline update [flags] [packages]"
+		case "hook":
+			opts.hook = true
+			args = args[1:]
+			usage = "namedreturns hook [flags] [files]"
+		case "fix":
+			opts.fix = true
+			args = args[1:]
+			usage = "namedreturns fix [flags] [packages]"
+		case "migrate":
+			opts.fix = true
+			opts.migrate = true
+			args = args[1:]
+			usage = "namedreturns migrate [flags] [packages]"
+		case "lsp":
+			opts.lsp = true
+			args = args[1:]
+			usage = "namedreturns lsp [flags]"
+		}
+	}
+
+	opts.ctx = context.Background()
+	fs := flag.NewFlagSet("namedreturns", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&opts.format, "format", "text", fmt.Sprintf("output format, one of: %s", strings.Join(report.Formats(), ", ")))
+	fs.BoolVar(&opts.tests, "test", true, "also analyze test files")
+	fs.BoolVar(&opts.stats, "stats", false, "print issue counts per rule, package and file plus the compliance percentage after the findings")
+	fs.BoolVar(&opts.statsOnly, "stats-only", false, "print only the statistics, not the individual findings")
+	fs.BoolVar(&opts.relative, "relative-paths", false, "write the paths of files relative to the working directory rather than absolute")
+	fs.BoolVar(&opts.source, "show-source", false, "with -format=text, show the source line of each finding with carets under what it points at")
+	fs.BoolVar(&opts.summary, "summary-only", false, "with -format=markdown, print only the number of findings per rule")
+	fs.StringVar(&opts.metricsPush, "metrics-push", "", "URL of a Prometheus push gateway group, e.g. http://gateway:9091/metrics/job/namedreturns, the findings per rule and package and the duration of the run are pushed to")
+
+	var failOn, failOnSeverity, maxPerRule string
+	var maxIssues int
+	fs.StringVar(&failOn, "fail-on", "", "comma separated rule IDs or names whose findings fail the run (default all rules)")
+	fs.StringVar(&failOnSeverity, "fail-on-severity", "", "only findings of at least this severity (error, warning, info) fail the run")
+	fs.IntVar(&maxIssues, "max-issues", 0, "fail only when more than this many findings count towards failure")
+	fs.StringVar(&maxPerRule, "max-issues-per-rule", "", "fail only when more findings of a rule than given count towards failure, as comma separated rule=count pairs, e.g. NR003=20,NR004=0, or a count for all rules not listed; without -max-issues, the total is not limited")
+	fs.StringVar(&opts.ratchet, "ratchet", "", "file recording the number of findings counting towards failure; fail only when it goes up, and record it when it goes down")
+	fs.StringVar(&opts.baseline, "baseline", "", "\"write\" records the current findings in the baseline file, \"check\" reports only findings not in it")
+	fs.StringVar(&opts.baselineFile, "baseline-file", baseline.DefaultFile, "path of the baseline file")
+	fs.BoolVar(&opts.listSuppressions, "list-suppressions", false, "list the nolint and namedreturns directives, configuration settings and baseline entries silencing findings in the given directories, with their reasons and how long ago they were committed, instead of the findings")
+	fs.StringVar(&opts.diff, "diff", "", "only report findings on lines added or changed by this unified diff file, \"-\" reads it from stdin")
+	fs.StringVar(&opts.diffRef, "diff-ref", "", "only report findings on lines changed since this git revision")
+	fs.StringVar(&opts.changedSince, "changed-since", "", "only analyze the packages of the Go files changed since this git revision, or untracked, and report the findings in those files")
+	fs.StringVar(&opts.changedFiles, "changed-files", "", "like -changed-since, for the files listed one per line in this file, \"-\" reads them from stdin")
+	fs.Func("exclude-dir", "glob of directories, relative to the working directory, not to analyze along with those below them; may be repeated or comma separated (vendor and testdata are always skipped)", func(value string) (err error) {
+		for _, glob := range strings.Split(value, ",") {
+			glob = filepath.ToSlash(filepath.Clean(strings.TrimSpace(glob)))
+			if _, err = path.Match(glob, ""); err != nil {
+				err = fmt.Errorf("invalid glob %q: %w", glob, err)
+				return err
+			}
+			opts.excludeDirs = append(opts.excludeDirs, glob)
+		}
+		return err
+	})
+	fs.Func("include-path", "import path pattern, in which ... matches any string, of the packages to analyze, e.g. example.com/repo/services/billing/...; may be repeated or comma separated (default all packages matching the package patterns)", func(value string) (err error) {
+		opts.paths.include, err = appendPathPatterns(opts.paths.include, value)
+		return err
+	})
+	fs.Func("exclude-path", "import path pattern, in which ... matches any string, of packages not to analyze; may be repeated or comma separated", func(value string) (err error) {
+		opts.paths.exclude, err = appendPathPatterns(opts.paths.exclude, value)
+		return err
+	})
+	fs.Func("tags", "comma separated build tags selecting the files to analyze, as for go build", func(value string) (err error) {
+		for _, tag := range strings.Split(value, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				opts.tags = append(opts.tags, tag)
+			}
+		}
+		return err
+	})
+	fs.Func("platforms", "comma separated GOOS/GOARCH pairs to analyze the code for, each selecting the files its build constraints allow; findings in files shared by several are reported once (default the environment's)", func(value string) (err error) {
+		for _, s := range strings.Split(value, ",") {
+			var p platform
+			p, err = parsePlatform(strings.TrimSpace(s))
+			if err != nil {
+				return err
+			}
+			opts.platforms = append(opts.platforms, p)
+		}
+		return err
+	})
+	fs.BoolVar(&opts.variants, "build-variants", false, "analyze the code for every platform and combination of build tags the build constraints of its files select, so each file is analyzed at least once; findings are noted with the variants they are only found for")
+	fs.BoolVar(&opts.fast, "fast", false, "only parse the files of the given directories, skipping package loading and type checking; much faster, but checks that need type information are approximated")
+	fs.IntVar(&opts.jobs, "jobs", runtime.GOMAXPROCS(0), "number of packages analyzed concurrently")
+	fs.BoolVar(&opts.noCache, "no-cache", false, "analyze every package, neither reading nor writing the cache of results")
+	fs.StringVar(&opts.cacheDir, "cache-dir", "", fmt.Sprintf("directory of the cache of results (default $%s, or namedreturns in the user cache directory)", EnvCache))
+	fs.BoolVar(&opts.watch, "watch", false, "keep running, re-analyzing the packages affected by each change to their files")
+	fs.DurationVar(&opts.watchInterval, "watch-interval", time.Second, "how often -watch checks for changes")
+	fs.BoolVar(&opts.stdin, "stdin", false, "analyze the content of the file named by -stdin-filename read from stdin, e.g. an unsaved editor buffer")
+	fs.StringVar(&opts.stdinFilename, "stdin-filename", "", "path of the file whose content is read from stdin")
+	if opts.fix {
+		fs.BoolVar(&opts.dryRun, "dry-run", false, "print the unified diffs of the fixes, and how many there are, instead of changing the files")
+	}
+	if update {
+		fs.BoolVar(&opts.failOnGrowth, "fail-on-growth", false, "fail, leaving the baseline file as it is, when findings are not in the baseline")
+	}
+	if opts.migrate {
+		fs.BoolVar(&opts.bareReturns, "bare-returns", false, "also turn the last return statements of functions returning their named results, in order, into bare returns")
+	}
+
+	// Each run has analyzers of its own, so the flags of one don't carry
+	// over to the next when the command is embedded
+	opts.analyzer = analyzer.NewAnalyzer(analyzer.Config{})
+	opts.propagation = analyzer.NewPropagation(analyzer.Config{})
+	opts.analyzer.Flags.VisitAll(func(f *flag.Flag) {
+		fs.Var(f.Value, f.Name, f.Usage)
+	})
+
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "%s\n\nUsage: %s\n\nFlags:\n", opts.analyzer.Doc, usage)
+		fs.PrintDefaults()
+	}
+
+	err = fs.Parse(args)
+	if err != nil {
+		return opts, err
+	}
+
+	// Propagation runs along with Analyzer, under the same flags
+	fs.Visit(func(f *flag.Flag) {
+		if err == nil && opts.propagation.Flags.Lookup(f.Name) != nil {
+			err = opts.propagation.Flags.Set(f.Name, f.Value.String())
+		}
+	})
+	if err != nil {
+		return opts, err
+	}
+
+	if opts.baseline != "" && opts.baseline != baselineWrite && opts.baseline != baselineCheck {
+		err = fmt.Errorf("invalid -baseline %q, expected %q or %q", opts.baseline, baselineWrite, baselineCheck)
+		return opts, err
+	}
+
+	if update {
+		switch {
+		case opts.baseline != "":
+			err = errors.New("baseline update cannot be combined with -baseline")
+		case opts.changed(), opts.diff != "", opts.diffRef != "", opts.stdin, opts.watch, opts.ratchet != "":
+			err = errors.New("baseline update cannot be combined with -changed-since, -changed-files, -diff, -diff-ref, -stdin, -watch or -ratchet, as it needs the findings of all packages")
+		}
+		if err != nil {
+			return opts, err
+		}
+		opts.baseline = baselineUpdate
+	}
+
+	if opts.diff != "" && opts.diffRef != "" {
+		err = errors.New("-diff and -diff-ref are mutually exclusive")
+		return opts, err
+	}
+
+	if opts.changed() {
+		switch {
+		case opts.changedSince != "" && opts.changedFiles != "":
+			err = errors.New("-changed-since and -changed-files are mutually exclusive")
+		case opts.stdin, opts.hook, opts.watch:
+			err = errors.New("-changed-since and -changed-files cannot be combined with -stdin, -watch or hook")
+		case opts.baseline == baselineWrite:
+			err = errors.New("-changed-since and -changed-files cannot be combined with -baseline=write, which needs the findings of all packages")
+		case opts.changedFiles == "-" && opts.diff == "-":
+			err = errors.New("-changed-files=- and -diff=- both read stdin")
+		case fs.NArg() > 0:
+			err = errors.New("-changed-since and -changed-files do not take package patterns")
+		}
+		if err != nil {
+			return opts, err
+		}
+	}
+
+	if opts.jobs < 1 {
+		err = errors.New("-jobs must be at least 1")
+		return opts, err
+	}
+
+	if opts.variants && len(opts.platforms) > 0 {
+		err = errors.New("-build-variants and -platforms are mutually exclusive")
+		return opts, err
+	}
+
+	if opts.fast && opts.paths.active() {
+		err = errors.New("-include-path and -exclude-path cannot be combined with -fast, which doesn't load the import paths of packages")
+		return opts, err
+	}
+
+	if opts.summary && opts.format != "markdown" {
+		err = errors.New("-summary-only requires -format=markdown")
+		return opts, err
+	}
+
+	if opts.listSuppressions {
+		switch {
+		case opts.format != "text" && opts.format != "json":
+			err = errors.New("-list-suppressions supports -format=text or -format=json")
+		case opts.baseline != "", opts.fix, opts.hook, opts.lsp, opts.watch, opts.stdin, opts.changed():
+			err = errors.New("-list-suppressions cannot be combined with -baseline, -stdin, -watch, -changed-since, -changed-files, hook, fix, migrate, lsp or baseline update")
+		}
+		if err != nil {
+			return opts, err
+		}
+	}
+
+	if opts.source && opts.format != "text" {
+		err = errors.New("-show-source requires -format=text")
+		return opts, err
+	}
+
+	if opts.fix && (opts.stdin || opts.baseline == baselineWrite) {
+		err = errors.New("fix and migrate cannot be combined with -stdin or -baseline=write")
+		return opts, err
+	}
+
+	if opts.watch {
+		switch {
+		case opts.stdin, opts.hook, opts.fix, opts.fast:
+			err = errors.New("-watch cannot be combined with -stdin, -fast, hook, fix or migrate")
+		case opts.baseline == baselineWrite, opts.statsOnly, opts.diff == "-", opts.metricsPush != "":
+			err = errors.New("-watch cannot be combined with -baseline=write, -stats-only, -diff=- or -metrics-push")
+		case opts.watchInterval <= 0:
+			err = errors.New("-watch-interval must be positive")
+		}
+		if err != nil {
+			return opts, err
+		}
+	}
+
+	if opts.lsp {
+		switch {
+		case fs.NArg() > 0:
+			err = errors.New("lsp does not take package patterns, it analyzes the packages of the documents opened")
+		case opts.stdin, opts.watch, opts.fast, opts.changed():
+			err = errors.New("lsp cannot be combined with -stdin, -watch, -fast, -changed-since or -changed-files")
+		case opts.baseline == baselineWrite, opts.diff == "-", opts.ratchet != "", opts.metricsPush != "":
+			err = errors.New("lsp cannot be combined with -baseline=write, -diff=-, -ratchet or -metrics-push")
+		}
+		if err != nil {
+			return opts, err
+		}
+	}
+
+	var maxIssuesSet bool
+	fs.Visit(func(f *flag.Flag) {
+		maxIssuesSet = maxIssuesSet || f.Name == "max-issues"
+	})
+
+	if opts.ratchet != "" {
+		switch {
+		case maxIssuesSet, maxPerRule != "":
+			err = errors.New("-ratchet replaces -max-issues and -max-issues-per-rule")
+		case opts.watch, opts.fix, opts.hook, opts.stdin:
+			err = errors.New("-ratchet cannot be combined with -stdin, -watch, hook, fix or migrate")
+		case opts.baseline == baselineWrite, opts.diff != "", opts.diffRef != "", opts.changed():
+			err = errors.New("-ratchet cannot be combined with -baseline=write, -diff, -diff-ref, -changed-since or -changed-files, which would record the findings of part of the code")
+		}
+		if err != nil {
+			return opts, err
+		}
+	}
+
+	opts.policy, err = newPolicy(failOn, failOnSeverity, maxIssues, maxPerRule)
+	if err != nil {
+		return opts, err
+	}
+	if maxPerRule != "" && !maxIssuesSet {
+		opts.policy.maxIssues = noLimit
+	}
+
+	opts.patterns = fs.Args()
+
+	if opts.stdin {
+		if opts.stdinFilename == "" {
+			err = errors.New("-stdin requires -stdin-filename")
+			return opts, err
+		}
+		if len(opts.patterns) > 0 {
+			err = errors.New("-stdin does not take package patterns")
+			return opts, err
+		}
+		if opts.fast {
+			err = errors.New("-stdin and -fast are mutually exclusive")
+			return opts, err
+		}
+		if opts.diff == "-" {
+			err = errors.New("-stdin and -diff=- both read stdin")
+			return opts, err
+		}
+
+		opts.stdinFilename, err = filepath.Abs(opts.stdinFilename)
+		if err != nil {
+			return opts, err
+		}
+		opts.patterns = []string{"file=" + opts.stdinFilename}
+		opts.files = map[string]bool{opts.stdinFilename: true}
+	}
+
+	if opts.hook {
+		if opts.stdin {
+			err = errors.New("hook does not support -stdin")
+			return opts, err
+		}
+		opts.patterns, opts.files, err = hookPackages(opts.patterns)
+		return opts, err
+	}
+
+	// The changed files determine the patterns
+	if len(opts.patterns) == 0 && !opts.changed() {
+		opts.patterns = []string{"."}
+	}
+	return opts, err
+}
+
+// changed reports whether only the packages of changed files are analyzed.
+func (opts options) changed() (changed bool) {
+	changed = opts.changedSince != "" || opts.changedFiles != ""
+	return changed
+}
+
+// analyze loads the packages matching opts.patterns, runs the analyzer on
+// them and returns the deduplicated issues in a stable order. Unless
+// disabled, the results of packages that didn't change since an earlier run
+// are taken from the cache.
+func analyze(opts options) (out outcome, err error) {
+	var c *cache.Cache
+	c, err = openCache(opts)
+	if err != nil {
+		return out, err
+	}
+
+	var results []packageResult
+	targets := opts.targets()
+	for _, p := range targets {
+		var platformResults []packageResult
+		if c != nil {
+			platformResults, err = analyzeCached(opts, p, c)
+		} else {
+			var pkgs []*packages.Package
+			pkgs, err = load(opts, p, packages.LoadAllSyntax, opts.patterns)
+			if err == nil {
+				platformResults, err = analyzePackages(opts, pkgs)
+			}
+		}
+		if err != nil {
+			if p.String() != "" {
+				err = fmt.Errorf("%s: %w", p, err)
+			}
+			return out, err
+		}
+		if len(targets) > 1 {
+			noteVariant(platformResults, p)
+		}
+		results = append(results, platformResults...)
+	}
+
+	out = merge(results, len(targets))
+	return out, err
+}
+
+// merge merges the results of packages for the given number of platforms
+// into an outcome. Findings in a file analyzed more than once, as part of a
+// package and its test variant or for several platforms, are reported once,
+// and the file is counted once. Findings made for only some of the
+// platforms keep noting those.
+func merge(results []packageResult, platforms int) (out outcome) {
+	counted := make(map[string]bool)
+
+	for _, result := range results {
+		out.issues = append(out.issues, result.Issues...)
+		for filename, fileStats := range result.Files {
+			if counted[filename] {
+				continue
+			}
+			counted[filename] = true
+			out.files = append(out.files, filename)
+			out.functions += fileStats.Functions
+			out.fullyNamed += fileStats.FullyNamed
+		}
+	}
+
+	sort.Strings(out.files)
+	out.issues = report.Dedupe(out.issues)
+	for i := range out.issues {
+		if len(out.issues[i].Variants) == platforms {
+			out.issues[i].Variants = nil
+		}
+	}
+	report.Sort(out.issues)
+	return out
+}
+
+// packageResult is what analyzing one package produced, as it is cached.
+type packageResult struct {
+	Issues []report.Issue                 `json:"issues"`
+	Files  map[string]*analyzer.FileStats `json:"files"`
+}
+
+// load loads the packages matching patterns for platform p, with the
+// information mode asks for, except those in excluded directories or whose
+// import paths are filtered out.
+func load(opts options, p platform, mode packages.LoadMode, patterns []string) (pkgs []*packages.Package, err error) {
+	cfg := &packages.Config{
+		Context:    opts.ctx,
+		Mode:       mode,
+		Tests:      opts.tests,
+		Overlay:    opts.overlay,
+		Env:        p.env(),
+		BuildFlags: p.buildFlags(opts.tags),
+	}
+
+	var files *fileFilter
+	files, err = newFileFilter(opts.analyzer)
+	if err != nil {
+		return pkgs, err
+	}
+	keep := func(pkg *packages.Package) (kept bool) {
+		kept = opts.paths.kept(pkg.PkgPath) && !files.excluded(pkg)
+		return kept
+	}
+
+	// Type checking the packages of a whole monorepo to drop most of them
+	// takes long, so those filtered out by import path or excluded files
+	// are dropped first
+	if (opts.paths.active() || files.active()) && mode&packages.NeedTypes != 0 {
+		patterns, err = prune(cfg, patterns, keep)
+		if err == nil {
+			err = files.err
+		}
+		if err != nil {
+			err = fmt.Errorf("loading packages: %w", err)
+			return pkgs, err
+		}
+		if len(patterns) == 0 {
+			return pkgs, err
+		}
+	}
+
+	pkgs, err = packages.Load(cfg, patterns...)
+	if err != nil {
+		err = fmt.Errorf("loading packages: %w", err)
+		return pkgs, err
+	}
+	var kept []*packages.Package
+	for _, pkg := range pkgs {
+		if keep(pkg) {
+			kept = append(kept, pkg)
+		}
+	}
+	pkgs = kept
+	if files.err != nil {
+		err = files.err
+		return pkgs, err
+	}
+
+	var filter dirFilter
+	filter, err = newFilter(opts)
+	if err != nil {
+		return pkgs, err
+	}
+	pkgs = filter.packages(pkgs)
+
+	// An edited buffer or a partially staged package may well not type
+	// check, which the analyzer copes with, so load errors are only fatal
+	// when analyzing whole packages on disk
+	if !opts.stdin && !opts.hook && !opts.lsp {
+		err = loadErrors(pkgs)
+	}
+	return pkgs, err
+}
+
+// analyzePackages runs the analyzer on the loaded pkgs, returning their
+// results in the same order. Propagation runs along with it on the packages
+// error-propagation is set for.
+func analyzePackages(opts options, pkgs []*packages.Package) (results []packageResult, err error) {
+	// Packages are analyzed independently. The facts the analyzer needs
+	// about their dependencies are cheap to compute for each of them
+	results = make([]packageResult, len(pkgs))
+	a := analyzer.WithContext(opts.ctx, opts.analyzer)
+	propagation := analyzer.WithContext(opts.ctx, opts.propagation)
+	err = forEach(len(pkgs), opts.jobs, func(i int) (analyzeErr error) {
+		analyzeErr = opts.ctx.Err()
+		if analyzeErr != nil {
+			return analyzeErr
+		}
+
+		analyzers := []*analysis.Analyzer{a}
+		var cfg analyzer.Config
+		cfg, analyzeErr = analyzer.ResolveConfig(opts.analyzer, packageDir(pkgs[i]))
+		if analyzeErr != nil {
+			analyzeErr = fmt.Errorf("analyzing %s: %w", pkgs[i].PkgPath, analyzeErr)
+			return analyzeErr
+		}
+		if cfg.ErrorPropagation {
+			analyzers = append(analyzers, propagation)
+		}
+
+		var graph *checker.Graph
+		graph, analyzeErr = checker.Analyze(analyzers, pkgs[i:i+1], &checker.Options{Sequential: true})
+		if analyzeErr == nil {
+			analyzeErr = opts.ctx.Err()
+		}
+		if analyzeErr != nil {
+			return analyzeErr
+		}
+
+		for _, act := range graph.Roots {
+			if act.Err != nil {
+				analyzeErr = fmt.Errorf("analyzing %s: %w", act.Package.PkgPath, act.Err)
+				return analyzeErr
+			}
+
+			for _, d := range act.Diagnostics {
+				results[i].Issues = append(results[i].Issues, report.FromDiagnostic(act.Package.Fset, act.Package.PkgPath, d))
+			}
+			if result, ok := act.Result.(*analyzer.Result); ok && act.Analyzer == a {
+				results[i].Files = result.Files
+			}
+		}
+		return analyzeErr
+	})
+	return results, err
+}
+
+// loadErrors collects the errors of the loaded packages and their
+// dependencies into a single error.
+func loadErrors(pkgs []*packages.Package) (err error) {
+	var messages []string
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		for _, e := range pkg.Errors {
+			messages = append(messages, e.Error())
+		}
+	})
+
+	if len(messages) > 0 {
+		err = fmt.Errorf("failed to load packages:\n%s", strings.Join(messages, "\n"))
+	}
+	return err
+}
+
+// relativeFormatter wraps formatter to write the paths of files relative to
+// the working directory.
+func relativeFormatter(formatter report.Formatter) (relative report.Formatter) {
+	relative = func(w io.Writer, issues []report.Issue) (err error) {
+		var root string
+		root, err = os.Getwd()
+		if err != nil {
+			return err
+		}
+
+		err = formatter(w, report.RelativePaths(root, issues))
+		return err
+	}
+	return relative
+}
+
+// writeBaseline records issues in the baseline file at path.
+func writeBaseline(path string, issues []report.Issue, stderr io.Writer) (err error) {
+	var root string
+	root, err = baseline.Root(path)
+	if err != nil {
+		return err
+	}
+
+	err = baseline.New(root, issues).Save(path)
+	if err != nil {
+		err = fmt.Errorf("writing baseline: %w", err)
+		return err
+	}
+
+	fmt.Fprintf(stderr, "namedreturns: recorded %d findings in %s\n", len(issues), path)
+	return err
+}
+
+// updateBaseline drops the findings that no longer occur from the baseline
+// file at path, reporting how many were burned down, and returns the process
+// exit code. With failOnGrowth, findings missing from the baseline fail the
+// run, which leaves the file as it is.
+func updateBaseline(path string, failOnGrowth bool, issues []report.Issue, stderr io.Writer) (code int) {
+	root, err := baseline.Root(path)
+	var b baseline.Baseline
+	if err == nil {
+		b, err = baseline.Load(path)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "namedreturns: %s\n", err)
+		code = exitError
+		return code
+	}
+
+	pruned, removed, uncovered := b.Prune(root, issues)
+	if uncovered > 0 {
+		fmt.Fprintf(stderr, "namedreturns: %d findings are not in baseline %s\n", uncovered, path)
+		if failOnGrowth {
+			code = exitIssues
+			return code
+		}
+	}
+
+	if removed > 0 {
+		err = pruned.Save(path)
+		if err != nil {
+			fmt.Fprintf(stderr, "namedreturns: writing baseline: %s\n", err)
+			code = exitError
+			return code
+		}
+	}
+
+	fmt.Fprintf(stderr, "namedreturns: burned down %d findings, %d left in %s\n", removed, pruned.Findings(), path)
+	code = exitOK
+	return code
+}
+
+// checkBaseline drops the issues recorded in the baseline file at path.
+func checkBaseline(path string, issues []report.Issue, stderr io.Writer) (fresh []report.Issue, err error) {
+	var root string
+	root, err = baseline.Root(path)
+	if err != nil {
+		return fresh, err
+	}
+
+	var b baseline.Baseline
+	b, err = baseline.Load(path)
+	if err != nil {
+		return fresh, err
+	}
+
+	var suppressed int
+	fresh, suppressed = b.Filter(root, issues)
+	if suppressed > 0 {
+		fmt.Fprintf(stderr, "namedreturns: %d findings suppressed by baseline %s\n", suppressed, path)
+	}
+	return fresh, err
+}
+
+// changedIssues keeps the issues on lines changed by the diff given in opts.
+func changedIssues(opts options, issues []report.Issue, stdin io.Reader) (changed []report.Issue, err error) {
+	var lines changes.Lines
+	if opts.diffRef != "" {
+		lines, err = changes.GitDiff(opts.diffRef)
+	} else {
+		lines, err = changes.Read(opts.diff, stdin)
+	}
+	if err != nil {
+		return changed, err
+	}
+
+	var root string
+	root, err = changes.Root()
+	if err != nil {
+		return changed, err
+	}
+
+	changed = lines.Filter(root, issues)
+	return changed, err
+}
+
+// stdinOverlay reads the content of filename from stdin, as an overlay
+// replacing the file on disk.
+func stdinOverlay(filename string, stdin io.Reader) (overlay map[string][]byte, err error) {
+	var content []byte
+	content, err = io.ReadAll(stdin)
+	if err != nil {
+		return overlay, err
+	}
+
+	overlay = map[string][]byte{filename: content}
+	return overlay, err
+}
+
+// narrow drops the issues excluded by the baseline, the files to report on
+// and the diff given in opts.
+func narrow(opts options, issues []report.Issue, stdin io.Reader, stderr io.Writer) (kept []report.Issue, err error) {
+	kept = issues
+
+	if opts.baseline == baselineCheck {
+		kept, err = checkBaseline(opts.baselineFile, kept, stderr)
+		if err != nil {
+			return kept, err
+		}
+	}
+
+	if opts.files != nil {
+		kept = inFiles(kept, opts.files)
+	}
+
+	if opts.diff != "" || opts.diffRef != "" {
+		kept, err = changedIssues(opts, kept, stdin)
+		if err != nil {
+			return kept, err
+		}
+	}
+	return kept, err
+}
+
+// inFiles returns the issues located in one of files.
+func inFiles(issues []report.Issue, files map[string]bool) (kept []report.Issue) {
+	for _, issue := range issues {
+		if files[issue.File] {
+			kept = append(kept, issue)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,815 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/nikogura/namedreturns/analyzer"
+	"github.com/nikogura/namedreturns/internal/baseline"
+	"github.com/nikogura/namedreturns/report"
+)
+
+const fixture = "../../testdata/src/default-config"
+
+// TestMain keeps the results the tests cache out of the user's cache.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "namedreturns-cache")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv(EnvCache, dir)
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func TestMainJSON(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := Main([]string{"-format=json", fixture}, nil, &stdout, &stderr)
+	if code != exitIssues {
+		t.Fatalf("expected exit code %d, got %d (stderr: %s)", exitIssues, code, stderr.String())
+	}
+
+	var doc struct {
+		Issues []report.Issue `json:"issues"`
+	}
+	err := json.Unmarshal(stdout.Bytes(), &doc)
+	if err != nil {
+		t.Fatalf("output is not valid JSON: %s", err)
+	}
+
+	rules := make(map[string]int)
+	for _, issue := range doc.Issues {
+		rules[issue.Rule]++
+		if issue.Severity == "" || issue.Line == 0 || issue.Column == 0 {
+			t.Errorf("incomplete issue: %+v", issue)
+		}
+	}
+
+	for _, rule := range []string{"NR001", "NR002", "NR003", "NR004"} {
+		if rules[rule] == 0 {
+			t.Errorf("expected at least one %s issue", rule)
+		}
+	}
+}
+
+func TestMainQuickfixRelativePaths(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := Main([]string{"-format=quickfix", "-relative-paths", fixture}, nil, &stdout, &stderr)
+	if code != exitIssues {
+		t.Fatalf("expected exit code %d, got %d (stderr: %s)", exitIssues, code, stderr.String())
+	}
+
+	prefix := filepath.Join("..", "..", "testdata", "src", "default-config", "default_config.go") + ":"
+	lines := strings.Split(strings.TrimSuffix(stdout.String(), "\n"), "\n")
+	for _, line := range lines {
+		if !strings.HasPrefix(line, prefix) || strings.HasSuffix(line, "]") || strings.HasPrefix(line, "\t") {
+			t.Errorf("expected a bare file:line:col: message line relative to the working directory, got %q", line)
+		}
+	}
+}
+
+func TestMainShowSource(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := Main([]string{"-show-source", fixture}, nil, &stdout, &stderr)
+	if code != exitIssues {
+		t.Fatalf("expected exit code %d, got %d (stderr: %s)", exitIssues, code, stderr.String())
+	}
+
+	if !strings.Contains(stdout.String(), " 57 | func unnamedReturns() (int, error) {") || !strings.Contains(stdout.String(), "    | ~~~~~~~~~~~~~~~~~~~~~~~~~~~~^^^^^\n") {
+		t.Errorf("expected the signature with carets under the result, got:\n%s", stdout.String())
+	}
+
+	stdout.Reset()
+	code = Main([]string{"-show-source", "-format=json", fixture}, nil, &stdout, &stderr)
+	if code != exitError {
+		t.Errorf("expected -show-source without -format=text to fail, got exit code %d", code)
+	}
+}
+
+func TestMainConfigValidate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".namedreturns.yaml")
+	err := os.WriteFile(path, []byte("error-convention: true\ndisable: [error-convention]\nmode: errors\n"), 0o600)
+	if err != nil {
+		t.Fatalf("Failed to write config: %s", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := Main([]string{"config", "validate", dir}, nil, &stdout, &stderr)
+	if code != exitIssues {
+		t.Fatalf("expected exit code %d, got %d (stderr: %s)", exitIssues, code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "# Settings in effect, from "+path+"\n") || !strings.Contains(stdout.String(), "\nmode: errors\n") || !strings.Contains(stdout.String(), "\nerror-name: err\n") {
+		t.Errorf("expected the resolved settings, got:\n%s", stdout.String())
+	}
+	if stderr.String() != "namedreturns: error-convention is set, but its rule NR006 is disabled\n" {
+		t.Errorf("expected the conflict, got:\n%s", stderr.String())
+	}
+
+	// Every unknown setting is reported, not just the first
+	err = os.WriteFile(path, []byte("report-errors-in-defer: true\nmode: some\nmin-returns: 2\n"), 0o600)
+	if err != nil {
+		t.Fatalf("Failed to write config: %s", err)
+	}
+	stdout.Reset()
+	stderr.Reset()
+	code = Main([]string{"config", "validate", dir}, nil, &stdout, &stderr)
+	if code != exitIssues || stdout.Len() != 0 {
+		t.Fatalf("expected exit code %d without settings, got %d:\n%s", exitIssues, code, stdout.String())
+	}
+	if !strings.Contains(stderr.String(), `invalid value "some" for setting "mode"`) || !strings.Contains(stderr.String(), `unknown setting "report-errors-in-defer"`) {
+		t.Errorf("expected both problems, got:\n%s", stderr.String())
+	}
+
+	err = os.WriteFile(path, []byte("min-returns: 2\n"), 0o600)
+	if err != nil {
+		t.Fatalf("Failed to write config: %s", err)
+	}
+	stdout.Reset()
+	stderr.Reset()
+	code = Main([]string{"config", "validate", dir}, nil, &stdout, &stderr)
+	if code != exitOK || stderr.Len() != 0 {
+		t.Errorf("expected a valid configuration, got exit code %d:\n%s", code, stderr.String())
+	}
+}
+
+func TestMainConfigInit(t *testing.T) {
+	for _, preset := range append(analyzer.Presets(), "") {
+		dir := t.TempDir()
+		path := filepath.Join(dir, ".namedreturns.yaml")
+
+		var stdout, stderr bytes.Buffer
+		code := Main([]string{"config", "init", "-preset=" + preset, "-output=" + path}, nil, &stdout, &stderr)
+		if code != exitOK {
+			t.Fatalf("%s: expected exit code %d, got %d (stderr: %s)", preset, exitOK, code, stderr.String())
+		}
+
+		// The starter file is valid and resolves to the preset
+		stdout.Reset()
+		stderr.Reset()
+		code = Main([]string{"config", "validate", dir}, nil, &stdout, &stderr)
+		if code != exitOK {
+			t.Errorf("%s: expected a valid configuration, got exit code %d:\n%s", preset, code, stderr.String())
+		}
+		want := analyzer.Config{}
+		if preset != "" {
+			want, _ = analyzer.LookupPreset(preset)
+		}
+		got, err := analyzer.ResolveConfig(analyzer.Analyzer, dir)
+		if err != nil {
+			t.Fatalf("%s: ResolveConfig failed: %s", preset, err)
+		}
+		if fmt.Sprint(got.WithDefaults()) != fmt.Sprint(want.WithDefaults()) {
+			t.Errorf("%s: expected the settings of the preset, got %+v", preset, got)
+		}
+
+		code = Main([]string{"config", "init", "-output=" + path}, nil, &stdout, &stderr)
+		if code != exitError || !strings.Contains(stderr.String(), "-force overwrites it") {
+			t.Errorf("%s: expected an existing file not to be overwritten, got exit code %d", preset, code)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := Main([]string{"config", "init", "-preset=lax", "-output=-"}, nil, &stdout, &stderr)
+	if code != exitError || !strings.Contains(stderr.String(), `unknown preset "lax"`) {
+		t.Errorf("expected an unknown preset error, got exit code %d: %s", code, stderr.String())
+	}
+}
+
+func TestMainConfigImportGolangci(t *testing.T) {
+	tests := []struct {
+		name     string
+		golangci string
+		want     []string
+		warning  string
+	}{
+		{
+			name: "v1",
+			golangci: `linters-settings:
+  nonamedreturns:
+    report-error-in-defer: true
+issues:
+  exclude-dirs:
+    - ^legacy
+  exclude-rules:
+    - path: _test\.go
+      linters: [nonamedreturns]
+      text: shadowed-result
+    - path: \.pb\.go$
+      linters: [nonamedreturns, errcheck]
+    - path: internal/
+      linters: [nonamedreturns]
+      source: "^func"
+`,
+			want:    []string{"report-error-in-defer: true", "- legacy/**", "- '**/*.pb.go'", "tests:", "- NR004"},
+			warning: `exclusion rule {path="internal/" source="^func"} has no equivalent`,
+		},
+		{
+			name: "v2",
+			golangci: `version: "2"
+linters:
+  settings:
+    custom:
+      namedreturns:
+        type: module
+        settings:
+          mode: errors
+          disable: [NR003]
+  exclusions:
+    paths:
+      - zz_generated\..*\.go$
+      - (a|b)/
+    rules:
+      - linters: [namedreturns]
+        text: ^NR005$
+      - linters: [govet]
+        text: shadow
+`,
+			want:    []string{"mode: errors", "- NR003", "- NR005", "- '**/*zz_generated.*.go'"},
+			warning: `path "(a|b)/" has no glob equivalent`,
+		},
+	}
+
+	for _, tc := range tests {
+		dir := t.TempDir()
+		path := filepath.Join(dir, ".golangci.yml")
+		err := os.WriteFile(path, []byte(tc.golangci), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var stdout, stderr bytes.Buffer
+		code := Main([]string{"config", "import-golangci", "-output=-", path}, nil, &stdout, &stderr)
+		if code != exitOK {
+			t.Fatalf("%s: expected exit code %d, got %d (stderr: %s)", tc.name, exitOK, code, stderr.String())
+		}
+		for _, want := range tc.want {
+			if !strings.Contains(stdout.String(), want) {
+				t.Errorf("%s: expected %q in the imported configuration:\n%s", tc.name, want, stdout.String())
+			}
+		}
+		if !strings.Contains(stderr.String(), tc.warning) {
+			t.Errorf("%s: expected warning %q, got:\n%s", tc.name, tc.warning, stderr.String())
+		}
+
+		// The imported configuration is valid
+		err = os.WriteFile(filepath.Join(dir, ".namedreturns.yaml"), stdout.Bytes(), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stdout.Reset()
+		stderr.Reset()
+		code = Main([]string{"config", "validate", dir}, nil, &stdout, &stderr)
+		if code != exitOK {
+			t.Errorf("%s: expected a valid configuration, got exit code %d:\n%s", tc.name, code, stderr.String())
+		}
+	}
+}
+
+func TestMainHelp(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := Main([]string{"-h"}, nil, &stdout, &stderr)
+	if code != exitOK {
+		t.Fatalf("expected exit code %d, got %d", exitOK, code)
+	}
+
+	usage := stderr.String()
+	if !strings.Contains(usage, "-"+analyzer.FlagConfig) {
+		t.Errorf("expected the usage to list the -%s flag, got:\n%s", analyzer.FlagConfig, usage)
+	}
+	if strings.Contains(usage, "panic calling String method") {
+		t.Errorf("expected the usage to print every default, got:\n%s", usage)
+	}
+}
+
+func TestMainUnknownFormat(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := Main([]string{"-format=yaml", fixture}, nil, &stdout, &stderr)
+	if code != exitError {
+		t.Fatalf("expected exit code %d, got %d", exitError, code)
+	}
+}
+
+func TestMainStatsOnly(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := Main([]string{"-stats-only", fixture}, nil, &stdout, &stderr)
+	if code != exitIssues {
+		t.Fatalf("expected exit code %d, got %d (stderr: %s)", exitIssues, code, stderr.String())
+	}
+
+	if strings.Contains(stdout.String(), "default_config.go:") {
+		t.Errorf("individual findings should not be printed with -stats-only:\n%s", stdout.String())
+	}
+
+	if !strings.Contains(stdout.String(), "% compliant)") {
+		t.Errorf("expected a compliance summary, got:\n%s", stdout.String())
+	}
+}
+
+func TestMainMarkdownSummary(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := Main([]string{"-format=markdown", "-summary-only", fixture}, nil, &stdout, &stderr)
+	if code != exitIssues {
+		t.Fatalf("expected exit code %d, got %d (stderr: %s)", exitIssues, code, stderr.String())
+	}
+
+	if strings.Contains(stdout.String(), "default_config.go:") || !strings.Contains(stdout.String(), "| NR001 | unnamed-result |") {
+		t.Errorf("expected only the counts per rule, got:\n%s", stdout.String())
+	}
+
+	stdout.Reset()
+	code = Main([]string{"-summary-only", fixture}, nil, &stdout, &stderr)
+	if code != exitError {
+		t.Errorf("expected -summary-only without -format=markdown to fail, got exit code %d", code)
+	}
+}
+
+func TestMainMetricsPush(t *testing.T) {
+	var method, body string
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		method, body = r.Method, string(data)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	var stdout, stderr bytes.Buffer
+	code := Main([]string{"-metrics-push=" + server.URL + "/metrics/job/namedreturns", fixture}, nil, &stdout, &stderr)
+	if code != exitIssues {
+		t.Fatalf("expected exit code %d, got %d (stderr: %s)", exitIssues, code, stderr.String())
+	}
+
+	if method != http.MethodPut || !strings.Contains(body, `namedreturns_rule_findings{rule="NR001"}`) || !strings.Contains(body, "namedreturns_analysis_duration_seconds ") {
+		t.Errorf("unexpected push %s:\n%s", method, body)
+	}
+
+	status = http.StatusBadRequest
+	code = Main([]string{"-metrics-push=" + server.URL, fixture}, nil, &stdout, &stderr)
+	if code != exitError {
+		t.Errorf("expected a rejected push to fail, got exit code %d", code)
+	}
+}
+
+func TestMainBaseline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.json")
+
+	var stdout, stderr bytes.Buffer
+	code := Main([]string{"-baseline=write", "-baseline-file=" + path, fixture}, nil, &stdout, &stderr)
+	if code != exitOK {
+		t.Fatalf("writing baseline: expected exit code %d, got %d (stderr: %s)", exitOK, code, stderr.String())
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	code = Main([]string{"-baseline=check", "-baseline-file=" + path, fixture}, nil, &stdout, &stderr)
+	if code != exitOK {
+		t.Fatalf("checking baseline: expected exit code %d, got %d (stdout: %s, stderr: %s)", exitOK, code, stdout.String(), stderr.String())
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("expected no findings, got:\n%s", stdout.String())
+	}
+}
+
+func TestMainBaselineUpdate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.json")
+
+	var stdout, stderr bytes.Buffer
+	code := Main([]string{"-baseline=write", "-baseline-file=" + path, fixture}, nil, &stdout, &stderr)
+	if code != exitOK {
+		t.Fatalf("writing baseline: expected exit code %d, got %d (stderr: %s)", exitOK, code, stderr.String())
+	}
+
+	// Swap a recorded finding for one that was fixed since
+	b, err := baseline.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorded := b.Findings()
+	b.Entries[0] = baseline.Entry{File: "gone.go", Rule: "NR001", Message: "func gone: unnamed return", Fingerprint: "gone", Count: 2}
+	err = b.Save(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stderr.Reset()
+	code = Main([]string{"baseline", "update", "-fail-on-growth", "-baseline-file=" + path, fixture}, nil, &stdout, &stderr)
+	if code != exitIssues || !strings.Contains(stderr.String(), "1 findings are not in baseline") {
+		t.Errorf("expected a finding missing from the baseline to fail, got exit code %d: %s", code, stderr.String())
+	}
+	if data, _ := os.ReadFile(path); !bytes.Equal(data, saved) {
+		t.Errorf("expected a failed update to leave the baseline as it was")
+	}
+
+	stderr.Reset()
+	code = Main([]string{"baseline", "update", "-baseline-file=" + path, fixture}, nil, &stdout, &stderr)
+	if code != exitOK || !strings.Contains(stderr.String(), fmt.Sprintf("burned down 2 findings, %d left", recorded-1)) {
+		t.Errorf("expected the fixed findings to be burned down, got exit code %d: %s", code, stderr.String())
+	}
+	b, err = baseline.Load(path)
+	if err != nil || b.Findings() != recorded-1 {
+		t.Errorf("expected %d findings left in the baseline, got %+v (%v)", recorded-1, b.Entries, err)
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("expected baseline update to print no findings, got:\n%s", stdout.String())
+	}
+}
+
+func TestMainListSuppressions(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/suppressed\n",
+		"a.go": `package suppressed
+
+//namedreturns:ignore NR001 expires=2999-12-31 -- matches the callback signature
+func A() int {
+	return 1
+}
+
+func B() int { //nolint:namedreturns // legacy
+	return 2
+}
+
+//namedreturns:ignore -- names no rule, so suppresses nothing
+func C() int {
+	return 3
+}
+`,
+		".namedreturns.yaml": `disable:
+  - NR012 # docs are reviewed separately
+exclude-files:
+  - "**/*.pb.go"
+`,
+	}
+	for name, content := range files {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	b := baseline.New(dir, []report.Issue{{File: filepath.Join(dir, "a.go"), Line: 13, Column: 1, Rule: "NR001", Message: "func C: unnamed return"}})
+	baselineFile := filepath.Join(dir, baseline.DefaultFile)
+	err := b.Save(baselineFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := Main([]string{"-list-suppressions", "-format=json", "-baseline-file=" + baselineFile, dir}, nil, &stdout, &stderr)
+	if code != exitOK {
+		t.Fatalf("expected exit code %d, got %d (stderr: %s)", exitOK, code, stderr.String())
+	}
+
+	var found []suppression
+	err = json.Unmarshal(stdout.Bytes(), &found)
+	if err != nil {
+		t.Fatalf("parsing the suppressions: %s\n%s", err, stdout.String())
+	}
+
+	want := []string{
+		"ignore a.go:3 [NR001] expires 2999-12-31: matches the callback signature",
+		"nolint a.go:8 []: legacy",
+		"config .namedreturns.yaml:2 [NR012]: docs are reviewed separately",
+		"config .namedreturns.yaml:4 [] **/*.pb.go: ",
+		"baseline .namedreturns-baseline.json:8 [NR001] a.go: func C: unnamed return: ",
+	}
+	var got []string
+	for _, s := range found {
+		entry := fmt.Sprintf("%s %s:%d %v", s.Kind, filepath.Base(s.File), s.Line, s.Rules)
+		if s.Target != "" {
+			entry += " " + s.Target
+		}
+		if s.Expires != "" {
+			entry += " expires " + s.Expires
+		}
+		got = append(got, entry+": "+s.Reason)
+		if s.Since != nil {
+			t.Errorf("expected no age outside of a repository, got %s", s.Since)
+		}
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("expected suppressions:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+}
+
+func TestMainFlagsPerRun(t *testing.T) {
+	count := func(args ...string) (issues int) {
+		var stdout, stderr bytes.Buffer
+		Main(append([]string{"-no-cache", "-format=json"}, append(args, fixture)...), nil, &stdout, &stderr)
+
+		var doc struct {
+			Issues []report.Issue `json:"issues"`
+		}
+		err := json.Unmarshal(stdout.Bytes(), &doc)
+		if err != nil {
+			t.Fatalf("output is not valid JSON: %s (stderr: %s)", err, stderr.String())
+		}
+		issues = len(doc.Issues)
+		return issues
+	}
+
+	all := count()
+	ambiguous := count("-" + analyzer.FlagMode + "=ambiguous")
+	if ambiguous >= all {
+		t.Fatalf("expected fewer than %d issues with -%s=ambiguous, got %d", all, analyzer.FlagMode, ambiguous)
+	}
+
+	// The flags of the previous run must not carry over
+	again := count()
+	if again != all {
+		t.Errorf("expected %d issues without flags after a run with -%s=ambiguous, got %d", all, analyzer.FlagMode, again)
+	}
+}
+
+func TestMainContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, args := range [][]string{{"-no-cache", fixture}, {"-fast", fixture}} {
+		var stdout, stderr bytes.Buffer
+		code := MainContext(ctx, args, nil, &stdout, &stderr)
+		if code != exitError || !strings.Contains(stderr.String(), context.Canceled.Error()) {
+			t.Errorf("%v: expected a canceled run to fail, got exit code %d: %s", args, code, stderr.String())
+		}
+		if stdout.Len() != 0 {
+			t.Errorf("%v: expected no findings from a canceled run, got:\n%s", args, stdout.String())
+		}
+	}
+}
+
+func TestMainRatchet(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ratchet.json")
+
+	// The first run records the count, later ones pass as long as it
+	// doesn't go up
+	for _, args := range [][]string{{"-ratchet=" + path, fixture}, {"-ratchet=" + path, fixture}} {
+		var stdout, stderr bytes.Buffer
+		code := Main(args, nil, &stdout, &stderr)
+		if code != exitOK {
+			t.Fatalf("expected exit code %d, got %d (stderr: %s)", exitOK, code, stderr.String())
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading ratchet file: %s", err)
+	}
+	var recorded ratchetFile
+	err = json.Unmarshal(data, &recorded)
+	if err != nil || recorded.Count == 0 {
+		t.Fatalf("expected the count of findings to be recorded, got %s", data)
+	}
+
+	err = writeRatchet(path, recorded.Count-1)
+	if err != nil {
+		t.Fatalf("writing ratchet file: %s", err)
+	}
+	var stdout, stderr bytes.Buffer
+	code := Main([]string{"-ratchet=" + path, fixture}, nil, &stdout, &stderr)
+	if code != exitIssues || !strings.Contains(stderr.String(), "up from") {
+		t.Errorf("expected the run to fail as the count went up, got exit code %d (stderr: %s)", code, stderr.String())
+	}
+
+	// Counting only some rules, the count goes down and is recorded
+	stderr.Reset()
+	code = Main([]string{"-ratchet=" + path, "-fail-on=NR001", fixture}, nil, &stdout, &stderr)
+	if code != exitOK || !strings.Contains(stderr.String(), "down from") {
+		t.Errorf("expected the run to pass as the count went down, got exit code %d (stderr: %s)", code, stderr.String())
+	}
+
+	code = Main([]string{"-ratchet=" + path, "-max-issues=3", fixture}, nil, &stdout, &stderr)
+	if code != exitError {
+		t.Errorf("expected -ratchet with -max-issues to be rejected, got exit code %d", code)
+	}
+}
+
+func TestMainMaxIssuesPerRule(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := Main([]string{"-max-issues-per-rule=1000", fixture}, nil, &stdout, &stderr)
+	if code != exitOK {
+		t.Errorf("expected exit code %d under the thresholds of the rules, got %d (stderr: %s)", exitOK, code, stderr.String())
+	}
+
+	code = Main([]string{"-max-issues-per-rule=1000,NR001=0", fixture}, nil, &stdout, &stderr)
+	if code != exitIssues {
+		t.Errorf("expected exit code %d over the threshold of NR001, got %d (stderr: %s)", exitIssues, code, stderr.String())
+	}
+
+	code = Main([]string{"-max-issues-per-rule=1000", "-max-issues=0", fixture}, nil, &stdout, &stderr)
+	if code != exitIssues {
+		t.Errorf("expected exit code %d over the total threshold, got %d (stderr: %s)", exitIssues, code, stderr.String())
+	}
+}
+
+func TestMainDiff(t *testing.T) {
+	diff := `--- a/testdata/src/default-config/default_config.go
++++ b/testdata/src/default-config/default_config.go
+@@ -62 +62 @@
+-func singleUnnamedReturn() int {
++func singleUnnamedReturn() int {
+`
+
+	var stdout, stderr bytes.Buffer
+	code := Main([]string{"-diff=-", fixture}, strings.NewReader(diff), &stdout, &stderr)
+	if code != exitIssues {
+		t.Fatalf("expected exit code %d, got %d (stderr: %s)", exitIssues, code, stderr.String())
+	}
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 1 || !strings.Contains(lines[0], "func singleUnnamedReturn") {
+		t.Errorf("expected only the finding on the changed line, got:\n%s", stdout.String())
+	}
+}
+
+func TestMainChangedFiles(t *testing.T) {
+	list := fixture + "/default_config.go\n" + fixture + "/deleted.go\nREADME.md\n"
+
+	var stdout, stderr bytes.Buffer
+	code := Main([]string{"-changed-files=-"}, strings.NewReader(list), &stdout, &stderr)
+	if code != exitIssues {
+		t.Fatalf("expected exit code %d, got %d (stderr: %s)", exitIssues, code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "default_config.go:") {
+		t.Errorf("expected the findings in the changed file, got:\n%s", stdout.String())
+	}
+
+	// Without changed Go files, the output still describes an empty run
+	stdout.Reset()
+	code = Main([]string{"-changed-files=-", "-format=json"}, strings.NewReader("README.md\n"), &stdout, &stderr)
+	if code != exitOK || !strings.Contains(stdout.String(), `"issues": []`) {
+		t.Errorf("expected a clean run, got exit code %d and:\n%s", code, stdout.String())
+	}
+
+	code = Main([]string{"-changed-files=-", fixture}, strings.NewReader(list), &stdout, &stderr)
+	if code != exitError {
+		t.Errorf("expected package patterns to be rejected, got exit code %d", code)
+	}
+}
+
+func TestMainStdin(t *testing.T) {
+	// An unsaved buffer that doesn't type check
+	buffer := `package example
+
+func edited() int {
+	return undefined
+}
+`
+
+	var stdout, stderr bytes.Buffer
+	code := Main([]string{"-stdin", "-stdin-filename=" + fixture + "/unsaved.go"}, strings.NewReader(buffer), &stdout, &stderr)
+	if code != exitIssues {
+		t.Fatalf("expected exit code %d, got %d (stderr: %s)", exitIssues, code, stderr.String())
+	}
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 1 || !strings.Contains(lines[0], "unsaved.go:3:1: func edited: unnamed return") {
+		t.Errorf("expected only the finding in the buffer, got:\n%s", stdout.String())
+	}
+}
+
+func TestMainFast(t *testing.T) {
+	var full, fast, stderr bytes.Buffer
+	code := Main([]string{fixture}, nil, &full, &stderr)
+	if code != exitIssues {
+		t.Fatalf("expected exit code %d, got %d (stderr: %s)", exitIssues, code, stderr.String())
+	}
+
+	code = Main([]string{"-fast", fixture}, nil, &fast, &stderr)
+	if code != exitIssues {
+		t.Fatalf("-fast: expected exit code %d, got %d (stderr: %s)", exitIssues, code, stderr.String())
+	}
+
+	// The fixture has no findings that depend on type information
+	if fast.String() != full.String() {
+		t.Errorf("-fast findings differ from a full run:\n%s\nwant:\n%s", fast.String(), full.String())
+	}
+}
+
+func TestMainHook(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := Main([]string{"hook", fixture + "/default_config.go", fixture + "/deleted.go", "README.md"}, nil, &stdout, &stderr)
+	if code != exitIssues {
+		t.Fatalf("expected exit code %d, got %d (stderr: %s)", exitIssues, code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "default_config.go:") {
+		t.Errorf("expected findings in the staged file, got:\n%s", stdout.String())
+	}
+
+	stdout.Reset()
+	code = Main([]string{"hook", fixture + "/deleted.go", "README.md"}, nil, &stdout, &stderr)
+	if code != exitOK || stdout.Len() != 0 {
+		t.Errorf("expected a clean run without Go files, got exit code %d and:\n%s", code, stdout.String())
+	}
+}
+
+func TestMainPlatforms(t *testing.T) {
+	const platforms = "../../testdata/src/platforms"
+	for _, fast := range []string{"-fast=false", "-fast=true"} {
+		var stdout, stderr bytes.Buffer
+		code := Main([]string{fast, "-platforms=linux/amd64,windows/amd64", "-tags=extra", platforms}, nil, &stdout, &stderr)
+		if code != exitIssues {
+			t.Fatalf("%s: expected exit code %d, got %d (stderr: %s)", fast, exitIssues, code, stderr.String())
+		}
+
+		files := make(map[string]int)
+		for _, line := range strings.Split(strings.TrimSpace(stdout.String()), "\n") {
+			file, _, _ := strings.Cut(line, ":")
+			files[filepath.Base(file)]++
+		}
+		expected := map[string]int{"platforms.go": 1, "platforms_linux.go": 1, "platforms_windows.go": 1, "platforms_extra.go": 1}
+		if len(files) != len(expected) {
+			t.Errorf("%s: expected findings in %v, got:\n%s", fast, expected, stdout.String())
+		}
+		for file, n := range expected {
+			if files[file] != n {
+				t.Errorf("%s: expected %d finding in %s, got %d", fast, n, file, files[file])
+			}
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := Main([]string{"-platforms=linux", platforms}, nil, &stdout, &stderr)
+	if code != exitError {
+		t.Errorf("expected exit code %d for a platform without GOARCH, got %d", exitError, code)
+	}
+}
+
+func TestMainBuildVariants(t *testing.T) {
+	const platforms = "../../testdata/src/platforms"
+	env := runtime.GOOS + "/" + runtime.GOARCH
+	for _, fast := range []string{"-fast=false", "-fast=true"} {
+		var stdout, stderr bytes.Buffer
+		code := Main([]string{fast, "-build-variants", platforms}, nil, &stdout, &stderr)
+		if code != exitIssues {
+			t.Fatalf("%s: expected exit code %d, got %d (stderr: %s)", fast, exitIssues, code, stderr.String())
+		}
+
+		// Each file is analyzed, and findings not made for every variant
+		// note those they are made for
+		expected := map[string]string{
+			"platforms.go":         "[NR001]\n",
+			"platforms_extra.go":   "(only for " + env + " tags=extra)\n",
+			"platforms_windows.go": "(only for windows/",
+		}
+		for file, suffix := range expected {
+			var line string
+			for _, l := range strings.SplitAfter(stdout.String(), "\n") {
+				if strings.Contains(l, "/"+file+":") {
+					line = l
+				}
+			}
+			if !strings.Contains(line, suffix) {
+				t.Errorf("%s: expected the finding in %s to end with %q, got:\n%s", fast, file, suffix, stdout.String())
+			}
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := Main([]string{"-build-variants", "-platforms=linux/amd64", platforms}, nil, &stdout, &stderr)
+	if code != exitError {
+		t.Errorf("expected exit code %d for -build-variants with -platforms, got %d", exitError, code)
+	}
+}
+
+func TestMainErrorPropagation(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := Main([]string{"-no-cache", "-error-propagation", "-format=json", "../../testdata/src/error-propagation"}, nil, &stdout, &stderr)
+	if code != exitIssues {
+		t.Fatalf("expected exit code %d, got %d (stderr: %s)", exitIssues, code, stderr.String())
+	}
+
+	var doc struct {
+		Issues []report.Issue `json:"issues"`
+	}
+	err := json.Unmarshal(stdout.Bytes(), &doc)
+	if err != nil {
+		t.Fatalf("output is not valid JSON: %s", err)
+	}
+
+	propagated := 0
+	for _, issue := range doc.Issues {
+		if issue.Rule == analyzer.RuleUnpropagatedError {
+			propagated++
+		}
+	}
+	if propagated == 0 {
+		t.Errorf("expected %s issues, got %+v", analyzer.RuleUnpropagatedError, doc.Issues)
+	}
+}
@@ -0,0 +1,217 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nikogura/namedreturns/analyzer"
+	"gopkg.in/yaml.v3"
+)
+
+// configFileName is the name of the configuration files config init writes
+// by default, the first the analyzer looks up.
+const configFileName = ".namedreturns.yaml"
+
+// configCommand runs the config subcommand named by the first of args and
+// returns the process exit code.
+func configCommand(args []string, stdout io.Writer, stderr io.Writer) (code int) {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, "namedreturns: config needs a command: init, validate or import-golangci")
+		code = exitError
+		return code
+	}
+
+	switch args[0] {
+	case "init":
+		code = initConfig(args[1:], stdout, stderr)
+	case "validate":
+		code = validateConfig(args[1:], stdout, stderr)
+	case "import-golangci":
+		code = importGolangci(args[1:], stdout, stderr)
+	default:
+		fmt.Fprintf(stderr, "namedreturns: unknown config command %q, expected init, validate or import-golangci\n", args[0])
+		code = exitError
+	}
+	return code
+}
+
+// initConfig writes a starter configuration file, with the settings of a
+// preset or the defaults, each described.
+func initConfig(args []string, stdout io.Writer, stderr io.Writer) (code int) {
+	var preset, output string
+	var force bool
+	fs := flag.NewFlagSet("namedreturns config init", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&preset, "preset", "", fmt.Sprintf("preset the settings are taken from, one of: %s (default the default settings)", strings.Join(analyzer.Presets(), ", ")))
+	fs.StringVar(&output, "output", configFileName, "file written, \"-\" for stdout")
+	fs.BoolVar(&force, "force", false, "overwrite the file if it exists")
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Writes a starter configuration file describing every setting.\n\nUsage: namedreturns config init [flags]\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+
+	err := fs.Parse(args)
+	if errors.Is(err, flag.ErrHelp) {
+		code = exitOK
+		return code
+	}
+	if err == nil && fs.NArg() > 0 {
+		err = errors.New("config init takes no arguments")
+	}
+
+	var cfg analyzer.Config
+	if err == nil && preset != "" {
+		cfg, err = analyzer.LookupPreset(preset)
+	}
+
+	var data []byte
+	if err == nil {
+		data, err = analyzer.StarterConfig(cfg)
+	}
+
+	if err == nil {
+		switch {
+		case output == "-":
+			_, err = stdout.Write(data)
+		case force:
+			err = os.WriteFile(output, data, 0o644)
+		default:
+			err = writeNewFile(output, data)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "namedreturns: %s\n", err)
+		code = exitError
+		return code
+	}
+
+	if output != "-" {
+		fmt.Fprintf(stderr, "namedreturns: wrote %s\n", output)
+	}
+	code = exitOK
+	return code
+}
+
+// writeNewFile writes data to a file at path, failing if it exists.
+func writeNewFile(path string, data []byte) (err error) {
+	var f *os.File
+	f, err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if errors.Is(err, fs.ErrExist) {
+		err = fmt.Errorf("%s exists, -force overwrites it", path)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(data)
+	closeErr := f.Close()
+	if err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// validateConfig checks the configuration in effect for the directory in
+// args, the working directory by default: every setting of its files, and
+// the settings together once resolved, which it prints. It fails when it
+// finds problems.
+func validateConfig(args []string, stdout io.Writer, stderr io.Writer) (code int) {
+	fs := flag.NewFlagSet("namedreturns config validate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	a := analyzer.NewAnalyzer(analyzer.Config{})
+	a.Flags.VisitAll(func(f *flag.Flag) {
+		fs.Var(f.Value, f.Name, f.Usage)
+	})
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Checks the configuration in effect for a directory and prints the resolved settings.\n\nUsage: namedreturns config validate [flags] [dir]\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+
+	err := fs.Parse(args)
+	if errors.Is(err, flag.ErrHelp) {
+		code = exitOK
+		return code
+	}
+	if err == nil && fs.NArg() > 1 {
+		err = errors.New("config validate takes at most one directory")
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "namedreturns: %s\n", err)
+		code = exitError
+		return code
+	}
+
+	dir := "."
+	if fs.NArg() == 1 {
+		dir = fs.Arg(0)
+	}
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		fmt.Fprintf(stderr, "namedreturns: %s\n", err)
+		code = exitError
+		return code
+	}
+
+	paths, _ := analyzer.ConfigFiles(a, dir)
+	var problems []string
+	for _, path := range paths {
+		for _, problem := range analyzer.ValidateConfigFile(path) {
+			problems = append(problems, problem.Error())
+		}
+	}
+
+	// The files' problems stop the resolution too, so they are only
+	// reported once
+	cfg, err := analyzer.ResolveConfig(a, dir)
+	switch {
+	case err != nil && len(problems) == 0:
+		problems = append(problems, err.Error())
+	case err == nil:
+		problems = append(problems, cfg.Conflicts()...)
+		err = writeSettings(stdout, paths, cfg)
+		if err != nil {
+			fmt.Fprintf(stderr, "namedreturns: writing settings: %s\n", err)
+			code = exitError
+			return code
+		}
+	}
+
+	for _, problem := range problems {
+		fmt.Fprintf(stderr, "namedreturns: %s\n", problem)
+	}
+	code = exitOK
+	if len(problems) > 0 {
+		code = exitIssues
+	}
+	return code
+}
+
+// writeSettings writes cfg as YAML, with its defaults filled in, under a
+// comment listing the configuration files it was resolved from.
+func writeSettings(w io.Writer, paths []string, cfg analyzer.Config) (err error) {
+	source := "no configuration file"
+	if len(paths) > 0 {
+		source = "from " + strings.Join(paths, ", ")
+	}
+
+	_, err = fmt.Fprintf(w, "# Settings in effect, %s\n", source)
+	if err != nil {
+		return err
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	err = encoder.Encode(cfg.WithDefaults())
+	if err != nil {
+		return err
+	}
+	err = encoder.Close()
+	return err
+}
@@ -0,0 +1,321 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/nikogura/namedreturns/analyzer"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/packages"
+)
+
+// skippedDirs hold code the user typically can't change, third party code
+// and test fixtures. They are skipped below the directory a pattern names,
+// so they can still be analyzed by naming them.
+var skippedDirs = map[string]bool{"vendor": true, "testdata": true}
+
+// dirFilter decides which package directories are analyzed.
+type dirFilter struct {
+	root  string   // directory paths are made relative to, the working directory
+	bases []string // directories named by the patterns, relative to root
+	globs []string // excluded directories, relative to root
+}
+
+// newDirFilter creates the filter for the given patterns and exclusion
+// globs, relative to the working directory root.
+func newDirFilter(root string, patterns []string, globs []string) (f dirFilter) {
+	f = dirFilter{root: root, globs: globs}
+	for _, pattern := range patterns {
+		// The package of a file query is named by the file's directory
+		if file, ok := strings.CutPrefix(pattern, "file="); ok {
+			f.bases = append(f.bases, f.relative(filepath.Dir(file)))
+			continue
+		}
+
+		base, _ := strings.CutSuffix(pattern, "...")
+		base = strings.TrimSuffix(base, "/")
+		if base == "" {
+			base = "."
+		}
+
+		// Only directory patterns name directories, import paths don't
+		if !filepath.IsAbs(base) && base != "." && base != ".." &&
+			!strings.HasPrefix(base, "./") && !strings.HasPrefix(base, "../") {
+			continue
+		}
+		f.bases = append(f.bases, f.relative(base))
+	}
+	return f
+}
+
+// newFilter creates the filter for the patterns and exclusions of opts.
+func newFilter(opts options) (f dirFilter, err error) {
+	var root string
+	root, err = os.Getwd()
+	if err != nil {
+		return f, err
+	}
+
+	f = newDirFilter(root, opts.patterns, opts.excludeDirs)
+	return f, err
+}
+
+// relative returns dir relative to the root, with forward slashes, or dir
+// itself when it is outside the root.
+func (f dirFilter) relative(dir string) (rel string) {
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(f.root, dir)
+	}
+
+	var err error
+	rel, err = filepath.Rel(f.root, dir)
+	if err != nil {
+		rel = filepath.ToSlash(dir)
+		return rel
+	}
+	rel = filepath.ToSlash(rel)
+	return rel
+}
+
+// excluded reports whether dir is excluded, either because it lies in a
+// vendor or testdata directory below the directory a pattern names, or
+// because it or one of its parents matches an exclusion glob.
+func (f dirFilter) excluded(dir string) (skip bool) {
+	rel := f.relative(dir)
+
+	// Look for skipped directories below the longest matching base
+	below := rel
+	longest := -1
+	for _, base := range f.bases {
+		if len(base) <= longest {
+			continue
+		}
+		switch {
+		case base == ".":
+			below, longest = rel, len(base)
+		case rel == base:
+			below, longest = "", len(base)
+		case strings.HasPrefix(rel, base+"/"):
+			below, longest = strings.TrimPrefix(rel, base+"/"), len(base)
+		}
+	}
+	if below != "" {
+		for _, elem := range strings.Split(below, "/") {
+			if skippedDirs[elem] {
+				skip = true
+				return skip
+			}
+		}
+	}
+
+	// A glob matching a directory excludes the directories below it too
+	for prefix := rel; prefix != "." && prefix != "/" && prefix != ""; prefix = path.Dir(prefix) {
+		for _, glob := range f.globs {
+			if matched, _ := path.Match(glob, prefix); matched {
+				skip = true
+				return skip
+			}
+		}
+	}
+	return skip
+}
+
+// packages drops the packages in excluded directories from pkgs.
+func (f dirFilter) packages(pkgs []*packages.Package) (kept []*packages.Package) {
+	for _, pkg := range pkgs {
+		dir := packageDir(pkg)
+		if dir != "" && f.excluded(dir) {
+			continue
+		}
+		kept = append(kept, pkg)
+	}
+	return kept
+}
+
+// packageDir returns the directory of pkg's files, or "" if it has none.
+func packageDir(pkg *packages.Package) (dir string) {
+	for _, files := range [][]string{pkg.GoFiles, pkg.OtherFiles, pkg.IgnoredFiles} {
+		if len(files) > 0 {
+			dir = filepath.Dir(files[0])
+			return dir
+		}
+	}
+	return dir
+}
+
+// pathFilter decides which packages are analyzed by their import paths,
+// given as patterns in which "..." matches any string, as for go list.
+type pathFilter struct {
+	include []string // patterns of which a package must match one, when any
+	exclude []string // patterns of which a package must match none
+}
+
+// appendPathPatterns appends the comma separated import path patterns in
+// value to patterns.
+func appendPathPatterns(patterns []string, value string) (appended []string, err error) {
+	appended = patterns
+	for _, pattern := range strings.Split(value, ",") {
+		pattern = strings.TrimSpace(pattern)
+		switch {
+		case pattern == "":
+			continue
+		case pattern == "." || strings.HasPrefix(pattern, "./") || strings.HasPrefix(pattern, "../") || filepath.IsAbs(pattern):
+			err = fmt.Errorf("%q is a directory, not an import path pattern; use -exclude-dir for directories", pattern)
+			return appended, err
+		}
+		appended = append(appended, pattern)
+	}
+	return appended, err
+}
+
+// active reports whether the filter drops any packages.
+func (f pathFilter) active() (active bool) {
+	active = len(f.include) > 0 || len(f.exclude) > 0
+	return active
+}
+
+// kept reports whether the package with the import path pkgPath is
+// analyzed. Test packages are kept along with the package they test.
+func (f pathFilter) kept(pkgPath string) (kept bool) {
+	pkgPath = testedPath(pkgPath)
+
+	kept = len(f.include) == 0
+	for _, pattern := range f.include {
+		kept = kept || matchPath(pattern, pkgPath)
+	}
+	for _, pattern := range f.exclude {
+		kept = kept && !matchPath(pattern, pkgPath)
+	}
+	return kept
+}
+
+// fileFilter drops the packages whose Go files are all excluded by the
+// exclude-files setting in effect for them, which the analyzer would find
+// nothing in.
+type fileFilter struct {
+	analyzer *analysis.Analyzer         // whose settings exclude the files
+	root     analyzer.Config            // in effect in the working directory
+	configs  map[string]analyzer.Config // by package directory
+	err      error                      // of the first setting that couldn't be resolved
+}
+
+// newFileFilter creates the filter of files excluded by the settings of a.
+func newFileFilter(a *analysis.Analyzer) (f *fileFilter, err error) {
+	f = &fileFilter{analyzer: a, configs: make(map[string]analyzer.Config)}
+
+	var dir string
+	dir, err = os.Getwd()
+	if err != nil {
+		return f, err
+	}
+	f.root, err = analyzer.ResolveConfig(a, dir)
+	return f, err
+}
+
+// active reports whether the settings in effect in the working directory
+// exclude files, so packages are worth pruning before type checking. Those
+// of nested configuration files are only applied once packages are loaded.
+func (f *fileFilter) active() (active bool) {
+	active = len(f.root.ExcludeFiles) > 0
+	return active
+}
+
+// excluded reports whether every Go file of pkg is excluded.
+func (f *fileFilter) excluded(pkg *packages.Package) (excluded bool) {
+	if len(pkg.GoFiles) == 0 {
+		return excluded
+	}
+
+	dir := filepath.Dir(pkg.GoFiles[0])
+	cfg, ok := f.configs[dir]
+	if !ok {
+		var err error
+		cfg, err = analyzer.ResolveConfig(f.analyzer, dir)
+		if err != nil && f.err == nil {
+			f.err = err
+		}
+		f.configs[dir] = cfg
+	}
+
+	// Test files may be subject to other exclusions, which are left to the
+	// analyzer
+	_, testsFiles := cfg.Tests[analyzer.FlagExcludeFiles]
+	_, testsPresets := cfg.Tests[analyzer.FlagExcludePresets]
+	testsOverride := testsFiles || testsPresets
+	for _, file := range pkg.GoFiles {
+		if testsOverride && strings.HasSuffix(file, "_test.go") || !cfg.ExcludesFile(file) {
+			return excluded
+		}
+	}
+	excluded = true
+	return excluded
+}
+
+// prune lists the packages matching patterns, without parsing or type
+// checking them, and returns the import paths of those kept, which load the
+// same packages minus the dropped ones.
+func prune(cfg *packages.Config, patterns []string, keep func(*packages.Package) bool) (paths []string, err error) {
+	listCfg := *cfg
+	listCfg.Mode = packages.NeedName | packages.NeedFiles
+
+	var listed []*packages.Package
+	listed, err = packages.Load(&listCfg, patterns...)
+	if err != nil {
+		return paths, err
+	}
+
+	seen := make(map[string]bool)
+	for _, pkg := range listed {
+		// Test variants are loaded along with the package they test
+		pkgPath := testedPath(pkg.PkgPath)
+		if keep(pkg) && pkgPath != "" && !seen[pkgPath] {
+			seen[pkgPath] = true
+			paths = append(paths, pkgPath)
+		}
+	}
+	return paths, err
+}
+
+// testedPath returns the import path of the package a test package with the
+// import path pkgPath tests, or pkgPath itself for other packages.
+func testedPath(pkgPath string) (tested string) {
+	tested = strings.TrimSuffix(pkgPath, ".test")
+	tested = strings.TrimSuffix(tested, "_test")
+	return tested
+}
+
+// matchPath reports whether the import path pkgPath matches pattern, in
+// which "..." matches any string. As for go list, a pattern ending in
+// "/..." also matches the path before it.
+func matchPath(pattern string, pkgPath string) (matched bool) {
+	if base, ok := strings.CutSuffix(pattern, "/..."); ok && pkgPath == base {
+		matched = true
+		return matched
+	}
+
+	parts := strings.Split(pattern, "...")
+	rest := pkgPath
+	for i, part := range parts {
+		switch {
+		case i == 0:
+			if !strings.HasPrefix(rest, part) {
+				return matched
+			}
+			rest = rest[len(part):]
+		case i == len(parts)-1:
+			matched = strings.HasSuffix(rest, part)
+			return matched
+		default:
+			j := strings.Index(rest, part)
+			if j < 0 {
+				return matched
+			}
+			rest = rest[j+len(part):]
+		}
+	}
+	matched = rest == ""
+	return matched
+}
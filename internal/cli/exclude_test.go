@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDirFilter(t *testing.T) {
+	f := newDirFilter("/repo", []string{"./...", "./third_party/vendor/kept", "example.com/other/..."}, []string{"gen", "internal/*/mocks"})
+
+	tests := []struct {
+		dir      string
+		excluded bool
+	}{
+		{"/repo", false},
+		{"/repo/pkg/server", false},
+		{"/repo/vendor/github.com/x/y", true},
+		{"/repo/pkg/testdata/src/p", true},
+		{"/repo/third_party/vendor/kept", false},
+		{"/repo/third_party/vendor/kept/sub", false},
+		{"/repo/third_party/vendor/other", true},
+		{"/repo/gen", true},
+		{"/repo/gen/api", true},
+		{"/repo/pkg/gen", false},
+		{"/repo/internal/store/mocks", true},
+		{"/repo/internal/store", false},
+	}
+	for _, test := range tests {
+		if got := f.excluded(test.dir); got != test.excluded {
+			t.Errorf("excluded(%q) = %v, want %v", test.dir, got, test.excluded)
+		}
+	}
+}
+
+func TestMainExcludeDir(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := Main([]string{"-exclude-dir", "../../testdata/src/default-config", fixture}, nil, &stdout, &stderr)
+	if code != exitOK || stdout.Len() != 0 {
+		t.Errorf("expected the excluded package not to be analyzed, got exit code %d and:\n%s%s", code, stdout.String(), stderr.String())
+	}
+
+	code = Main([]string{"-exclude-dir", "[", fixture}, nil, &stdout, &stderr)
+	if code != exitError {
+		t.Errorf("expected exit code %d for an invalid glob, got %d", exitError, code)
+	}
+}
+
+func TestPathFilter(t *testing.T) {
+	f := pathFilter{
+		include: []string{"example.com/repo/services/...", "example.com/repo/cmd/tool"},
+		exclude: []string{"example.com/repo/services/.../mocks", "example.com/repo/services/legacy/..."},
+	}
+
+	tests := []struct {
+		pkgPath string
+		kept    bool
+	}{
+		{"example.com/repo/services", true},
+		{"example.com/repo/services/billing", true},
+		{"example.com/repo/services/billing_test", true},
+		{"example.com/repo/services/billing.test", true},
+		{"example.com/repo/services/billing/mocks", false},
+		{"example.com/repo/services/legacy", false},
+		{"example.com/repo/services/legacy/store", false},
+		{"example.com/repo/servicesx", false},
+		{"example.com/repo/cmd/tool", true},
+		{"example.com/repo/cmd/tool/sub", false},
+		{"example.com/repo/internal/db", false},
+	}
+	for _, test := range tests {
+		if got := f.kept(test.pkgPath); got != test.kept {
+			t.Errorf("kept(%q) = %v, want %v", test.pkgPath, got, test.kept)
+		}
+	}
+
+	if !(pathFilter{}).kept("example.com/anything") {
+		t.Errorf("expected an empty filter to keep every package")
+	}
+}
+
+func TestMainIncludePath(t *testing.T) {
+	other := "../../testdata/src/suggested-fixes"
+
+	var stdout, stderr bytes.Buffer
+	code := Main([]string{"-no-cache", "-include-path", "github.com/nikogura/namedreturns/testdata/.../default-config", fixture, other}, nil, &stdout, &stderr)
+	if code != exitIssues || !strings.Contains(stdout.String(), "default-config") || strings.Contains(stdout.String(), "suggested-fixes") {
+		t.Errorf("expected only the included package to be analyzed, got exit code %d and:\n%s%s", code, stdout.String(), stderr.String())
+	}
+
+	stdout.Reset()
+	code = Main([]string{"-exclude-path", "github.com/nikogura/namedreturns/testdata/...", fixture, other}, nil, &stdout, &stderr)
+	if code != exitOK || stdout.Len() != 0 {
+		t.Errorf("expected the excluded packages not to be analyzed, got exit code %d and:\n%s%s", code, stdout.String(), stderr.String())
+	}
+
+	code = Main([]string{"-exclude-path", "./testdata", fixture}, nil, &stdout, &stderr)
+	if code != exitError {
+		t.Errorf("expected exit code %d for a directory, got %d", exitError, code)
+	}
+}
+
+func TestMainExcludeFiles(t *testing.T) {
+	// The package must be part of the module to be loaded
+	dir, err := os.MkdirTemp("../../testdata/src", "exclude-files-")
+	if err != nil {
+		t.Fatalf("creating package: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// A package made only of excluded files isn't even type checked
+	for name, content := range map[string]string{
+		".namedreturns.yaml": "exclude-files: [\"**/*.pb.go\"]\n",
+		"api.pb.go":          "package api\n\nfunc broken() int {\n\treturn undefined\n}\n",
+	} {
+		err = os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644)
+		if err != nil {
+			t.Fatalf("writing %s: %s", name, err)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := Main([]string{"-no-cache", dir}, nil, &stdout, &stderr)
+	if code != exitOK || stdout.Len() != 0 {
+		t.Errorf("expected the excluded package not to be analyzed, got exit code %d and:\n%s%s", code, stdout.String(), stderr.String())
+	}
+}
@@ -0,0 +1,194 @@
+package cli
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/nikogura/namedreturns/analyzer"
+	"github.com/nikogura/namedreturns/report"
+)
+
+// analyzeSyntax is the -fast counterpart of analyze: it parses the files of
+// the directories matching opts.patterns and runs the analyzer on them
+// without loading packages or type checking.
+func analyzeSyntax(opts options) (out outcome, err error) {
+	var dirs []string
+	dirs, err = patternDirs(opts.patterns)
+	if err != nil {
+		return out, err
+	}
+
+	var filter dirFilter
+	filter, err = newFilter(opts)
+	if err != nil {
+		return out, err
+	}
+
+	// Directories are parsed and analyzed concurrently, for each platform,
+	// and merged in order
+	fset := token.NewFileSet()
+	a := analyzer.WithContext(opts.ctx, opts.analyzer)
+	var results []packageResult
+	targets := opts.targets()
+	for _, p := range targets {
+		ctx := p.context(opts.tags)
+		dirResults := make([][]packageResult, len(dirs))
+		err = forEach(len(dirs), opts.jobs, func(i int) (dirErr error) {
+			dir := dirs[i]
+			if filter.excluded(dir) {
+				return dirErr
+			}
+			dirErr = opts.ctx.Err()
+			if dirErr != nil {
+				return dirErr
+			}
+
+			var pkgs map[string][]*ast.File
+			pkgs, dirErr = parseDir(fset, &ctx, dir, opts.tests, opts.overlay)
+			if dirErr != nil {
+				return dirErr
+			}
+
+			// A directory holds a package and possibly its external test package
+			names := make([]string, 0, len(pkgs))
+			for name := range pkgs {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			for _, name := range names {
+				diagnostics, result, runErr := analyzer.RunSyntax(a, fset, pkgs[name])
+				if runErr != nil {
+					dirErr = fmt.Errorf("analyzing %s: %w", dir, runErr)
+					return dirErr
+				}
+
+				pkgResult := packageResult{Files: result.Files}
+				for _, d := range diagnostics {
+					pkgResult.Issues = append(pkgResult.Issues, report.FromDiagnostic(fset, filepath.ToSlash(dir), d))
+				}
+				dirResults[i] = append(dirResults[i], pkgResult)
+			}
+			return dirErr
+		})
+		if err != nil {
+			return out, err
+		}
+
+		for _, dirResult := range dirResults {
+			if len(targets) > 1 {
+				noteVariant(dirResult, p)
+			}
+			results = append(results, dirResult...)
+		}
+	}
+
+	out = merge(results, len(targets))
+	return out, err
+}
+
+// patternDirs resolves package patterns to directories. Only directory
+// patterns are supported, optionally ending in "/..." to include the
+// directories below, which skips testdata, vendor and the directories the go
+// tool ignores.
+func patternDirs(patterns []string) (dirs []string, err error) {
+	seen := make(map[string]bool)
+	add := func(dir string) {
+		// Report absolute paths, like a full run does
+		if abs, absErr := filepath.Abs(dir); absErr == nil {
+			dir = abs
+		}
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+
+	for _, pattern := range patterns {
+		root, recursive := strings.CutSuffix(pattern, "/...")
+		if root == "" || root == "." && recursive {
+			root = "."
+		}
+
+		info, statErr := os.Stat(root)
+		if statErr != nil || !info.IsDir() {
+			err = fmt.Errorf("-fast and -list-suppressions take directories, %q is not one", pattern)
+			return dirs, err
+		}
+
+		if !recursive {
+			add(root)
+			continue
+		}
+
+		err = filepath.WalkDir(root, func(path string, d fs.DirEntry, walkErr error) (skip error) {
+			skip = walkErr
+			if skip != nil || !d.IsDir() {
+				return skip
+			}
+
+			name := d.Name()
+			if path != root && (name == "testdata" || name == "vendor" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				skip = filepath.SkipDir
+				return skip
+			}
+
+			add(path)
+			return skip
+		})
+		if err != nil {
+			return dirs, err
+		}
+	}
+	return dirs, err
+}
+
+// parseDir parses the Go files in dir that match the build context ctx, as
+// overlay or else the disk holds them, grouped by package name.
+func parseDir(fset *token.FileSet, ctx *build.Context, dir string, tests bool, overlay map[string][]byte) (pkgs map[string][]*ast.File, err error) {
+	pkgs = make(map[string][]*ast.File)
+
+	var entries []os.DirEntry
+	entries, err = os.ReadDir(dir)
+	if err != nil {
+		return pkgs, err
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") {
+			continue
+		}
+		if !tests && strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+
+		match, matchErr := ctx.MatchFile(dir, name)
+		if matchErr != nil || !match {
+			continue
+		}
+
+		filename := filepath.Join(dir, name)
+		var src []byte
+		src, err = readFile(overlay, filename)
+		if err != nil {
+			return pkgs, err
+		}
+
+		var file *ast.File
+		file, err = parser.ParseFile(fset, filename, src, parser.ParseComments|parser.SkipObjectResolution)
+		if err != nil {
+			return pkgs, err
+		}
+		pkgs[file.Name.Name] = append(pkgs[file.Name.Name], file)
+	}
+	return pkgs, err
+}
@@ -0,0 +1,295 @@
+package cli
+
+import (
+	"fmt"
+	"go/format"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/nikogura/namedreturns/report"
+)
+
+// maxFixRounds bounds how often fix re-analyzes the code to apply the fixes
+// enabled by earlier ones, e.g. naming results and then returning them.
+const maxFixRounds = 4
+
+// fix applies the suggested fixes of the findings, over several rounds, then
+// reports the findings left. Migrating applies only those of the
+// migrationRules and, once they are all applied, turns trailing returns
+// into bare ones if asked to. A dry run keeps the fixed contents in the
+// overlay rather than writing them, and prints their diffs instead of the
+// findings.
+func fix(opts options, formatter report.Formatter, stdin io.Reader, stdout io.Writer, stderr io.Writer) (code int) {
+	rounds := maxFixRounds
+	if opts.diff != "" || opts.dryRun && opts.diffRef != "" {
+		// The lines of a given diff, or those of the files on disk in a dry
+		// run, are only accurate before the first round
+		rounds = 1
+	}
+
+	var out outcome
+	var err error
+	bared := false
+	applied := make(map[string]int) // fixes, by file
+	for round := 0; ; round++ {
+		if opts.fast {
+			out, err = analyzeSyntax(opts)
+		} else {
+			out, err = analyze(opts)
+		}
+		if err == nil {
+			out.issues, err = narrow(opts, out.issues, stdin, stderr)
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "namedreturns: %s\n", err)
+			code = exitError
+			return code
+		}
+		if round == rounds {
+			break
+		}
+
+		fixable := out.issues
+		if opts.migrate {
+			fixable = migrations(out.issues)
+		}
+
+		var contents map[string][]byte
+		var n map[string]int
+		contents, n, err = fixedContents(fixable, opts.overlay)
+		if err == nil && len(n) == 0 && opts.bareReturns && !bared {
+			// The returns fixed in earlier rounds are among those restating
+			// the named results
+			bared = true
+			contents, n, err = bareReturns(out.files, opts.overlay)
+		}
+		if err == nil && !opts.dryRun {
+			err = writeFiles(contents)
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "namedreturns: applying fixes: %s\n", err)
+			code = exitError
+			return code
+		}
+		if len(n) == 0 {
+			break
+		}
+
+		for file, count := range n {
+			applied[file] += count
+		}
+		if opts.dryRun {
+			opts.overlay = withContents(opts.overlay, contents)
+		}
+	}
+
+	total := 0
+	for _, count := range applied {
+		total += count
+	}
+
+	if opts.dryRun {
+		err = writeDiffs(stdout, stderr, opts.overlay, applied)
+		if err != nil {
+			fmt.Fprintf(stderr, "namedreturns: writing diffs: %s\n", err)
+			code = exitError
+			return code
+		}
+		fmt.Fprintf(stderr, "namedreturns: would apply %d fixes to %d files\n", total, len(applied))
+	} else {
+		fmt.Fprintf(stderr, "namedreturns: applied %d fixes to %d files\n", total, len(applied))
+
+		err = formatter(stdout, out.issues)
+		if err != nil {
+			fmt.Fprintf(stderr, "namedreturns: writing output: %s\n", err)
+			code = exitError
+			return code
+		}
+	}
+
+	code = exitOK
+	if opts.policy.fails(out.issues) {
+		code = exitIssues
+	}
+	return code
+}
+
+// fixedContents applies the first suggested fix of every issue to the files,
+// as overlay or else the disk holds them, and returns the new contents of
+// the changed files, formatted, along with the number of fixes applied to
+// each. Edits repeated by several issues are
+// applied once, and a fix conflicting with one already accepted is skipped;
+// another round will find it again if it is still needed.
+func fixedContents(issues []report.Issue, overlay map[string][]byte) (contents map[string][]byte, applied map[string]int, err error) {
+	accepted := make(map[string][]report.Edit)
+	applied = make(map[string]int)
+
+	for _, issue := range issues {
+		if len(issue.Fixes) == 0 {
+			continue
+		}
+
+		var added []report.Edit
+		conflict := false
+		for _, edit := range issue.Fixes[0].Edits {
+			duplicate := false
+			for _, other := range accepted[edit.File] {
+				if edit == other {
+					duplicate = true
+					break
+				}
+				if overlaps(edit, other) {
+					conflict = true
+					break
+				}
+			}
+			if conflict {
+				break
+			}
+			if !duplicate {
+				added = append(added, edit)
+			}
+		}
+		if conflict {
+			continue
+		}
+
+		fixed := make(map[string]bool)
+		for _, edit := range added {
+			accepted[edit.File] = append(accepted[edit.File], edit)
+			fixed[edit.File] = true
+		}
+		for file := range fixed {
+			applied[file]++
+		}
+	}
+
+	contents = make(map[string][]byte, len(accepted))
+	for file, edits := range accepted {
+		var content []byte
+		content, err = readFile(overlay, file)
+		if err != nil {
+			return contents, applied, err
+		}
+
+		content, err = applyEdits(content, edits)
+		if err != nil {
+			err = fmt.Errorf("%s: %w", file, err)
+			return contents, applied, err
+		}
+		contents[file] = content
+	}
+	return contents, applied, err
+}
+
+// overlaps reports whether two edits of the same file touch the same text,
+// or insert text at the same place, so applying both is ambiguous.
+func overlaps(a report.Edit, b report.Edit) (overlap bool) {
+	aStart, aEnd := a.Start.Offset, a.End.Offset
+	bStart, bEnd := b.Start.Offset, b.End.Offset
+	overlap = aStart < bEnd && bStart < aEnd || aStart == bStart && (aStart == aEnd || bStart == bEnd)
+	return overlap
+}
+
+// applyEdits applies non-overlapping edits to content and formats the result.
+func applyEdits(content []byte, edits []report.Edit) (fixed []byte, err error) {
+	sorted := append([]report.Edit(nil), edits...)
+	sort.Slice(sorted, func(i, j int) (less bool) {
+		less = sorted[i].Start.Offset > sorted[j].Start.Offset
+		return less
+	})
+
+	fixed = append([]byte(nil), content...)
+	for _, edit := range sorted {
+		if edit.End.Offset > len(fixed) || edit.Start.Offset > edit.End.Offset {
+			err = fmt.Errorf("edit at offset %d is out of range, was the file changed?", edit.Start.Offset)
+			return fixed, err
+		}
+		fixed = append(fixed[:edit.Start.Offset], append([]byte(edit.NewText), fixed[edit.End.Offset:]...)...)
+	}
+
+	fixed, err = format.Source(fixed)
+	return fixed, err
+}
+
+// writeFiles replaces the content of files, keeping their permissions.
+func writeFiles(contents map[string][]byte) (err error) {
+	for file, content := range contents {
+		var info os.FileInfo
+		info, err = os.Stat(file)
+		if err != nil {
+			return err
+		}
+
+		err = os.WriteFile(file, content, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// readFile returns the content of file, from overlay when it holds it.
+func readFile(overlay map[string][]byte, file string) (content []byte, err error) {
+	if abs, absErr := filepath.Abs(file); absErr == nil {
+		if overlaid, ok := overlay[abs]; ok {
+			content = overlaid
+			return content, err
+		}
+	}
+
+	content, err = os.ReadFile(file)
+	return content, err
+}
+
+// withContents returns overlay extended with contents, by absolute path as
+// package loading requires.
+func withContents(overlay map[string][]byte, contents map[string][]byte) (extended map[string][]byte) {
+	extended = make(map[string][]byte, len(overlay)+len(contents))
+	for file, content := range overlay {
+		extended[file] = content
+	}
+	for file, content := range contents {
+		if abs, err := filepath.Abs(file); err == nil {
+			file = abs
+		}
+		extended[file] = content
+	}
+	return extended
+}
+
+// writeDiffs writes the diffs of the files fixed in overlay, named relative
+// to the working directory when below it, to stdout, and the number of
+// fixes applied to each to stderr.
+func writeDiffs(stdout io.Writer, stderr io.Writer, overlay map[string][]byte, applied map[string]int) (err error) {
+	files := make([]string, 0, len(applied))
+	for file := range applied {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	wd, _ := os.Getwd()
+	for _, file := range files {
+		var before, after []byte
+		before, err = os.ReadFile(file)
+		if err == nil {
+			after, err = readFile(overlay, file)
+		}
+		if err != nil {
+			return err
+		}
+
+		name := file
+		if rel, relErr := filepath.Rel(wd, file); relErr == nil && filepath.IsLocal(rel) {
+			name = rel
+		}
+		_, err = io.WriteString(stdout, report.Diff(filepath.ToSlash(name), before, after))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stderr, "namedreturns: %s: %d fixes\n", name, applied[file])
+	}
+	return err
+}
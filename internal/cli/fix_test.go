@@ -0,0 +1,208 @@
+package cli
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nikogura/namedreturns/report"
+)
+
+func TestMainFix(t *testing.T) {
+	// The fixed package must be part of the module to be loaded
+	dir, err := os.MkdirTemp("../../testdata/src", "fix-")
+	if err != nil {
+		t.Fatalf("creating package: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	source, err := os.ReadFile("../../testdata/src/suggested-fixes/fixes.go")
+	if err != nil {
+		t.Fatalf("reading fixture: %s", err)
+	}
+	file := filepath.Join(dir, "fixes.go")
+	err = os.WriteFile(file, source, 0o644)
+	if err != nil {
+		t.Fatalf("writing fixture: %s", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := Main([]string{"fix", dir}, nil, &stdout, &stderr)
+	if code != exitOK {
+		t.Fatalf("expected exit code %d, got %d (stdout: %s, stderr: %s)", exitOK, code, stdout.String(), stderr.String())
+	}
+
+	fixed, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("reading fixed file: %s", err)
+	}
+	for _, expected := range []string{
+		"func unnamed() (n int, err error) {",
+		"\tn, err = 42, nil\n\treturn n, err\n",
+		"func underscores() (n, n2 int) {",
+	} {
+		if !strings.Contains(string(fixed), expected) {
+			t.Errorf("expected the fixed file to contain:\n%s\ngot:\n%s", expected, fixed)
+		}
+	}
+}
+
+func TestMainFixDryRun(t *testing.T) {
+	// The fixed package must be part of the module to be loaded
+	dir, err := os.MkdirTemp("../../testdata/src", "dry-run-")
+	if err != nil {
+		t.Fatalf("creating package: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	source, err := os.ReadFile("../../testdata/src/suggested-fixes/fixes.go")
+	if err != nil {
+		t.Fatalf("reading fixture: %s", err)
+	}
+	file := filepath.Join(dir, "fixes.go")
+	err = os.WriteFile(file, source, 0o644)
+	if err != nil {
+		t.Fatalf("writing fixture: %s", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := Main([]string{"fix", "-dry-run", dir}, nil, &stdout, &stderr)
+	if code != exitOK {
+		t.Fatalf("expected exit code %d, got %d (stdout: %s, stderr: %s)", exitOK, code, stdout.String(), stderr.String())
+	}
+
+	unchanged, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("reading file: %s", err)
+	}
+	if !bytes.Equal(unchanged, source) {
+		t.Errorf("expected the file to be left alone, got:\n%s", unchanged)
+	}
+
+	// The fixes of all rounds are in the diff
+	for _, expected := range []string{
+		"-func unnamed() (int, error) {",
+		"+func unnamed() (n int, err error) {",
+		"+\tn, err = 42, nil\n+\treturn n, err\n",
+	} {
+		if !strings.Contains(stdout.String(), expected) {
+			t.Errorf("expected the diff to contain:\n%s\ngot:\n%s", expected, stdout.String())
+		}
+	}
+	if !strings.Contains(stderr.String(), "fixes.go: ") || !strings.Contains(stderr.String(), "would apply") {
+		t.Errorf("expected the number of fixes per file and in total, got:\n%s", stderr.String())
+	}
+}
+
+func TestMainMigrate(t *testing.T) {
+	// The migrated package must be part of the module to be loaded
+	dir, err := os.MkdirTemp("../../testdata/src", "migrate-")
+	if err != nil {
+		t.Fatalf("creating package: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	source, err := os.ReadFile("../../testdata/src/suggested-fixes/fixes.go")
+	if err != nil {
+		t.Fatalf("reading fixture: %s", err)
+	}
+	file := filepath.Join(dir, "fixes.go")
+	err = os.WriteFile(file, source, 0o644)
+	if err != nil {
+		t.Fatalf("writing fixture: %s", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := Main([]string{"migrate", "-bare-returns", dir}, nil, &stdout, &stderr)
+	if code != exitIssues {
+		t.Fatalf("expected exit code %d for the shadowing left, got %d (stdout: %s, stderr: %s)", exitIssues, code, stdout.String(), stderr.String())
+	}
+
+	migrated, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("reading migrated file: %s", err)
+	}
+	for _, expected := range []string{
+		"func unnamed() (n int, err error) {",
+		"\tn, err = 42, nil\n\treturn\n}\n\nfunc single() (config *Config) {",
+		"func underscores() (n, n2 int) {",
+		"func withComments() ( /* count */ n int, // want",
+		"\t\tresult, err = 1, errors.New(\"positive\")\n\t\treturn result, err\n\t}\n\tresult = 0\n\treturn\n}",
+		// Shadowing is left alone
+		"if value, err := strconv.Atoi(s); err != nil {",
+		"for result := 0; result < 3; result++ {",
+	} {
+		if !strings.Contains(string(migrated), expected) {
+			t.Errorf("expected the migrated file to contain:\n%s\ngot:\n%s", expected, migrated)
+		}
+	}
+	if !strings.Contains(stdout.String(), "NR004") {
+		t.Errorf("expected the shadowing findings to be reported, got:\n%s", stdout.String())
+	}
+}
+
+func TestRestatedReturn(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		bare   bool
+	}{
+		{name: "restated", source: "func f() (n int, err error) { n = 1; return n, err }", bare: true},
+		{name: "reordered", source: "func f() (a, b int) { return b, a }"},
+		{name: "expression", source: "func f() (n int, err error) { return n + 1, err }"},
+		{name: "unnamed", source: "func f() (int, error) { return 0, nil }"},
+		{name: "bare", source: "func f() (n int) { return }"},
+		{name: "not last", source: "func f() (n int) { if n > 0 { return n }; n++; return n }", bare: true},
+		{name: "underscore", source: "func f() (_ int) { return _ }"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			file, err := parser.ParseFile(token.NewFileSet(), "a.go", "package a\n"+tc.source, 0)
+			if err != nil {
+				t.Fatalf("parsing: %s", err)
+			}
+			decl := file.Decls[0].(*ast.FuncDecl)
+
+			_, bare := restatedReturn(decl.Type, decl.Body)
+			if bare != tc.bare {
+				t.Errorf("expected %t, got %t", tc.bare, bare)
+			}
+		})
+	}
+}
+
+func TestFixedContentsConflicts(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "a.go")
+	err := os.WriteFile(file, []byte("package a\n\nvar x = 1\n"), 0o644)
+	if err != nil {
+		t.Fatalf("writing file: %s", err)
+	}
+
+	edit := func(start int, end int, text string) (fix report.Fix) {
+		fix = report.Fix{Edits: []report.Edit{{File: file, Start: report.Position{Offset: start}, End: report.Position{Offset: end}, NewText: text}}}
+		return fix
+	}
+	issues := []report.Issue{
+		{Fixes: []report.Fix{edit(15, 16, "y")}},
+		{Fixes: []report.Fix{edit(15, 16, "y")}},     // same edit, applied once
+		{Fixes: []report.Fix{edit(15, 20, "z = 2")}}, // conflicting, skipped
+		{Fixes: []report.Fix{edit(19, 20, "3")}},
+	}
+
+	contents, applied, err := fixedContents(issues, nil)
+	if err != nil {
+		t.Fatalf("applying fixes: %s", err)
+	}
+	if applied[file] != 2 {
+		t.Errorf("expected 2 fixes applied, got %d", applied[file])
+	}
+	if got := string(contents[file]); got != "package a\n\nvar y = 3\n" {
+		t.Errorf("unexpected fixed content %q", got)
+	}
+}
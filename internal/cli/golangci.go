@@ -0,0 +1,370 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/nikogura/namedreturns/analyzer"
+	"gopkg.in/yaml.v3"
+)
+
+// golangciLinters are the names the linter goes by in golangci-lint
+// configurations: its own, as a module plugin, and that of the linter it
+// derives from, whose settings it shares.
+var golangciLinters = []string{"namedreturns", "nonamedreturns"}
+
+// golangciConfig holds the parts of a golangci-lint configuration, of
+// version 1 or 2, that translate into namedreturns settings.
+type golangciConfig struct {
+	// Version 1
+	LintersSettings golangciSettings `yaml:"linters-settings"`
+	Issues          struct {
+		ExcludeDirs  []string       `yaml:"exclude-dirs"`
+		ExcludeFiles []string       `yaml:"exclude-files"`
+		ExcludeRules []golangciRule `yaml:"exclude-rules"`
+	} `yaml:"issues"`
+
+	// Version 2
+	Linters struct {
+		Settings   golangciSettings `yaml:"settings"`
+		Exclusions struct {
+			Paths []string       `yaml:"paths"`
+			Rules []golangciRule `yaml:"rules"`
+		} `yaml:"exclusions"`
+	} `yaml:"linters"`
+}
+
+type golangciSettings struct {
+	Nonamedreturns map[string]interface{} `yaml:"nonamedreturns"`
+	Custom         map[string]struct {
+		Settings map[string]interface{} `yaml:"settings"`
+	} `yaml:"custom"`
+}
+
+type golangciRule struct {
+	Path       string   `yaml:"path"`
+	PathExcept string   `yaml:"path-except"`
+	Linters    []string `yaml:"linters"`
+	Text       string   `yaml:"text"`
+	Source     string   `yaml:"source"`
+}
+
+// importGolangci translates the settings and exclusions of the namedreturns
+// and nonamedreturns linters in a golangci-lint configuration into a
+// namedreturns configuration file, warning about what doesn't translate.
+func importGolangci(args []string, stdout io.Writer, stderr io.Writer) (code int) {
+	var output string
+	var force bool
+	fs := flag.NewFlagSet("namedreturns config import-golangci", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&output, "output", configFileName, "file written, \"-\" for stdout")
+	fs.BoolVar(&force, "force", false, "overwrite the file if it exists")
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Translates the namedreturns settings and exclusions of a golangci-lint configuration.\n\nUsage: namedreturns config import-golangci [flags] [.golangci.yml]\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+
+	err := fs.Parse(args)
+	if errors.Is(err, flag.ErrHelp) {
+		code = exitOK
+		return code
+	}
+	if err == nil && fs.NArg() > 1 {
+		err = errors.New("config import-golangci takes at most one file")
+	}
+
+	path := ".golangci.yml"
+	if fs.NArg() == 1 {
+		path = fs.Arg(0)
+	}
+
+	var data []byte
+	if err == nil {
+		var warnings []string
+		data, warnings, err = translateGolangci(path)
+		for _, warning := range warnings {
+			fmt.Fprintf(stderr, "namedreturns: %s: %s\n", path, warning)
+		}
+	}
+
+	if err == nil {
+		switch {
+		case output == "-":
+			_, err = stdout.Write(data)
+		case force:
+			err = os.WriteFile(output, data, 0o644)
+		default:
+			err = writeNewFile(output, data)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "namedreturns: %s\n", err)
+		code = exitError
+		return code
+	}
+
+	if output != "-" {
+		fmt.Fprintf(stderr, "namedreturns: wrote %s\n", output)
+	}
+	code = exitOK
+	return code
+}
+
+// translateGolangci returns the namedreturns configuration file equivalent
+// to the golangci-lint configuration at path, along with warnings about the
+// parts that have no equivalent.
+func translateGolangci(path string) (data []byte, warnings []string, err error) {
+	data, err = os.ReadFile(path)
+	if err != nil {
+		return data, warnings, err
+	}
+
+	var gc golangciConfig
+	err = yaml.Unmarshal(data, &gc)
+	if err != nil {
+		err = fmt.Errorf("parsing %s: %w", path, err)
+		return data, warnings, err
+	}
+
+	settings := make(map[string]interface{})
+
+	// Settings given in a configuration file of the linter are taken
+	// over, and overridden by those given inline, as the plugin does
+	for _, s := range []golangciSettings{gc.LintersSettings, gc.Linters.Settings} {
+		for name, value := range s.Nonamedreturns {
+			settings[name] = value
+		}
+
+		inline := s.Custom["namedreturns"].Settings
+		if file, ok := inline[analyzer.FlagConfig].(string); ok {
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(filepath.Dir(path), file)
+			}
+			err = mergeConfigFile(settings, file)
+			if err != nil {
+				return data, warnings, err
+			}
+		}
+		for name, value := range inline {
+			if name != analyzer.FlagConfig {
+				settings[name] = value
+			}
+		}
+	}
+
+	var excludes []string
+	var disable, testsDisable []string
+	exclude := func(pattern string, dir bool) {
+		glob, ok := regexpGlob(pattern, dir)
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf("path %q has no glob equivalent and is left out", pattern))
+			return
+		}
+		excludes = append(excludes, glob)
+	}
+
+	for _, dir := range gc.Issues.ExcludeDirs {
+		exclude(dir, true)
+	}
+	for _, file := range append(gc.Issues.ExcludeFiles, gc.Linters.Exclusions.Paths...) {
+		exclude(file, false)
+	}
+
+	for _, rule := range append(gc.Issues.ExcludeRules, gc.Linters.Exclusions.Rules...) {
+		if len(rule.Linters) > 0 && !slices.ContainsFunc(rule.Linters, func(linter string) (found bool) {
+			found = slices.Contains(golangciLinters, linter)
+			return found
+		}) {
+			continue
+		}
+
+		ruleID, byRule := textRule(rule.Text)
+		ours := len(rule.Linters) > 0
+		switch {
+		case !ours && (rule.Text != "" && !byRule || rule.Source != ""):
+			// Rules of all linters matching messages are about the others
+			continue
+		case rule.PathExcept != "" || rule.Source != "" || rule.Text != "" && !byRule:
+			warnings = append(warnings, fmt.Sprintf("exclusion rule %s has no equivalent and is left out", describeRule(rule)))
+		case byRule && rule.Path == "":
+			disable = append(disable, ruleID)
+		case byRule && isTestPath(rule.Path):
+			testsDisable = append(testsDisable, ruleID)
+		case byRule:
+			warnings = append(warnings, fmt.Sprintf("exclusion rule %s has no equivalent and is left out: rules can only be disabled everywhere or in tests", describeRule(rule)))
+		case rule.Path != "":
+			exclude(rule.Path, false)
+		}
+	}
+
+	if len(excludes) > 0 {
+		settings[analyzer.FlagExcludeFiles] = appendSetting(settings[analyzer.FlagExcludeFiles], excludes)
+	}
+	if len(disable) > 0 {
+		settings[analyzer.FlagDisable] = appendSetting(settings[analyzer.FlagDisable], disable)
+	}
+	if len(testsDisable) > 0 {
+		tests, _ := settings["tests"].(map[string]interface{})
+		if tests == nil {
+			tests = make(map[string]interface{})
+		}
+		tests[analyzer.FlagDisable] = appendSetting(tests[analyzer.FlagDisable], testsDisable)
+		settings["tests"] = tests
+	}
+
+	// The settings are checked as the analyzer would load them
+	data, err = json.Marshal(settings)
+	if err == nil {
+		var probe analyzer.Config
+		err = analyzer.ParseConfigJSON(data, &probe)
+	}
+	if err != nil {
+		err = fmt.Errorf("translating %s: %w", path, err)
+		return data, warnings, err
+	}
+
+	data, err = yaml.Marshal(settings)
+	if err != nil {
+		return data, warnings, err
+	}
+
+	if len(settings) == 0 {
+		data = nil
+		warnings = append(warnings, "no settings or exclusions of namedreturns or nonamedreturns found")
+	}
+	data = append([]byte(fmt.Sprintf("# Imported from %s\n", filepath.Base(path))), data...)
+	return data, warnings, err
+}
+
+// mergeConfigFile adds the settings of the namedreturns configuration file
+// at path to settings.
+func mergeConfigFile(settings map[string]interface{}, path string) (err error) {
+	var data []byte
+	data, err = os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var values map[string]interface{}
+	err = yaml.Unmarshal(data, &values)
+	if err != nil {
+		err = fmt.Errorf("parsing %s: %w", path, err)
+		return err
+	}
+	for name, value := range values {
+		settings[name] = value
+	}
+	return err
+}
+
+// appendSetting appends items to the list setting value, given as a list or
+// as a comma separated string.
+func appendSetting(value interface{}, items []string) (list []string) {
+	switch v := value.(type) {
+	case string:
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			list = append(list, fmt.Sprint(item))
+		}
+	}
+	for _, item := range items {
+		if !slices.Contains(list, item) {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// textRule returns the rule a text pattern of an exclusion rule matches
+// the findings of, when it is the ID or name of a rule, possibly anchored.
+func textRule(text string) (id string, ok bool) {
+	text = strings.TrimSuffix(strings.TrimPrefix(text, "^"), "$")
+	if rule, found := analyzer.LookupRule(text); found {
+		id = rule.ID
+		ok = true
+	}
+	return id, ok
+}
+
+// isTestPath reports whether pattern is one of the usual path patterns of
+// exclusion rules matching test files.
+func isTestPath(pattern string) (test bool) {
+	test = slices.Contains([]string{`_test\.go`, `_test\.go$`, `_test.go`, `(.+)_test\.go`}, pattern)
+	return test
+}
+
+// describeRule renders an exclusion rule for warnings.
+func describeRule(rule golangciRule) (s string) {
+	var fields []string
+	for _, field := range [][2]string{{"path", rule.Path}, {"path-except", rule.PathExcept}, {"text", rule.Text}, {"source", rule.Source}} {
+		if field[1] != "" {
+			fields = append(fields, fmt.Sprintf("%s=%q", field[0], field[1]))
+		}
+	}
+	s = "{" + strings.Join(fields, " ") + "}"
+	return s
+}
+
+// regexpGlob translates a golangci-lint path pattern, a regular expression
+// matched against paths relative to the configuration, into an
+// exclude-files glob, when it uses no more than anchors, escaped dots and
+// .* wildcards. Patterns of directories match the files below them.
+func regexpGlob(pattern string, dir bool) (glob string, ok bool) {
+	rest := strings.TrimPrefix(pattern, "^")
+	anchoredStart := len(rest) < len(pattern)
+	anchoredEnd := strings.HasSuffix(rest, "$") && !strings.HasSuffix(rest, `\$`)
+	rest = strings.TrimSuffix(rest, "$")
+
+	var b strings.Builder
+	for rest != "" {
+		translated := false
+		for _, token := range [][2]string{{`\.`, "."}, {`\/`, "/"}, {`\-`, "-"}, {".*", "*"}, {"[^/]*", "*"}} {
+			if strings.HasPrefix(rest, token[0]) {
+				b.WriteString(token[1])
+				rest = rest[len(token[0]):]
+				translated = true
+				break
+			}
+		}
+		if translated {
+			continue
+		}
+		if strings.ContainsRune(`\.+?*()|[]{}^$`, rune(rest[0])) {
+			return glob, ok
+		}
+		b.WriteByte(rest[0])
+		rest = rest[1:]
+	}
+
+	glob = b.String()
+	if glob == "" {
+		return glob, ok
+	}
+
+	// Unanchored patterns match anywhere in the path: those of names
+	// match the end of any file name, the others below any directory
+	if !anchoredStart && !strings.HasPrefix(glob, "*") {
+		if dir || strings.Contains(strings.TrimSuffix(glob, "/"), "/") {
+			glob = "**/" + glob
+		} else {
+			glob = "**/*" + glob
+		}
+	}
+	if dir || strings.HasSuffix(glob, "/") || !anchoredEnd && !strings.HasSuffix(glob, ".go") {
+		glob = strings.TrimSuffix(glob, "/") + "/**"
+	}
+	ok = true
+	return glob, ok
+}
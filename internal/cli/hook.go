@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// hookPackages maps the files a pre-commit hook was given to the directories
+// of their packages, which become the patterns to analyze, and to the set of
+// files to report on. Files that don't exist, as deleted files or the old
+// names of renamed ones, and files other than Go sources are skipped.
+func hookPackages(files []string) (dirs []string, reported map[string]bool, err error) {
+	reported = make(map[string]bool)
+	seen := make(map[string]bool)
+
+	for _, file := range files {
+		if !strings.HasSuffix(file, ".go") {
+			continue
+		}
+
+		info, statErr := os.Stat(file)
+		if statErr != nil || info.IsDir() {
+			continue
+		}
+
+		var abs string
+		abs, err = filepath.Abs(file)
+		if err != nil {
+			return dirs, reported, err
+		}
+		reported[abs] = true
+
+		dir := filepath.Dir(abs)
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+
+	sort.Strings(dirs)
+	return dirs, reported, err
+}
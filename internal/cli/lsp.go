@@ -0,0 +1,482 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/nikogura/namedreturns/analyzer"
+	"github.com/nikogura/namedreturns/report"
+)
+
+// JSON-RPC error codes used by the language server.
+const (
+	lspMethodNotFound = -32601
+	lspInvalidParams  = -32602
+	lspRequestFailed  = -32803
+)
+
+// lspSeverities maps the severities of rules to those of LSP diagnostics.
+var lspSeverities = map[string]int{
+	analyzer.SeverityError:   1,
+	analyzer.SeverityWarning: 2,
+	analyzer.SeverityInfo:    3,
+}
+
+// lspMessage is a JSON-RPC message received from the client, a request when
+// it has an ID and a notification otherwise.
+type lspMessage struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// lspResponse answers a request, with either a result or an error.
+type lspResponse struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      json.RawMessage  `json:"id"`
+	Result  *json.RawMessage `json:"result,omitempty"`
+	Error   *lspError        `json:"error,omitempty"`
+}
+
+type lspError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// lspNotification is a JSON-RPC notification sent to the client.
+type lspNotification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"` // in UTF-16 code units
+}
+
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+type lspLocation struct {
+	URI   string   `json:"uri"`
+	Range lspRange `json:"range"`
+}
+
+type lspDiagnostic struct {
+	Range              lspRange        `json:"range"`
+	Severity           int             `json:"severity,omitempty"`
+	Code               string          `json:"code"`
+	Source             string          `json:"source"`
+	Message            string          `json:"message"`
+	RelatedInformation []lspRelatedInf `json:"relatedInformation,omitempty"`
+}
+
+type lspRelatedInf struct {
+	Location lspLocation `json:"location"`
+	Message  string      `json:"message"`
+}
+
+type lspTextEdit struct {
+	Range   lspRange `json:"range"`
+	NewText string   `json:"newText"`
+}
+
+type lspCodeAction struct {
+	Title       string          `json:"title"`
+	Kind        string          `json:"kind"`
+	Diagnostics []lspDiagnostic `json:"diagnostics"`
+	Edit        struct {
+		Changes map[string][]lspTextEdit `json:"changes"`
+	} `json:"edit"`
+}
+
+// lspDocument is a document the client opened.
+type lspDocument struct {
+	uri     string
+	content []byte
+	issues  []report.Issue // found in the content
+}
+
+// lspServer serves the findings of the analyzer, and their fixes, to an
+// editor over the language server protocol, for the documents it opens.
+type lspServer struct {
+	opts     options
+	out      io.Writer
+	stderr   io.Writer
+	docs     map[string]*lspDocument // by absolute path
+	shutdown bool                    // requested by the client, which may now exit
+}
+
+// serveLSP runs a language server reading requests from in and writing
+// responses to out until the client exits, and returns the exit code the
+// protocol asks for.
+func serveLSP(opts options, in io.Reader, out io.Writer, stderr io.Writer) (code int) {
+	s := &lspServer{opts: opts, out: out, stderr: stderr, docs: make(map[string]*lspDocument)}
+	r := bufio.NewReader(in)
+	for {
+		msg, err := readLSPMessage(r)
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				fmt.Fprintf(stderr, "namedreturns: lsp: %s\n", err)
+			}
+			code = exitError
+			return code
+		}
+
+		if msg.Method == "exit" {
+			code = exitError
+			if s.shutdown {
+				code = exitOK
+			}
+			return code
+		}
+
+		result, rpcErr := s.handle(msg)
+		if msg.ID == nil {
+			// Notifications get no response
+			continue
+		}
+
+		response := lspResponse{JSONRPC: "2.0", ID: msg.ID, Error: rpcErr}
+		if rpcErr == nil {
+			var data []byte
+			data, err = json.Marshal(result)
+			if err != nil {
+				response.Error = &lspError{Code: lspRequestFailed, Message: err.Error()}
+			} else {
+				raw := json.RawMessage(data)
+				response.Result = &raw
+			}
+		}
+		err = writeLSPMessage(s.out, response)
+		if err != nil {
+			fmt.Fprintf(stderr, "namedreturns: lsp: %s\n", err)
+			code = exitError
+			return code
+		}
+	}
+}
+
+// handle handles a request or notification, returning the result of
+// requests.
+func (s *lspServer) handle(msg lspMessage) (result interface{}, rpcErr *lspError) {
+	var params struct {
+		TextDocument struct {
+			URI  string `json:"uri"`
+			Text string `json:"text"`
+		} `json:"textDocument"`
+		ContentChanges []struct {
+			Text string `json:"text"`
+		} `json:"contentChanges"`
+		Range lspRange `json:"range"`
+	}
+	if len(msg.Params) > 0 {
+		err := json.Unmarshal(msg.Params, &params)
+		if err != nil {
+			rpcErr = &lspError{Code: lspInvalidParams, Message: err.Error()}
+			return result, rpcErr
+		}
+	}
+	path, pathErr := uriPath(params.TextDocument.URI)
+
+	switch msg.Method {
+	case "initialize":
+		result = map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				// Whole documents are synchronized, as packages are
+				// analyzed as a whole anyway
+				"textDocumentSync": map[string]interface{}{
+					"openClose": true,
+					"change":    1,
+					"save":      map[string]bool{"includeText": false},
+				},
+				"codeActionProvider": map[string]interface{}{"codeActionKinds": []string{"quickfix"}},
+			},
+			"serverInfo": map[string]string{"name": "namedreturns"},
+		}
+	case "shutdown":
+		s.shutdown = true
+	case "textDocument/didOpen":
+		if pathErr == nil {
+			s.docs[path] = &lspDocument{uri: params.TextDocument.URI, content: []byte(params.TextDocument.Text)}
+			s.check(path)
+		}
+	case "textDocument/didChange":
+		doc, ok := s.docs[path]
+		if ok && len(params.ContentChanges) > 0 {
+			doc.content = []byte(params.ContentChanges[len(params.ContentChanges)-1].Text)
+			s.check(path)
+		}
+	case "textDocument/didSave":
+		if _, ok := s.docs[path]; ok {
+			s.check(path)
+		}
+	case "textDocument/didClose":
+		if doc, ok := s.docs[path]; ok {
+			delete(s.docs, path)
+			s.publish(doc.uri, []lspDiagnostic{})
+		}
+	case "textDocument/codeAction":
+		actions := []lspCodeAction{}
+		if doc, ok := s.docs[path]; ok {
+			actions = s.codeActions(doc, params.Range)
+		}
+		result = actions
+	default:
+		if msg.ID != nil {
+			rpcErr = &lspError{Code: lspMethodNotFound, Message: fmt.Sprintf("method %q not supported", msg.Method)}
+		}
+	}
+	return result, rpcErr
+}
+
+// check analyzes the package of the document at path and publishes the
+// findings in it. Documents with unsaved changes are analyzed as the editor
+// holds them, which bypasses the cache of results.
+func (s *lspServer) check(path string) {
+	doc := s.docs[path]
+
+	opts := s.opts
+	opts.patterns = []string{"file=" + path}
+	opts.files = map[string]bool{path: true}
+	opts.overlay = nil
+	for docPath, d := range s.docs {
+		if onDisk, err := os.ReadFile(docPath); err != nil || !bytes.Equal(onDisk, d.content) {
+			if opts.overlay == nil {
+				opts.overlay = make(map[string][]byte)
+			}
+			opts.overlay[docPath] = d.content
+		}
+	}
+
+	out, err := analyze(opts)
+	if err != nil {
+		fmt.Fprintf(s.stderr, "namedreturns: lsp: %s\n", err)
+		return
+	}
+
+	doc.issues, err = narrow(opts, out.issues, nil, s.stderr)
+	if err != nil {
+		fmt.Fprintf(s.stderr, "namedreturns: lsp: %s\n", err)
+		return
+	}
+
+	diagnostics := make([]lspDiagnostic, 0, len(doc.issues))
+	for _, issue := range doc.issues {
+		diagnostics = append(diagnostics, s.diagnostic(doc, issue))
+	}
+	s.publish(doc.uri, diagnostics)
+}
+
+// publish sends the diagnostics of the document at uri to the client.
+func (s *lspServer) publish(uri string, diagnostics []lspDiagnostic) {
+	err := writeLSPMessage(s.out, lspNotification{
+		JSONRPC: "2.0",
+		Method:  "textDocument/publishDiagnostics",
+		Params:  map[string]interface{}{"uri": uri, "diagnostics": diagnostics},
+	})
+	if err != nil {
+		fmt.Fprintf(s.stderr, "namedreturns: lsp: %s\n", err)
+	}
+}
+
+// diagnostic converts an issue found in doc.
+func (s *lspServer) diagnostic(doc *lspDocument, issue report.Issue) (d lspDiagnostic) {
+	start := lspPositionAt(doc.content, issue.Line, issue.Column)
+	end := start
+	if issue.EndLine > 0 {
+		end = lspPositionAt(doc.content, issue.EndLine, issue.EndColumn)
+	}
+
+	d = lspDiagnostic{
+		Range:    lspRange{Start: start, End: end},
+		Severity: lspSeverities[issue.Severity],
+		Code:     issue.Rule,
+		Source:   "namedreturns",
+		Message:  issue.Message,
+	}
+	for _, related := range issue.Related {
+		content := s.content(related.File)
+		pos := lspPositionAt(content, related.Line, related.Column)
+		d.RelatedInformation = append(d.RelatedInformation, lspRelatedInf{
+			Location: lspLocation{URI: s.uri(related.File), Range: lspRange{Start: pos, End: pos}},
+			Message:  related.Message,
+		})
+	}
+	return d
+}
+
+// codeActions returns the fixes of the findings in doc overlapping r.
+func (s *lspServer) codeActions(doc *lspDocument, r lspRange) (actions []lspCodeAction) {
+	actions = []lspCodeAction{}
+	for _, issue := range doc.issues {
+		d := s.diagnostic(doc, issue)
+		if lspBefore(d.Range.End, r.Start) || lspBefore(r.End, d.Range.Start) {
+			continue
+		}
+
+		for _, fix := range issue.Fixes {
+			action := lspCodeAction{Title: fix.Message, Kind: "quickfix", Diagnostics: []lspDiagnostic{d}}
+			action.Edit.Changes = make(map[string][]lspTextEdit)
+			for _, edit := range fix.Edits {
+				content := s.content(edit.File)
+				uri := s.uri(edit.File)
+				action.Edit.Changes[uri] = append(action.Edit.Changes[uri], lspTextEdit{
+					Range:   lspRange{Start: lspPositionOf(content, edit.Start.Offset), End: lspPositionOf(content, edit.End.Offset)},
+					NewText: edit.NewText,
+				})
+			}
+			actions = append(actions, action)
+		}
+	}
+	return actions
+}
+
+// content returns the content of file, as the editor holds it when open.
+func (s *lspServer) content(file string) (content []byte) {
+	if doc, ok := s.docs[file]; ok {
+		content = doc.content
+		return content
+	}
+
+	content, _ = os.ReadFile(file)
+	return content
+}
+
+// uri returns the URI of file, as the editor gave it when open.
+func (s *lspServer) uri(file string) (uri string) {
+	if doc, ok := s.docs[file]; ok {
+		uri = doc.uri
+		return uri
+	}
+
+	slashed := filepath.ToSlash(file)
+	if !strings.HasPrefix(slashed, "/") {
+		// Windows paths start with the drive letter
+		slashed = "/" + slashed
+	}
+	uri = (&url.URL{Scheme: "file", Path: slashed}).String()
+	return uri
+}
+
+// uriPath returns the absolute path of the file at a file URI.
+func uriPath(uri string) (path string, err error) {
+	var u *url.URL
+	u, err = url.Parse(uri)
+	if err != nil {
+		return path, err
+	}
+	if u.Scheme != "file" {
+		err = fmt.Errorf("unsupported URI %q", uri)
+		return path, err
+	}
+
+	path = u.Path
+	if len(path) > 2 && path[0] == '/' && path[2] == ':' {
+		// A Windows drive letter
+		path = path[1:]
+	}
+	path = filepath.FromSlash(path)
+	return path, err
+}
+
+// lspPositionAt returns the LSP position of the 1-based line and byte
+// column in content.
+func lspPositionAt(content []byte, line int, column int) (pos lspPosition) {
+	offset := 0
+	for l := 1; l < line && offset < len(content); l++ {
+		next := bytes.IndexByte(content[offset:], '\n')
+		if next < 0 {
+			offset = len(content)
+			break
+		}
+		offset += next + 1
+	}
+
+	pos = lspPositionOf(content, offset+max(column-1, 0))
+	return pos
+}
+
+// lspPositionOf returns the LSP position of a byte offset in content.
+func lspPositionOf(content []byte, offset int) (pos lspPosition) {
+	offset = min(max(offset, 0), len(content))
+	lineStart := bytes.LastIndexByte(content[:offset], '\n') + 1
+	pos.Line = bytes.Count(content[:lineStart], []byte("\n"))
+
+	for rest := content[lineStart:offset]; len(rest) > 0; {
+		r, size := utf8.DecodeRune(rest)
+		pos.Character += len(utf16.Encode([]rune{r}))
+		rest = rest[size:]
+	}
+	return pos
+}
+
+// lspBefore reports whether a comes before b.
+func lspBefore(a lspPosition, b lspPosition) (before bool) {
+	before = a.Line < b.Line || a.Line == b.Line && a.Character < b.Character
+	return before
+}
+
+// readLSPMessage reads a message, framed by headers giving its length.
+func readLSPMessage(r *bufio.Reader) (msg lspMessage, err error) {
+	length := -1
+	for {
+		var line string
+		line, err = r.ReadString('\n')
+		if err != nil {
+			return msg, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+
+		name, value, found := strings.Cut(line, ":")
+		if found && strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+			length, err = strconv.Atoi(strings.TrimSpace(value))
+			if err != nil {
+				err = fmt.Errorf("invalid header %q", line)
+				return msg, err
+			}
+		}
+	}
+	if length < 0 {
+		err = errors.New("message without Content-Length header")
+		return msg, err
+	}
+
+	body := make([]byte, length)
+	_, err = io.ReadFull(r, body)
+	if err != nil {
+		return msg, err
+	}
+
+	err = json.Unmarshal(body, &msg)
+	return msg, err
+}
+
+// writeLSPMessage writes a message, framed by a header giving its length.
+func writeLSPMessage(w io.Writer, msg interface{}) (err error) {
+	var body []byte
+	body, err = json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	return err
+}
@@ -0,0 +1,153 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMainLSP(t *testing.T) {
+	// The package of the document must be part of the module to be loaded
+	dir, err := os.MkdirTemp("../../testdata/src", "lsp-")
+	if err != nil {
+		t.Fatalf("creating package: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	file, err := filepath.Abs(filepath.Join(dir, "lsp.go"))
+	if err != nil {
+		t.Fatalf("resolving path: %s", err)
+	}
+	err = os.WriteFile(file, []byte("package lsp\n\nfunc answer() (n int) {\n\tn = 42\n\treturn n\n}\n"), 0o644)
+	if err != nil {
+		t.Fatalf("writing file: %s", err)
+	}
+	uri := "file://" + filepath.ToSlash(file)
+
+	// The document is analyzed as edited, not as saved
+	edited := "package lsp\n\n// é\nfunc answer() int { return 42 }\n"
+	var in bytes.Buffer
+	for _, msg := range []string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`,
+		`{"jsonrpc":"2.0","method":"initialized","params":{}}`,
+		`{"jsonrpc":"2.0","method":"textDocument/didOpen","params":{"textDocument":{"uri":"` + uri + `","languageId":"go","version":1,"text":` + quote(t, edited) + `}}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"textDocument/codeAction","params":{"textDocument":{"uri":"` + uri + `"},"range":{"start":{"line":3,"character":0},"end":{"line":3,"character":0}},"context":{"diagnostics":[]}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"textDocument/hover","params":{}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"shutdown"}`,
+		`{"jsonrpc":"2.0","method":"exit"}`,
+	} {
+		err = writeLSPMessage(&in, json.RawMessage(msg))
+		if err != nil {
+			t.Fatalf("writing request: %s", err)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := Main([]string{"lsp"}, &in, &stdout, &stderr)
+	if code != exitOK {
+		t.Fatalf("expected exit code %d, got %d (stderr: %s)", exitOK, code, stderr.String())
+	}
+
+	var messages []map[string]json.RawMessage
+	r := bufio.NewReader(&stdout)
+	for {
+		line, readErr := r.ReadString('\n')
+		if readErr != nil {
+			break
+		}
+		var length int
+		_, err = fmt.Sscanf(line, "Content-Length: %d\r\n", &length)
+		if err != nil {
+			t.Fatalf("reading header %q: %s", line, err)
+		}
+		_, _ = r.ReadString('\n')
+		body := make([]byte, length)
+		_, err = io.ReadFull(r, body)
+		if err != nil {
+			t.Fatalf("reading body: %s", err)
+		}
+		var msg map[string]json.RawMessage
+		err = json.Unmarshal(body, &msg)
+		if err != nil {
+			t.Fatalf("decoding %s: %s", body, err)
+		}
+		messages = append(messages, msg)
+	}
+	if len(messages) != 5 {
+		t.Fatalf("expected 5 messages, got %d:\n%s", len(messages), stdout.String())
+	}
+
+	if !strings.Contains(string(messages[0]["result"]), `"codeActionProvider"`) {
+		t.Errorf("expected the capabilities to include code actions, got %s", messages[0]["result"])
+	}
+
+	var diagnostics struct {
+		URI         string          `json:"uri"`
+		Diagnostics []lspDiagnostic `json:"diagnostics"`
+	}
+	err = json.Unmarshal(messages[1]["params"], &diagnostics)
+	if err != nil {
+		t.Fatalf("decoding diagnostics: %s", err)
+	}
+	if diagnostics.URI != uri || len(diagnostics.Diagnostics) != 1 {
+		t.Fatalf("expected one diagnostic for %s, got %s", uri, messages[1]["params"])
+	}
+	d := diagnostics.Diagnostics[0]
+	if d.Code != "NR001" || d.Severity != 1 || d.Range.Start != (lspPosition{Line: 3, Character: 0}) {
+		t.Errorf("expected an NR001 error at 3:0, got %+v", d)
+	}
+
+	var actions []lspCodeAction
+	err = json.Unmarshal(messages[2]["result"], &actions)
+	if err != nil {
+		t.Fatalf("decoding code actions: %s", err)
+	}
+	if len(actions) == 0 || actions[0].Kind != "quickfix" || len(actions[0].Edit.Changes[uri]) == 0 {
+		t.Fatalf("expected a quick fix editing the document, got %s", messages[2]["result"])
+	}
+	if edit := actions[0].Edit.Changes[uri][0]; edit.Range.Start.Line != 3 {
+		t.Errorf("expected the fix to edit line 3, got %+v", edit)
+	}
+
+	if !strings.Contains(string(messages[3]["error"]), "-32601") {
+		t.Errorf("expected unsupported methods to fail, got %s", messages[3]["error"])
+	}
+	if string(messages[4]["result"]) != "null" {
+		t.Errorf("expected shutdown to return null, got %s", messages[4]["result"])
+	}
+}
+
+func TestLSPPositionOf(t *testing.T) {
+	content := []byte("a\n// é𝄞x\n")
+	for _, tc := range []struct {
+		offset   int
+		expected lspPosition
+	}{
+		{0, lspPosition{0, 0}},
+		{2, lspPosition{1, 0}},
+		{5, lspPosition{1, 3}},
+		// é is two bytes and one UTF-16 unit, 𝄞 four bytes and two units
+		{7, lspPosition{1, 4}},
+		{11, lspPosition{1, 6}},
+		{100, lspPosition{2, 0}},
+	} {
+		if got := lspPositionOf(content, tc.offset); got != tc.expected {
+			t.Errorf("offset %d: expected %+v, got %+v", tc.offset, tc.expected, got)
+		}
+	}
+}
+
+func quote(t *testing.T, s string) (quoted string) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatalf("quoting %q: %s", s, err)
+	}
+	quoted = string(data)
+	return quoted
+}
@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/nikogura/namedreturns/report"
+)
+
+// metricsTimeout bounds pushing the metrics, so an unreachable gateway
+// doesn't hang the CI job.
+const metricsTimeout = 10 * time.Second
+
+// pushMetrics sends stats and the duration of the run to the Prometheus push
+// gateway URL, e.g. http://gateway:9091/metrics/job/namedreturns. It uses
+// PUT, which replaces all metrics of the group, so the series of packages
+// that have no findings anymore go away.
+func pushMetrics(url string, stats report.Stats, duration time.Duration) (err error) {
+	var body bytes.Buffer
+	err = report.WriteMetrics(&body, stats, duration)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), metricsTimeout)
+	defer cancel()
+
+	var req *http.Request
+	req, err = http.NewRequestWithContext(ctx, http.MethodPut, url, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", report.MetricsContentType)
+
+	var resp *http.Response
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		err = fmt.Errorf("push gateway responded %s", resp.Status)
+	}
+	return err
+}
@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+
+	"github.com/nikogura/namedreturns/analyzer"
+	"github.com/nikogura/namedreturns/report"
+)
+
+// migrationRules are the rules whose fixes migrate applies: naming the
+// results, replacing underscores and returning the names, which together
+// convert a function to named returns without changing what it does.
+var migrationRules = map[string]bool{
+	analyzer.RuleUnnamedResult:    true,
+	analyzer.RuleUnderscoreResult: true,
+	analyzer.RuleUnusedInReturn:   true,
+}
+
+// migrations returns the issues of the rules migrate fixes.
+func migrations(issues []report.Issue) (kept []report.Issue) {
+	for _, issue := range issues {
+		if migrationRules[issue.Rule] {
+			kept = append(kept, issue)
+		}
+	}
+	return kept
+}
+
+// bareReturns turns the trailing return statements restating the named
+// results of their function, in order, into bare returns, in the given files
+// but generated ones, as overlay or else the disk holds them. It returns the
+// new contents of the changed files, formatted, along with the number of
+// statements changed in each.
+func bareReturns(files []string, overlay map[string][]byte) (contents map[string][]byte, applied map[string]int, err error) {
+	contents = make(map[string][]byte)
+	applied = make(map[string]int)
+	for _, filename := range files {
+		var content []byte
+		content, err = readFile(overlay, filename)
+		if err != nil {
+			return contents, applied, err
+		}
+
+		fset := token.NewFileSet()
+		var file *ast.File
+		file, err = parser.ParseFile(fset, filename, content, parser.ParseComments)
+		if err != nil {
+			return contents, applied, err
+		}
+		if ast.IsGenerated(file) {
+			continue
+		}
+
+		var edits []report.Edit
+		ast.Inspect(file, func(node ast.Node) (descend bool) {
+			descend = true
+			var typ *ast.FuncType
+			var body *ast.BlockStmt
+			switch n := node.(type) {
+			case *ast.FuncDecl:
+				typ, body = n.Type, n.Body
+			case *ast.FuncLit:
+				typ, body = n.Type, n.Body
+			default:
+				return descend
+			}
+
+			if ret, ok := restatedReturn(typ, body); ok {
+				edits = append(edits, report.Edit{
+					File:    filename,
+					Start:   report.Position{Offset: fset.Position(ret.Pos()).Offset},
+					End:     report.Position{Offset: fset.Position(ret.End()).Offset},
+					NewText: "return",
+				})
+			}
+			return descend
+		})
+		if len(edits) == 0 {
+			continue
+		}
+
+		content, err = applyEdits(content, edits)
+		if err != nil {
+			return contents, applied, err
+		}
+		contents[filename] = content
+		applied[filename] = len(edits)
+	}
+	return contents, applied, err
+}
+
+// restatedReturn returns the last statement of body when it returns the
+// named results of typ, in order, so it could be a bare return instead. The
+// statement can't see a shadowing declaration, as the results share the
+// scope of the body's own declarations, which can't redeclare them.
+func restatedReturn(typ *ast.FuncType, body *ast.BlockStmt) (ret *ast.ReturnStmt, ok bool) {
+	if body == nil || len(body.List) == 0 || typ.Results == nil {
+		return ret, ok
+	}
+	var isReturn bool
+	ret, isReturn = body.List[len(body.List)-1].(*ast.ReturnStmt)
+	if !isReturn || len(ret.Results) == 0 {
+		return ret, ok
+	}
+
+	var names []string
+	for _, field := range typ.Results.List {
+		for _, n := range field.Names {
+			names = append(names, n.Name)
+		}
+	}
+	if len(names) != len(ret.Results) {
+		return ret, ok
+	}
+	for i, result := range ret.Results {
+		ident, isIdent := result.(*ast.Ident)
+		if !isIdent || ident.Name != names[i] || ident.Name == "_" {
+			return ret, ok
+		}
+	}
+
+	ok = true
+	return ret, ok
+}
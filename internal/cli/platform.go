@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"fmt"
+	"go/build"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// platform is a target operating system and architecture to analyze the
+// code for, which selects the files whose build constraints it satisfies.
+// The zero platform stands for the one the environment selects.
+type platform struct {
+	goos   string
+	goarch string
+	tags   []string // build tags set on top of those given, by a build variant
+}
+
+// parsePlatform parses a platform given as GOOS/GOARCH.
+func parsePlatform(s string) (p platform, err error) {
+	goos, goarch, found := strings.Cut(s, "/")
+	if !found || goos == "" || goarch == "" || strings.Contains(goarch, "/") {
+		err = fmt.Errorf("invalid platform %q, expected GOOS/GOARCH, e.g. linux/amd64", s)
+		return p, err
+	}
+
+	p = platform{goos: goos, goarch: goarch}
+	return p, err
+}
+
+// String returns the platform as GOOS/GOARCH followed by its own build
+// tags, if any, e.g. "linux/arm64 tags=integration", or "" for the
+// environment's.
+func (p platform) String() (s string) {
+	var parts []string
+	if p.goos != "" {
+		parts = append(parts, p.goos+"/"+p.goarch)
+	}
+	if len(p.tags) > 0 {
+		parts = append(parts, "tags="+strings.Join(p.tags, ","))
+	}
+	s = strings.Join(parts, " ")
+	return s
+}
+
+// env returns the environment of the go command loading packages for the
+// platform, nil for the current environment.
+func (p platform) env() (env []string) {
+	if p.goos == "" {
+		return env
+	}
+
+	// Cgo needs a C toolchain for the platform, which cross builds lack
+	env = append(os.Environ(), "GOOS="+p.goos, "GOARCH="+p.goarch)
+	if !p.native() {
+		env = append(env, "CGO_ENABLED=0")
+	}
+	return env
+}
+
+// native reports whether the platform is the one namedreturns runs on.
+func (p platform) native() (native bool) {
+	native = p.goos == "" || p.goos == runtime.GOOS && p.goarch == runtime.GOARCH
+	return native
+}
+
+// context returns the build context selecting the files of the platform
+// with the given build tags, for -fast mode.
+func (p platform) context(tags []string) (ctx build.Context) {
+	ctx = build.Default
+	if p.goos != "" {
+		ctx.GOOS = p.goos
+		ctx.GOARCH = p.goarch
+		ctx.CgoEnabled = ctx.CgoEnabled && p.native()
+	}
+	ctx.BuildTags = slices.Concat(ctx.BuildTags, tags, p.tags)
+	return ctx
+}
+
+// targets returns the platforms opts asks for, or the zero platform when
+// it asks for none.
+func (opts options) targets() (platforms []platform) {
+	platforms = opts.platforms
+	if len(platforms) == 0 {
+		platforms = []platform{{}}
+	}
+	return platforms
+}
+
+// buildFlags returns the flags passing the given build tags, along with
+// the platform's own, to the go command.
+func (p platform) buildFlags(tags []string) (flags []string) {
+	if all := slices.Concat(tags, p.tags); len(all) > 0 {
+		flags = []string{"-tags=" + strings.Join(all, ",")}
+	}
+	return flags
+}
+
+// noteVariant notes p on the issues of results, found for it among several
+// platforms.
+func noteVariant(results []packageResult, p platform) {
+	for _, result := range results {
+		for i := range result.Issues {
+			result.Issues[i].Variants = []string{p.String()}
+		}
+	}
+}
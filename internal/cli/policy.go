@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/nikogura/namedreturns/analyzer"
+	"github.com/nikogura/namedreturns/report"
+)
+
+// severityRank orders severities from least to most severe.
+var severityRank = map[string]int{
+	analyzer.SeverityInfo:    1,
+	analyzer.SeverityWarning: 2,
+	analyzer.SeverityError:   3,
+}
+
+// policy decides whether the findings of a run fail it, so a codebase can
+// adopt the linter incrementally.
+type policy struct {
+	rules       map[string]bool // rule IDs that count; empty means all rules count
+	minSeverity string          // findings below this severity don't count; empty means all count
+	maxIssues   int             // the run fails when more findings than this count; noLimit for no limit
+	maxPerRule  map[string]int  // the run fails when more findings of a rule than this count, by rule ID
+	maxEachRule int             // the limit of the rules not in maxPerRule; noLimit for no limit
+}
+
+// noLimit stands for the absence of an issue threshold.
+const noLimit = -1
+
+// newPolicy builds a policy from the comma separated rule IDs or names in
+// failOn, the minimum severity, the issue threshold and the thresholds per
+// rule in maxPerRule, given as comma separated rule=count pairs or a count
+// for all rules not listed.
+func newPolicy(failOn string, minSeverity string, maxIssues int, maxPerRule string) (p policy, err error) {
+	p = policy{rules: make(map[string]bool), minSeverity: minSeverity, maxIssues: maxIssues, maxPerRule: make(map[string]int), maxEachRule: noLimit}
+
+	for _, name := range strings.Split(failOn, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		rule, ok := analyzer.LookupRule(name)
+		if !ok {
+			err = fmt.Errorf("unknown rule %q in -fail-on", name)
+			return p, err
+		}
+		p.rules[rule.ID] = true
+	}
+
+	if minSeverity != "" && severityRank[minSeverity] == 0 {
+		err = fmt.Errorf("unknown severity %q in -fail-on-severity, expected one of %s, %s, %s",
+			minSeverity, analyzer.SeverityError, analyzer.SeverityWarning, analyzer.SeverityInfo)
+		return p, err
+	}
+
+	if maxIssues < 0 {
+		err = fmt.Errorf("-max-issues must not be negative, got %d", maxIssues)
+		return p, err
+	}
+
+	for _, item := range strings.Split(maxPerRule, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		name, value, found := strings.Cut(item, "=")
+		if !found {
+			name, value = "", item
+		}
+
+		var limit int
+		limit, err = strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || limit < 0 {
+			err = fmt.Errorf("invalid count %q in -max-issues-per-rule, expected a non-negative number", value)
+			return p, err
+		}
+
+		if !found {
+			p.maxEachRule = limit
+			continue
+		}
+		rule, ok := analyzer.LookupRule(strings.TrimSpace(name))
+		if !ok {
+			err = fmt.Errorf("unknown rule %q in -max-issues-per-rule", name)
+			return p, err
+		}
+		p.maxPerRule[rule.ID] = limit
+	}
+	return p, err
+}
+
+// counts reports whether issue counts towards failing the run.
+func (p policy) counts(issue report.Issue) (counted bool) {
+	if len(p.rules) > 0 && !p.rules[issue.Rule] {
+		return counted
+	}
+	if p.minSeverity != "" && severityRank[issue.Severity] < severityRank[p.minSeverity] {
+		return counted
+	}
+	counted = true
+	return counted
+}
+
+// count returns the number of issues counting towards failing the run, in
+// total and by rule ID.
+func (p policy) count(issues []report.Issue) (total int, byRule map[string]int) {
+	byRule = make(map[string]int)
+	for _, issue := range issues {
+		if p.counts(issue) {
+			total++
+			byRule[issue.Rule]++
+		}
+	}
+	return total, byRule
+}
+
+// fails reports whether issues fail the run, by exceeding the threshold in
+// total or that of a rule.
+func (p policy) fails(issues []report.Issue) (failed bool) {
+	total, byRule := p.count(issues)
+	if p.maxIssues != noLimit && total > p.maxIssues {
+		failed = true
+		return failed
+	}
+
+	for rule, n := range byRule {
+		limit, ok := p.maxPerRule[rule]
+		if !ok {
+			limit = p.maxEachRule
+		}
+		if limit != noLimit && n > limit {
+			failed = true
+			break
+		}
+	}
+	return failed
+}
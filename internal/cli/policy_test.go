@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/nikogura/namedreturns/report"
+)
+
+func TestPolicy(t *testing.T) {
+	issues := []report.Issue{
+		{Rule: "NR001", Severity: "error"},
+		{Rule: "NR003", Severity: "warning"},
+		{Rule: "NR003", Severity: "warning"},
+	}
+
+	cases := []struct {
+		name     string
+		failOn   string
+		severity string
+		max      int
+		perRule  string
+		fails    bool
+	}{
+		{name: "default fails on any finding", fails: true},
+		{name: "only listed rules count", failOn: "NR004", fails: false},
+		{name: "rules can be given by name", failOn: "unused-in-return", fails: true},
+		{name: "threshold not exceeded", max: 3, fails: false},
+		{name: "threshold exceeded", max: 2, fails: true},
+		{name: "only errors count", severity: "error", max: 1, fails: false},
+		{name: "warnings and above count", severity: "warning", max: 2, fails: true},
+		{name: "rule threshold not exceeded", max: noLimit, perRule: "NR003=2,NR001=1", fails: false},
+		{name: "rule threshold exceeded", max: noLimit, perRule: "unused-in-return=1,NR001=1", fails: true},
+		{name: "threshold of unlisted rules exceeded", max: noLimit, perRule: "1,NR001=1", fails: true},
+		{name: "total threshold exceeded along with rule thresholds", max: 2, perRule: "5", fails: true},
+	}
+
+	for _, c := range cases {
+		p, err := newPolicy(c.failOn, c.severity, max(c.max, 0), c.perRule)
+		if err != nil {
+			t.Fatalf("%s: %s", c.name, err)
+		}
+		p.maxIssues = c.max
+		if got := p.fails(issues); got != c.fails {
+			t.Errorf("%s: expected fails=%t, got %t", c.name, c.fails, got)
+		}
+	}
+
+	_, err := newPolicy("NR999", "", 0, "")
+	if err == nil {
+		t.Errorf("expected an error for an unknown rule")
+	}
+
+	_, err = newPolicy("", "fatal", 0, "")
+	if err == nil {
+		t.Errorf("expected an error for an unknown severity")
+	}
+
+	for _, perRule := range []string{"NR999=1", "NR001=-1", "NR001=many"} {
+		_, err = newPolicy("", "", 0, perRule)
+		if err == nil {
+			t.Errorf("expected an error for -max-issues-per-rule=%s", perRule)
+		}
+	}
+}
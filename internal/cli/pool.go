@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"sync"
+)
+
+// forEach calls work for each index from 0 to n-1 on at most jobs goroutines
+// at a time. work stores its results by index, so they come out in the same
+// order however the calls are scheduled. Of the failed calls, the error of
+// the one with the lowest index is returned, for the same reason. At least
+// one goroutine runs, whatever jobs is.
+func forEach(n int, jobs int, work func(i int) (err error)) (err error) {
+	errs := make([]error, n)
+	indices := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < min(max(jobs, 1), n); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				errs[i] = work(i)
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+
+	for _, e := range errs {
+		if e != nil {
+			err = e
+			return err
+		}
+	}
+	return err
+}
@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestForEach(t *testing.T) {
+	squares := make([]int, 100)
+	err := forEach(len(squares), 8, func(i int) (err error) {
+		squares[i] = i * i
+		if i%10 == 7 {
+			err = fmt.Errorf("failed at %d", i)
+		}
+		return err
+	})
+	if err == nil || err.Error() != "failed at 7" {
+		t.Errorf("expected the error of the lowest index, got %v", err)
+	}
+	for i, square := range squares {
+		if square != i*i {
+			t.Fatalf("index %d was not processed", i)
+		}
+	}
+
+	err = forEach(3, 0, func(int) (err error) {
+		err = errors.New("ran")
+		return err
+	})
+	if err == nil {
+		t.Errorf("expected work to run with jobs below 1")
+	}
+}
+
+func TestMainJobsDeterministic(t *testing.T) {
+	var sequential, concurrent, stderr bytes.Buffer
+	Main([]string{"-fast", "-jobs=1", "../../testdata/src/..."}, nil, &sequential, &stderr)
+	Main([]string{"-fast", "-jobs=8", "../../testdata/src/..."}, nil, &concurrent, &stderr)
+	if sequential.Len() == 0 || sequential.String() != concurrent.String() {
+		t.Errorf("expected the same findings whatever the number of jobs, got:\n%s\nand:\n%s%s", sequential.String(), concurrent.String(), stderr.String())
+	}
+
+	code := Main([]string{"-jobs=0", fixture}, nil, &sequential, &stderr)
+	if code != exitError {
+		t.Errorf("expected exit code %d for -jobs=0, got %d", exitError, code)
+	}
+}
@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+)
+
+// ratchetFile is the content of the ratchet file.
+type ratchetFile struct {
+	Count int `json:"count"` // findings counting towards failure
+}
+
+// ratchet compares count, the number of findings counting towards failure,
+// with the one recorded in the ratchet file at path. The run fails when the
+// count went up. The count is recorded when the file doesn't exist yet or it
+// went down, so it can only ever decrease.
+func ratchet(path string, count int, stderr io.Writer) (failed bool, err error) {
+	var data []byte
+	data, err = os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		err = writeRatchet(path, count)
+		if err == nil {
+			fmt.Fprintf(stderr, "namedreturns: recorded %d findings in %s\n", count, path)
+		}
+		return failed, err
+	case err != nil:
+		return failed, err
+	}
+
+	var recorded ratchetFile
+	err = json.Unmarshal(data, &recorded)
+	if err != nil {
+		err = fmt.Errorf("parsing %s: %w", path, err)
+		return failed, err
+	}
+
+	switch {
+	case count > recorded.Count:
+		fmt.Fprintf(stderr, "namedreturns: %d findings, up from %d recorded in %s\n", count, recorded.Count, path)
+		failed = true
+	case count < recorded.Count:
+		err = writeRatchet(path, count)
+		if err == nil {
+			fmt.Fprintf(stderr, "namedreturns: %d findings, down from %d, recorded in %s\n", count, recorded.Count, path)
+		}
+	}
+	return failed, err
+}
+
+// writeRatchet records count in the ratchet file at path.
+func writeRatchet(path string, count int) (err error) {
+	var data []byte
+	data, err = json.MarshalIndent(ratchetFile{Count: count}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(path, append(data, '\n'), 0o644)
+	return err
+}
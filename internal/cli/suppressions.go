@@ -0,0 +1,390 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/token"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nikogura/namedreturns/analyzer"
+	"github.com/nikogura/namedreturns/internal/baseline"
+	"github.com/nikogura/namedreturns/internal/changes"
+	"gopkg.in/yaml.v3"
+)
+
+// Kinds of suppressions besides the directives of the analyzer.
+const (
+	suppressionBaseline = "baseline"
+	suppressionConfig   = "config"
+)
+
+// suppression is an entry of the -list-suppressions report: a directive, a
+// setting of a configuration file or a baseline entry silencing findings.
+type suppression struct {
+	Kind    string     `json:"kind"`              // a directive, "config" or "baseline"
+	File    string     `json:"file"`              // holding the suppression
+	Line    int        `json:"line"`              // of the suppression in File
+	Setting string     `json:"setting,omitempty"` // of a config suppression, e.g. "tests.disable"
+	Rules   []string   `json:"rules"`             // IDs of the rules suppressed, none for all
+	Target  string     `json:"target,omitempty"`  // what is silenced: a glob, an exemption rule, a baseline finding
+	Reason  string     `json:"reason,omitempty"`
+	Expires string     `json:"expires,omitempty"`
+	Since   *time.Time `json:"since,omitempty"` // when the line was last committed, if it was
+}
+
+// listSuppressions writes every suppression in effect for the directories
+// matching opts.patterns: the nolint, ignore and disable-file directives in
+// their files, the settings of their configuration files excluding files or
+// disabling or exempting rules, and the entries of the baseline.
+func listSuppressions(opts options, stdout io.Writer, stderr io.Writer) (code int) {
+	found, err := collectSuppressions(opts)
+	if err == nil {
+		addAges(found)
+	}
+	if err == nil && opts.relative {
+		var wd string
+		wd, err = os.Getwd()
+		for i := range found {
+			if rel, relErr := filepath.Rel(wd, found[i].File); relErr == nil {
+				found[i].File = rel
+			}
+		}
+	}
+	if err == nil {
+		err = writeSuppressions(stdout, opts.format, found, time.Now())
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "namedreturns: listing suppressions: %s\n", err)
+		code = exitError
+		return code
+	}
+
+	fmt.Fprintf(stderr, "namedreturns: %d suppressions\n", len(found))
+	code = exitOK
+	return code
+}
+
+// collectSuppressions finds the suppressions in effect for the directories
+// matching opts.patterns, in file order.
+func collectSuppressions(opts options) (found []suppression, err error) {
+	var dirs []string
+	dirs, err = patternDirs(opts.patterns)
+	if err != nil {
+		return found, err
+	}
+
+	var filter dirFilter
+	filter, err = newFilter(opts)
+	if err != nil {
+		return found, err
+	}
+
+	fset := token.NewFileSet()
+	ctx := opts.targets()[0].context(opts.tags)
+	configs := make(map[string]bool)
+	var configFiles []string
+	for _, dir := range dirs {
+		if filter.excluded(dir) {
+			continue
+		}
+
+		var cfg analyzer.Config
+		cfg, err = analyzer.ResolveConfig(opts.analyzer, dir)
+		if err != nil {
+			return found, err
+		}
+
+		var paths []string
+		paths, err = analyzer.ConfigFiles(opts.analyzer, dir)
+		if err != nil {
+			return found, err
+		}
+		for _, path := range paths {
+			if !configs[path] {
+				configs[path] = true
+				configFiles = append(configFiles, path)
+			}
+		}
+
+		pkgs, parseErr := parseDir(fset, &ctx, dir, opts.tests, opts.overlay)
+		if parseErr != nil {
+			err = parseErr
+			return found, err
+		}
+
+		for _, pkgFiles := range pkgs {
+			for _, file := range pkgFiles {
+				name := fset.File(file.Pos()).Name()
+				if cfg.ExcludesFile(name) || cfg.ExcludesGenerated(file) {
+					continue
+				}
+
+				for _, s := range analyzer.FileSuppressions(fset, file) {
+					if s.Kind == analyzer.SuppressionNolint && cfg.IgnoreNolint {
+						continue
+					}
+					found = append(found, suppression{
+						Kind:    s.Kind,
+						File:    s.Pos.Filename,
+						Line:    s.Pos.Line,
+						Rules:   append([]string{}, s.Rules...),
+						Reason:  s.Reason,
+						Expires: s.Expires,
+					})
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(found, func(i int, j int) (less bool) {
+		less = found[i].File < found[j].File || found[i].File == found[j].File && found[i].Line < found[j].Line
+		return less
+	})
+
+	for _, path := range configFiles {
+		var settings []suppression
+		settings, err = configSuppressions(path)
+		if err != nil {
+			return found, err
+		}
+		found = append(found, settings...)
+	}
+
+	var entries []suppression
+	entries, err = baselineSuppressions(opts.baselineFile)
+	found = append(found, entries...)
+	return found, err
+}
+
+// suppressingSettings are the settings of configuration files silencing
+// findings, which hold lists of rules or of what they exempt.
+var suppressingSettings = []string{
+	analyzer.FlagDisable,
+	analyzer.FlagExcludeFiles,
+	analyzer.FlagExcludePresets,
+	analyzer.FlagExemptResultTypes,
+	analyzer.FlagExemptCommaOK,
+	"exempt",
+}
+
+// configSuppressions returns the settings of the configuration file at path
+// silencing findings, an entry for each item of their lists. Comments on
+// the items, or on the settings, are taken as their reasons.
+func configSuppressions(path string) (found []suppression, err error) {
+	var data []byte
+	data, err = os.ReadFile(path)
+	if err != nil {
+		return found, err
+	}
+
+	var doc yaml.Node
+	err = yaml.Unmarshal(data, &doc)
+	if err != nil {
+		err = fmt.Errorf("parsing %s: %w", path, err)
+		return found, err
+	}
+	if len(doc.Content) == 0 {
+		return found, err
+	}
+
+	var walk func(mapping *yaml.Node, prefix string)
+	walk = func(mapping *yaml.Node, prefix string) {
+		for i := 0; i+1 < len(mapping.Content); i += 2 {
+			key, value := mapping.Content[i], mapping.Content[i+1]
+			if key.Value == "tests" && prefix == "" && value.Kind == yaml.MappingNode {
+				walk(value, "tests.")
+				continue
+			}
+			if !slices.Contains(suppressingSettings, key.Value) {
+				continue
+			}
+
+			items := []*yaml.Node{value}
+			if value.Kind == yaml.SequenceNode {
+				items = value.Content
+			}
+			for _, item := range items {
+				s := suppression{
+					Kind:    suppressionConfig,
+					File:    path,
+					Line:    item.Line,
+					Setting: prefix + key.Value,
+					Rules:   []string{},
+					Reason:  yamlComment(item, key),
+				}
+				switch key.Value {
+				case analyzer.FlagDisable:
+					for _, name := range strings.Split(item.Value, ",") {
+						if rule, ok := analyzer.LookupRule(strings.TrimSpace(name)); ok {
+							s.Rules = append(s.Rules, rule.ID)
+						}
+					}
+				case analyzer.FlagExemptCommaOK:
+					if item.Value != "true" {
+						continue
+					}
+				default:
+					s.Target = yamlText(item)
+				}
+				found = append(found, s)
+			}
+		}
+	}
+	if root := doc.Content[0]; root.Kind == yaml.MappingNode {
+		walk(root, "")
+	}
+	return found, err
+}
+
+// yamlComment returns the comment on the line of item, or above it, or else
+// the one of key, without the # marks.
+func yamlComment(item *yaml.Node, key *yaml.Node) (comment string) {
+	for _, c := range []string{item.LineComment, item.HeadComment, key.LineComment, key.HeadComment} {
+		if c != "" {
+			lines := strings.Split(c, "\n")
+			for i, line := range lines {
+				lines[i] = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "#"))
+			}
+			comment = strings.Join(lines, " ")
+			return comment
+		}
+	}
+	return comment
+}
+
+// yamlText renders a node on a single line, in flow style.
+func yamlText(node *yaml.Node) (text string) {
+	if node.Kind == yaml.ScalarNode {
+		text = node.Value
+		return text
+	}
+
+	flow := *node
+	flow.Style = yaml.FlowStyle
+	flow.HeadComment, flow.LineComment, flow.FootComment = "", "", ""
+	data, err := yaml.Marshal(&flow)
+	if err != nil {
+		return text
+	}
+	text = strings.TrimSpace(string(data))
+	return text
+}
+
+// baselineSuppressions returns the entries of the baseline file at path,
+// none when it doesn't exist.
+func baselineSuppressions(path string) (found []suppression, err error) {
+	var b baseline.Baseline
+	b, err = baseline.Load(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		err = nil
+		return found, err
+	}
+	if err != nil {
+		return found, err
+	}
+
+	var abs string
+	abs, err = filepath.Abs(path)
+	if err != nil {
+		return found, err
+	}
+
+	// Entries are found by their fingerprint, which is on a line of its own
+	var data []byte
+	data, err = os.ReadFile(path)
+	if err != nil {
+		return found, err
+	}
+	lines := make(map[string]int)
+	for i, line := range strings.Split(string(data), "\n") {
+		if _, fp, ok := strings.Cut(line, `"fingerprint": "`); ok {
+			lines[strings.TrimSuffix(strings.TrimSpace(fp), `",`)] = i + 1
+		}
+	}
+
+	for _, entry := range b.Entries {
+		target := fmt.Sprintf("%s: %s", entry.File, entry.Message)
+		if entry.Count > 1 {
+			target = fmt.Sprintf("%s (%d times)", target, entry.Count)
+		}
+		found = append(found, suppression{
+			Kind:   suppressionBaseline,
+			File:   abs,
+			Line:   lines[entry.Fingerprint],
+			Rules:  []string{entry.Rule},
+			Target: target,
+		})
+	}
+	return found, err
+}
+
+// addAges sets when the line of each suppression was last committed, for the
+// files under version control.
+func addAges(found []suppression) {
+	blamed := make(map[string]map[int]time.Time)
+	for i := range found {
+		times, ok := blamed[found[i].File]
+		if !ok {
+			// Files outside of a repository, or not committed, have no age
+			times, _ = changes.Blame(found[i].File)
+			blamed[found[i].File] = times
+		}
+		if since, committed := times[found[i].Line]; committed {
+			found[i].Since = &since
+		}
+	}
+}
+
+// writeSuppressions writes the suppressions as text, one per line, or as a
+// JSON array.
+func writeSuppressions(w io.Writer, format string, found []suppression, now time.Time) (err error) {
+	if format == "json" {
+		if found == nil {
+			found = []suppression{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(found)
+		return err
+	}
+
+	for _, s := range found {
+		kind := s.Kind
+		if s.Setting != "" {
+			kind = fmt.Sprintf("%s %s", s.Kind, s.Setting)
+		}
+		rules := "all"
+		if len(s.Rules) > 0 {
+			rules = strings.Join(s.Rules, ",")
+		}
+
+		line := fmt.Sprintf("%s:%d: %s %s", s.File, s.Line, kind, rules)
+		if s.Target != "" {
+			line += " " + s.Target
+		}
+		if s.Expires != "" {
+			line += " expires=" + s.Expires
+		}
+		if s.Reason != "" {
+			line += " -- " + s.Reason
+		}
+
+		age := "uncommitted"
+		if s.Since != nil {
+			age = fmt.Sprintf("%d days old", int(now.Sub(*s.Since).Hours()/24))
+		}
+		_, err = fmt.Fprintf(w, "%s (%s)\n", line, age)
+		if err != nil {
+			return err
+		}
+	}
+	return err
+}
@@ -0,0 +1,325 @@
+package cli
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/build/constraint"
+	"go/parser"
+	"go/token"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// maxVariantTags bounds the custom tags of one build constraint whose
+// combinations are tried, beyond which only the first ones are.
+const maxVariantTags = 8
+
+// unixOS are the operating systems satisfying the unix build tag.
+var unixOS = map[string]bool{
+	"aix": true, "android": true, "darwin": true, "dragonfly": true, "freebsd": true, "hurd": true, "illumos": true,
+	"ios": true, "linux": true, "netbsd": true, "openbsd": true, "solaris": true,
+}
+
+// impliedOS maps operating systems to the one whose build tag they satisfy
+// as well, e.g. android files build for linux.
+var impliedOS = map[string]string{"android": "linux", "illumos": "solaris", "ios": "darwin"}
+
+// ports holds the platforms the go command supports.
+type ports struct {
+	arches map[string][]string // of each operating system, in the order listed
+	arch   map[string]bool
+}
+
+// supportedPorts returns the platforms listed by the go command.
+func supportedPorts() (p ports, err error) {
+	var out []byte
+	out, err = exec.Command("go", "tool", "dist", "list").Output()
+	if err != nil {
+		err = fmt.Errorf("listing platforms: %w", err)
+		return p, err
+	}
+
+	p = ports{arches: make(map[string][]string), arch: make(map[string]bool)}
+	for _, line := range strings.Fields(string(out)) {
+		if goos, goarch, ok := strings.Cut(line, "/"); ok {
+			p.arches[goos] = append(p.arches[goos], goarch)
+			p.arch[goarch] = true
+		}
+	}
+	return p, err
+}
+
+// buildVariants returns the platforms and build tags, on top of those of
+// opts, under which every file of the packages matching opts.patterns is
+// analyzed, the environment's first. Each variant is the first found
+// satisfying the build constraints of some file, preferring the
+// environment's platform and fewer tags.
+func buildVariants(opts options) (variants []platform, err error) {
+	var files []string
+	files, err = variantFiles(opts)
+	if err != nil {
+		return variants, err
+	}
+
+	var p ports
+	p, err = supportedPorts()
+	if err != nil {
+		return variants, err
+	}
+
+	env := platform{goos: build.Default.GOOS, goarch: build.Default.GOARCH}
+	seen := map[string]bool{env.String(): true}
+	variants = []platform{env}
+	for _, file := range files {
+		var expr constraint.Expr
+		expr, err = p.fileConstraint(opts.overlay, file)
+		if err != nil {
+			return variants, err
+		}
+
+		v, ok := p.satisfying(expr, opts.tags)
+		if ok && !seen[v.String()] {
+			seen[v.String()] = true
+			variants = append(variants, v)
+		}
+	}
+
+	sort.SliceStable(variants[1:], func(i, j int) (less bool) {
+		less = variants[1+i].String() < variants[1+j].String()
+		return less
+	})
+	return variants, err
+}
+
+// variantFiles returns the Go files of the packages matching opts.patterns
+// whatever their build constraints.
+func variantFiles(opts options) (files []string, err error) {
+	if opts.fast {
+		var dirs []string
+		dirs, err = patternDirs(opts.patterns)
+		if err != nil {
+			return files, err
+		}
+		for _, dir := range dirs {
+			var matches []string
+			matches, err = filepath.Glob(filepath.Join(dir, "*.go"))
+			if err != nil {
+				return files, err
+			}
+			files = append(files, matches...)
+		}
+		return files, err
+	}
+
+	var pkgs []*packages.Package
+	pkgs, err = load(opts, platform{}, packages.NeedName|packages.NeedFiles, opts.patterns)
+	if err != nil {
+		return files, err
+	}
+
+	seen := make(map[string]bool)
+	for _, pkg := range pkgs {
+		for _, file := range slices.Concat(pkg.GoFiles, pkg.IgnoredFiles) {
+			if filepath.Ext(file) == ".go" && !seen[file] {
+				seen[file] = true
+				files = append(files, file)
+			}
+		}
+	}
+	sort.Strings(files)
+	return files, err
+}
+
+// fileConstraint returns the build constraint of file, along with those
+// its name implies, or nil when it has none.
+func (p ports) fileConstraint(overlay map[string][]byte, file string) (expr constraint.Expr, err error) {
+	var content []byte
+	content, err = readFile(overlay, file)
+	if err != nil {
+		return expr, err
+	}
+
+	var f *ast.File
+	f, err = parser.ParseFile(token.NewFileSet(), file, content, parser.PackageClauseOnly|parser.ParseComments)
+	if err != nil {
+		// The analysis reports files that don't parse
+		err = nil
+		return expr, err
+	}
+
+	var goBuild constraint.Expr
+	var plusBuild []constraint.Expr
+	for _, group := range f.Comments {
+		if group.Pos() >= f.Package {
+			break
+		}
+		for _, c := range group.List {
+			switch {
+			case constraint.IsGoBuild(c.Text):
+				goBuild, _ = constraint.Parse(c.Text)
+			case constraint.IsPlusBuild(c.Text):
+				if plus, parseErr := constraint.Parse(c.Text); parseErr == nil {
+					plusBuild = append(plusBuild, plus)
+				}
+			}
+		}
+	}
+
+	// //go:build lines take precedence over // +build ones
+	var exprs []constraint.Expr
+	if goBuild != nil {
+		exprs = append(exprs, goBuild)
+	} else {
+		exprs = append(exprs, plusBuild...)
+	}
+	exprs = append(exprs, p.nameConstraint(filepath.Base(file))...)
+
+	for _, e := range exprs {
+		if expr == nil {
+			expr = e
+			continue
+		}
+		expr = &constraint.AndExpr{X: expr, Y: e}
+	}
+	return expr, err
+}
+
+// nameConstraint returns the constraints implied by a file name ending in
+// _GOOS, _GOARCH or _GOOS_GOARCH, before any _test suffix.
+func (p ports) nameConstraint(name string) (exprs []constraint.Expr) {
+	name = strings.TrimSuffix(strings.TrimSuffix(name, ".go"), "_test")
+	parts := strings.Split(name, "_")
+	if len(parts) < 2 {
+		return exprs
+	}
+
+	n := len(parts)
+	last := parts[n-1]
+	if n >= 3 && p.arches[parts[n-2]] != nil && p.arch[last] {
+		exprs = []constraint.Expr{&constraint.TagExpr{Tag: parts[n-2]}, &constraint.TagExpr{Tag: last}}
+		return exprs
+	}
+	if p.arches[last] != nil || p.arch[last] {
+		exprs = []constraint.Expr{&constraint.TagExpr{Tag: last}}
+	}
+	return exprs
+}
+
+// satisfying returns the first platform and tags satisfying expr, trying
+// the environment's platform first, then those expr names, and the tags
+// expr names in growing sets. ok is false when nothing does, as for files
+// constrained by ignore.
+func (p ports) satisfying(expr constraint.Expr, extraTags []string) (v platform, ok bool) {
+	if expr == nil {
+		return v, ok
+	}
+
+	envOS, envArch := build.Default.GOOS, build.Default.GOARCH
+	var oses, arches, custom []string
+	for _, tag := range constraintTags(expr) {
+		switch {
+		case p.arches[tag] != nil:
+			oses = append(oses, tag)
+		case tag == "unix" && !unixOS[envOS]:
+			oses = append(oses, "linux")
+		case p.arch[tag]:
+			arches = append(arches, tag)
+		case tag == "unix", tag == "cgo", tag == "gc", tag == "gccgo", tag == "ignore", strings.HasPrefix(tag, "go1."):
+		default:
+			if !slices.Contains(extraTags, tag) && len(custom) < maxVariantTags {
+				custom = append(custom, tag)
+			}
+		}
+	}
+
+	for _, goos := range append([]string{envOS}, oses...) {
+		supported := p.arches[goos]
+		for _, goarch := range append([]string{envArch}, arches...) {
+			if !slices.Contains(supported, goarch) {
+				continue
+			}
+			for _, tags := range subsets(custom) {
+				candidate := platform{goos: goos, goarch: goarch, tags: tags}
+				if expr.Eval(candidate.satisfies(extraTags)) {
+					v, ok = candidate, true
+					return v, ok
+				}
+			}
+		}
+
+		// An operating system expr names may not support the
+		// environment's architecture
+		if goos != envOS && len(supported) > 0 && !slices.Contains(supported, envArch) {
+			for _, tags := range subsets(custom) {
+				candidate := platform{goos: goos, goarch: supported[0], tags: tags}
+				if expr.Eval(candidate.satisfies(extraTags)) {
+					v, ok = candidate, true
+					return v, ok
+				}
+			}
+		}
+	}
+	return v, ok
+}
+
+// satisfies returns whether a build tag is satisfied on the platform, by
+// its own tags or extraTags, as the go command decides.
+func (v platform) satisfies(extraTags []string) (match func(tag string) bool) {
+	cgo := build.Default.CgoEnabled && v.goos == build.Default.GOOS && v.goarch == build.Default.GOARCH
+	match = func(tag string) (ok bool) {
+		ok = tag == v.goos || tag == v.goarch || tag == impliedOS[v.goos] ||
+			tag == "unix" && unixOS[v.goos] || tag == "gc" || tag == "cgo" && cgo ||
+			slices.Contains(build.Default.ReleaseTags, tag) ||
+			slices.Contains(v.tags, tag) || slices.Contains(extraTags, tag)
+		return ok
+	}
+	return match
+}
+
+// constraintTags returns the tags expr names, in order.
+func constraintTags(expr constraint.Expr) (tags []string) {
+	switch e := expr.(type) {
+	case *constraint.TagExpr:
+		tags = []string{e.Tag}
+	case *constraint.NotExpr:
+		tags = constraintTags(e.X)
+	case *constraint.AndExpr:
+		tags = append(constraintTags(e.X), constraintTags(e.Y)...)
+	case *constraint.OrExpr:
+		tags = append(constraintTags(e.X), constraintTags(e.Y)...)
+	}
+
+	var unique []string
+	for _, tag := range tags {
+		if !slices.Contains(unique, tag) {
+			unique = append(unique, tag)
+		}
+	}
+	tags = unique
+	return tags
+}
+
+// subsets returns the subsets of tags, smallest first.
+func subsets(tags []string) (sets [][]string) {
+	for mask := 0; mask < 1<<len(tags); mask++ {
+		var set []string
+		for i, tag := range tags {
+			if mask&(1<<i) != 0 {
+				set = append(set, tag)
+			}
+		}
+		sets = append(sets, set)
+	}
+
+	sort.SliceStable(sets, func(i, j int) (less bool) {
+		less = len(sets[i]) < len(sets[j])
+		return less
+	})
+	return sets
+}
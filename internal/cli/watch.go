@@ -0,0 +1,255 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nikogura/namedreturns/report"
+	"golang.org/x/tools/go/packages"
+)
+
+// watcher re-analyzes packages as their files change. It polls the
+// directories of the watched packages rather than relying on file system
+// notifications, which keeps it portable and free of dependencies.
+type watcher struct {
+	opts      options
+	formatter report.Formatter
+	stdout    io.Writer
+	stderr    io.Writer
+
+	dirs      map[string][]string       // package path to the directories of its files
+	importers map[string][]string       // package path to the watched packages importing it
+	modTimes  map[string]time.Time      // Go file to its modification time
+	issues    map[string][]report.Issue // package path to its current findings
+}
+
+// watch analyzes the packages matching opts.patterns, then re-analyzes the
+// ones affected by each change until ctx is done.
+func watch(ctx context.Context, opts options, formatter report.Formatter, stdout io.Writer, stderr io.Writer) (err error) {
+	// An interrupt stops the analysis in progress too
+	opts.ctx = ctx
+	w := &watcher{
+		opts:      opts,
+		formatter: formatter,
+		stdout:    stdout,
+		stderr:    stderr,
+		issues:    make(map[string][]report.Issue),
+	}
+
+	err = w.loadGraph()
+	if err != nil {
+		return err
+	}
+	w.modTimes = w.scan()
+	w.analyze(w.packages())
+
+	ticker := time.NewTicker(opts.watchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return err
+		case <-ticker.C:
+		}
+
+		modTimes := w.scan()
+		changed := changedDirs(w.modTimes, modTimes)
+		w.modTimes = modTimes
+		if len(changed) == 0 {
+			continue
+		}
+
+		// Imports may have changed along with the files, and packages
+		// may have been added or removed
+		err = w.loadGraph()
+		if err != nil {
+			fmt.Fprintf(stderr, "namedreturns: %s\n", err)
+			err = nil
+			continue
+		}
+		w.modTimes = w.scan()
+		w.analyze(w.affected(changed))
+	}
+}
+
+// loadGraph loads the names, files and imports of the watched packages.
+func (w *watcher) loadGraph() (err error) {
+	cfg := &packages.Config{
+		Mode:  packages.NeedName | packages.NeedFiles | packages.NeedImports,
+		Tests: w.opts.tests,
+	}
+
+	var pkgs []*packages.Package
+	pkgs, err = packages.Load(cfg, w.opts.patterns...)
+	if err != nil {
+		err = fmt.Errorf("loading packages: %w", err)
+		return err
+	}
+
+	w.dirs = make(map[string][]string)
+	w.importers = make(map[string][]string)
+	for _, pkg := range pkgs {
+		// Test variants share the path of the package they extend
+		for _, file := range append(pkg.GoFiles, pkg.OtherFiles...) {
+			w.dirs[pkg.PkgPath] = appendUnique(w.dirs[pkg.PkgPath], filepath.Dir(file))
+		}
+	}
+	for _, pkg := range pkgs {
+		for path := range pkg.Imports {
+			if _, watched := w.dirs[path]; watched && path != pkg.PkgPath {
+				w.importers[path] = appendUnique(w.importers[path], pkg.PkgPath)
+			}
+		}
+	}
+
+	// Forget the findings of packages that are gone
+	for path := range w.issues {
+		if _, ok := w.dirs[path]; !ok {
+			delete(w.issues, path)
+		}
+	}
+	return err
+}
+
+// scan records the modification time of the Go files in the directories of
+// the watched packages.
+func (w *watcher) scan() (modTimes map[string]time.Time) {
+	modTimes = make(map[string]time.Time)
+	for _, dirs := range w.dirs {
+		for _, dir := range dirs {
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				continue
+			}
+			for _, entry := range entries {
+				if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".go") {
+					continue
+				}
+				info, err := entry.Info()
+				if err != nil {
+					continue
+				}
+				modTimes[filepath.Join(dir, entry.Name())] = info.ModTime()
+			}
+		}
+	}
+	return modTimes
+}
+
+// changedDirs returns the directories in which a Go file was added, removed
+// or modified between two scans.
+func changedDirs(before map[string]time.Time, after map[string]time.Time) (dirs map[string]bool) {
+	dirs = make(map[string]bool)
+	for file, modTime := range after {
+		if prev, ok := before[file]; !ok || !prev.Equal(modTime) {
+			dirs[filepath.Dir(file)] = true
+		}
+	}
+	for file := range before {
+		if _, ok := after[file]; !ok {
+			dirs[filepath.Dir(file)] = true
+		}
+	}
+	return dirs
+}
+
+// affected returns the watched packages with files in the changed
+// directories, along with the packages importing them directly or
+// indirectly, whose type information depends on them.
+func (w *watcher) affected(changed map[string]bool) (paths []string) {
+	seen := make(map[string]bool)
+	var queue []string
+	for path, dirs := range w.dirs {
+		for _, dir := range dirs {
+			if changed[dir] && !seen[path] {
+				seen[path] = true
+				queue = append(queue, path)
+			}
+		}
+	}
+
+	for len(queue) > 0 {
+		path := queue[0]
+		queue = queue[1:]
+		paths = append(paths, path)
+		for _, importer := range w.importers[path] {
+			if !seen[importer] {
+				seen[importer] = true
+				queue = append(queue, importer)
+			}
+		}
+	}
+
+	sort.Strings(paths)
+	return paths
+}
+
+// packages returns the paths of all watched packages.
+func (w *watcher) packages() (paths []string) {
+	for path := range w.dirs {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// analyze re-analyzes the packages with the given paths and prints their
+// findings, followed by a summary of all current findings. Errors are
+// printed rather than returned, as code being edited is often broken for a
+// moment.
+func (w *watcher) analyze(paths []string) {
+	if len(paths) == 0 {
+		return
+	}
+
+	opts := w.opts
+	opts.patterns = paths
+	out, err := analyze(opts)
+	if err == nil {
+		out.issues, err = narrow(opts, out.issues, nil, w.stderr)
+	}
+	if err != nil {
+		fmt.Fprintf(w.stderr, "namedreturns: %s\n", err)
+		return
+	}
+
+	for _, path := range paths {
+		delete(w.issues, path)
+	}
+	for _, issue := range out.issues {
+		w.issues[issue.Package] = append(w.issues[issue.Package], issue)
+	}
+
+	err = w.formatter(w.stdout, out.issues)
+	if err != nil {
+		fmt.Fprintf(w.stderr, "namedreturns: writing output: %s\n", err)
+		return
+	}
+
+	var total int
+	for _, issues := range w.issues {
+		total += len(issues)
+	}
+	fmt.Fprintf(w.stderr, "namedreturns: %s: analyzed %d packages, %d findings in them, %d in total\n",
+		time.Now().Format(time.TimeOnly), len(paths), len(out.issues), total)
+}
+
+// appendUnique appends s to list unless it is already in it.
+func appendUnique(list []string, s string) (appended []string) {
+	appended = list
+	for _, item := range list {
+		if item == s {
+			return appended
+		}
+	}
+
+	appended = append(appended, s)
+	return appended
+}
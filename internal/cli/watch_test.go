@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestChangedDirs(t *testing.T) {
+	now := time.Now()
+	before := map[string]time.Time{
+		"/a/a.go":  now,
+		"/b/b.go":  now,
+		"/c/c.go":  now,
+		"/d/d1.go": now,
+	}
+	after := map[string]time.Time{
+		"/a/a.go":  now,
+		"/b/b.go":  now.Add(time.Second),
+		"/d/d1.go": now,
+		"/d/d2.go": now,
+	}
+
+	changed := changedDirs(before, after)
+	expected := map[string]bool{"/b": true, "/c": true, "/d": true}
+	if !reflect.DeepEqual(changed, expected) {
+		t.Errorf("expected changed directories %v, got %v", expected, changed)
+	}
+}
+
+func TestAffected(t *testing.T) {
+	w := &watcher{
+		dirs: map[string][]string{
+			"m/a": {"/m/a"},
+			"m/b": {"/m/b"},
+			"m/c": {"/m/c"},
+			"m/d": {"/m/d"},
+		},
+		importers: map[string][]string{
+			"m/a": {"m/b"},
+			"m/b": {"m/c"},
+		},
+	}
+
+	affected := w.affected(map[string]bool{"/m/a": true})
+	expected := []string{"m/a", "m/b", "m/c"}
+	if !reflect.DeepEqual(affected, expected) {
+		t.Errorf("expected affected packages %v, got %v", expected, affected)
+	}
+}
@@ -1,10 +1,11 @@
 package main
 
 import (
-	"github.com/nikogura/namedreturns/analyzer"
-	"golang.org/x/tools/go/analysis/singlechecker"
+	"os"
+
+	"github.com/nikogura/namedreturns/internal/cli"
 )
 
 func main() {
-	singlechecker.Main(analyzer.Analyzer)
+	os.Exit(cli.Main(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
 }
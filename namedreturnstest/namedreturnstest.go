@@ -0,0 +1,183 @@
+// Package namedreturnstest helps test the namedreturns analyzer under
+// particular configurations, such as those of forks, plugins and company
+// presets. It wraps analysistest, so fixtures follow its conventions: packages
+// live below dir/src and expect findings with // want comments.
+package namedreturnstest
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/nikogura/namedreturns/analyzer"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/analysistest"
+	"golang.org/x/tools/go/analysis/checker"
+	"golang.org/x/tools/go/packages"
+)
+
+// Case is a configuration to check packages under: the analyzer flags to set,
+// by name, and the patterns of the packages.
+type Case struct {
+	Name     string
+	Flags    map[string]string
+	Patterns []string
+}
+
+// NewAnalyzer returns a new instance of the analyzer with the given flags
+// set, leaving analyzer.Analyzer untouched.
+func NewAnalyzer(flags map[string]string) (a *analysis.Analyzer, err error) {
+	a = analyzer.NewAnalyzer(analyzer.Config{})
+
+	// Set the flags in a stable order so errors are reproducible
+	names := make([]string, 0, len(flags))
+	for name := range flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		err = a.Flags.Set(name, flags[name])
+		if err != nil {
+			err = fmt.Errorf("setting flag %s: %w", name, err)
+			return a, err
+		}
+	}
+	return a, err
+}
+
+// Run checks the packages matching patterns below dir/src with an analyzer
+// created from cfg against their // want comments.
+func Run(t testing.TB, dir string, cfg analyzer.Config, patterns ...string) (results []*analysistest.Result) {
+	t.Helper()
+	results = analysistest.Run(t, dir, analyzer.NewAnalyzer(cfg), patterns...)
+	return results
+}
+
+// RunWithFlags is like Run, but configures the analyzer with flags, as
+// drivers and plugins do.
+func RunWithFlags(t testing.TB, dir string, flags map[string]string, patterns ...string) (results []*analysistest.Result) {
+	t.Helper()
+	a, err := NewAnalyzer(flags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results = analysistest.Run(t, dir, a, patterns...)
+	return results
+}
+
+// RunWithSuggestedFixes is like RunWithFlags, and also checks the files
+// resulting from the suggested fixes against their .golden counterparts.
+func RunWithSuggestedFixes(t testing.TB, dir string, flags map[string]string, patterns ...string) (results []*analysistest.Result) {
+	t.Helper()
+	a, err := NewAnalyzer(flags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results = analysistest.RunWithSuggestedFixes(t, dir, a, patterns...)
+	return results
+}
+
+// RunCases runs each case as a subtest named after it.
+func RunCases(t *testing.T, dir string, cases []Case) {
+	t.Helper()
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			RunWithFlags(t, dir, c.Flags, c.Patterns...)
+		})
+	}
+}
+
+// Annotate analyzes the Go file filename with a and returns its source with a
+// // want comment at the end of each line reported on, matching the messages
+// reported there. The result is a starting point for a fixture, to be
+// reviewed before committing it. The file is analyzed on its own, so it may
+// only import packages that resolve from its directory.
+func Annotate(a *analysis.Analyzer, filename string) (annotated []byte, err error) {
+	filename, err = filepath.Abs(filename)
+	if err != nil {
+		return annotated, err
+	}
+
+	cfg := &packages.Config{Mode: packages.LoadAllSyntax, Dir: filepath.Dir(filename)}
+	var pkgs []*packages.Package
+	pkgs, err = packages.Load(cfg, filename)
+	if err != nil {
+		err = fmt.Errorf("loading %s: %w", filename, err)
+		return annotated, err
+	}
+
+	var graph *checker.Graph
+	graph, err = checker.Analyze([]*analysis.Analyzer{a}, pkgs, nil)
+	if err != nil {
+		return annotated, err
+	}
+
+	// Messages by line, in the order reported
+	messages := make(map[int][]string)
+	for _, act := range graph.Roots {
+		if act.Err != nil {
+			err = fmt.Errorf("analyzing %s: %w", filename, act.Err)
+			return annotated, err
+		}
+
+		for _, d := range act.Diagnostics {
+			pos := act.Package.Fset.Position(d.Pos)
+			if pos.Filename == filename {
+				messages[pos.Line] = append(messages[pos.Line], d.Message)
+			}
+		}
+	}
+
+	var src []byte
+	src, err = os.ReadFile(filename)
+	if err != nil {
+		return annotated, err
+	}
+
+	annotated = annotate(src, messages)
+	return annotated, err
+}
+
+// annotate appends a // want comment to the lines of src with messages.
+func annotate(src []byte, messages map[int][]string) (annotated []byte) {
+	lines := bytes.SplitAfter(src, []byte("\n"))
+	var buf bytes.Buffer
+	for i, line := range lines {
+		msgs := messages[i+1]
+		if len(msgs) == 0 {
+			buf.Write(line)
+			continue
+		}
+
+		patterns := make([]string, 0, len(msgs))
+		for _, msg := range msgs {
+			patterns = append(patterns, wantPattern(msg))
+		}
+
+		content := bytes.TrimRight(line, "\r\n")
+		buf.Write(content)
+		buf.WriteString(" // want " + strings.Join(patterns, " "))
+		buf.Write(line[len(content):])
+	}
+	annotated = buf.Bytes()
+	return annotated
+}
+
+// wantPattern quotes a pattern matching msg literally as a Go string, raw
+// when possible as messages often contain double quotes.
+func wantPattern(msg string) (pattern string) {
+	re := "^" + regexp.QuoteMeta(msg) + "$"
+	if strings.Contains(re, "`") {
+		pattern = fmt.Sprintf("%q", re)
+		return pattern
+	}
+
+	pattern = "`" + re + "`"
+	return pattern
+}
@@ -0,0 +1,65 @@
+package namedreturnstest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nikogura/namedreturns/analyzer"
+)
+
+func TestRunCases(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get wd: %s", err)
+	}
+
+	testdata := filepath.Join(filepath.Dir(wd), "testdata")
+	RunCases(t, testdata, []Case{
+		{Name: "defaults", Patterns: []string{"default-config"}},
+		{Name: "report-error-in-defer", Flags: map[string]string{analyzer.FlagReportErrorInDefer: "true"}, Patterns: []string{"report-error-in-defer"}},
+		{Name: "config-json", Flags: map[string]string{analyzer.FlagConfigJSON: `{"report-error-in-defer": true}`}, Patterns: []string{"report-error-in-defer"}},
+	})
+
+	_, err = NewAnalyzer(map[string]string{"no-such-flag": "true"})
+	if err == nil {
+		t.Errorf("expected an error for an unknown flag")
+	}
+}
+
+func TestAnnotate(t *testing.T) {
+	dir := t.TempDir()
+	pkgDir := filepath.Join(dir, "src", "plain")
+	err := os.MkdirAll(pkgDir, 0o700)
+	if err != nil {
+		t.Fatalf("Failed to create package: %s", err)
+	}
+
+	src := "package plain\n\nfunc unnamed() int {\n\treturn 1\n}\n\nfunc named() (n int) {\n\tn = 1\n\treturn n\n}\n"
+	path := filepath.Join(pkgDir, "plain.go")
+	err = os.WriteFile(path, []byte(src), 0o600)
+	if err != nil {
+		t.Fatalf("Failed to write source: %s", err)
+	}
+
+	annotated, err := Annotate(analyzer.NewAnalyzer(analyzer.Config{}), path)
+	if err != nil {
+		t.Fatalf("Annotate failed: %s", err)
+	}
+
+	lines := strings.Split(string(annotated), "\n")
+	if !strings.HasPrefix(lines[2], "func unnamed() int { // want `^func unnamed: unnamed return") {
+		t.Errorf("expected a want comment on the unnamed result, got %q", lines[2])
+	}
+	if strings.Count(string(annotated), "// want") != 1 {
+		t.Errorf("expected a single want comment, got:\n%s", annotated)
+	}
+
+	// The fixture passes against the analyzer it was generated with
+	err = os.WriteFile(path, annotated, 0o600)
+	if err != nil {
+		t.Fatalf("Failed to write fixture: %s", err)
+	}
+	RunWithFlags(t, dir, nil, "plain")
+}
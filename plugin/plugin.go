@@ -0,0 +1,198 @@
+// Package plugin integrates namedreturns with golangci-lint's module plugin
+// system. Importing it registers the linter under the name "namedreturns".
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/golangci/plugin-module-register/register"
+	"github.com/nikogura/namedreturns/analyzer"
+	"golang.org/x/tools/go/analysis"
+)
+
+//nolint:gochecknoinits // registering with golangci-lint requires an init function
+func init() {
+	register.Plugin("namedreturns", newPlugin)
+}
+
+// Settings mirrors the analyzer flags, under the same names, for the
+// settings section of the golangci-lint configuration:
+//
+//	linters:
+//	  settings:
+//	    custom:
+//	      namedreturns:
+//	        type: module
+//	        settings:
+//	          report-error-in-defer: true
+//
+// Settings left at their zero value don't override the configuration file.
+type Settings struct {
+	// Config is the path of the namedreturns configuration file.
+	Config string `json:"config,omitempty"`
+
+	ReportErrorInDefer bool     `json:"report-error-in-defer,omitempty"`
+	Mode               string   `json:"mode,omitempty"`
+	MinReturns         int      `json:"min-returns,omitempty"`
+	ErrorConvention    bool     `json:"error-convention,omitempty"`
+	ErrorName          string   `json:"error-name,omitempty"`
+	DeferErrorHandler  bool     `json:"defer-error-handler,omitempty"`
+	ReportUnusedNames  bool     `json:"report-unused-names,omitempty"`
+	ReportInconsistent bool     `json:"report-inconsistent-names,omitempty"`
+	BoolConvention     bool     `json:"bool-convention,omitempty"`
+	BoolNames          []string `json:"bool-names,omitempty"`
+	InterfaceNames     bool     `json:"interface-names,omitempty"`
+	DocResults         bool     `json:"doc-results,omitempty"`
+	Locale             string   `json:"locale,omitempty"`
+	Disable            []string `json:"disable,omitempty"`
+	ExcludeFiles       []string `json:"exclude-files,omitempty"`
+	ExcludePresets     []string `json:"exclude-presets,omitempty"`
+	InternalPreset     string   `json:"internal-preset,omitempty"`
+	PublicPreset       string   `json:"public-preset,omitempty"`
+	InternalPackages   []string `json:"internal-packages,omitempty"`
+	ExemptResultTypes  []string `json:"exempt-result-types,omitempty"`
+	Targets            []string `json:"targets,omitempty"`
+	Func               []string `json:"func,omitempty"`
+	GroupByFunction    bool     `json:"group-by-function,omitempty"`
+	OncePerFunction    bool     `json:"once-per-function,omitempty"`
+	ExemptCommaOK      bool     `json:"exempt-comma-ok,omitempty"`
+	ExemptTestHelpers  bool     `json:"exempt-test-helpers,omitempty"`
+	MustAssignError    bool     `json:"must-assign-error,omitempty"`
+	ErrorPropagation   bool     `json:"error-propagation,omitempty"`
+	PanicRecover       bool     `json:"panic-recover,omitempty"`
+	ReportReordered    bool     `json:"report-reordered-results,omitempty"`
+	PreferBareReturn   bool     `json:"prefer-bare-return,omitempty"`
+	ShadowedReturns    bool     `json:"report-shadowed-returns,omitempty"`
+	ExemptYieldFuncs   bool     `json:"exempt-yield-funcs,omitempty"`
+	IteratorConvention bool     `json:"iterator-convention,omitempty"`
+	IteratorNames      []string `json:"iterator-names,omitempty"`
+
+	// Tests holds the settings overriding the others in _test.go files.
+	Tests map[string]any `json:"tests,omitempty"`
+
+	// Messages holds the message templates by rule ID or name.
+	Messages map[string]string `json:"messages,omitempty"`
+
+	// Exempt holds the rules exempting functions from all checks.
+	Exempt []analyzer.Exemption `json:"exempt,omitempty"`
+}
+
+// New returns the analyzers configured by settings: one reporting every rule
+// but NR014, along with Propagation, reporting NR014, when
+// error-propagation is set.
+func New(settings Settings) (analyzers []*analysis.Analyzer, err error) {
+	var data []byte
+	data, err = json.Marshal(settings)
+	if err != nil {
+		return analyzers, err
+	}
+
+	var values map[string]interface{}
+	err = json.Unmarshal(data, &values)
+	if err != nil {
+		return analyzers, err
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// golangci-lint applies nolint directives itself, and reports the
+	// directives of linters that found nothing to suppress as unused
+	defaults := analyzer.Config{IgnoreNolint: true}
+	analyzers = []*analysis.Analyzer{analyzer.NewAnalyzer(defaults)}
+
+	// The errors are followed through the SSA form of the functions, which
+	// the other checks go without
+	if settings.ErrorPropagation {
+		analyzers = append(analyzers, analyzer.NewPropagation(defaults))
+	}
+
+	// Settings are applied as flags so they take precedence over the
+	// configuration file, as they would on the command line.
+	//
+	// The settings holding documents of their own, which no flag takes,
+	// are passed together as JSON settings
+	documents := make(map[string]interface{})
+	for _, name := range names {
+		if name == "tests" || name == "messages" || name == "exempt" {
+			documents[name] = values[name]
+			continue
+		}
+
+		err = setFlag(analyzers, name, flagValue(values[name]))
+		if err != nil {
+			err = fmt.Errorf("setting %s: %w", name, err)
+			return analyzers, err
+		}
+	}
+
+	if len(documents) > 0 {
+		data, err = json.Marshal(documents)
+		if err == nil {
+			err = setFlag(analyzers, analyzer.FlagConfigJSON, string(data))
+		}
+		if err != nil {
+			return analyzers, err
+		}
+	}
+	return analyzers, err
+}
+
+// setFlag sets the flag called name of each of analyzers to value.
+func setFlag(analyzers []*analysis.Analyzer, name string, value string) (err error) {
+	for _, a := range analyzers {
+		err = a.Flags.Set(name, value)
+		if err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// flagValue renders a decoded setting the way it would be given as a flag.
+func flagValue(value interface{}) (s string) {
+	items, ok := value.([]interface{})
+	if !ok {
+		s = fmt.Sprint(value)
+		return s
+	}
+
+	parts := make([]string, 0, len(items))
+	for _, item := range items {
+		parts = append(parts, fmt.Sprint(item))
+	}
+	s = strings.Join(parts, ",")
+	return s
+}
+
+// linterPlugin adapts New to the golangci-lint plugin interface.
+type linterPlugin struct {
+	settings Settings
+}
+
+func newPlugin(conf any) (p register.LinterPlugin, err error) {
+	var settings Settings
+	settings, err = register.DecodeSettings[Settings](conf)
+	if err != nil {
+		return p, err
+	}
+
+	p = &linterPlugin{settings: settings}
+	return p, err
+}
+
+func (p *linterPlugin) BuildAnalyzers() (analyzers []*analysis.Analyzer, err error) {
+	analyzers, err = New(p.settings)
+	return analyzers, err
+}
+
+func (p *linterPlugin) GetLoadMode() (mode string) {
+	mode = register.LoadModeTypesInfo
+	return mode
+}
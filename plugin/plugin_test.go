@@ -0,0 +1,93 @@
+package plugin
+
+import (
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nikogura/namedreturns/analyzer"
+)
+
+func TestNewPlugin(t *testing.T) {
+	p, err := newPlugin(map[string]any{"report-error-in-defer": true})
+	if err != nil {
+		t.Fatalf("creating plugin: %s", err)
+	}
+
+	analyzers, err := p.BuildAnalyzers()
+	if err != nil {
+		t.Fatalf("building analyzers: %s", err)
+	}
+	if len(analyzers) != 1 {
+		t.Fatalf("expected 1 analyzer, got %d", len(analyzers))
+	}
+
+	value := analyzers[0].Flags.Lookup(analyzer.FlagReportErrorInDefer).Value.String()
+	if value != "true" {
+		t.Errorf("expected %s to be set, got %q", analyzer.FlagReportErrorInDefer, value)
+	}
+}
+
+func TestNewPluginErrorPropagation(t *testing.T) {
+	analyzers, err := New(Settings{ErrorPropagation: true, ErrorName: "failure"})
+	if err != nil {
+		t.Fatalf("creating analyzers: %s", err)
+	}
+	if len(analyzers) != 2 || analyzers[1].Name != analyzer.Propagation.Name {
+		t.Fatalf("expected the analyzer and %s, got %v", analyzer.Propagation.Name, analyzers)
+	}
+
+	// Both run under the same settings
+	for _, a := range analyzers {
+		if value := a.Flags.Lookup(analyzer.FlagErrorName).Value.String(); value != "failure" {
+			t.Errorf("expected %s of %s to be set, got %q", analyzer.FlagErrorName, a.Name, value)
+		}
+	}
+}
+
+func TestNewPluginUnknownSetting(t *testing.T) {
+	_, err := newPlugin(map[string]any{"report-everything": true})
+	if err == nil {
+		t.Error("expected an error for an unknown setting")
+	}
+}
+
+func TestNewPluginDocuments(t *testing.T) {
+	p, err := newPlugin(map[string]any{
+		"tests":    map[string]any{"mode": "errors", "disable": []any{"NR004"}},
+		"messages": map[string]any{"NR001": "{{.Text}}"},
+		"exempt":   []any{map[string]any{"receiver": "*Mock*"}},
+	})
+	if err != nil {
+		t.Fatalf("creating plugin: %s", err)
+	}
+
+	analyzers, err := p.BuildAnalyzers()
+	if err != nil {
+		t.Fatalf("building analyzers: %s", err)
+	}
+
+	value := analyzers[0].Flags.Lookup(analyzer.FlagConfigJSON).Value.String()
+	if value != `{"exempt":[{"receiver":"*Mock*"}],"messages":{"NR001":"{{.Text}}"},"tests":{"disable":["NR004"],"mode":"errors"}}` {
+		t.Errorf("unexpected %s: %s", analyzer.FlagConfigJSON, value)
+	}
+}
+
+func TestSettingsCoverFlags(t *testing.T) {
+	// The plugin sets these itself
+	internal := map[string]bool{analyzer.FlagConfigJSON: true, analyzer.FlagIgnoreNolint: true}
+
+	settings := make(map[string]bool)
+	fields := reflect.TypeOf(Settings{})
+	for i := 0; i < fields.NumField(); i++ {
+		name, _, _ := strings.Cut(fields.Field(i).Tag.Get("json"), ",")
+		settings[name] = true
+	}
+
+	analyzer.Analyzer.Flags.VisitAll(func(f *flag.Flag) {
+		if !internal[f.Name] && !settings[f.Name] {
+			t.Errorf("flag %s has no setting", f.Name)
+		}
+	})
+}
@@ -0,0 +1,80 @@
+package report
+
+import (
+	"encoding/xml"
+	"io"
+
+	"github.com/nikogura/namedreturns/analyzer"
+)
+
+type checkstyleOutput struct {
+	XMLName xml.Name         `xml:"checkstyle"`
+	Version string           `xml:"version,attr"`
+	Files   []checkstyleFile `xml:"file"`
+}
+
+type checkstyleFile struct {
+	Name   string            `xml:"name,attr"`
+	Errors []checkstyleError `xml:"error"`
+}
+
+type checkstyleError struct {
+	Line     int    `xml:"line,attr"`
+	Column   int    `xml:"column,attr"`
+	Severity string `xml:"severity,attr"`
+	Message  string `xml:"message,attr"`
+	Source   string `xml:"source,attr"`
+}
+
+// WriteCheckstyle writes issues as a checkstyle XML report with one file
+// element per file, in the order the files first appear in issues.
+func WriteCheckstyle(w io.Writer, issues []Issue) (err error) {
+	output := checkstyleOutput{Version: "5.0"}
+
+	files := make(map[string]int)
+	for _, issue := range issues {
+		i, ok := files[issue.File]
+		if !ok {
+			i = len(output.Files)
+			files[issue.File] = i
+			output.Files = append(output.Files, checkstyleFile{Name: issue.File})
+		}
+
+		output.Files[i].Errors = append(output.Files[i].Errors, checkstyleError{
+			Line:     issue.Line,
+			Column:   issue.Column,
+			Severity: checkstyleSeverity(issue.Severity),
+			Message:  issue.Message,
+			Source:   analyzer.Analyzer.Name + "." + issue.Rule,
+		})
+	}
+
+	_, err = io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	err = encoder.Encode(output)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+// checkstyleSeverity maps a rule severity onto the checkstyle levels
+// error, warning and info.
+func checkstyleSeverity(severity string) (level string) {
+	switch severity {
+	case analyzer.SeverityError:
+		level = "error"
+	case analyzer.SeverityInfo:
+		level = "info"
+	default:
+		level = "warning"
+	}
+	return level
+}
@@ -0,0 +1,91 @@
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/nikogura/namedreturns/analyzer"
+)
+
+type codeClimateIssue struct {
+	Type        string              `json:"type"`
+	CheckName   string              `json:"check_name"`
+	Description string              `json:"description"`
+	Categories  []string            `json:"categories"`
+	Location    codeClimateLocation `json:"location"`
+	Severity    string              `json:"severity"`
+	Fingerprint string              `json:"fingerprint"`
+}
+
+type codeClimateLocation struct {
+	Path  string           `json:"path"`
+	Lines codeClimateLines `json:"lines"`
+}
+
+type codeClimateLines struct {
+	Begin int `json:"begin"`
+	End   int `json:"end,omitempty"`
+}
+
+// WriteCodeClimate writes issues as the JSON array of Code Climate issues
+// that GitLab's Code Quality widget reads. Paths below the working
+// directory, which is the checkout in a pipeline, are written relative to
+// it. Fingerprints build on Fingerprint, which ignores lines, numbering the
+// issues that share one in the order they appear, so that findings don't
+// show as new when code above them moves.
+func WriteCodeClimate(w io.Writer, issues []Issue) (err error) {
+	var root string
+	root, err = os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	output := make([]codeClimateIssue, 0, len(issues))
+	occurrences := make(map[string]int)
+	for _, issue := range issues {
+		fp := Fingerprint(root, issue)
+		occurrences[fp]++
+		if n := occurrences[fp]; n > 1 {
+			sum := sha256.Sum256([]byte(fmt.Sprintf("%s#%d", fp, n)))
+			fp = hex.EncodeToString(sum[:])[:32]
+		}
+
+		lines := codeClimateLines{Begin: issue.Line}
+		if issue.EndLine > issue.Line {
+			lines.End = issue.EndLine
+		}
+
+		output = append(output, codeClimateIssue{
+			Type:        "issue",
+			CheckName:   analyzer.Analyzer.Name + "." + issue.Rule,
+			Description: issue.Message,
+			Categories:  []string{"Style"},
+			Location:    codeClimateLocation{Path: DisplayPath(root, issue.File), Lines: lines},
+			Severity:    codeClimateSeverity(issue.Severity),
+			Fingerprint: fp,
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(output)
+	return err
+}
+
+// codeClimateSeverity maps a rule severity onto the Code Climate severities
+// major, minor and info.
+func codeClimateSeverity(severity string) (level string) {
+	switch severity {
+	case analyzer.SeverityError:
+		level = "major"
+	case analyzer.SeverityInfo:
+		level = "info"
+	default:
+		level = "minor"
+	}
+	return level
+}
@@ -0,0 +1,329 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around the changes of a
+// unified diff, as diff -u does.
+const diffContext = 3
+
+// diffChange replaces whole lines of a file: the lines from first to last,
+// 0-based and inclusive, spanning the bytes from start to end.
+type diffChange struct {
+	first, last int
+	start, end  int
+	edits       []Edit
+}
+
+// unifiedDiff renders edits to the file with the given content as a unified
+// diff. Diff is empty if an edit lies outside the content or overlaps
+// another one, other than by repeating it.
+func unifiedDiff(name string, content []byte, edits []Edit) (diff string) {
+	text := string(content)
+	edits = append([]Edit(nil), edits...)
+	sort.SliceStable(edits, func(i, j int) (less bool) {
+		less = edits[i].Start.Offset < edits[j].Start.Offset
+		return less
+	})
+
+	starts := []int{0}
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' && i+1 < len(text) {
+			starts = append(starts, i+1)
+		}
+	}
+	lineOf := func(offset int) (line int) {
+		line = sort.Search(len(starts), func(i int) (after bool) {
+			after = starts[i] > offset
+			return after
+		}) - 1
+		return line
+	}
+	lineEnd := func(line int) (end int) {
+		end = len(text)
+		if line+1 < len(starts) {
+			end = starts[line+1]
+		}
+		return end
+	}
+
+	// Edits touching the same lines make up a single change
+	var changes []*diffChange
+	for i, edit := range edits {
+		if i > 0 && edit == edits[i-1] {
+			// Repeated edits are applied once
+			continue
+		}
+		if edit.Start.Offset < 0 || edit.End.Offset < edit.Start.Offset || edit.End.Offset > len(text) {
+			return diff
+		}
+		if i > 0 && edit.Start.Offset < edits[i-1].End.Offset {
+			// Overlapping edits can't be applied together
+			return diff
+		}
+
+		first, last := lineOf(edit.Start.Offset), lineOf(edit.End.Offset)
+		switch {
+		case edit.End.Offset > edit.Start.Offset && edit.End.Offset == starts[last]:
+			last--
+		case edit.End.Offset == edit.Start.Offset && strings.HasSuffix(edit.NewText, "\n"):
+			// Whole lines inserted before a line, or after the last one,
+			// change no line
+			if edit.Start.Offset == len(text) && strings.HasSuffix(text, "\n") {
+				first, last = len(starts), len(starts)-1
+			} else if edit.Start.Offset == starts[first] {
+				last = first - 1
+			}
+		}
+		if n := len(changes); n > 0 && first <= changes[n-1].last {
+			prev := changes[n-1]
+			prev.last = max(prev.last, last)
+			prev.end = lineEnd(prev.last)
+			prev.edits = append(prev.edits, edit)
+			continue
+		}
+		changes = append(changes, &diffChange{first: first, last: last, start: lineEnd(first - 1), end: lineEnd(last), edits: []Edit{edit}})
+	}
+	if len(changes) == 0 {
+		return diff
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", name, name)
+
+	delta := 0
+	for i := 0; i < len(changes); {
+		// Changes separated by no more than the context of both share a hunk
+		j := i + 1
+		for j < len(changes) && changes[j].first-changes[j-1].last-1 <= 2*diffContext {
+			j++
+		}
+
+		first := max(changes[i].first-diffContext, 0)
+		last := min(changes[j-1].last+diffContext, len(starts)-1)
+
+		var body strings.Builder
+		oldCount, newCount := 0, 0
+		line := first
+		for _, change := range changes[i:j] {
+			for ; line < change.first; line++ {
+				writeDiffLine(&body, ' ', text[starts[line]:lineEnd(line)])
+				oldCount++
+				newCount++
+			}
+
+			old := text[change.start:change.end]
+			replaced := applyEdits(old, change.start, change.edits)
+			for _, l := range diffLines(old) {
+				writeDiffLine(&body, '-', l)
+				oldCount++
+			}
+			for _, l := range diffLines(replaced) {
+				writeDiffLine(&body, '+', l)
+				newCount++
+			}
+			line = change.last + 1
+		}
+		for ; line <= last; line++ {
+			writeDiffLine(&body, ' ', text[starts[line]:lineEnd(line)])
+			oldCount++
+			newCount++
+		}
+
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(first+1, oldCount), hunkRange(first+1+delta, newCount))
+		b.WriteString(body.String())
+
+		delta += newCount - oldCount
+		i = j
+	}
+
+	diff = b.String()
+	return diff
+}
+
+// maxDiffDistance bounds the number of lines inserted and deleted lineEdits
+// looks for the fewest of. Files differing more are diffed as one change.
+const maxDiffDistance = 1000
+
+// Diff renders the changes turning before into after, the contents of the
+// file with the given name, as a unified diff. Diff is empty when they are
+// the same.
+func Diff(name string, before []byte, after []byte) (diff string) {
+	diff = unifiedDiff(name, before, lineEdits(string(before), string(after)))
+	return diff
+}
+
+// lineEdits returns the edits turning the lines of before into those of
+// after, deleting and inserting as few lines as possible.
+func lineEdits(before string, after string) (edits []Edit) {
+	a, b := diffLines(before), diffLines(after)
+
+	// Lines shared at the start and the end are left out of the search
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	offsets := make([]int, len(a)+1)
+	for i, line := range a {
+		offsets[i+1] = offsets[i] + len(line)
+	}
+
+	// Runs of lines between the matching ones are replaced
+	matches := matchLines(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])
+	matches = append(matches, [2]int{len(a) - suffix - prefix, len(b) - suffix - prefix})
+	i, j := 0, 0
+	for _, match := range matches {
+		if match[0] > i || match[1] > j {
+			edits = append(edits, Edit{
+				Start:   Position{Offset: offsets[prefix+i]},
+				End:     Position{Offset: offsets[prefix+match[0]]},
+				NewText: strings.Join(b[prefix+j:prefix+match[1]], ""),
+			})
+		}
+		i, j = match[0]+1, match[1]+1
+	}
+	return edits
+}
+
+// matchLines returns the pairs of indexes of the lines of a and b kept by
+// the shortest way of turning a into b, found with Myers' algorithm. None
+// are kept when it takes more than maxDiffDistance insertions and
+// deletions.
+func matchLines(a []string, b []string) (matches [][2]int) {
+	n, m := len(a), len(b)
+	limit := min(n+m, maxDiffDistance)
+
+	// v holds the furthest x reached on each diagonal k = x - y, offset by
+	// limit+1; trace holds its diagonals -d..d after each round d
+	v := make([]int, 2*limit+3)
+	offset := limit + 1
+	var trace [][]int
+	found := false
+	for d := 0; d <= limit && !found; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || k != d && v[offset+k-1] < v[offset+k+1] {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				found = true
+			}
+		}
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+	}
+	if !found {
+		return matches
+	}
+
+	// Walk back from the end, collecting the diagonal moves
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		k := x - y
+		prevX, prevY := 0, 0
+		if d > 0 {
+			prev := trace[d-1]
+			at := func(k int) (x int) {
+				x = prev[k+d-1]
+				return x
+			}
+			prevK := k - 1
+			if k == -d || k != d && at(k-1) < at(k+1) {
+				prevK = k + 1
+			}
+			prevX = at(prevK)
+			prevY = prevX - prevK
+			if prevK == k+1 {
+				// Moved down, inserting a line of b
+				for x > prevX && y > prevY+1 {
+					x--
+					y--
+					matches = append(matches, [2]int{x, y})
+				}
+			} else {
+				// Moved right, deleting a line of a
+				for x > prevX+1 && y > prevY {
+					x--
+					y--
+					matches = append(matches, [2]int{x, y})
+				}
+			}
+		} else {
+			for x > 0 && y > 0 {
+				x--
+				y--
+				matches = append(matches, [2]int{x, y})
+			}
+		}
+		x, y = prevX, prevY
+	}
+
+	for i, j := 0, len(matches)-1; i < j; i, j = i+1, j-1 {
+		matches[i], matches[j] = matches[j], matches[i]
+	}
+	return matches
+}
+
+// applyEdits applies edits, sorted and given by offset in the file, to text,
+// the part of the file starting at offset base.
+func applyEdits(text string, base int, edits []Edit) (result string) {
+	var b strings.Builder
+	pos := 0
+	for _, edit := range edits {
+		start, end := edit.Start.Offset-base, edit.End.Offset-base
+		b.WriteString(text[pos:start])
+		b.WriteString(edit.NewText)
+		pos = end
+	}
+	b.WriteString(text[pos:])
+	result = b.String()
+	return result
+}
+
+// diffLines splits text into lines, keeping their line feeds.
+func diffLines(text string) (lines []string) {
+	lines = strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// writeDiffLine writes a line of a hunk, marking a last line without a line
+// feed as diff does.
+func writeDiffLine(b *strings.Builder, prefix byte, line string) {
+	b.WriteByte(prefix)
+	b.WriteString(line)
+	if !strings.HasSuffix(line, "\n") {
+		b.WriteString("\n\\ No newline at end of file\n")
+	}
+}
+
+// hunkRange formats the range of a hunk header, which omits a count of one
+// and gives an empty range as the line before it.
+func hunkRange(start int, count int) (r string) {
+	switch count {
+	case 0:
+		r = fmt.Sprintf("%d,0", start-1)
+	case 1:
+		r = fmt.Sprint(start)
+	default:
+		r = fmt.Sprintf("%d,%d", start, count)
+	}
+	return r
+}
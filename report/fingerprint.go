@@ -0,0 +1,34 @@
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+)
+
+// Fingerprint identifies issue independently of its line and column, so it
+// survives edits elsewhere in the file. It hashes the rule, the file as given
+// by DisplayPath and the message, which names the function and the result
+// variables involved. Issues repeated within a function share a fingerprint.
+func Fingerprint(root string, issue Issue) (fp string) {
+	h := sha256.New()
+	for _, part := range []string{issue.Rule, DisplayPath(root, issue.File), issue.Message} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	fp = hex.EncodeToString(h.Sum(nil))[:32]
+	return fp
+}
+
+// DisplayPath returns file relative to root, using forward slashes, when it
+// lives below root, and file unchanged otherwise.
+func DisplayPath(root string, file string) (path string) {
+	rel, ok := relativePath(root, file)
+	if !ok {
+		path = filepath.ToSlash(file)
+		return path
+	}
+
+	path = rel
+	return path
+}
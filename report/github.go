@@ -0,0 +1,70 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/nikogura/namedreturns/analyzer"
+)
+
+// WriteGitHub writes issues as GitHub Actions workflow commands, e.g.
+//
+//	::error file=pkg/server.go,line=12,col=1,title=namedreturns NR001::message
+//
+// so they are shown inline on pull requests. Files below the working
+// directory, which is the checkout in a workflow, are written relative to it.
+func WriteGitHub(w io.Writer, issues []Issue) (err error) {
+	var root string
+	root, err = os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	for _, issue := range issues {
+		file := issue.File
+		if rel, ok := relativePath(root, file); ok {
+			file = rel
+		}
+
+		properties := []string{
+			"file=" + escapeGitHubProperty(file),
+			fmt.Sprintf("line=%d", issue.Line),
+			fmt.Sprintf("col=%d", issue.Column),
+		}
+		if issue.EndLine > 0 {
+			properties = append(properties, fmt.Sprintf("endLine=%d", issue.EndLine), fmt.Sprintf("endColumn=%d", issue.EndColumn))
+		}
+		properties = append(properties, "title="+escapeGitHubProperty(analyzer.Analyzer.Name+" "+issue.Rule))
+
+		_, err = fmt.Fprintf(w, "::%s %s::%s\n", githubCommand(issue.Severity), strings.Join(properties, ","), escapeGitHubData(issue.Message))
+		if err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// githubCommand maps a rule severity onto the error, warning and notice commands.
+func githubCommand(severity string) (command string) {
+	switch severity {
+	case analyzer.SeverityError:
+		command = "error"
+	case analyzer.SeverityInfo:
+		command = "notice"
+	default:
+		command = "warning"
+	}
+	return command
+}
+
+func escapeGitHubData(s string) (escaped string) {
+	escaped = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+	return escaped
+}
+
+func escapeGitHubProperty(s string) (escaped string) {
+	escaped = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+	return escaped
+}
@@ -0,0 +1,170 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/nikogura/namedreturns/analyzer"
+)
+
+// snippetContext is how many lines around the line of an issue its snippet
+// shows.
+const snippetContext = 2
+
+type htmlReport struct {
+	Tool       string
+	Total      int
+	Severities []htmlSeverity
+	Packages   []htmlPackage
+}
+
+type htmlSeverity struct {
+	Name  string
+	Count int
+}
+
+type htmlPackage struct {
+	Name   string
+	Issues []htmlIssue
+}
+
+type htmlIssue struct {
+	Issue
+	Location string       // as shown, relative to the working directory when below it
+	Link     template.URL // file URLs are not trusted by the template otherwise
+	Snippet  []htmlLine
+}
+
+type htmlLine struct {
+	Number  int
+	Text    string
+	Current bool // the line of the issue
+}
+
+// WriteHTML writes issues as a self-contained HTML page, grouped by package,
+// with a filter per severity and the source lines around each issue. Files
+// below the working directory are shown and linked relative to it, so the
+// links work from a page saved at the root of the checkout.
+func WriteHTML(w io.Writer, issues []Issue) (err error) {
+	var root string
+	root, err = os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	r := htmlReport{Tool: analyzer.Analyzer.Name, Total: len(issues)}
+	severities := make(map[string]int)
+	packages := make(map[string]int)
+	sources := make(map[string][]string)
+	for _, issue := range issues {
+		severities[issue.Severity]++
+
+		name := issue.Package
+		if name == "" {
+			name = "(unknown package)"
+		}
+		i, ok := packages[name]
+		if !ok {
+			i = len(r.Packages)
+			packages[name] = i
+			r.Packages = append(r.Packages, htmlPackage{Name: name})
+		}
+
+		location := filepath.ToSlash(issue.File)
+		link := url.URL{Scheme: "file", Path: location, Fragment: fmt.Sprintf("L%d", issue.Line)}
+		if rel, relOK := relativePath(root, issue.File); relOK {
+			location = filepath.ToSlash(rel)
+			link = url.URL{Path: location, Fragment: link.Fragment}
+		}
+
+		r.Packages[i].Issues = append(r.Packages[i].Issues, htmlIssue{
+			Issue:    issue,
+			Location: fmt.Sprintf("%s:%d:%d", location, issue.Line, issue.Column),
+			Link:     template.URL(link.String()),
+			Snippet:  snippet(sources, issue.File, issue.Line),
+		})
+	}
+
+	sort.SliceStable(r.Packages, func(i, j int) (less bool) {
+		less = r.Packages[i].Name < r.Packages[j].Name
+		return less
+	})
+	for _, name := range []string{analyzer.SeverityError, analyzer.SeverityWarning, analyzer.SeverityInfo} {
+		if severities[name] > 0 {
+			r.Severities = append(r.Severities, htmlSeverity{Name: name, Count: severities[name]})
+		}
+	}
+
+	var buf bytes.Buffer
+	err = htmlTemplate.Execute(&buf, r)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// snippet returns the lines of file around line, reading each file once
+// through sources. Files that can't be read have no snippet.
+func snippet(sources map[string][]string, file string, line int) (lines []htmlLine) {
+	text := sourceLines(sources, file)
+
+	for n := max(line-snippetContext, 1); n <= min(line+snippetContext, len(text)); n++ {
+		lines = append(lines, htmlLine{Number: n, Text: text[n-1], Current: n == line})
+	}
+	return lines
+}
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Tool}} report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+h2 { font-size: 1.1em; border-bottom: 1px solid #ccc; padding-bottom: .2em; }
+.issue { margin: .6em 0; }
+.severity { display: inline-block; min-width: 5em; font-weight: bold; }
+.error .severity { color: #b00; }
+.warning .severity { color: #a60; }
+.info .severity { color: #06a; }
+.rule { color: #666; }
+pre { background: #f6f6f6; padding: .5em; margin: .3em 0 0 1em; }
+.current { background: #fde9a9; }
+.lineno { color: #999; user-select: none; }
+.filters label { margin-right: 1em; }
+</style>
+</head>
+<body>
+<h1>{{.Tool}}: {{.Total}} issue{{if ne .Total 1}}s{{end}}</h1>
+{{if .Severities}}<div class="filters">
+{{range .Severities}}<label><input type="checkbox" checked data-severity="{{.Name}}"> {{.Name}} ({{.Count}})</label>
+{{end}}</div>
+{{end}}{{range .Packages}}<section class="package">
+<h2>{{.Name}}</h2>
+{{range .Issues}}<div class="issue {{.Severity}}">
+<span class="severity">{{.Severity}}</span>
+<a href="{{.Link}}">{{.Location}}</a>: {{.Message}} <span class="rule">[{{.Rule}}]</span>
+{{if .Snippet}}<details><summary>source</summary><pre>{{range .Snippet}}<span{{if .Current}} class="current"{{end}}><span class="lineno">{{printf "%5d" .Number}}</span> {{.Text}}</span>
+{{end}}</pre></details>
+{{end}}</div>
+{{end}}</section>
+{{end}}<script>
+document.querySelectorAll("[data-severity]").forEach(function (box) {
+  box.addEventListener("change", function () {
+    document.querySelectorAll(".issue." + box.dataset.severity).forEach(function (issue) {
+      issue.style.display = box.checked ? "" : "none";
+    });
+  });
+});
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,46 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// jsonDocument is the top level object written by WriteJSON. Fields are only
+// ever added to it, never renamed or removed.
+type jsonDocument struct {
+	Issues []Issue `json:"issues"`
+}
+
+// WriteJSON writes issues as a single JSON object of the form
+//
+//	{
+//	  "issues": [
+//	    {
+//	      "file": "/abs/path/file.go",
+//	      "line": 12,
+//	      "column": 1,
+//	      "package": "example.com/pkg",
+//	      "rule": "NR001",
+//	      "message": "func load: unnamed return with type \"error\" found - named returns are required",
+//	      "severity": "error",
+//	      "related": [{"file": "...", "line": 14, "column": 2, "message": "..."}],
+//	      "fixes": [{"message": "...", "edits": [{"file": "...", "start": {"line": 12, "column": 20, "offset": 301}, "end": {...}, "new_text": "err "}], "diff": "--- ...\n+++ ...\n@@ -12 +12 @@\n..."}]
+//	    }
+//	  ]
+//	}
+//
+// The issues array is always present, even when empty. The positions of
+// edits refer to the files on disk, ignoring //line directives, and the
+// diff of a fix, which patch -p0 applies, holds the same edits as a unified
+// diff.
+func WriteJSON(w io.Writer, issues []Issue) (err error) {
+	doc := jsonDocument{Issues: issues}
+	if doc.Issues == nil {
+		doc.Issues = []Issue{}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(doc)
+	return err
+}
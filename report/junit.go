@@ -0,0 +1,91 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/nikogura/namedreturns/analyzer"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string       `xml:"name,attr"`
+	ClassName string       `xml:"classname,attr"`
+	File      string       `xml:"file,attr"`
+	Line      int          `xml:"line,attr"`
+	Failure   junitFailure `xml:"failure"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes issues as a JUnit XML report, each issue a failed test
+// case, with one test suite per package, in the order the packages first
+// appear in issues. Issues without a package are grouped by directory.
+func WriteJUnit(w io.Writer, issues []Issue) (err error) {
+	output := junitTestSuites{Name: analyzer.Analyzer.Name, Tests: len(issues), Failures: len(issues)}
+
+	suites := make(map[string]int)
+	for _, issue := range issues {
+		pkg := issue.Package
+		if pkg == "" {
+			pkg = filepath.Dir(issue.File)
+		}
+
+		i, ok := suites[pkg]
+		if !ok {
+			i = len(output.Suites)
+			suites[pkg] = i
+			output.Suites = append(output.Suites, junitTestSuite{Name: pkg})
+		}
+
+		location := fmt.Sprintf("%s:%d:%d", issue.File, issue.Line, issue.Column)
+		output.Suites[i].Tests++
+		output.Suites[i].Failures++
+		output.Suites[i].Cases = append(output.Suites[i].Cases, junitTestCase{
+			Name:      issue.Rule + " " + location,
+			ClassName: pkg,
+			File:      issue.File,
+			Line:      issue.Line,
+			Failure: junitFailure{
+				Message: issue.Message,
+				Type:    issue.Rule,
+				Text:    fmt.Sprintf("%s: %s [%s, %s]", location, issue.Message, issue.Rule, issue.Severity),
+			},
+		})
+	}
+
+	_, err = io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	err = encoder.Encode(output)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,108 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/nikogura/namedreturns/analyzer"
+)
+
+// WriteMarkdown writes issues as Markdown, with a table of the issues of
+// each package, for pull request descriptions and comments. Files below the
+// working directory are written relative to it.
+func WriteMarkdown(w io.Writer, issues []Issue) (err error) {
+	var root string
+	root, err = os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "## %s: %s\n", analyzer.Analyzer.Name, countIssues(len(issues)))
+	if err != nil {
+		return err
+	}
+
+	byPackage := make(map[string][]Issue)
+	for _, issue := range issues {
+		byPackage[issue.Package] = append(byPackage[issue.Package], issue)
+	}
+	packages := make([]string, 0, len(byPackage))
+	for pkg := range byPackage {
+		packages = append(packages, pkg)
+	}
+	sort.Strings(packages)
+
+	for _, pkg := range packages {
+		name := pkg
+		if name == "" {
+			name = "(unknown package)"
+		}
+		_, err = fmt.Fprintf(w, "\n### %s\n\n| Location | Rule | Severity | Message |\n|----------|------|----------|---------|\n", escapeMarkdown(name))
+		if err != nil {
+			return err
+		}
+
+		for _, issue := range byPackage[pkg] {
+			file := issue.File
+			if rel, ok := relativePath(root, file); ok {
+				file = rel
+			}
+			_, err = fmt.Fprintf(w, "| `%s:%d:%d` | %s | %s | %s |\n", file, issue.Line, issue.Column, issue.Rule, issue.Severity, escapeMarkdown(issue.Message))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return err
+}
+
+// WriteMarkdownSummary writes only the number of issues per rule, as a
+// Markdown table.
+func WriteMarkdownSummary(w io.Writer, issues []Issue) (err error) {
+	_, err = fmt.Fprintf(w, "## %s: %s\n", analyzer.Analyzer.Name, countIssues(len(issues)))
+	if err != nil || len(issues) == 0 {
+		return err
+	}
+
+	counts := make(map[string]int)
+	for _, issue := range issues {
+		counts[issue.Rule]++
+	}
+	ids := make([]string, 0, len(counts))
+	for id := range counts {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	_, err = io.WriteString(w, "\n| Rule | Name | Issues |\n|------|------|--------|\n")
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		rule, _ := analyzer.LookupRule(id)
+		_, err = fmt.Fprintf(w, "| %s | %s | %d |\n", id, rule.Name, counts[id])
+		if err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// countIssues describes a number of issues, e.g. "1 issue".
+func countIssues(n int) (s string) {
+	s = fmt.Sprintf("%d issues", n)
+	if n == 1 {
+		s = "1 issue"
+	}
+	return s
+}
+
+// escapeMarkdown escapes text for a table cell, where pipes end the cell and
+// newlines the row.
+func escapeMarkdown(s string) (escaped string) {
+	escaped = strings.NewReplacer("|", `\|`, "\r", "", "\n", "<br>").Replace(s)
+	return escaped
+}
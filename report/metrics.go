@@ -0,0 +1,64 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MetricsContentType is the media type of the metrics written by
+// WriteMetrics, the Prometheus text exposition format.
+const MetricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// WriteMetrics writes stats and the duration of the analysis in the
+// Prometheus text exposition format, as accepted by a push gateway. The
+// values describe one run, so they are gauges: each push replaces the
+// previous one.
+func WriteMetrics(w io.Writer, stats Stats, duration time.Duration) (err error) {
+	metrics := []struct {
+		name, help, label string
+		values            map[string]int
+	}{
+		{"namedreturns_findings", "Findings of the last run.", "", map[string]int{"": stats.Issues}},
+		{"namedreturns_rule_findings", "Findings of the last run by rule.", "rule", stats.Rules},
+		{"namedreturns_package_findings", "Findings of the last run by package.", "package", stats.Packages},
+		{"namedreturns_functions", "Analyzed functions with results.", "", map[string]int{"": stats.Functions}},
+		{"namedreturns_fully_named_functions", "Analyzed functions naming all of their results.", "", map[string]int{"": stats.FullyNamed}},
+	}
+
+	for _, m := range metrics {
+		_, err = fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", m.name, m.help, m.name)
+		if err != nil {
+			return err
+		}
+
+		keys := make([]string, 0, len(m.values))
+		for key := range m.values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			labels := ""
+			if m.label != "" {
+				labels = fmt.Sprintf(`{%s="%s"}`, m.label, escapeLabel(key))
+			}
+			_, err = fmt.Fprintf(w, "%s%s %d\n", m.name, labels, m.values[key])
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err = fmt.Fprintf(w, "# HELP namedreturns_analysis_duration_seconds Duration of the last run.\n# TYPE namedreturns_analysis_duration_seconds gauge\nnamedreturns_analysis_duration_seconds %g\n", duration.Seconds())
+	return err
+}
+
+// escapeLabel escapes a label value, in which the exposition format only
+// escapes backslashes, double quotes and newlines.
+func escapeLabel(s string) (escaped string) {
+	escaped = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+	return escaped
+}
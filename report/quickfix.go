@@ -0,0 +1,22 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteQuickfix writes one "file:line:column: message" line per issue and
+// nothing else, as the errorformat of Vim's quickfix list and Emacs'
+// compilation mode parse by default. Line breaks within messages are
+// replaced by spaces to keep each issue on its line.
+func WriteQuickfix(w io.Writer, issues []Issue) (err error) {
+	for _, issue := range issues {
+		message := strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ").Replace(issue.Message)
+		_, err = fmt.Fprintf(w, "%s:%d:%d: %s\n", issue.File, issue.Line, issue.Column, message)
+		if err != nil {
+			return err
+		}
+	}
+	return err
+}
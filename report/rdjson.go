@@ -0,0 +1,185 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/nikogura/namedreturns/analyzer"
+)
+
+// The types below follow the Reviewdog Diagnostic Format, see
+// https://github.com/reviewdog/reviewdog/tree/master/proto/rdf
+
+type rdjsonResult struct {
+	Source      rdjsonSource       `json:"source"`
+	Diagnostics []rdjsonDiagnostic `json:"diagnostics"`
+}
+
+type rdjsonSource struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+type rdjsonDiagnostic struct {
+	Message          string                  `json:"message"`
+	Location         rdjsonLocation          `json:"location"`
+	Severity         string                  `json:"severity"`
+	Source           rdjsonSource            `json:"source"`
+	Code             rdjsonCode              `json:"code"`
+	Suggestions      []rdjsonSuggestion      `json:"suggestions,omitempty"`
+	RelatedLocations []rdjsonRelatedLocation `json:"related_locations,omitempty"`
+}
+
+type rdjsonLocation struct {
+	Path  string      `json:"path"`
+	Range rdjsonRange `json:"range"`
+}
+
+type rdjsonRange struct {
+	Start rdjsonPosition  `json:"start"`
+	End   *rdjsonPosition `json:"end,omitempty"`
+}
+
+// rdjsonPosition is 1-based, with columns counting bytes like Issue's.
+type rdjsonPosition struct {
+	Line   int `json:"line"`
+	Column int `json:"column,omitempty"`
+}
+
+type rdjsonCode struct {
+	Value string `json:"value"`
+	URL   string `json:"url,omitempty"`
+}
+
+type rdjsonSuggestion struct {
+	Range rdjsonRange `json:"range"`
+	Text  string      `json:"text"`
+}
+
+type rdjsonRelatedLocation struct {
+	Message  string         `json:"message"`
+	Location rdjsonLocation `json:"location"`
+}
+
+// WriteRDJSON writes issues as a single Reviewdog Diagnostic Format result,
+// for reviewdog -f=rdjson.
+func WriteRDJSON(w io.Writer, issues []Issue) (err error) {
+	var diagnostics []rdjsonDiagnostic
+	diagnostics, err = rdjsonDiagnostics(issues)
+	if err != nil {
+		return err
+	}
+
+	result := rdjsonResult{Source: rdjsonTool(), Diagnostics: diagnostics}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(result)
+	return err
+}
+
+// WriteRDJSONL writes issues as Reviewdog Diagnostic Format diagnostics, one
+// per line, for reviewdog -f=rdjsonl.
+func WriteRDJSONL(w io.Writer, issues []Issue) (err error) {
+	var diagnostics []rdjsonDiagnostic
+	diagnostics, err = rdjsonDiagnostics(issues)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+	for _, diagnostic := range diagnostics {
+		err = encoder.Encode(diagnostic)
+		if err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// rdjsonDiagnostics converts issues into diagnostics with paths relative to
+// the working directory, which reviewdog matches against the diff. Fixes
+// become suggestions, which reviewdog posts as applicable review comments.
+func rdjsonDiagnostics(issues []Issue) (diagnostics []rdjsonDiagnostic, err error) {
+	var root string
+	root, err = os.Getwd()
+	if err != nil {
+		return diagnostics, err
+	}
+
+	diagnostics = []rdjsonDiagnostic{}
+	for _, issue := range issues {
+		diagnostic := rdjsonDiagnostic{
+			Message: issue.Message,
+			Location: rdjsonLocation{
+				Path:  DisplayPath(root, issue.File),
+				Range: rdjsonRange{Start: rdjsonPosition{Line: issue.Line, Column: issue.Column}},
+			},
+			Severity: rdjsonSeverity(issue.Severity),
+			Source:   rdjsonTool(),
+			Code:     rdjsonCode{Value: issue.Rule, URL: toolURI + "#rules"},
+		}
+		if issue.EndLine > 0 {
+			diagnostic.Location.Range.End = &rdjsonPosition{Line: issue.EndLine, Column: issue.EndColumn}
+		}
+
+		for _, r := range issue.Related {
+			diagnostic.RelatedLocations = append(diagnostic.RelatedLocations, rdjsonRelatedLocation{
+				Message: r.Message,
+				Location: rdjsonLocation{
+					Path:  DisplayPath(root, r.File),
+					Range: rdjsonRange{Start: rdjsonPosition{Line: r.Line, Column: r.Column}},
+				},
+			})
+		}
+
+		diagnostic.Suggestions = rdjsonSuggestions(issue)
+		diagnostics = append(diagnostics, diagnostic)
+	}
+	return diagnostics, err
+}
+
+// rdjsonSuggestions converts the first fix of issue into suggestions.
+// Reviewdog applies all suggestions of a diagnostic together and only within
+// the file of the diagnostic, so alternative fixes and fixes touching other
+// files are left out.
+func rdjsonSuggestions(issue Issue) (suggestions []rdjsonSuggestion) {
+	if len(issue.Fixes) == 0 {
+		return suggestions
+	}
+
+	for _, edit := range issue.Fixes[0].Edits {
+		if edit.File != issue.File {
+			suggestions = nil
+			return suggestions
+		}
+		suggestions = append(suggestions, rdjsonSuggestion{
+			Range: rdjsonRange{
+				Start: rdjsonPosition{Line: edit.Start.Line, Column: edit.Start.Column},
+				End:   &rdjsonPosition{Line: edit.End.Line, Column: edit.End.Column},
+			},
+			Text: edit.NewText,
+		})
+	}
+	return suggestions
+}
+
+func rdjsonTool() (source rdjsonSource) {
+	source = rdjsonSource{Name: analyzer.Analyzer.Name, URL: toolURI}
+	return source
+}
+
+// rdjsonSeverity maps a rule severity onto the reviewdog severities ERROR,
+// WARNING and INFO.
+func rdjsonSeverity(severity string) (level string) {
+	switch severity {
+	case analyzer.SeverityError:
+		level = "ERROR"
+	case analyzer.SeverityInfo:
+		level = "INFO"
+	default:
+		level = "WARNING"
+	}
+	return level
+}
@@ -0,0 +1,300 @@
+// Package report converts analysis diagnostics into issues and renders them
+// in the output formats supported by the namedreturns command.
+package report
+
+import (
+	"fmt"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/nikogura/namedreturns/analyzer"
+	"golang.org/x/tools/go/analysis"
+)
+
+// Issue is a single finding, independent of the analysis framework.
+type Issue struct {
+	File      string    `json:"file"`
+	Line      int       `json:"line"`
+	Column    int       `json:"column"`
+	EndLine   int       `json:"end_line,omitempty"`
+	EndColumn int       `json:"end_column,omitempty"`
+	Package   string    `json:"package,omitempty"`
+	Rule      string    `json:"rule"`
+	Message   string    `json:"message"`
+	Severity  string    `json:"severity"`
+	Related   []Related `json:"related,omitempty"`
+	Fixes     []Fix     `json:"fixes,omitempty"`
+
+	// Variants lists the platforms and build tags the issue was found for,
+	// when several were analyzed and only some of them have it.
+	Variants []string `json:"variants,omitempty"`
+}
+
+// Related is a secondary location attached to an issue.
+type Related struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Message string `json:"message"`
+}
+
+// Fix is a suggested fix made of edits that should be applied together.
+type Fix struct {
+	Message string `json:"message"`
+	Edits   []Edit `json:"edits"`
+
+	// Diff holds the edits as a unified diff of the files they change,
+	// empty if the files couldn't be read.
+	Diff string `json:"diff,omitempty"`
+}
+
+// Edit replaces the text between Start and End with NewText. Its positions
+// ignore //line directives, so they always refer to the file on disk,
+// whichever file set it was loaded with.
+type Edit struct {
+	File    string   `json:"file"`
+	Start   Position `json:"start"`
+	End     Position `json:"end"`
+	NewText string   `json:"new_text"`
+}
+
+// Position is a location in a file. Line and Column are 1-based, Column
+// counts bytes, and Offset is the 0-based byte offset from the start of the file.
+type Position struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	Offset int `json:"offset"`
+}
+
+// Formatter renders issues to w.
+type Formatter func(w io.Writer, issues []Issue) (err error)
+
+var formatters = map[string]Formatter{
+	"checkstyle":  WriteCheckstyle,
+	"codeclimate": WriteCodeClimate,
+	"github":      WriteGitHub,
+	"html":        WriteHTML,
+	"json":        WriteJSON,
+	"junit":       WriteJUnit,
+	"markdown":    WriteMarkdown,
+	"quickfix":    WriteQuickfix,
+	"rdjson":      WriteRDJSON,
+	"rdjsonl":     WriteRDJSONL,
+	"sarif":       WriteSARIF,
+	"teamcity":    WriteTeamCity,
+	"text":        WriteText,
+}
+
+// Lookup returns the formatter registered under name.
+func Lookup(name string) (formatter Formatter, err error) {
+	var ok bool
+	formatter, ok = formatters[name]
+	if !ok {
+		err = fmt.Errorf("unknown format %q, expected one of %v", name, Formats())
+		return formatter, err
+	}
+	return formatter, err
+}
+
+// Formats returns the names of all supported formats, sorted.
+func Formats() (names []string) {
+	for name := range formatters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FromDiagnostic converts a diagnostic reported in pkg into an Issue.
+func FromDiagnostic(fset *token.FileSet, pkg string, d analysis.Diagnostic) (issue Issue) {
+	pos := fset.Position(d.Pos)
+	issue = Issue{
+		File:     pos.Filename,
+		Line:     pos.Line,
+		Column:   pos.Column,
+		Package:  pkg,
+		Rule:     d.Category,
+		Message:  d.Message,
+		Severity: analyzer.SeverityWarning,
+	}
+
+	if d.End.IsValid() {
+		end := fset.Position(d.End)
+		issue.EndLine = end.Line
+		issue.EndColumn = end.Column
+	}
+
+	if rule, ok := analyzer.LookupRule(d.Category); ok {
+		issue.Severity = rule.Severity
+	}
+
+	for _, r := range d.Related {
+		related := fset.Position(r.Pos)
+		issue.Related = append(issue.Related, Related{
+			File:    related.Filename,
+			Line:    related.Line,
+			Column:  related.Column,
+			Message: r.Message,
+		})
+	}
+
+	contents := make(map[string][]byte)
+	for _, f := range d.SuggestedFixes {
+		fix := Fix{Message: f.Message, Edits: []Edit{}}
+		var files []string
+		for _, e := range f.TextEdits {
+			end := e.End
+			if !end.IsValid() {
+				end = e.Pos
+			}
+			start := fset.PositionFor(e.Pos, false)
+			fix.Edits = append(fix.Edits, Edit{
+				File:    start.Filename,
+				Start:   position(start),
+				End:     position(fset.PositionFor(end, false)),
+				NewText: string(e.NewText),
+			})
+			if !slices.Contains(files, start.Filename) {
+				files = append(files, start.Filename)
+			}
+		}
+		fix.Diff = fixDiff(fix.Edits, files, contents)
+		issue.Fixes = append(issue.Fixes, fix)
+	}
+
+	return issue
+}
+
+// fixDiff renders the edits of a fix as a unified diff of files, reading
+// the files not in contents yet. Diff is empty if a file can't be read.
+func fixDiff(edits []Edit, files []string, contents map[string][]byte) (diff string) {
+	var b strings.Builder
+	for _, file := range files {
+		content, ok := contents[file]
+		if !ok {
+			var err error
+			content, err = os.ReadFile(file)
+			if err != nil {
+				return diff
+			}
+			contents[file] = content
+		}
+
+		var fileEdits []Edit
+		for _, edit := range edits {
+			if edit.File == file {
+				fileEdits = append(fileEdits, edit)
+			}
+		}
+		b.WriteString(unifiedDiff(file, content, fileEdits))
+	}
+
+	diff = b.String()
+	return diff
+}
+
+func position(pos token.Position) (p Position) {
+	p = Position{Line: pos.Line, Column: pos.Column, Offset: pos.Offset}
+	return p
+}
+
+// Sort orders issues by file, position, rule and message.
+func Sort(issues []Issue) {
+	sort.SliceStable(issues, func(i, j int) (less bool) {
+		a, b := issues[i], issues[j]
+		switch {
+		case a.File != b.File:
+			less = a.File < b.File
+		case a.Line != b.Line:
+			less = a.Line < b.Line
+		case a.Column != b.Column:
+			less = a.Column < b.Column
+		case a.Rule != b.Rule:
+			less = a.Rule < b.Rule
+		default:
+			less = a.Message < b.Message
+		}
+		return less
+	})
+}
+
+// Dedupe removes issues reported more than once over the same range with the
+// same rule and message, as happens when a file belongs to both a package and
+// its test variant. The first occurrence is kept, noting the variants of all.
+// Issues starting at the same position but ending elsewhere are distinct, as
+// are those about the results of func f() (int, int).
+func Dedupe(issues []Issue) (unique []Issue) {
+	type key struct {
+		file               string
+		line, column       int
+		endLine, endColumn int
+		rule, msg          string
+	}
+
+	seen := make(map[key]int, len(issues)) // index in unique
+	for _, issue := range issues {
+		k := key{issue.File, issue.Line, issue.Column, issue.EndLine, issue.EndColumn, issue.Rule, issue.Message}
+		if i, ok := seen[k]; ok {
+			for _, variant := range issue.Variants {
+				if !slices.Contains(unique[i].Variants, variant) {
+					unique[i].Variants = append(unique[i].Variants, variant)
+				}
+			}
+			continue
+		}
+		seen[k] = len(unique)
+		unique = append(unique, issue)
+	}
+	return unique
+}
+
+// RelativePaths returns a copy of issues with the files of the issues, of
+// their related locations and of the edits of their fixes relative to root,
+// going up with .. for those outside it. Files on other volumes are left
+// unchanged.
+func RelativePaths(root string, issues []Issue) (rel []Issue) {
+	relative := func(file string) (path string) {
+		path = file
+		if r, err := filepath.Rel(root, file); err == nil && filepath.IsAbs(file) {
+			path = r
+		}
+		return path
+	}
+
+	rel = make([]Issue, len(issues))
+	for i, issue := range issues {
+		issue.File = relative(issue.File)
+		issue.Related = slices.Clone(issue.Related)
+		for j := range issue.Related {
+			issue.Related[j].File = relative(issue.Related[j].File)
+		}
+		issue.Fixes = slices.Clone(issue.Fixes)
+		for j, fix := range issue.Fixes {
+			fix.Edits = slices.Clone(fix.Edits)
+			for k := range fix.Edits {
+				fix.Edits[k].File = relative(fix.Edits[k].File)
+			}
+			issue.Fixes[j] = fix
+		}
+		rel[i] = issue
+	}
+	return rel
+}
+
+// relativePath returns file relative to root when file lives below root.
+func relativePath(root string, file string) (rel string, ok bool) {
+	var err error
+	rel, err = filepath.Rel(root, file)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return rel, ok
+	}
+
+	rel = filepath.ToSlash(rel)
+	ok = true
+	return rel, ok
+}
@@ -0,0 +1,679 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+func testIssues() (issues []Issue) {
+	issues = []Issue{
+		{File: "b.go", Line: 3, Column: 1, Package: "example.com/b", Rule: "NR001", Message: "func b: unnamed return with type \"error\" found - named returns are required", Severity: "error"},
+		{File: "a.go", Line: 7, Column: 2, Package: "example.com/a", Rule: "NR004", Message: "func a: named return variable \"err\" is shadowed by local variable declaration", Severity: "error",
+			Related: []Related{{File: "a.go", Line: 5, Column: 20, Message: "named return variable \"err\" declared here"}}},
+		{File: "a.go", Line: 5, Column: 1, Package: "example.com/a", Rule: "NR003", Message: "func a: named return variable \"n\" is declared but not used in return statement", Severity: "warning"},
+	}
+	return issues
+}
+
+func TestSortAndDedupe(t *testing.T) {
+	issues := append(testIssues(), testIssues()...)
+	issues = Dedupe(issues)
+	Sort(issues)
+
+	if len(issues) != 3 {
+		t.Fatalf("expected 3 unique issues, got %d", len(issues))
+	}
+
+	order := []string{"NR003", "NR004", "NR001"}
+	for i, rule := range order {
+		if issues[i].Rule != rule {
+			t.Errorf("issue %d: expected rule %s, got %s", i, rule, issues[i].Rule)
+		}
+	}
+}
+
+func TestDedupeSameTypedResults(t *testing.T) {
+	// The findings about the results of func f() (int, int) start at the
+	// function, ending at their own result
+	first := Issue{File: "f.go", Line: 3, Column: 1, EndLine: 3, EndColumn: 14, Rule: "NR001", Message: "func f: unnamed return with type \"int\" found - named returns are required", Severity: "error"}
+	second := first
+	second.EndColumn = 19
+
+	issues := Dedupe([]Issue{first, second, first, second})
+	if len(issues) != 2 {
+		t.Fatalf("expected the findings of both results, got %+v", issues)
+	}
+	if issues[0].EndColumn != 14 || issues[1].EndColumn != 19 {
+		t.Errorf("expected the findings in their order, got %+v", issues)
+	}
+}
+
+func TestDedupeVariants(t *testing.T) {
+	linux, windows := testIssues(), testIssues()
+	for i := range linux {
+		linux[i].Variants = []string{"linux/amd64"}
+		windows[i].Variants = []string{"windows/amd64"}
+	}
+
+	issues := Dedupe(append(append(linux, windows[:1]...), linux[:1]...))
+	if len(issues) != 3 {
+		t.Fatalf("expected 3 unique issues, got %d", len(issues))
+	}
+	if got := strings.Join(issues[0].Variants, ","); got != "linux/amd64,windows/amd64" {
+		t.Errorf("expected the variants of both occurrences, got %s", got)
+	}
+	if got := strings.Join(issues[1].Variants, ","); got != "linux/amd64" {
+		t.Errorf("expected the variant of the only occurrence, got %s", got)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteJSON(&buf, testIssues())
+	if err != nil {
+		t.Fatalf("WriteJSON failed: %s", err)
+	}
+
+	var doc struct {
+		Issues []map[string]interface{} `json:"issues"`
+	}
+	err = json.Unmarshal(buf.Bytes(), &doc)
+	if err != nil {
+		t.Fatalf("output is not valid JSON: %s", err)
+	}
+
+	if len(doc.Issues) != 3 {
+		t.Fatalf("expected 3 issues, got %d", len(doc.Issues))
+	}
+
+	for _, field := range []string{"file", "line", "column", "rule", "message", "severity"} {
+		if _, ok := doc.Issues[0][field]; !ok {
+			t.Errorf("issue is missing field %q", field)
+		}
+	}
+
+	buf.Reset()
+	err = WriteJSON(&buf, nil)
+	if err != nil {
+		t.Fatalf("WriteJSON failed: %s", err)
+	}
+	if got := buf.String(); got != "{\n  \"issues\": []\n}\n" {
+		t.Errorf("empty output should still contain an issues array, got %q", got)
+	}
+}
+
+func TestWriteSARIF(t *testing.T) {
+	issues := testIssues()
+	issues[0].Fixes = []Fix{{
+		Message: "name the result",
+		Edits:   []Edit{{File: "b.go", Start: Position{Line: 3, Column: 10, Offset: 40}, End: Position{Line: 3, Column: 10, Offset: 40}, NewText: "err "}},
+	}}
+
+	var buf bytes.Buffer
+	err := WriteSARIF(&buf, issues)
+	if err != nil {
+		t.Fatalf("WriteSARIF failed: %s", err)
+	}
+
+	var log sarifLog
+	err = json.Unmarshal(buf.Bytes(), &log)
+	if err != nil {
+		t.Fatalf("output is not valid JSON: %s", err)
+	}
+
+	if log.Version != sarifVersion || len(log.Runs) != 1 {
+		t.Fatalf("unexpected SARIF envelope: version %q with %d runs", log.Version, len(log.Runs))
+	}
+
+	run := log.Runs[0]
+	if len(run.Results) != len(issues) {
+		t.Fatalf("expected %d results, got %d", len(issues), len(run.Results))
+	}
+
+	for _, result := range run.Results {
+		if run.Tool.Driver.Rules[result.RuleIndex].ID != result.RuleID {
+			t.Errorf("result %s points at rule %s", result.RuleID, run.Tool.Driver.Rules[result.RuleIndex].ID)
+		}
+	}
+
+	if run.Results[2].Level != "warning" || run.Results[0].Level != "error" {
+		t.Errorf("severities not mapped to SARIF levels: %q, %q", run.Results[0].Level, run.Results[2].Level)
+	}
+
+	if len(run.Results[1].RelatedLocations) != 1 {
+		t.Errorf("expected related location on shadowing result")
+	}
+
+	fixes := run.Results[0].Fixes
+	if len(fixes) != 1 || fixes[0].ArtifactChanges[0].Replacements[0].InsertedContent.Text != "err " {
+		t.Errorf("fix not carried over: %+v", fixes)
+	}
+}
+
+func TestWriteRDJSON(t *testing.T) {
+	issues := testIssues()
+	issues[0].Fixes = []Fix{{
+		Message: "name the result",
+		Edits:   []Edit{{File: "b.go", Start: Position{Line: 3, Column: 10, Offset: 40}, End: Position{Line: 3, Column: 10, Offset: 40}, NewText: "err "}},
+	}}
+
+	var buf bytes.Buffer
+	err := WriteRDJSON(&buf, issues)
+	if err != nil {
+		t.Fatalf("WriteRDJSON failed: %s", err)
+	}
+
+	var result rdjsonResult
+	err = json.Unmarshal(buf.Bytes(), &result)
+	if err != nil {
+		t.Fatalf("output is not valid JSON: %s", err)
+	}
+
+	if result.Source.Name != "namedreturns" || len(result.Diagnostics) != len(issues) {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	first := result.Diagnostics[0]
+	if first.Severity != "ERROR" || first.Code.Value != "NR001" || first.Location.Path != "b.go" {
+		t.Errorf("unexpected diagnostic: %+v", first)
+	}
+
+	if len(first.Suggestions) != 1 || first.Suggestions[0].Text != "err " || first.Suggestions[0].Range.End.Column != 10 {
+		t.Errorf("fix not carried over as a suggestion: %+v", first.Suggestions)
+	}
+
+	if len(result.Diagnostics[1].RelatedLocations) != 1 {
+		t.Errorf("expected related location on shadowing diagnostic")
+	}
+
+	buf.Reset()
+	err = WriteRDJSONL(&buf, issues)
+	if err != nil {
+		t.Fatalf("WriteRDJSONL failed: %s", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != len(issues) {
+		t.Fatalf("expected %d lines, got %d", len(issues), len(lines))
+	}
+	for _, line := range lines {
+		var diagnostic rdjsonDiagnostic
+		err = json.Unmarshal([]byte(line), &diagnostic)
+		if err != nil {
+			t.Errorf("line is not valid JSON: %s", err)
+		}
+	}
+}
+
+func TestWriteCheckstyle(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteCheckstyle(&buf, testIssues())
+	if err != nil {
+		t.Fatalf("WriteCheckstyle failed: %s", err)
+	}
+
+	var output checkstyleOutput
+	err = xml.Unmarshal(buf.Bytes(), &output)
+	if err != nil {
+		t.Fatalf("output is not valid XML: %s", err)
+	}
+
+	if len(output.Files) != 2 {
+		t.Fatalf("expected issues grouped into 2 files, got %d", len(output.Files))
+	}
+
+	if output.Files[1].Name != "a.go" || len(output.Files[1].Errors) != 2 {
+		t.Errorf("expected both a.go issues in one file element, got %+v", output.Files[1])
+	}
+
+	if output.Files[1].Errors[1].Severity != "warning" || output.Files[1].Errors[1].Source != "namedreturns.NR003" {
+		t.Errorf("unexpected checkstyle error: %+v", output.Files[1].Errors[1])
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteJUnit(&buf, testIssues())
+	if err != nil {
+		t.Fatalf("WriteJUnit failed: %s", err)
+	}
+
+	var output junitTestSuites
+	err = xml.Unmarshal(buf.Bytes(), &output)
+	if err != nil {
+		t.Fatalf("output is not valid XML: %s", err)
+	}
+
+	if output.Tests != 3 || output.Failures != 3 || len(output.Suites) != 2 {
+		t.Fatalf("expected 3 failures in 2 suites, got %+v", output)
+	}
+
+	suite := output.Suites[1]
+	if suite.Name != "example.com/a" || suite.Tests != 2 || suite.Failures != 2 || len(suite.Cases) != 2 {
+		t.Errorf("expected both example.com/a issues in one suite, got %+v", suite)
+	}
+
+	testCase := suite.Cases[1]
+	if testCase.Name != "NR003 a.go:5:1" || testCase.ClassName != "example.com/a" || testCase.Failure.Type != "NR003" || !strings.Contains(testCase.Failure.Text, "a.go:5:1: func a") {
+		t.Errorf("unexpected test case: %+v", testCase)
+	}
+}
+
+func TestWriteCodeClimate(t *testing.T) {
+	issue := Issue{File: "a.go", Line: 5, Column: 1, Rule: "NR003", Message: "func a: named return variable \"n\" is declared but not used in return statement", Severity: "warning"}
+	shifted := issue
+	shifted.Line = 9
+	other := issue
+	other.Line = 12
+
+	write := func(issues []Issue) (output []codeClimateIssue) {
+		var buf bytes.Buffer
+		err := WriteCodeClimate(&buf, issues)
+		if err != nil {
+			t.Fatalf("WriteCodeClimate failed: %s", err)
+		}
+		err = json.Unmarshal(buf.Bytes(), &output)
+		if err != nil {
+			t.Fatalf("output is not valid JSON: %s", err)
+		}
+		return output
+	}
+
+	output := write([]Issue{issue, other})
+	if len(output) != 2 {
+		t.Fatalf("expected 2 issues, got %d", len(output))
+	}
+	if output[0].CheckName != "namedreturns.NR003" || output[0].Severity != "minor" || output[0].Location.Path != "a.go" || output[0].Location.Lines.Begin != 5 {
+		t.Errorf("unexpected issue: %+v", output[0])
+	}
+	if output[0].Fingerprint == output[1].Fingerprint {
+		t.Errorf("issues sharing a message must get distinct fingerprints, got %s twice", output[0].Fingerprint)
+	}
+
+	moved := write([]Issue{shifted, other})
+	if moved[0].Fingerprint != output[0].Fingerprint || moved[1].Fingerprint != output[1].Fingerprint {
+		t.Errorf("fingerprints changed when the issues moved: %+v, %+v", output, moved)
+	}
+
+	var buf bytes.Buffer
+	err := WriteCodeClimate(&buf, nil)
+	if err != nil {
+		t.Fatalf("WriteCodeClimate failed: %s", err)
+	}
+	if got := buf.String(); got != "[]\n" {
+		t.Errorf("empty output should be an empty array, got %q", got)
+	}
+}
+
+func TestWriteGitHub(t *testing.T) {
+	issues := []Issue{{File: "/elsewhere/a.go", Line: 4, Column: 2, Rule: "NR003", Severity: "warning", Message: "first line\nsecond line: 100%"}}
+
+	var buf bytes.Buffer
+	err := WriteGitHub(&buf, issues)
+	if err != nil {
+		t.Fatalf("WriteGitHub failed: %s", err)
+	}
+
+	expected := "::warning file=/elsewhere/a.go,line=4,col=2,title=namedreturns NR003::first line%0Asecond line: 100%25\n"
+	if buf.String() != expected {
+		t.Errorf("unexpected output:\n got: %q\nwant: %q", buf.String(), expected)
+	}
+}
+
+func TestWriteTextWithSource(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "a.go")
+	err := os.WriteFile(file, []byte("package a\n\nfunc load() (int, error) {\n\tif err := f(); err != nil {\n"), 0o600)
+	if err != nil {
+		t.Fatalf("Failed to write source: %s", err)
+	}
+
+	var buf bytes.Buffer
+	err = WriteTextWithSource(&buf, []Issue{
+		{File: file, Line: 3, Column: 1, EndLine: 3, EndColumn: 24, Rule: "NR001", Message: "unnamed"},
+		{File: file, Line: 4, Column: 5, Rule: "NR004", Message: "shadowed"},
+		{File: filepath.Join(filepath.Dir(file), "missing.go"), Line: 1, Column: 1, Rule: "NR001", Message: "unreadable"},
+	})
+	if err != nil {
+		t.Fatalf("WriteTextWithSource failed: %s", err)
+	}
+
+	expected := file + ":3:1: unnamed [NR001]\n" +
+		"   |\n 3 | func load() (int, error) {\n   | ~~~~~~~~~~~~~~~~~~^^^^^\n" +
+		file + ":4:5: shadowed [NR004]\n" +
+		"   |\n 4 | \tif err := f(); err != nil {\n   | \t   ^^^\n" +
+		filepath.Join(filepath.Dir(file), "missing.go") + ":1:1: unreadable [NR001]\n"
+	if buf.String() != expected {
+		t.Errorf("unexpected output:\n got: %q\nwant: %q", buf.String(), expected)
+	}
+}
+
+func TestWriteTeamCity(t *testing.T) {
+	issues := []Issue{
+		{File: "/elsewhere/a.go", Line: 4, Column: 2, Rule: "NR003", Severity: "warning", Message: "func a: named return variable 'n' [x|y]\nnext"},
+		{File: "/elsewhere/b.go", Line: 9, Column: 1, Rule: "NR003", Severity: "warning", Message: "func b"},
+		{File: "/elsewhere/c.go", Line: 1, Column: 1, Rule: "NR001", Severity: "error", Message: "func c"},
+	}
+
+	var buf bytes.Buffer
+	err := WriteTeamCity(&buf, issues)
+	if err != nil {
+		t.Fatalf("WriteTeamCity failed: %s", err)
+	}
+
+	expected := "##teamcity[inspectionType id='NR003' name='unused-in-return' description='return statements must return the named result variables' category='namedreturns']\n" +
+		"##teamcity[inspection typeId='NR003' message='func a: named return variable |'n|' |[x||y|]|nnext' file='/elsewhere/a.go' line='4' SEVERITY='WARNING']\n" +
+		"##teamcity[inspection typeId='NR003' message='func b' file='/elsewhere/b.go' line='9' SEVERITY='WARNING']\n" +
+		"##teamcity[inspectionType id='NR001' name='unnamed-result' description='function results must be named' category='namedreturns']\n" +
+		"##teamcity[inspection typeId='NR001' message='func c' file='/elsewhere/c.go' line='1' SEVERITY='ERROR']\n"
+	if buf.String() != expected {
+		t.Errorf("unexpected output:\n got: %q\nwant: %q", buf.String(), expected)
+	}
+}
+
+func TestWriteQuickfix(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteQuickfix(&buf, RelativePaths("/src", []Issue{
+		{File: "/src/a.go", Line: 4, Column: 2, Rule: "NR003", Message: "first line\nsecond line",
+			Related: []Related{{File: "/src/a.go", Line: 1, Column: 1, Message: "declared here"}}},
+		{File: "/elsewhere/b.go", Line: 9, Column: 1, Rule: "NR001", Message: "func b"},
+	}))
+	if err != nil {
+		t.Fatalf("WriteQuickfix failed: %s", err)
+	}
+
+	expected := "a.go:4:2: first line second line\n" + filepath.Join("..", "elsewhere", "b.go") + ":9:1: func b\n"
+	if buf.String() != expected {
+		t.Errorf("unexpected output:\n got: %q\nwant: %q", buf.String(), expected)
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.go")
+	err := os.WriteFile(file, []byte("package a\n\nfunc a() (n int, err error) {\n\treturn 1, <nil>\n}\n"), 0o644)
+	if err != nil {
+		t.Fatalf("Failed to write file: %s", err)
+	}
+
+	issues := testIssues()
+	for i := range issues {
+		if issues[i].File == "a.go" {
+			issues[i].File = file
+		}
+	}
+
+	var buf bytes.Buffer
+	err = WriteHTML(&buf, issues)
+	if err != nil {
+		t.Fatalf("WriteHTML failed: %s", err)
+	}
+	output := buf.String()
+
+	// Packages are listed in order, with the source around each issue,
+	// escaped
+	a, b := strings.Index(output, "<h2>example.com/a</h2>"), strings.Index(output, "<h2>example.com/b</h2>")
+	if a < 0 || b < a {
+		t.Errorf("expected issues grouped by package in order, got:\n%s", output)
+	}
+	for _, expected := range []string{
+		`data-severity="error"> error (2)`,
+		`data-severity="warning"> warning (1)`,
+		`<a href="file://` + filepath.ToSlash(file) + `#L7">`,
+		`return 1, &lt;nil&gt;`,
+		`shadowed by local variable declaration <span class="rule">[NR004]</span>`,
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected the report to contain %q", expected)
+		}
+	}
+
+	// Files that can't be read have no snippet
+	if strings.Count(output, "<details>") != 2 {
+		t.Errorf("expected snippets for the 2 issues in a.go only, got %d", strings.Count(output, "<details>"))
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	issues := testIssues()
+	issues[0].Message = "a | b\nc"
+
+	var buf bytes.Buffer
+	err := WriteMarkdown(&buf, issues)
+	if err != nil {
+		t.Fatalf("WriteMarkdown failed: %s", err)
+	}
+
+	expected := `## namedreturns: 3 issues
+
+### example.com/a
+
+| Location | Rule | Severity | Message |
+|----------|------|----------|---------|
+| ` + "`a.go:7:2`" + ` | NR004 | error | func a: named return variable "err" is shadowed by local variable declaration |
+| ` + "`a.go:5:1`" + ` | NR003 | warning | func a: named return variable "n" is declared but not used in return statement |
+
+### example.com/b
+
+| Location | Rule | Severity | Message |
+|----------|------|----------|---------|
+| ` + "`b.go:3:1`" + ` | NR001 | error | a \| b<br>c |
+`
+	if buf.String() != expected {
+		t.Errorf("unexpected output:\n got: %s\nwant: %s", buf.String(), expected)
+	}
+
+	buf.Reset()
+	err = WriteMarkdownSummary(&buf, testIssues())
+	if err != nil {
+		t.Fatalf("WriteMarkdownSummary failed: %s", err)
+	}
+
+	expected = `## namedreturns: 3 issues
+
+| Rule | Name | Issues |
+|------|------|--------|
+| NR001 | unnamed-result | 1 |
+| NR003 | unused-in-return | 1 |
+| NR004 | shadowed-result | 1 |
+`
+	if buf.String() != expected {
+		t.Errorf("unexpected summary:\n got: %s\nwant: %s", buf.String(), expected)
+	}
+}
+
+func TestStats(t *testing.T) {
+	stats := NewStats(testIssues(), 8, 6)
+
+	if stats.Rules["NR001"] != 1 || stats.Packages["example.com/a"] != 2 || stats.Files["a.go"] != 2 {
+		t.Errorf("unexpected counts: %+v", stats)
+	}
+
+	if stats.Compliance() != 75 {
+		t.Errorf("expected 75%% compliance, got %.1f", stats.Compliance())
+	}
+
+	if NewStats(nil, 0, 0).Compliance() != 100 {
+		t.Errorf("a package without functions with results should be fully compliant")
+	}
+
+	var buf bytes.Buffer
+	err := WriteStats(&buf, stats)
+	if err != nil {
+		t.Fatalf("WriteStats failed: %s", err)
+	}
+
+	if !strings.HasPrefix(buf.String(), "3 issues, 6 of 8 functions with results fully named (75.0% compliant)\n") {
+		t.Errorf("unexpected summary line: %q", buf.String())
+	}
+}
+
+func TestWriteMetrics(t *testing.T) {
+	stats := NewStats(testIssues(), 8, 6)
+	stats.Packages[`example.com/"c"`] = 0
+
+	var buf bytes.Buffer
+	err := WriteMetrics(&buf, stats, 1500*time.Millisecond)
+	if err != nil {
+		t.Fatalf("WriteMetrics failed: %s", err)
+	}
+
+	for _, line := range []string{
+		"# TYPE namedreturns_findings gauge\nnamedreturns_findings 3\n",
+		"namedreturns_rule_findings{rule=\"NR001\"} 1\n",
+		"namedreturns_package_findings{package=\"example.com/a\"} 2\n",
+		`namedreturns_package_findings{package="example.com/\"c\""} 0` + "\n",
+		"namedreturns_fully_named_functions 6\n",
+		"namedreturns_analysis_duration_seconds 1.5\n",
+	} {
+		if !strings.Contains(buf.String(), line) {
+			t.Errorf("expected %q in output:\n%s", line, buf.String())
+		}
+	}
+}
+
+func TestFromDiagnosticFixes(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.go")
+	src := "package a\n\n//line generated.go:100\nfunc a() (int, error) {\n\treturn 0, nil\n}\n"
+	err := os.WriteFile(file, []byte(src), 0o600)
+	if err != nil {
+		t.Fatalf("writing %s: %s", file, err)
+	}
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, file, src, 0)
+	if err != nil {
+		t.Fatalf("parsing %s: %s", file, err)
+	}
+
+	results := f.Decls[0].(*ast.FuncDecl).Type.Results
+	d := analysis.Diagnostic{
+		Pos:      results.Pos(),
+		Category: "NR001",
+		Message:  "func a: unnamed return with type \"int\" found - named returns are required",
+		SuggestedFixes: []analysis.SuggestedFix{{
+			Message: "Name the results",
+			TextEdits: []analysis.TextEdit{
+				{Pos: results.List[0].Pos(), NewText: []byte("n ")},
+				{Pos: results.List[1].Pos(), NewText: []byte("err ")},
+			},
+		}},
+	}
+
+	issue := FromDiagnostic(fset, "example.com/a", d)
+	if issue.File != filepath.Join(dir, "generated.go") || issue.Line != 100 {
+		t.Errorf("expected the issue at the position of the line directive, got %s:%d", issue.File, issue.Line)
+	}
+
+	fix := issue.Fixes[0]
+	start := fix.Edits[1].Start
+	if fix.Edits[1].File != file || start.Line != 4 || start.Offset != strings.Index(src, "error)") {
+		t.Errorf("expected the edit at the position in the file on disk, got %s:%d offset %d", fix.Edits[1].File, start.Line, start.Offset)
+	}
+
+	expected := "--- " + file + "\n+++ " + file + "\n" +
+		"@@ -1,6 +1,6 @@\n" +
+		" package a\n" +
+		" \n" +
+		" //line generated.go:100\n" +
+		"-func a() (int, error) {\n" +
+		"+func a() (n int, err error) {\n" +
+		" \treturn 0, nil\n" +
+		" }\n"
+	if fix.Diff != expected {
+		t.Errorf("unexpected diff:\n%s", fix.Diff)
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	var src strings.Builder
+	for i := 1; i <= 20; i++ {
+		fmt.Fprintf(&src, "line %d\n", i)
+	}
+	src.WriteString("last")
+	content := src.String()
+
+	edit := func(text string, newText string) (e Edit) {
+		offset := strings.Index(content, text)
+		e = Edit{Start: Position{Offset: offset}, End: Position{Offset: offset + len(text)}, NewText: newText}
+		return e
+	}
+
+	// Distant changes get hunks of their own, repeated edits are applied
+	// once, and a last line without line feed is marked
+	diff := unifiedDiff("f.go", []byte(content), []Edit{
+		edit("last", "final\nlines"),
+		edit("line 2\n", ""),
+		edit("line 2\n", ""),
+	})
+	expected := "--- f.go\n+++ f.go\n" +
+		"@@ -1,5 +1,4 @@\n" +
+		" line 1\n" +
+		"-line 2\n" +
+		" line 3\n" +
+		" line 4\n" +
+		" line 5\n" +
+		"@@ -18,4 +17,5 @@\n" +
+		" line 18\n" +
+		" line 19\n" +
+		" line 20\n" +
+		"-last\n" +
+		"\\ No newline at end of file\n" +
+		"+final\n" +
+		"+lines\n" +
+		"\\ No newline at end of file\n"
+	if diff != expected {
+		t.Errorf("unexpected diff:\n%s", diff)
+	}
+
+	diff = unifiedDiff("f.go", []byte(content), []Edit{edit("line 3\nline 4", "x"), edit("line 4", "y")})
+	if diff != "" {
+		t.Errorf("expected no diff for overlapping edits, got:\n%s", diff)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	before := "package a\n\nfunc f() (int, error) {\n\treturn 0, nil\n}\n\nfunc g() {}\n"
+	after := "package a\n\nfunc f() (n int, err error) {\n\tn, err = 0, nil\n\treturn n, err\n}\n\nfunc g() {}\n\nfunc h() {}\n"
+
+	diff := Diff("a.go", []byte(before), []byte(after))
+	expected := "--- a.go\n+++ a.go\n" +
+		"@@ -1,7 +1,10 @@\n" +
+		" package a\n" +
+		" \n" +
+		"-func f() (int, error) {\n" +
+		"-\treturn 0, nil\n" +
+		"+func f() (n int, err error) {\n" +
+		"+\tn, err = 0, nil\n" +
+		"+\treturn n, err\n" +
+		" }\n" +
+		" \n" +
+		" func g() {}\n" +
+		"+\n" +
+		"+func h() {}\n"
+	if diff != expected {
+		t.Errorf("unexpected diff:\n%s", diff)
+	}
+
+	// Lines inserted in between change none of the others
+	diff = Diff("a.go", []byte("a\nb\n"), []byte("a\nx\nb\n"))
+	if expected = "--- a.go\n+++ a.go\n@@ -1,2 +1,3 @@\n a\n+x\n b\n"; diff != expected {
+		t.Errorf("unexpected diff:\n%s", diff)
+	}
+
+	if diff = Diff("a.go", []byte(before), []byte(before)); diff != "" {
+		t.Errorf("expected no diff for the same contents, got:\n%s", diff)
+	}
+}
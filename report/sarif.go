@@ -0,0 +1,248 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/nikogura/namedreturns/analyzer"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifSrcRoot = "%SRCROOT%"
+	toolURI      = "https://github.com/nikogura/namedreturns"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool               sarifTool                        `json:"tool"`
+	OriginalURIBaseIDs map[string]sarifArtifactLocation `json:"originalUriBaseIds,omitempty"`
+	Results            []sarifResult                    `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	Name                 string             `json:"name"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	HelpURI              string             `json:"helpUri"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID           string          `json:"ruleId"`
+	RuleIndex        int             `json:"ruleIndex"`
+	Level            string          `json:"level"`
+	Message          sarifMessage    `json:"message"`
+	Locations        []sarifLocation `json:"locations"`
+	RelatedLocations []sarifLocation `json:"relatedLocations,omitempty"`
+	Fixes            []sarifFix      `json:"fixes,omitempty"`
+}
+
+type sarifLocation struct {
+	ID               int                   `json:"id,omitempty"`
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	Message          *sarifMessage         `json:"message,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine   int  `json:"startLine"`
+	StartColumn int  `json:"startColumn"`
+	EndLine     int  `json:"endLine,omitempty"`
+	EndColumn   int  `json:"endColumn,omitempty"`
+	ByteOffset  *int `json:"byteOffset,omitempty"`
+	ByteLength  *int `json:"byteLength,omitempty"`
+}
+
+type sarifFix struct {
+	Description     sarifMessage          `json:"description"`
+	ArtifactChanges []sarifArtifactChange `json:"artifactChanges"`
+}
+
+type sarifArtifactChange struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Replacements     []sarifReplacement    `json:"replacements"`
+}
+
+type sarifReplacement struct {
+	DeletedRegion   sarifRegion  `json:"deletedRegion"`
+	InsertedContent sarifMessage `json:"insertedContent"`
+}
+
+// WriteSARIF writes issues as a SARIF 2.1.0 log with a single run. File
+// locations below the working directory are made relative to the %SRCROOT%
+// base so the log can be uploaded to GitHub Code Scanning.
+func WriteSARIF(w io.Writer, issues []Issue) (err error) {
+	var root string
+	root, err = os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           analyzer.Analyzer.Name,
+			InformationURI: toolURI,
+		}},
+		OriginalURIBaseIDs: map[string]sarifArtifactLocation{
+			sarifSrcRoot: {URI: fileURI(root) + "/"},
+		},
+		Results: []sarifResult{},
+	}
+
+	ruleIndex := make(map[string]int)
+	for _, rule := range analyzer.Rules() {
+		ruleIndex[rule.ID] = len(run.Tool.Driver.Rules)
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+			ID:                   rule.ID,
+			Name:                 rule.Name,
+			ShortDescription:     sarifMessage{Text: rule.Doc},
+			HelpURI:              toolURI + "#rules",
+			DefaultConfiguration: sarifConfiguration{Level: sarifLevel(rule.Severity)},
+		})
+	}
+
+	for _, issue := range issues {
+		result := sarifResult{
+			RuleID:    issue.Rule,
+			RuleIndex: ruleIndex[issue.Rule],
+			Level:     sarifLevel(issue.Severity),
+			Message:   sarifMessage{Text: issue.Message},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: artifactLocation(root, issue.File),
+					Region: sarifRegion{
+						StartLine:   issue.Line,
+						StartColumn: issue.Column,
+						EndLine:     issue.EndLine,
+						EndColumn:   issue.EndColumn,
+					},
+				},
+			}},
+		}
+
+		for i, r := range issue.Related {
+			result.RelatedLocations = append(result.RelatedLocations, sarifLocation{
+				ID: i + 1,
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: artifactLocation(root, r.File),
+					Region:           sarifRegion{StartLine: r.Line, StartColumn: r.Column},
+				},
+				Message: &sarifMessage{Text: r.Message},
+			})
+		}
+
+		for _, fix := range issue.Fixes {
+			result.Fixes = append(result.Fixes, sarifFixFrom(root, fix))
+		}
+
+		run.Results = append(run.Results, result)
+	}
+
+	log := sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{run}}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(log)
+	return err
+}
+
+// sarifFixFrom groups the edits of a fix by file, as SARIF expects one
+// artifact change per file.
+func sarifFixFrom(root string, fix Fix) (sf sarifFix) {
+	sf = sarifFix{Description: sarifMessage{Text: fix.Message}}
+
+	changes := make(map[string]int)
+	for _, edit := range fix.Edits {
+		i, ok := changes[edit.File]
+		if !ok {
+			i = len(sf.ArtifactChanges)
+			changes[edit.File] = i
+			sf.ArtifactChanges = append(sf.ArtifactChanges, sarifArtifactChange{
+				ArtifactLocation: artifactLocation(root, edit.File),
+			})
+		}
+
+		offset := edit.Start.Offset
+		length := edit.End.Offset - edit.Start.Offset
+		sf.ArtifactChanges[i].Replacements = append(sf.ArtifactChanges[i].Replacements, sarifReplacement{
+			DeletedRegion: sarifRegion{
+				StartLine:   edit.Start.Line,
+				StartColumn: edit.Start.Column,
+				EndLine:     edit.End.Line,
+				EndColumn:   edit.End.Column,
+				ByteOffset:  &offset,
+				ByteLength:  &length,
+			},
+			InsertedContent: sarifMessage{Text: edit.NewText},
+		})
+	}
+	return sf
+}
+
+// artifactLocation refers to file relative to %SRCROOT% when it lives below
+// root, and by absolute URI otherwise.
+func artifactLocation(root string, file string) (location sarifArtifactLocation) {
+	rel, ok := relativePath(root, file)
+	if !ok {
+		location = sarifArtifactLocation{URI: fileURI(file)}
+		return location
+	}
+
+	location = sarifArtifactLocation{URI: filepath.ToSlash(rel), URIBaseID: sarifSrcRoot}
+	return location
+}
+
+func fileURI(path string) (uri string) {
+	u := url.URL{Scheme: "file", Path: filepath.ToSlash(path)}
+	uri = u.String()
+	return uri
+}
+
+func sarifLevel(severity string) (level string) {
+	switch severity {
+	case analyzer.SeverityError:
+		level = "error"
+	case analyzer.SeverityInfo:
+		level = "note"
+	default:
+		level = "warning"
+	}
+	return level
+}
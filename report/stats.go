@@ -0,0 +1,113 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/nikogura/namedreturns/analyzer"
+)
+
+// Stats aggregates issues per rule, package and file, together with how many
+// of the analyzed functions name all of their results.
+type Stats struct {
+	Issues     int            `json:"issues"`
+	Rules      map[string]int `json:"rules"`
+	Packages   map[string]int `json:"packages"`
+	Files      map[string]int `json:"files"`
+	Functions  int            `json:"functions"`
+	FullyNamed int            `json:"fully_named"`
+}
+
+// NewStats counts issues. functions and fullyNamed are the totals of the
+// analyzer's per-package results.
+func NewStats(issues []Issue, functions int, fullyNamed int) (stats Stats) {
+	stats = Stats{
+		Issues:     len(issues),
+		Rules:      make(map[string]int),
+		Packages:   make(map[string]int),
+		Files:      make(map[string]int),
+		Functions:  functions,
+		FullyNamed: fullyNamed,
+	}
+
+	for _, issue := range issues {
+		stats.Rules[issue.Rule]++
+		stats.Packages[issue.Package]++
+		stats.Files[issue.File]++
+	}
+	return stats
+}
+
+// Compliance returns the percentage of functions with results whose results
+// are all named. A codebase without such functions is fully compliant.
+func (s Stats) Compliance() (percent float64) {
+	percent = 100
+	if s.Functions > 0 {
+		percent = 100 * float64(s.FullyNamed) / float64(s.Functions)
+	}
+	return percent
+}
+
+// WriteStats writes a human readable summary of stats.
+func WriteStats(w io.Writer, stats Stats) (err error) {
+	_, err = fmt.Fprintf(w, "%d issues, %d of %d functions with results fully named (%.1f%% compliant)\n",
+		stats.Issues, stats.FullyNamed, stats.Functions, stats.Compliance())
+	if err != nil {
+		return err
+	}
+
+	rules := make(map[string]int, len(stats.Rules))
+	for id, count := range stats.Rules {
+		label := id
+		if rule, ok := analyzer.LookupRule(id); ok {
+			label += " " + rule.Name
+		}
+		rules[label] = count
+	}
+
+	sections := []struct {
+		title  string
+		counts map[string]int
+	}{
+		{"rule", rules},
+		{"package", stats.Packages},
+		{"file", stats.Files},
+	}
+
+	for _, section := range sections {
+		if len(section.counts) == 0 {
+			continue
+		}
+
+		_, err = fmt.Fprintf(w, "\nIssues by %s:\n", section.title)
+		if err != nil {
+			return err
+		}
+
+		for _, key := range sortedByCount(section.counts) {
+			_, err = fmt.Fprintf(w, "  %6d  %s\n", section.counts[key], key)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return err
+}
+
+// sortedByCount returns the keys of counts, highest count first and
+// alphabetically among equal counts.
+func sortedByCount(counts map[string]int) (keys []string) {
+	for key := range counts {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) (less bool) {
+		less = keys[i] < keys[j]
+		if counts[keys[i]] != counts[keys[j]] {
+			less = counts[keys[i]] > counts[keys[j]]
+		}
+		return less
+	})
+	return keys
+}
@@ -0,0 +1,75 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/nikogura/namedreturns/analyzer"
+)
+
+// WriteTeamCity writes issues as TeamCity service messages, e.g.
+//
+//	##teamcity[inspectionType id='NR001' name='unnamed-result' description='function results must be named' category='namedreturns']
+//	##teamcity[inspection typeId='NR001' message='...' file='pkg/server.go' line='12' SEVERITY='ERROR']
+//
+// so they are listed in the Code Inspections tab of the build. Each rule is
+// declared as an inspection type before its first issue. Files below the
+// working directory, which is the checkout in a build, are written relative
+// to it.
+func WriteTeamCity(w io.Writer, issues []Issue) (err error) {
+	var root string
+	root, err = os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	declared := make(map[string]bool)
+	for _, issue := range issues {
+		if !declared[issue.Rule] {
+			declared[issue.Rule] = true
+			name, description := issue.Rule, issue.Rule
+			if rule, ok := analyzer.LookupRule(issue.Rule); ok {
+				name, description = rule.Name, rule.Doc
+			}
+
+			_, err = fmt.Fprintf(w, "##teamcity[inspectionType id='%s' name='%s' description='%s' category='%s']\n",
+				escapeTeamCity(issue.Rule), escapeTeamCity(name), escapeTeamCity(description), escapeTeamCity(analyzer.Analyzer.Name))
+			if err != nil {
+				return err
+			}
+		}
+
+		file := issue.File
+		if rel, ok := relativePath(root, file); ok {
+			file = rel
+		}
+
+		_, err = fmt.Fprintf(w, "##teamcity[inspection typeId='%s' message='%s' file='%s' line='%d' SEVERITY='%s']\n",
+			escapeTeamCity(issue.Rule), escapeTeamCity(issue.Message), escapeTeamCity(file), issue.Line, teamCitySeverity(issue.Severity))
+		if err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// teamCitySeverity maps a rule severity onto the inspection severities
+// ERROR, WARNING and INFO.
+func teamCitySeverity(severity string) (level string) {
+	switch severity {
+	case analyzer.SeverityError:
+		level = "ERROR"
+	case analyzer.SeverityInfo:
+		level = "INFO"
+	default:
+		level = "WARNING"
+	}
+	return level
+}
+
+func escapeTeamCity(s string) (escaped string) {
+	escaped = strings.NewReplacer("|", "||", "'", "|'", "\n", "|n", "\r", "|r", "[", "|[", "]", "|]").Replace(s)
+	return escaped
+}
@@ -0,0 +1,155 @@
+package report
+
+import (
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// WriteText writes one "file:line:column: message [rule]" line per issue,
+// ending with the build variants it is only found for, if any, followed by
+// an indented line for each related location.
+func WriteText(w io.Writer, issues []Issue) (err error) {
+	err = writeText(w, issues, nil)
+	return err
+}
+
+// WriteTextWithSource writes issues as WriteText does, showing below each
+// the source line it points at with carets under the token at its column.
+// When the issue ends further on the same line, as those about a result do
+// at the result, the carets go under the last token of its range and
+// tildes under the rest, e.g.
+//
+//	  |
+//	7 | func load() (int, error) {
+//	  | ~~~~~~~~~~~~~~~~~~^^^^^
+//
+// Files that can't be read are written without source.
+func WriteTextWithSource(w io.Writer, issues []Issue) (err error) {
+	err = writeText(w, issues, make(map[string][]string))
+	return err
+}
+
+// writeText writes issues as text, with their source lines read through
+// sources unless it is nil.
+func writeText(w io.Writer, issues []Issue, sources map[string][]string) (err error) {
+	for _, issue := range issues {
+		_, err = fmt.Fprintf(w, "%s:%d:%d: %s [%s]", issue.File, issue.Line, issue.Column, issue.Message, issue.Rule)
+		if err != nil {
+			return err
+		}
+		if len(issue.Variants) > 0 {
+			_, err = fmt.Fprintf(w, " (only for %s)", strings.Join(issue.Variants, "; "))
+			if err != nil {
+				return err
+			}
+		}
+		_, err = fmt.Fprintln(w)
+		if err != nil {
+			return err
+		}
+
+		if sources != nil {
+			err = writeSource(w, issue, sources)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, r := range issue.Related {
+			_, err = fmt.Fprintf(w, "\t%s:%d:%d: %s\n", r.File, r.Line, r.Column, r.Message)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return err
+}
+
+// writeSource writes the source line of issue, numbered, with carets under
+// the range it points at.
+func writeSource(w io.Writer, issue Issue, sources map[string][]string) (err error) {
+	text := sourceLines(sources, issue.File)
+	if issue.Line < 1 || issue.Line > len(text) {
+		return err
+	}
+
+	line := strings.TrimSuffix(text[issue.Line-1], "\r")
+	end := 0
+	if issue.EndLine == issue.Line {
+		end = issue.EndColumn
+	}
+	start, last, end := sourceRange(line, issue.Column, end)
+
+	// Tabs are kept in the padding so the carets line up however wide
+	// they are shown
+	var pad strings.Builder
+	for _, r := range line[:start] {
+		if r == '\t' {
+			pad.WriteRune('\t')
+		} else {
+			pad.WriteRune(' ')
+		}
+	}
+
+	number := fmt.Sprint(issue.Line)
+	gutter := strings.Repeat(" ", len(number))
+	marks := strings.Repeat("~", utf8.RuneCountInString(line[start:last])) + strings.Repeat("^", max(utf8.RuneCountInString(line[last:end]), 1))
+	_, err = fmt.Fprintf(w, " %s |\n %s | %s\n %s | %s%s\n", gutter, number, line, gutter, pad.String(), marks)
+	return err
+}
+
+// sourceRange returns the byte offsets in line of the range starting at
+// column, both 1-based byte columns, and ending at endColumn, along with
+// the offset of the last Go token within it. When endColumn doesn't follow
+// column, the range spans the token at column.
+func sourceRange(line string, column int, endColumn int) (start int, last int, end int) {
+	start = min(max(column-1, 0), len(line))
+	last, end = start, start
+	limit := len(line)
+	if endColumn > column {
+		limit = min(endColumn-1, len(line))
+	}
+
+	rest := line[start:limit]
+	fset := token.NewFileSet()
+	file := fset.AddFile("", -1, len(rest))
+	var s scanner.Scanner
+	s.Init(file, []byte(rest), nil, 0)
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF || tok == token.ILLEGAL || tok == token.SEMICOLON && lit == "\n" {
+			break
+		}
+
+		length := len(tok.String())
+		if lit != "" {
+			length = len(lit)
+		}
+		last = start + file.Offset(pos)
+		end = min(last+length, len(line))
+		if endColumn <= column {
+			break
+		}
+	}
+	return start, last, end
+}
+
+// sourceLines returns the lines of file, reading each file once through
+// sources. Files that can't be read have no lines.
+func sourceLines(sources map[string][]string, file string) (text []string) {
+	var ok bool
+	text, ok = sources[file]
+	if !ok {
+		data, err := os.ReadFile(file)
+		if err == nil {
+			text = strings.Split(string(data), "\n")
+		}
+		sources[file] = text
+	}
+	return text
+}
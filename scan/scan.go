@@ -0,0 +1,139 @@
+// Package scan runs the namedreturns analyzer over packages and returns its
+// findings as values, for programs embedding the linter rather than printing
+// its diagnostics.
+package scan
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/nikogura/namedreturns/analyzer"
+	"github.com/nikogura/namedreturns/report"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/checker"
+	"golang.org/x/tools/go/packages"
+)
+
+// Issue is a finding: its position, rule, message, severity and the edits of
+// its suggested fixes.
+type Issue = report.Issue
+
+// Config controls a scan.
+type Config struct {
+	Analyzer analyzer.Config // settings of the analyzer, overridden by configuration files as usual
+	Dir      string          // directory the patterns are resolved in, the working directory if empty
+	Env      []string        // environment of the build system, the current one if nil
+	Tests    bool            // also scan test files
+
+	// AllowErrors scans packages that fail to load or type check, as the
+	// analyzer copes with incomplete type information, instead of failing
+	AllowErrors bool
+}
+
+// Scan analyzes the packages matching patterns, as understood by go list,
+// and returns the findings sorted by position. It stops when ctx is done,
+// while loading the packages, between them or between the functions of a
+// package, and returns the error of ctx.
+func Scan(ctx context.Context, patterns []string, cfg Config) (issues []Issue, err error) {
+	loadCfg := &packages.Config{
+		Context: ctx,
+		Mode:    packages.LoadAllSyntax,
+		Dir:     cfg.Dir,
+		Env:     cfg.Env,
+		Tests:   cfg.Tests,
+	}
+
+	var pkgs []*packages.Package
+	pkgs, err = packages.Load(loadCfg, patterns...)
+	if err != nil {
+		err = fmt.Errorf("loading packages: %w", err)
+		return issues, err
+	}
+
+	if !cfg.AllowErrors {
+		err = loadErrors(pkgs)
+		if err != nil {
+			return issues, err
+		}
+	}
+
+	err = ctx.Err()
+	if err != nil {
+		return issues, err
+	}
+
+	a := analyzer.NewAnalyzer(cfg.Analyzer)
+	analyzers := []*analysis.Analyzer{analyzer.WithContext(ctx, a)}
+	var propagates bool
+	propagates, err = errorPropagation(a, pkgs)
+	if err != nil {
+		return issues, err
+	}
+	if propagates {
+		analyzers = append(analyzers, analyzer.WithContext(ctx, analyzer.NewPropagation(cfg.Analyzer)))
+	}
+
+	var graph *checker.Graph
+	graph, err = checker.Analyze(analyzers, pkgs, nil)
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		return issues, err
+	}
+
+	for _, act := range graph.Roots {
+		if act.Err != nil {
+			err = fmt.Errorf("analyzing %s: %w", act.Package.PkgPath, act.Err)
+			return issues, err
+		}
+
+		for _, d := range act.Diagnostics {
+			issues = append(issues, report.FromDiagnostic(act.Package.Fset, act.Package.PkgPath, d))
+		}
+	}
+
+	issues = report.Dedupe(issues)
+	report.Sort(issues)
+	return issues, err
+}
+
+// errorPropagation reports whether error-propagation is set for any of pkgs
+// in the settings of a, so Propagation is to run along with it.
+func errorPropagation(a *analysis.Analyzer, pkgs []*packages.Package) (propagates bool, err error) {
+	for _, pkg := range pkgs {
+		if len(pkg.GoFiles) == 0 {
+			continue
+		}
+
+		var cfg analyzer.Config
+		cfg, err = analyzer.ResolveConfig(a, filepath.Dir(pkg.GoFiles[0]))
+		if err != nil {
+			err = fmt.Errorf("analyzing %s: %w", pkg.PkgPath, err)
+			return propagates, err
+		}
+		if cfg.ErrorPropagation {
+			propagates = true
+			return propagates, err
+		}
+	}
+	return propagates, err
+}
+
+// loadErrors collects the errors of the loaded packages and their
+// dependencies into a single error.
+func loadErrors(pkgs []*packages.Package) (err error) {
+	var messages []string
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		for _, e := range pkg.Errors {
+			messages = append(messages, e.Error())
+		}
+	})
+
+	if len(messages) > 0 {
+		err = fmt.Errorf("failed to load packages:\n%s", strings.Join(messages, "\n"))
+	}
+	return err
+}
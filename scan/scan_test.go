@@ -0,0 +1,110 @@
+package scan
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nikogura/namedreturns/analyzer"
+)
+
+func TestScan(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/scanned\n\ngo 1.23\n",
+		"scanned.go": "package scanned\n\nimport \"errors\"\n\n" +
+			"func unnamed() error {\n\treturn nil\n}\n\n" +
+			"func deferred() (err error) {\n\tdefer func() { err = errors.New(\"deferred\") }()\n\treturn nil\n}\n",
+	}
+	for name, content := range files {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600)
+		if err != nil {
+			t.Fatalf("Failed to write %s: %s", name, err)
+		}
+	}
+
+	issues, err := Scan(context.Background(), []string{"./..."}, Config{Dir: dir})
+	if err != nil {
+		t.Fatalf("Scan failed: %s", err)
+	}
+
+	if len(issues) != 1 {
+		t.Fatalf("expected 1 issue, got %+v", issues)
+	}
+	issue := issues[0]
+	if issue.Rule != "NR001" || issue.Line != 5 || issue.Severity != analyzer.SeverityError || issue.Package != "example.com/scanned" {
+		t.Errorf("unexpected issue: %+v", issue)
+	}
+	if len(issue.Fixes) != 1 || len(issue.Fixes[0].Edits) == 0 {
+		t.Errorf("expected the fix edits to be carried over, got %+v", issue.Fixes)
+	}
+
+	issues, err = Scan(context.Background(), []string{"./..."}, Config{Dir: dir, Analyzer: analyzer.Config{ReportErrorInDefer: true}})
+	if err != nil {
+		t.Fatalf("Scan failed: %s", err)
+	}
+	if len(issues) != 2 {
+		t.Errorf("expected the configuration to report the deferred assignment too, got %+v", issues)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = Scan(ctx, []string{"./..."}, Config{Dir: dir})
+	if err == nil {
+		t.Errorf("expected an error for a canceled context")
+	}
+}
+
+func TestScanSameTypedResults(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":  "module example.com/scanned\n\ngo 1.23\n",
+		"pair.go": "package scanned\n\nfunc pair() (int, int) {\n\treturn 0, 0\n}\n",
+	}
+	for name, content := range files {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600)
+		if err != nil {
+			t.Fatalf("Failed to write %s: %s", name, err)
+		}
+	}
+
+	issues, err := Scan(context.Background(), []string{"./..."}, Config{Dir: dir})
+	if err != nil {
+		t.Fatalf("Scan failed: %s", err)
+	}
+	if len(issues) != 2 || issues[0].Rule != analyzer.RuleUnnamedResult || issues[1].Rule != analyzer.RuleUnnamedResult {
+		t.Errorf("expected a finding for each result, got %+v", issues)
+	}
+}
+
+func TestScanErrorPropagation(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/scanned\n\ngo 1.23\n",
+		"logged.go": "package scanned\n\nimport (\n\t\"log\"\n\t\"os\"\n)\n\n" +
+			"func logged(path string) (err error) {\n\tif e := os.Remove(path); e != nil {\n\t\tlog.Print(e)\n\t}\n\treturn\n}\n",
+	}
+	for name, content := range files {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600)
+		if err != nil {
+			t.Fatalf("Failed to write %s: %s", name, err)
+		}
+	}
+
+	issues, err := Scan(context.Background(), []string{"./..."}, Config{Dir: dir})
+	if err != nil {
+		t.Fatalf("Scan failed: %s", err)
+	}
+	if len(issues) != 0 {
+		t.Errorf("expected no issues without error-propagation, got %+v", issues)
+	}
+
+	issues, err = Scan(context.Background(), []string{"./..."}, Config{Dir: dir, Analyzer: analyzer.Config{ErrorPropagation: true}})
+	if err != nil {
+		t.Fatalf("Scan failed: %s", err)
+	}
+	if len(issues) != 1 || issues[0].Rule != analyzer.RuleUnpropagatedError || issues[0].Line != 9 {
+		t.Errorf("expected the dropped error to be reported, got %+v", issues)
+	}
+}
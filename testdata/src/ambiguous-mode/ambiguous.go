@@ -0,0 +1,43 @@
+package ambiguousmode
+
+import "time"
+
+// =============================================================================
+// GOOD EXAMPLES - These should NOT trigger any reports
+// =============================================================================
+
+// Results of distinct types tell themselves apart
+func distinct() (int, string, error) {
+	return 0, "", nil
+}
+
+func single() int {
+	return 0
+}
+
+// Type parameters are distinct types, whatever their constraints
+func pair[K comparable, V comparable](k K, v V) (K, V) {
+	return k, v
+}
+
+// Ambiguous results are fine once named
+func dimensions() (width int, height int, err error) {
+	return width, height, err
+}
+
+// =============================================================================
+// BAD EXAMPLES - These SHOULD trigger reports
+// =============================================================================
+
+func bounds() (int, int, error) { // want `func bounds: unnamed return with type "int" found - named returns are required` `func bounds: unnamed return with type "int" found - named returns are required` `func bounds: unnamed return with type "error" found - named returns are required`
+	return 0, 0, nil
+}
+
+// time.Duration has the underlying type of int64
+func elapsed() (int64, time.Duration) { // want `func elapsed: unnamed return with type "int64" found - named returns are required` `func elapsed: unnamed return with type "time.Duration" found - named returns are required`
+	return 0, 0
+}
+
+func split[T any](items []T) ([]T, []T) { // want `func split: unnamed return with type "\[\]T" found - named returns are required` `func split: unnamed return with type "\[\]T" found - named returns are required`
+	return items[:1], items[1:]
+}
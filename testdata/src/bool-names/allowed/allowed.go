@@ -0,0 +1,15 @@
+package allowed
+
+// With bool-names extended, these names are allowed
+func contains(items []string, item string) (result bool) {
+	for _, candidate := range items {
+		if candidate == item {
+			result = true
+		}
+	}
+	return result
+}
+
+func parse(s string) (n int, b bool) {
+	return n, b
+}
@@ -0,0 +1,41 @@
+package boolnames
+
+import "os"
+
+// =============================================================================
+// GOOD EXAMPLES - These should NOT trigger any reports
+// =============================================================================
+
+// The comma-ok idiom
+func lookup(m map[string]int, key string) (value int, ok bool) {
+	value, ok = m[key]
+	return value, ok
+}
+
+func fileExists(path string) (exists bool) {
+	_, err := os.Stat(path)
+	exists = err == nil
+	return exists
+}
+
+// Results of other types are not concerned
+func flag() (flag string) {
+	return flag
+}
+
+// =============================================================================
+// BAD EXAMPLES - These SHOULD trigger reports
+// =============================================================================
+
+func contains(items []string, item string) (result bool) { // want `func contains: boolean result "result" should be named one of: ok, found, exists, done`
+	for _, candidate := range items {
+		if candidate == item {
+			result = true
+		}
+	}
+	return result
+}
+
+func parse(s string) (n int, b bool) { // want `func parse: boolean result "b" should be named one of: ok, found, exists, done`
+	return n, b
+}
@@ -0,0 +1,16 @@
+package main
+
+import "errors"
+
+// =============================================================================
+// TESTING SETTINGS FROM A DISCOVERED CONFIGURATION FILE
+// =============================================================================
+
+// The configuration file next to this package enables report-error-in-defer,
+// so the named error is checked even though it is assigned inside defer
+func errorAssignedInDefer() (err error) { // want `func errorAssignedInDefer: named return variable "err" is declared but not used in return statement`
+	defer func() {
+		err = errors.New("error occurred")
+	}()
+	return errors.New("returned directly")
+}
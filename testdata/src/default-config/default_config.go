@@ -54,7 +54,7 @@ func errorWithDeferAssignment() (err error) {
 // =============================================================================
 
 // Unnamed returns - should report
-func unnamedReturns() (int, error) { // want `unnamed return with type "int" found - named returns are required` `unnamed return with type "error" found - named returns are required`
+func unnamedReturns() (int, error) { // want `func unnamedReturns: unnamed return with type "int" found - named returns are required` `unnamed return with type "error" found - named returns are required`
 	return 42, errors.New("error")
 }
 
@@ -121,7 +121,7 @@ func shadowInRange() (key string, value int) {
 // Shadowing with var declarations - should report
 func shadowWithVar() (result int, err error) {
 	{
-		var result int = 42 // want `named return variable "result" is shadowed by local variable declaration`
+		var result int = 42 // want `func shadowWithVar: named return variable "result" is shadowed by local variable declaration`
 		// This shadows the named return variable
 		_ = result
 	}
@@ -129,6 +129,29 @@ func shadowWithVar() (result int, err error) {
 	return result, err
 }
 
+// Unnamed returns on a method - should report with the receiver in the message
+func (e *example) unnamedMethod() error { // want `method \(\*example\)\.unnamedMethod: unnamed return with type "error" found - named returns are required`
+	return nil
+}
+
+// Unnamed returns on a value receiver - should report with the receiver in the message
+func (e example) unnamedValueMethod() error { // want `method example\.unnamedValueMethod: unnamed return with type "error" found - named returns are required`
+	return nil
+}
+
+// Unnamed returns in a function literal - should report with the enclosing function
+func unnamedFuncLiteral() {
+	fn := func() int { // want `func literal in func unnamedFuncLiteral: unnamed return with type "int" found - named returns are required`
+		return 42
+	}
+	_ = fn
+}
+
+// Unnamed returns in a package-level function literal - should report without an enclosing function
+var badFuncLiteral = func() int { // want `func literal: unnamed return with type "int" found - named returns are required`
+	return 42
+}
+
 // =============================================================================
 // HELPER FUNCTIONS - These are just for testing, not for analysis
 // =============================================================================
@@ -0,0 +1,116 @@
+package deferhandler
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// =============================================================================
+// GOOD EXAMPLES - These should NOT trigger any reports
+// =============================================================================
+
+// The deferred function records the error of Close
+func ReadConfig(path string) (data []byte, err error) {
+	var f *os.File
+	f, err = os.Open(path)
+	if err != nil {
+		return data, err
+	}
+	defer func() {
+		closeErr := f.Close()
+		if err == nil {
+			err = closeErr
+		}
+	}()
+	return data, err
+}
+
+// The deferred call is given the address of the error
+func Commit(tx *sql.Tx) (err error) {
+	defer rollback(tx, &err)
+	err = tx.Commit()
+	return err
+}
+
+func rollback(tx *sql.Tx, err *error) {
+	if *err != nil {
+		*err = errors.Join(*err, tx.Rollback())
+	}
+}
+
+// A lock released along with a wrapping handler
+type Store struct {
+	mu sync.Mutex
+}
+
+func (s *Store) Save(path string) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("saving %s: %w", path, err)
+		}
+	}()
+	err = os.WriteFile(path, nil, 0o644)
+	return err
+}
+
+// Unexported functions are not concerned
+func readConfig(path string) (data []byte, err error) {
+	var f *os.File
+	f, err = os.Open(path)
+	if err != nil {
+		return data, err
+	}
+	defer f.Close()
+	return data, err
+}
+
+// Neither are functions without a named error
+func (s *Store) Count() (n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return n
+}
+
+// Nor functions deferring something other than releasing a resource
+func Log(msg string) (err error) {
+	defer fmt.Println("done")
+	_, err = fmt.Println(msg)
+	return err
+}
+
+// =============================================================================
+// BAD EXAMPLES - These SHOULD trigger reports
+// =============================================================================
+
+func ReadAll(path string) (data []byte, err error) {
+	var f *os.File
+	f, err = os.Open(path)
+	if err != nil {
+		return data, err
+	}
+	defer f.Close() // want `func ReadAll: defers f.Close\(\) but no deferred function handles the named error "err"`
+	return data, err
+}
+
+func (s *Store) Load(path string) (data []byte, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock() // want `method \(\*Store\).Load: defers s.mu.Unlock\(\) but no deferred function handles the named error "err"`
+	data, err = os.ReadFile(path)
+	return data, err
+}
+
+// A deferred literal with its own error doesn't handle the function's
+func Update(tx *sql.Tx) (err error) {
+	defer tx.Rollback() // want `func Update: defers tx.Rollback\(\) but no deferred function handles the named error "err"`
+	defer func() {
+		var err error // want `func Update: named return variable "err" is shadowed by variable declared in a deferred function, which leaves the named result unchanged`
+		err = tx.Commit()
+		_ = err
+	}()
+	return err
+}
@@ -0,0 +1,64 @@
+package redeclare
+
+import (
+	"log"
+	"os"
+)
+
+// =============================================================================
+// GOOD EXAMPLES - These should NOT trigger any reports
+// =============================================================================
+
+// The deferred function assigns the named error
+func Close(f *os.File) (err error) {
+	defer func() {
+		if closeErr := f.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+	return
+}
+
+// The deferred function's own error doesn't stand in for the named one, but
+// the bare return uses it
+func Flush(f *os.File) (err error) {
+	defer func() {
+		var syncErr error
+		syncErr = f.Sync()
+		err = syncErr
+	}()
+	return
+}
+
+// =============================================================================
+// BAD EXAMPLES - These SHOULD trigger reports
+// =============================================================================
+
+func Remove(f *os.File) (err error) {
+	defer func() {
+		err := os.Remove(f.Name()) // want `func Remove: named return variable "err" is shadowed by variable declared in a deferred function, which leaves the named result unchanged`
+		log.Print(err)
+	}()
+	return
+}
+
+func Sync(f *os.File) (err error) {
+	defer func() {
+		if err := f.Sync(); err != nil { // want `func Sync: named return variable "err" is shadowed by variable declared in a deferred function, which leaves the named result unchanged`
+			log.Print(err)
+		}
+	}()
+	return
+}
+
+// Assigning the deferred function's own err doesn't exempt the named one
+func Write(f *os.File, b []byte) (n int, err error) { // want `func Write: named return variable "err" is declared but not used in return statement`
+	defer func() {
+		var err error // want `func Write: named return variable "err" is shadowed by variable declared in a deferred function, which leaves the named result unchanged`
+		err = f.Close()
+		log.Print(err)
+	}()
+	var writeErr error
+	n, writeErr = f.Write(b)
+	return n, writeErr
+}
@@ -0,0 +1,69 @@
+package defersmode
+
+import (
+	"errors"
+	"os"
+)
+
+// =============================================================================
+// GOOD EXAMPLES - These should NOT trigger any reports
+// =============================================================================
+
+// Without a defer, unnamed results are fine
+func noDefer() (int, error) {
+	return 42, nil
+}
+
+// Without a defer, results need not be returned by name either
+func noDeferNamed() (n int, err error) {
+	return 42, nil
+}
+
+// A defer in a function literal belongs to the literal, which has no results
+func deferInLiteral() error {
+	cleanup := func() {
+		defer os.Remove("tmp")
+	}
+	cleanup()
+	return nil
+}
+
+// Named results with a defer - this is fine
+func deferNamed(path string) (data []byte, err error) {
+	var f *os.File
+	f, err = os.Open(path)
+	if err != nil {
+		return data, err
+	}
+	defer f.Close()
+	return data, err
+}
+
+// =============================================================================
+// BAD EXAMPLES - These SHOULD trigger reports
+// =============================================================================
+
+// A defer requires named results
+func deferUnnamed(path string) ([]byte, error) { // want `func deferUnnamed: unnamed return with type "\[\]byte" found` `func deferUnnamed: unnamed return with type "error" found`
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return nil, nil
+}
+
+// And their use in return statements
+func deferNotReturned() (n int, err error) { // want `func deferNotReturned: named return variable "n" is declared but not used in return statement` `func deferNotReturned: named return variable "err" is declared but not used in return statement`
+	defer func() {}()
+	return 1, errors.New("failed")
+}
+
+// A function literal with a defer is checked on its own
+func literalWithDefer() {
+	f := func() error { // want `func literal in func literalWithDefer: unnamed return with type "error" found`
+		defer func() {}()
+		return nil
+	}
+	_ = f()
+}
@@ -0,0 +1,9 @@
+// Code maintained by an external tool, which the generated code check misses.
+
+//namedreturns:disable-file -- maintained by the schema tool
+
+package disablefile
+
+func all() int {
+	return 1
+}
@@ -0,0 +1,7 @@
+//namedreturns:disable-file expires=2020-01-31 -- until the tool is fixed // want `namedreturns:disable-file directive expired on 2020-01-31`
+
+package disablefile
+
+func expired() int { // want `func expired: unnamed return with type "int" found - named returns are required`
+	return 1
+}
@@ -0,0 +1,9 @@
+//namedreturns:disable-file NR999 // want `namedreturns:disable-file directive names unknown rule "NR999"`
+
+package disablefile
+
+//namedreturns:disable-file // want `namedreturns:disable-file directive must precede the package clause`
+
+func reported() int { // want `func reported: unnamed return with type "int" found - named returns are required`
+	return 1
+}
@@ -0,0 +1,19 @@
+//namedreturns:disable-file NR001, unused-in-return
+
+package disablefile
+
+func scoped() int {
+	return 1
+}
+
+func unused() (n int) {
+	return 1
+}
+
+func shadowing() (err error) {
+	{
+		err := error(nil) // want `func shadowing: named return variable "err" is shadowed by local variable declaration`
+		_ = err
+	}
+	return err
+}
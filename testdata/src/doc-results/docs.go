@@ -0,0 +1,68 @@
+package docresults
+
+import "strings"
+
+// =============================================================================
+// GOOD EXAMPLES - These should NOT trigger any reports
+// =============================================================================
+
+// Split returns the fields of s as fields, and their count as n.
+func Split(s string) (fields []string, n int) {
+	fields = strings.Fields(s)
+	n = len(fields)
+	return fields, n
+}
+
+// Load reads the file at path into Data, returning an error if it fails.
+func Load(path string) (data []byte, err error) {
+	return data, err
+}
+
+// Lookup returns the value under key, with ok telling whether it was found,
+// and err when the store is unavailable.
+func Lookup(key string) (value string, ok bool, err error) {
+	return value, ok, err
+}
+
+// Open opens the store, naming only the result of type error, which the
+// errors it returns describe.
+func Open() (err error) {
+	return err
+}
+
+// Unexported functions aren't documented for others
+func parse(s string) (count int, err error) {
+	return count, err
+}
+
+func Undocumented() (count int, err error) {
+	return count, err
+}
+
+type store struct{}
+
+// Len counts nothing, but its type is unexported.
+func (s *store) Len() (count int) {
+	return count
+}
+
+type Store struct{}
+
+// Len returns the number of entries as count.
+func (s *Store) Len() (count int) {
+	return count
+}
+
+// =============================================================================
+// BAD EXAMPLES - These SHOULD trigger reports
+// =============================================================================
+
+// Parse parses s, returning the number of fields read as n.
+func Parse(s string) (count int, err error) { // want `func Parse: the doc comment doesn't mention result "count"` `func Parse: the doc comment mentions neither result "err" nor the errors returned`
+	return count, err
+}
+
+// Size returns the number of bytes stored as n.
+func (s *Store) Size() (size int) { // want `method \(\*Store\)\.Size: the doc comment doesn't mention result "size"`
+	return size
+}
@@ -0,0 +1,58 @@
+package errorconvention
+
+import (
+	"errors"
+	"strconv"
+)
+
+// =============================================================================
+// GOOD EXAMPLES - These should NOT trigger any reports
+// =============================================================================
+
+// The error comes last and is named err
+func parse(s string) (n int, err error) {
+	n, err = strconv.Atoi(s)
+	return n, err
+}
+
+// Functions without an error are not concerned
+func pair() (a int, b int) {
+	a, b = 1, 2
+	return a, b
+}
+
+// Generic error results follow the convention too
+func try[E error](f func() E) (ok bool, err E) {
+	err = f()
+	return ok, err
+}
+
+// =============================================================================
+// BAD EXAMPLES - These SHOULD trigger reports
+// =============================================================================
+
+// The error must come last
+func errorFirst(s string) (err error, n int) { // want `func errorFirst: error result "err" must be the last result`
+	n, err = strconv.Atoi(s)
+	return err, n
+}
+
+// And be named err
+func misnamed() (e error) { // want `func misnamed: error result "e" should be named "err"`
+	e = errors.New("failed")
+	return e
+}
+
+func misnamedLast() (n int, failure error) { // want `func misnamedLast: error result "failure" should be named "err"`
+	return n, failure
+}
+
+// Of several errors, only the last one may be
+func twoErrors() (first, err error) { // want `func twoErrors: error result "first" must be the last result`
+	return first, err
+}
+
+// The order of unnamed results is checked along with their naming
+func unnamed() (error, int) { // want `func unnamed: unnamed return with type "error" found` `func unnamed: unnamed return with type "int" found` `func unnamed: error result must be the last result`
+	return nil, 0
+}
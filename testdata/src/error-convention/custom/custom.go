@@ -0,0 +1,15 @@
+package custom
+
+import "errors"
+
+// The package's own name is fine
+func short() (e error) {
+	e = errors.New("failed")
+	return e
+}
+
+// The default name is not
+func long() (err error) { // want `func long: error result "err" should be named "e"`
+	err = errors.New("failed")
+	return err
+}
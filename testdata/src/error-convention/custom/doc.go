@@ -0,0 +1,4 @@
+// Package custom names its error results e.
+//
+//namedreturns:config error-name=e
+package custom
@@ -0,0 +1,112 @@
+package propagation
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+)
+
+type recorder struct {
+	last error
+}
+
+// =============================================================================
+// GOOD EXAMPLES - These should NOT trigger any reports
+// =============================================================================
+
+func assigned(path string) (err error) {
+	err = os.Remove(path)
+	return
+}
+
+func wrapped(path string) (err error) {
+	if e := os.Remove(path); e != nil {
+		err = fmt.Errorf("removing %s: %w", path, e)
+		return
+	}
+	return
+}
+
+func joined(paths []string) (err error) {
+	var errs []error
+	for _, p := range paths {
+		if e := os.Remove(p); e != nil {
+			errs = append(errs, e)
+		}
+	}
+	err = errors.Join(errs...)
+	return
+}
+
+func returned(path string) (err error) {
+	e := os.Remove(path)
+	return e
+}
+
+func panicked(path string) (err error) {
+	if e := os.Remove(path); e != nil {
+		panic(e)
+	}
+	return
+}
+
+// Errors discarded outright are dropped on purpose
+func discarded(path string) (err error) {
+	_ = os.Remove(path)
+	os.Remove(path)
+	return
+}
+
+// Errors stored beyond the function are handled there
+func (r *recorder) record(path string) (err error) {
+	r.last = os.Remove(path)
+	return
+}
+
+// Without a named error there is nothing to propagate into
+func unnamed(path string) error {
+	if e := os.Remove(path); e != nil {
+		log.Print(e)
+	}
+	return nil
+}
+
+// =============================================================================
+// BAD EXAMPLES - These SHOULD trigger reports
+// =============================================================================
+
+func logged(path string) (err error) {
+	if e := os.Remove(path); e != nil { // want `func logged: the error of os.Remove is stored but never reaches a return, leaving "err" unset on failure`
+		log.Print(e)
+	}
+	return
+}
+
+func shadowed(path string) (err error) {
+	if err := os.Remove(path); err != nil { // want `func shadowed: the error of os.Remove is stored but never reaches a return, leaving "err" unset on failure`
+		log.Print(err)
+	}
+	return
+}
+
+func fallback(s string) (n int, err error) {
+	n, e := strconv.Atoi(s) // want `func fallback: the error of strconv.Atoi is stored but never reaches a return, leaving "err" unset on failure`
+	if e != nil {
+		n = 0
+	}
+	return n, nil
+}
+
+func literal() (err error) {
+	cleanup := func(path string) (err error) {
+		e := os.Remove(path) // want `func literal in func literal: the error of os.Remove is stored but never reaches a return, leaving "err" unset on failure`
+		if e != nil {
+			log.Print(e)
+		}
+		return
+	}
+	err = cleanup("tmp")
+	return
+}
@@ -0,0 +1,66 @@
+package errorsmode
+
+import (
+	"errors"
+	"strconv"
+)
+
+// =============================================================================
+// GOOD EXAMPLES - These should NOT trigger any reports
+// =============================================================================
+
+// Without an error, unnamed results are fine
+func pureValue() (int, string) {
+	return 42, "answer"
+}
+
+// Without an error, results need not be returned by name either
+func pureValueNamed() (n int, s string) {
+	return 42, "answer"
+}
+
+// A function literal without an error is fine too
+func literalWithoutError() (err error) {
+	double := func(n int) int {
+		return 2 * n
+	}
+	_ = double(21)
+	return err
+}
+
+// Named results with an error - this is fine
+func parse(s string) (n int, err error) {
+	n, err = strconv.Atoi(s)
+	return n, err
+}
+
+// =============================================================================
+// BAD EXAMPLES - These SHOULD trigger reports
+// =============================================================================
+
+// An error anywhere in the results requires named results
+func errorLast(s string) (int, error) { // want `func errorLast: unnamed return with type "int" found` `func errorLast: unnamed return with type "error" found`
+	return strconv.Atoi(s)
+}
+
+func errorFirst() (error, bool) { // want `func errorFirst: unnamed return with type "error" found` `func errorFirst: unnamed return with type "bool" found`
+	return nil, false
+}
+
+// And their use in return statements
+func errorNotReturned() (n int, err error) { // want `func errorNotReturned: named return variable "n" is declared but not used in return statement` `func errorNotReturned: named return variable "err" is declared but not used in return statement`
+	return 1, errors.New("failed")
+}
+
+// A type parameter constrained by error counts as an error
+func first[E error](errs []E) E { // want `func first: unnamed return with type "E" found`
+	return errs[0]
+}
+
+// A function literal returning an error is checked on its own
+func literalWithError() {
+	f := func() error { // want `func literal in func literalWithError: unnamed return with type "error" found`
+		return nil
+	}
+	_ = f()
+}
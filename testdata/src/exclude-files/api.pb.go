@@ -0,0 +1,10 @@
+package files
+
+func generated() int {
+	return 2
+}
+
+//namedreturns:ignore without a reason, which isn't reported in excluded files
+func misplaced() int {
+	return 3
+}
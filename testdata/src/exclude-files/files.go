@@ -0,0 +1,6 @@
+package files
+
+// Only the files the exclude-files globs don't match are analyzed
+func kept() int { // want `func kept: unnamed return with type "int" found - named returns are required`
+	return 1
+}
@@ -0,0 +1,5 @@
+package files
+
+func deepCopy() (int, error) {
+	return 4, nil
+}
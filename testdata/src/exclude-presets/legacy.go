@@ -0,0 +1,13 @@
+// Code generated by protoc-gen-go.
+// source: legacy.proto
+// DO NOT EDIT!
+
+package presets
+
+func (m *Message) GetName() string {
+	return m.name
+}
+
+type Message struct {
+	name string
+}
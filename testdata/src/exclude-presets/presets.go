@@ -0,0 +1,5 @@
+package presets
+
+func handWritten() int { // want `unnamed return with type "int" found`
+	return 1
+}
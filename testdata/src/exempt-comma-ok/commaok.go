@@ -0,0 +1,39 @@
+package commaok
+
+type cache struct {
+	entries map[string]int
+	events  chan string
+}
+
+func (c *cache) Get(key string) (int, bool) {
+	v, ok := c.entries[key]
+	return v, ok
+}
+
+func (c *cache) Next() (string, bool) {
+	event, ok := <-c.events
+	return event, ok
+}
+
+func asString(v any) (string, bool) {
+	s, ok := (v).(string)
+	return s, ok
+}
+
+func (c *cache) Lookup(key string) (int, bool) { // want `method \(\*cache\).Lookup: unnamed return with type "int" found - named returns are required` `method \(\*cache\).Lookup: unnamed return with type "bool" found - named returns are required`
+	v, ok := c.entries[key]
+	if !ok {
+		return 0, false
+	}
+	return v, ok
+}
+
+func (c *cache) Swapped(key string) (bool, int) { // want `method \(\*cache\).Swapped: unnamed return with type "bool" found - named returns are required` `method \(\*cache\).Swapped: unnamed return with type "int" found - named returns are required`
+	v, ok := c.entries[key]
+	return ok, v
+}
+
+func (c *cache) Negated(n int) (int, bool) { // want `method \(\*cache\).Negated: unnamed return with type "int" found - named returns are required` `method \(\*cache\).Negated: unnamed return with type "bool" found - named returns are required`
+	v, ok := -n, true
+	return v, ok
+}
@@ -0,0 +1,75 @@
+package types
+
+import (
+	"io"
+	"iter"
+)
+
+type done struct{}
+
+type stringer interface {
+	String() string
+}
+
+// Results of the exempted categories need no name
+func cleanup() func() {
+	return func() {}
+}
+
+func events() <-chan int {
+	return nil
+}
+
+func signal() done {
+	return done{}
+}
+
+func values() iter.Seq[int] {
+	return nil
+}
+
+func pairs() func(yield func(string, int) bool) {
+	return nil
+}
+
+func describe() stringer {
+	return nil
+}
+
+func reader() io.Reader {
+	return nil
+}
+
+// They may be named _ where the other results are named
+func start() (_ func(), err error) {
+	return func() {}, err
+}
+
+// The other results still need names
+func open() (func(), error) { // want `func open: unnamed return with type "error" found - named returns are required`
+	return nil, nil
+}
+
+func count() int { // want `func count: unnamed return with type "int" found - named returns are required`
+	return 0
+}
+
+// error is no exempted single method interface
+func check() error { // want `func check: unnamed return with type "error" found - named returns are required`
+	return nil
+}
+
+func underscore() (_ int, cancel func()) { // want `func underscore: underscore as a return variable name is unacceptable for type "int"`
+	return 0, cancel
+}
+
+func structs() struct{ n int } { // want `func structs: unnamed return with type "struct{n int}" found - named returns are required`
+	return struct{ n int }{}
+}
+
+func methods() interface { // want `func methods: unnamed return with type "interface{Read\(\) int; Close\(\) error}" found - named returns are required`
+	Read() int
+	Close() error
+} {
+	return nil
+}
@@ -0,0 +1,10 @@
+package gen
+
+// =============================================================================
+// TESTING PACKAGES EXEMPTED BY EXEMPTION RULES
+// =============================================================================
+
+// Code generated by a tool that doesn't name results.
+func Lookup(key string) (int, bool) {
+	return len(key), true
+}
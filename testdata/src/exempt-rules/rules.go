@@ -0,0 +1,44 @@
+package rules
+
+import "os"
+
+// =============================================================================
+// TESTING FUNCTIONS EXEMPTED BY EXEMPTION RULES
+// =============================================================================
+
+type MockStore struct{}
+
+// Methods of mocks are exempted by their receiver
+func (m *MockStore) Get(key string) (string, error) {
+	return key, nil
+}
+
+// Function literals are exempted along with the function declaring them
+func (m *MockStore) Keys() []string {
+	list := func() []string {
+		return nil
+	}
+	return list()
+}
+
+type Store struct{}
+
+// Methods of Store are exempted if they return a value and an error
+func (s Store) Get(key string) (string, error) {
+	return key, nil
+}
+
+// All the fields of a rule must match
+func (s Store) Len() int { // want `method Store.Len: unnamed return with type "int" found - named returns are required`
+	return 0
+}
+
+// Result types are matched as written in the package
+func tempDir() (string, func()) { // want `func tempDir: unnamed return with type "string" found - named returns are required` `func tempDir: unnamed return with type "func\(\)" found - named returns are required`
+	dir, _ := os.MkdirTemp("", "rules")
+	return dir, func() { os.RemoveAll(dir) }
+}
+
+func cleanup(f *os.File) func() {
+	return func() { f.Close() }
+}
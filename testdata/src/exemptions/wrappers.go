@@ -0,0 +1,37 @@
+package exemptions
+
+import "strconv"
+
+// =============================================================================
+// TESTING FUNCTIONS EXEMPTED BY REGISTERED EXEMPTIONS
+// =============================================================================
+
+// Request is what the framework passes to handlers.
+type Request struct {
+	Query string
+}
+
+// Wrappers generated by the framework are recognized by their signature
+func wrapList(r *Request) (int, error) {
+	return strconv.Atoi(r.Query)
+}
+
+func wrapGet(r *Request) error {
+	return nil
+}
+
+// Other functions are checked as usual
+func parse(s string) (int, error) { // want `func parse: unnamed return with type "int" found - named returns are required` `func parse: unnamed return with type "error" found - named returns are required`
+	return strconv.Atoi(s)
+}
+
+// Exempted functions still have their literals checked, unless those are
+// exempted too
+func wrapPost(r *Request) error {
+	atoi := func(s string) int { // want `func literal in func wrapPost: unnamed return with type "int" found - named returns are required`
+		n, _ := strconv.Atoi(s)
+		return n
+	}
+	_ = atoi(r.Query)
+	return nil
+}
@@ -0,0 +1,14 @@
+package a
+
+type Reader interface { // want Reader:"results Read\\(n, err\\)"
+	Read(p []byte) (n int, err error)
+}
+
+type Closer interface { // want Closer:"results Close\\(-\\)"
+	Close() error
+}
+
+// Unexported interfaces can't be referenced by other packages, so no fact is exported
+type closer interface {
+	Close() (err error)
+}
@@ -0,0 +1,10 @@
+package b
+
+import "facts/a"
+
+type ReadCloser interface { // want ReadCloser:"results Close\\(-\\) Read\\(n, err\\)"
+	a.Reader
+	a.Closer
+}
+
+var _ a.Reader
@@ -0,0 +1,61 @@
+package opts
+
+import (
+	"errors"
+	"strconv"
+)
+
+// =============================================================================
+// TESTING SETTINGS CHANGED FOR SINGLE FUNCTIONS
+// =============================================================================
+
+// lookup is on the hot path and returns a single result on purpose.
+//
+//namedreturns:opts min-returns=2
+func lookup(key string) int {
+	return len(key)
+}
+
+// Other functions keep the package's settings
+func size(key string) int { // want `func size: unnamed return with type "int" found - named returns are required`
+	return len(key)
+}
+
+//namedreturns:opts mode=errors report-error-in-defer
+func convert(s string) (n int, err error) { // want `func convert: named return variable "err" is declared but not used in return statement`
+	defer func() {
+		err = errors.New("deferred")
+	}()
+
+	// Function literals take the options of the enclosing function
+	double := func(n int) int {
+		return 2 * n
+	}
+	n, err = strconv.Atoi(s)
+	n = double(n)
+	return n, nil
+}
+
+// A mode alone is short for mode=mode
+//
+//namedreturns:opts min-returns=2 error-only
+func parse(s string) (int, error) { // want `func parse: unnamed return with type "int" found - named returns are required` `func parse: unnamed return with type "error" found - named returns are required`
+	return strconv.Atoi(s)
+}
+
+//namedreturns:opts min-returns=2 error-only
+func pair() (int, int) {
+	return 0, 0
+}
+
+//namedreturns:opts // want `namedreturns:opts directive names no setting`
+func noSetting() (n int) {
+	return n
+}
+
+//namedreturns:opts mode=sometimes locale=de // want `namedreturns:opts directive: invalid value "sometimes" for setting "mode"` `namedreturns:opts directive can't change locale, which applies to the whole package`
+func invalid() (n int) {
+	return n
+}
+
+var _ = 1 //namedreturns:opts min-returns=3 // want `namedreturns:opts directive is not attached to a function`
@@ -0,0 +1,49 @@
+package selector
+
+import "strconv"
+
+// =============================================================================
+// TESTING THE SELECTION OF FUNCTIONS BY NAME
+// =============================================================================
+
+type Server struct{}
+
+// Selected by import path
+func (s *Server) Start() error { // want `method \(\*Server\).Start: unnamed return with type "error" found - named returns are required`
+	return nil
+}
+
+type Client struct{}
+
+// A method of the same name on another receiver isn't selected
+func (c *Client) Start() error {
+	return nil
+}
+
+type Box[T any] struct{}
+
+// Selected without the type parameters of the receiver, along with the
+// function literals within
+func (b *Box[T]) Parse(s string) (int, error) { // want `method \(\*Box\[T\]\).Parse: unnamed return with type "int" found - named returns are required` `method \(\*Box\[T\]\).Parse: unnamed return with type "error" found - named returns are required`
+	parse := func() int { // want `func literal in method \(\*Box\[T\]\).Parse: unnamed return with type "int" found - named returns are required`
+		n, _ := strconv.Atoi(s)
+		return n
+	}
+	return parse(), nil
+}
+
+// Selected by package name
+func parse(s string) (int, error) { // want `func parse: unnamed return with type "int" found - named returns are required` `func parse: unnamed return with type "error" found - named returns are required`
+	return strconv.Atoi(s)
+}
+
+func format(n int) string {
+	return strconv.Itoa(n)
+}
+
+// Findings about directives outside the selected functions are left out too
+//
+//namedreturns:ignore
+func size(s string) int {
+	return len(s)
+}
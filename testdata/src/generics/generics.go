@@ -0,0 +1,163 @@
+package generics
+
+import "errors"
+
+// =============================================================================
+// GOOD EXAMPLES - These should NOT trigger any reports
+// =============================================================================
+
+// Result types referencing type parameters, properly named
+func first[T any](values []T) (value T, ok bool) {
+	if len(values) == 0 {
+		return value, ok
+	}
+	value, ok = values[0], true
+	return value, ok
+}
+
+// Stack is a generic type with methods
+type Stack[T any] struct {
+	items []T
+}
+
+// Generic receiver with properly named returns
+func (s *Stack[T]) Pop() (item T, ok bool) {
+	if len(s.items) == 0 {
+		return item, ok
+	}
+	item, ok = s.items[len(s.items)-1], true
+	s.items = s.items[:len(s.items)-1]
+	return item, ok
+}
+
+// Pair is a generic type with two type parameters
+type Pair[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+// Instantiated generic result, properly named
+func pairOf(key string, value int) (pair Pair[string, int]) {
+	pair = Pair[string, int]{Key: key, Value: value}
+	return pair
+}
+
+// A result whose constraint is error is an error result, exempt when the
+// defer assigns it
+func guard[E error](f func() E) (value int, err E) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = f()
+		}
+	}()
+	value = 42
+	return value, f()
+}
+
+// A constraint embedding error counts too
+func guardComparable[E interface {
+	comparable
+	error
+}](f func() E) (value int, err E) {
+	defer func() {
+		err = f()
+	}()
+	value = 42
+	return value, f()
+}
+
+// Function literal inside a generic function, properly named
+func apply[T any](values []T, f func(T) T) (applied []T) {
+	double := func(value T) (result T) {
+		result = f(f(value))
+		return result
+	}
+	for _, value := range values {
+		applied = append(applied, double(value))
+	}
+	return applied
+}
+
+// =============================================================================
+// BAD EXAMPLES - These SHOULD trigger reports
+// =============================================================================
+
+// Unnamed result of a type parameter
+func last[T any](values []T) T { // want `func last: unnamed return with type "T" found - named returns are required`
+	return values[len(values)-1]
+}
+
+// Unnamed instantiated generic result
+func makePair[K comparable, V any](key K, value V) Pair[K, V] { // want `func makePair: unnamed return with type "Pair\[K, V\]" found - named returns are required`
+	return Pair[K, V]{Key: key, Value: value}
+}
+
+// Generic receiver with an unnamed result
+func (s *Stack[T]) Peek() T { // want `method \(\*Stack\[T\]\).Peek: unnamed return with type "T" found - named returns are required`
+	return s.items[len(s.items)-1]
+}
+
+// Generic receiver with an underscore result
+func (s *Stack[T]) Len() (_ int) { // want `method \(\*Stack\[T\]\).Len: underscore as a return variable name is unacceptable for type "int"`
+	return len(s.items)
+}
+
+// Named result of a type parameter not returned
+func orDefault[T comparable](value T, fallback T) (result T) { // want `func orDefault: named return variable "result" is declared but not used in return statement`
+	var zero T
+	if value == zero {
+		return fallback
+	}
+	result = value
+	return result
+}
+
+// Named result of a type parameter shadowed
+func sum[T int | float64](values []T) (total T) {
+	for _, value := range values {
+		total := total + value // want `func sum: named return variable "total" is shadowed by local variable declaration`
+		_ = total
+	}
+	return total
+}
+
+// Error constrained result not assigned in a defer is not exempt
+func wrap[E error](f func() E) (value int, err E) { // want `func wrap: named return variable "err" is declared but not used in return statement`
+	value = 42
+	return value, f()
+}
+
+// An error result of a generic type isn't an error result
+func check[T any](value T) (result T, err Pair[string, error]) { // want `func check: named return variable "err" is declared but not used in return statement`
+	defer func() {
+		err = Pair[string, error]{Value: errors.New("checked")}
+	}()
+	result = value
+	return result, Pair[string, error]{}
+}
+
+// Function literal inside a generic function with an unnamed result
+func transform[T any](values []T, f func(T) T) (transformed []T) {
+	twice := func(value T) T { // want `func literal in func transform: unnamed return with type "T" found - named returns are required`
+		return f(f(value))
+	}
+	for _, value := range values {
+		transformed = append(transformed, twice(value))
+	}
+	return transformed
+}
+
+// Function literal inside a generic method not returning its named result
+func (s *Stack[T]) Drain() (items []T) {
+	take := func() (item T, ok bool) { // want `func literal in method \(\*Stack\[T\]\).Drain: named return variable "item" is declared but not used in return statement`
+		item, ok = s.Pop()
+		return s.items[0], ok
+	}
+	for {
+		item, ok := take()
+		if !ok {
+			return items
+		}
+		items = append(items, item)
+	}
+}
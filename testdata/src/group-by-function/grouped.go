@@ -0,0 +1,29 @@
+package grouped
+
+import "strconv"
+
+// =============================================================================
+// TESTING FINDINGS GROUPED BY FUNCTION
+// =============================================================================
+
+// The findings about a function are reported as one, under the rule of the
+// most severe
+func load(s string) (n int, _ string, err error) { // want `func load: 4 problems with the results: underscore as a return variable name is unacceptable for type "string"; named return variable "err" is declared but not used in return statement; named return variable "n" is shadowed by local variable declaration at line 12; named return variable "err" is shadowed by local variable declaration at line 12`
+	if n, err := strconv.Atoi(s); err == nil {
+		return n, s, nil
+	}
+	return n, s, err
+}
+
+// A single finding is reported as it is
+func size(s string) int { // want `func size: unnamed return with type "int" found - named returns are required`
+	return len(s)
+}
+
+// Suppressed findings are left out of the group
+func parse(s string) (n int, ok bool) { // want `func parse: named return variable "n" is declared but not used in return statement`
+	//nolint:namedreturns // the error is handled by the caller
+	v, err := strconv.Atoi(s)
+	ok = err == nil
+	return v, ok
+}
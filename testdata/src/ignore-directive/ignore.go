@@ -0,0 +1,82 @@
+package ignore
+
+import "strconv"
+
+// parse keeps the signature of the callback it implements.
+//
+//namedreturns:ignore NR001 -- must match the signature of strconv.Atoi
+func parse(s string) (int, error) {
+	return strconv.Atoi(s)
+}
+
+//namedreturns:ignore unused-in-return, shadowed-result -- ported as is, cleanup tracked separately
+func ported(s string) (n int, err error) {
+	if s != "" {
+		n, err := strconv.Atoi(s)
+		return n, err
+	}
+	return 0, nil
+}
+
+// Only the named rules are suppressed
+//
+//namedreturns:ignore NR003 -- returns literals on purpose
+func partial() (n int, err error) {
+	{
+		err := strconv.ErrRange // want `func partial: named return variable "err" is shadowed by local variable declaration`
+		_ = err
+	}
+	return 0, nil
+}
+
+func literal() {
+	//namedreturns:ignore NR001 -- matches the callback signature
+	callback := func() int {
+		return 1
+	}
+	_ = callback
+}
+
+//namedreturns:ignore NR001 // want `namedreturns:ignore directive requires a reason, e.g. //namedreturns:ignore NR003 -- reason`
+func noReason() int { // want `func noReason: unnamed return with type "int" found - named returns are required`
+	return 1
+}
+
+//namedreturns:ignore NR001 -- // want `namedreturns:ignore directive requires a reason`
+func emptyReason() int { // want `func emptyReason: unnamed return with type "int" found - named returns are required`
+	return 1
+}
+
+//namedreturns:ignore -- forgot the rule // want `namedreturns:ignore directive names no rule`
+func noRule() int { // want `func noRule: unnamed return with type "int" found - named returns are required`
+	return 1
+}
+
+//namedreturns:ignore NR999 -- no such rule // want `namedreturns:ignore directive names unknown rule "NR999"`
+func unknownRule() int { // want `func unknownRule: unnamed return with type "int" found - named returns are required`
+	return 1
+}
+
+//namedreturns:ignore NR001 -- detached from any function // want `namedreturns:ignore directive is not attached to a function`
+
+var detached = 1
+
+//namedreturns:ignore NR001 expires=2999-12-31 -- until the callback API is replaced
+func temporary() int {
+	return 1
+}
+
+//namedreturns:ignore NR001 expires=2020-01-31 -- until the callback API is replaced // want `namedreturns:ignore directive expired on 2020-01-31`
+func expired() int { // want `func expired: unnamed return with type "int" found - named returns are required`
+	return 1
+}
+
+//namedreturns:ignore NR001 expires=soon -- until the callback API is replaced // want `namedreturns:ignore directive has invalid expiry date "soon", expected YYYY-MM-DD`
+func invalidExpiry() int { // want `func invalidExpiry: unnamed return with type "int" found - named returns are required`
+	return 1
+}
+
+//namedreturns:ignore NR001 owner=platform -- until the callback API is replaced // want `namedreturns:ignore directive has unknown attribute "owner"`
+func unknownAttribute() int { // want `func unknownAttribute: unnamed return with type "int" found - named returns are required`
+	return 1
+}
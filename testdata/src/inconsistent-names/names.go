@@ -0,0 +1,48 @@
+package inconsistentnames
+
+import (
+	"errors"
+	"os"
+)
+
+// Errors are usually named err in this package
+func open(path string) (f *os.File, err error) {
+	f, err = os.Open(path)
+	return f, err
+}
+
+func create(path string) (f *os.File, err error) {
+	f, err = os.Create(path)
+	return f, err
+}
+
+func remove(path string) (err error) {
+	err = os.Remove(path)
+	return err
+}
+
+func mkdir(path string) (err error) {
+	err = os.Mkdir(path, 0o755)
+	return err
+}
+
+// An outlier
+func fail() (e error) { // want `func fail: result "e" of type "error" is usually named "err", as 4 of 5 results of the type in the package are`
+	e = errors.New("failed")
+	return e
+}
+
+// Counts are not used often enough for any name to be usual
+func count() (n int) {
+	return n
+}
+
+func size() (total int) {
+	return total
+}
+
+// Names are compared by type, so this doesn't count against f
+func name(path string) (file string) {
+	file = path
+	return file
+}
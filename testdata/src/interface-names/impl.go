@@ -0,0 +1,80 @@
+package interfacenames
+
+import (
+	"io"
+
+	"interface-names/store"
+)
+
+var (
+	_ store.Getter = (*cache)(nil)
+	_ store.Putter = (*cache)(nil)
+	_ store.Closer = (*cache)(nil)
+	_ store.Getter = disk{}
+	_ store.Putter = (*disk)(nil)
+	_ io.Reader    = (*disk)(nil)
+)
+
+// =============================================================================
+// GOOD EXAMPLES - These should NOT trigger any reports
+// =============================================================================
+
+type cache struct {
+	items map[string][]byte
+}
+
+// Named as the interfaces name them
+func (c *cache) Get(key string) (value []byte, found bool, err error) {
+	value, found = c.items[key]
+	return value, found, err
+}
+
+func (c *cache) Put(key string, value []byte) (err error) {
+	c.items[key] = value
+	return err
+}
+
+// The interface doesn't name the result
+func (c *cache) Close() (closeErr error) {
+	c.items = nil
+	return closeErr
+}
+
+// Agreeing with one of the interfaces implemented is enough
+func (c *cache) Save() (written int, err error) {
+	written = len(c.items)
+	return written, err
+}
+
+// A method of a type implementing no interface declaring it
+type half struct{}
+
+func (h half) Get(key string) (data []byte, err error) {
+	return data, err
+}
+
+// Functions aren't methods
+func Get(key string) (data []byte, ok bool, err error) {
+	return data, ok, err
+}
+
+// =============================================================================
+// BAD EXAMPLES - These SHOULD trigger reports
+// =============================================================================
+
+type disk struct {
+	dir string
+}
+
+func (d disk) Get(key string) (data []byte, ok bool, err error) { // want `method disk\.Get: result "data" should be named "value", as in store\.Getter` `method disk\.Get: result "ok" should be named "found", as in store\.Getter`
+	return data, ok, err
+}
+
+func (d *disk) Put(key string, value []byte) error { // want `method \(\*disk\)\.Put: unnamed return with type "error" found - named returns are required` `method \(\*disk\)\.Put: result of type "error" should be named "err", as in store\.Putter`
+	return nil
+}
+
+// Interfaces of the standard library count as well
+func (d *disk) Read(p []byte) (count int, err error) { // want `method \(\*disk\)\.Read: result "count" should be named "n", as in io\.Reader`
+	return count, err
+}
@@ -0,0 +1,25 @@
+package store
+
+// Getter names its results, which implementations must follow
+type Getter interface {
+	Get(key string) (value []byte, found bool, err error)
+}
+
+// Putter names its only result
+type Putter interface {
+	Put(key string, value []byte) (err error)
+}
+
+// Closer names nothing, so implementations may name their results freely
+type Closer interface {
+	Close() error
+}
+
+// Saver and Writer disagree about the names of Save
+type Saver interface {
+	Save() (n int, err error)
+}
+
+type Writer interface {
+	Save() (written int, err error)
+}
@@ -0,0 +1,61 @@
+package iterators
+
+import "iter"
+
+func Values(xs []int) (seq iter.Seq[int]) {
+	seq = func(yield func(int) bool) {
+		for _, x := range xs {
+			if !yield(x) {
+				return
+			}
+		}
+	}
+	return seq
+}
+
+func Pairs(m map[string]int) (it iter.Seq2[string, int]) { // want `Pairs: iterator result "it" should be named one of: seq`
+	it = func(yield func(string, int) bool) {
+		for k, v := range m {
+			if !yield(k, v) {
+				return
+			}
+		}
+	}
+	return it
+}
+
+// Functions of the shape of an iterator are iterators too
+func Evens(n int) (all func(yield func(int) bool)) { // want `Evens: iterator result "all" should be named one of: seq`
+	all = func(yield func(int) bool) {
+		for i := 0; i < n; i += 2 {
+			if !yield(i) {
+				return
+			}
+		}
+	}
+	return all
+}
+
+// The yield functions passed to iterators are left alone
+func Sum(xs []int) (total int) {
+	Values(xs)(func(x int) bool {
+		total += x
+		return true
+	})
+	return total
+}
+
+// Other function literals returning bool are still checked
+func Count(xs []int, keep func(int) bool) (n int) {
+	for _, x := range xs {
+		if keep(x) {
+			n++
+		}
+	}
+	return n
+}
+
+func Positive(xs []int) (n int) {
+	n = Count(xs, func(x int) bool { return x > 0 }) // want `func literal in func Positive: unnamed return with type "bool" found`
+	return n
+}
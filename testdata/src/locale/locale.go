@@ -0,0 +1,23 @@
+package locale
+
+import "strconv"
+
+// =============================================================================
+// TESTING MESSAGES IN ANOTHER LOCALE
+// =============================================================================
+
+func count() int { // want `^func count: unbenanntes Ergebnis vom Typ "int" gefunden - Ergebnisse müssen benannt sein$`
+	return 1
+}
+
+func parse(s string) (n int, err error) { // want `^func parse: das benannte Ergebnis "n" wird in der return-Anweisung nicht verwendet$` `^func parse: das benannte Ergebnis "err" wird in der return-Anweisung nicht verwendet$`
+	for _, n := range s { // want `^func parse: das benannte Ergebnis "n" wird durch eine range-Schleifenvariable verdeckt$`
+		_ = n
+	}
+	return strconv.Atoi(s)
+}
+
+//namedreturns:ignore NR001 // want `^die namedreturns:ignore-Direktive braucht eine Begründung`
+func ignored() (n int) {
+	return n
+}
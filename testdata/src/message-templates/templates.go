@@ -0,0 +1,21 @@
+package templates
+
+// =============================================================================
+// TESTING CUSTOM MESSAGE TEMPLATES
+// =============================================================================
+
+func unnamed() int { // want `^func unnamed must name its int result, see https://style.example.com/results \(NR001 unnamed-result\)$`
+	return 1
+}
+
+// Rules without a template keep the default message
+func shadowed() (n int) {
+	if n := 2; n > 1 { // want `^func shadowed: named return variable "n" is shadowed by local variable declaration$`
+	}
+	return n
+}
+
+//namedreturns:ignore NR001 // want `^namedreturns:ignore directive requires a reason, e.g. //namedreturns:ignore NR003 -- reason, see https://style.example.com/directives$`
+func ignored() int { // want `^func ignored must name its int result`
+	return 1
+}
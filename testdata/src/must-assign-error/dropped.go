@@ -0,0 +1,118 @@
+package dropped
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+)
+
+// =============================================================================
+// GOOD EXAMPLES - These should NOT trigger any reports
+// =============================================================================
+
+// The error is passed on
+func removeWrapped(path string) (err error) {
+	if e := os.Remove(path); e != nil {
+		err = fmt.Errorf("removing %s: %w", path, e)
+		return
+	}
+	return
+}
+
+// Clearing the error says it is dropped on purpose
+func removeQuietly(path string) (err error) {
+	if e := os.Remove(path); e != nil {
+		log.Print(e)
+		err = nil
+		return
+	}
+	return
+}
+
+// Calls that never return end the path
+func mustRemove(path string) (err error) {
+	e := os.Remove(path)
+	if e != nil {
+		panic(e)
+	}
+	return
+}
+
+func exitOnFailure(path string) (err error) {
+	if e := os.Remove(path); e != nil {
+		os.Exit(1)
+	}
+	return
+}
+
+// Deferred code may still assign the error
+func removeDeferred(path string) (err error) {
+	var e error
+	defer func() {
+		err = e
+	}()
+	e = os.Remove(path)
+	return
+}
+
+// Without a bare return the results say what is returned
+func removeExplicit(path string) (err error) {
+	e := os.Remove(path)
+	if e != nil {
+		log.Print(e)
+		return err
+	}
+	return err
+}
+
+// The error assigned to the named error isn't dropped
+func parse(s string) (n int, err error) {
+	n, err = strconv.Atoi(s)
+	return
+}
+
+// =============================================================================
+// BAD EXAMPLES - These SHOULD trigger reports
+// =============================================================================
+
+func remove(path string) (err error) {
+	if e := os.Remove(path); e != nil {
+		log.Print(e)
+		return // want `func remove: bare return may leave "err" unassigned after the error of os.Remove is dropped`
+	}
+	return
+}
+
+func removeAll(paths []string) (n int, err error) {
+	for _, p := range paths {
+		e := os.Remove(p)
+		if e != nil {
+			continue
+		}
+		n++
+	}
+	return // want `func removeAll: bare return may leave "err" unassigned after the error of os.Remove is dropped`
+}
+
+func sum(values []string) (total int, err error) {
+	for _, s := range values {
+		n, e := strconv.Atoi(s)
+		if e == nil && n > 0 {
+			total += n
+		}
+	}
+	return // want `func sum: bare return may leave "err" unassigned after the error of strconv.Atoi is dropped`
+}
+
+func literal() (err error) {
+	cleanup := func(path string) (err error) {
+		var e = os.Remove(path)
+		if e != nil {
+			return // want `func literal in func literal: bare return may leave "err" unassigned after the error of os.Remove is dropped`
+		}
+		return
+	}
+	err = cleanup("tmp")
+	return
+}
@@ -0,0 +1,15 @@
+package isolated
+
+// The parent configuration doesn't apply, so error results may be named
+// freely, while single results need no name
+func parse() (n int, e error) {
+	return n, e
+}
+
+func count() int {
+	return 0
+}
+
+func split() (int, error) { // want `func split: unnamed return with type "int" found - named returns are required` `func split: unnamed return with type "error" found - named returns are required`
+	return 0, nil
+}
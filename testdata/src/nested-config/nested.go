@@ -0,0 +1,10 @@
+package nested
+
+// The configuration file of this directory enables error-convention
+func parse() (n int, e error) { // want `func parse: error result "e" should be named "err"`
+	return n, e
+}
+
+func count() int { // want `func count: unnamed return with type "int" found - named returns are required`
+	return 0
+}
@@ -0,0 +1,11 @@
+package relaxed
+
+// Only errors must be named here, still by the convention of the parent
+// directory
+func parse() (n int, e error) { // want `func parse: error result "e" should be named "err"`
+	return n, e
+}
+
+func count() int {
+	return 0
+}
@@ -0,0 +1,49 @@
+package nested
+
+import (
+	"errors"
+	"sort"
+)
+
+// Returns of a function literal belong to the literal, not to the function
+// enclosing it
+func sorted(values []int) (result []int) {
+	result = append(result, values...)
+	sort.Slice(result, func(i, j int) (less bool) {
+		less = result[i] < result[j]
+		return less
+	})
+	return result
+}
+
+func literalReturnsOwnResult() (n int) {
+	fn := func() (m int) { // want `func literal in func literalReturnsOwnResult: named return variable "m" is declared but not used in return statement`
+		return 42
+	}
+	n = fn()
+	return n
+}
+
+// A declaration in a literal still shadows the results of the enclosing function
+func shadowedInLiteral() (err error) {
+	fn := func() {
+		err := errors.New("inner") // want `func shadowedInLiteral: named return variable "err" is shadowed by local variable declaration`
+		_ = err
+	}
+	fn()
+	return err
+}
+
+// An assignment in a deferred literal nested in another literal exempts the
+// results of both
+func deferredInLiteral() (err error) {
+	fn := func() (inner error) {
+		defer func() {
+			inner = errors.New("deferred")
+			err = errors.New("deferred")
+		}()
+		return nil
+	}
+	_ = fn()
+	return nil
+}
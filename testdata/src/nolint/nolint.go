@@ -0,0 +1,62 @@
+package nolint
+
+import "strconv"
+
+//nolint:namedreturns // a directive above the function covers all of it
+func above() (int, error) {
+	result, err := strconv.Atoi("1")
+	return result, err
+}
+
+// documented covers the function from the end of its doc comment.
+//
+//nolint:errcheck,namedreturns
+func documented() int {
+	return 1
+}
+
+func inline() int { //nolint:namedreturns
+	return 1
+}
+
+func bare() int { //nolint
+	return 1
+}
+
+func all() int { //nolint:all
+	return 1
+}
+
+func otherLinter() int { //nolint:errcheck // want `func otherLinter: unnamed return with type "int" found - named returns are required`
+	return 1
+}
+
+// The directive on the function line covers only that line
+func shadowing() (err error) { //nolint:namedreturns
+	if true {
+		err := strconv.ErrRange // want `func shadowing: named return variable "err" is shadowed by local variable declaration`
+		_ = err
+	}
+	return err
+}
+
+func statement() (err error) {
+	if true {
+		//nolint:namedreturns // a directive above a statement covers it
+		err := strconv.ErrRange
+		_ = err
+	}
+	return err
+}
+
+func line() (err error) {
+	if true {
+		err := strconv.ErrRange //nolint:namedreturns
+		_ = err
+	}
+	return err
+}
+
+func misspelled() int { // nolint:namedreturn // want `func misspelled: unnamed return with type "int" found - named returns are required`
+	return 1
+}
@@ -0,0 +1,30 @@
+package once
+
+import "strconv"
+
+// =============================================================================
+// TESTING A SINGLE FINDING PER FUNCTION
+// =============================================================================
+
+// Only the most severe finding is reported, the shadowing rather than the
+// unused result
+func load(s string) (n int, err error) {
+	if n, err := strconv.Atoi(s); err == nil { // want `func load: named return variable "n" is shadowed by local variable declaration`
+		return n, nil
+	}
+	return n, err
+}
+
+// The first of the most severe findings
+func pair() (int, string) { // want `func pair: unnamed return with type "int" found - named returns are required`
+	return 0, ""
+}
+
+// Function literals are functions of their own
+func outer() (err error) {
+	inner := func() int { // want `func literal in func outer: unnamed return with type "int" found - named returns are required`
+		return 0
+	}
+	_ = inner()
+	return err
+}
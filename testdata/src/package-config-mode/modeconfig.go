@@ -0,0 +1,17 @@
+// Package modeconfig checks only functions with several results, one of
+// them an error.
+//
+//namedreturns:config mode=error-only min-returns=2
+package modeconfig
+
+func single() error {
+	return nil
+}
+
+func pair() (int, error) { // want `func pair: unnamed return with type "int" found - named returns are required` `func pair: unnamed return with type "error" found - named returns are required`
+	return 0, nil
+}
+
+func coordinates() (int, int) {
+	return 0, 0
+}
@@ -0,0 +1,4 @@
+// Package pkgconfig checks only functions with several results.
+//
+//namedreturns:config min-returns=2 report-error-in-defer
+package pkgconfig
@@ -0,0 +1,22 @@
+//namedreturns:config frobnicate=yes // want `namedreturns:config directive: unknown setting "frobnicate"`
+package pkgconfig
+
+import "errors"
+
+//namedreturns:config min-returns=1 // want `namedreturns:config directive must be part of the package doc comment`
+
+func single() error {
+	return nil
+}
+
+func pair() (int, error) { // want `func pair: unnamed return with type "int" found - named returns are required` `func pair: unnamed return with type "error" found - named returns are required`
+	return 0, nil
+}
+
+// The directive also turned on report-error-in-defer
+func deferred() (n int, err error) { // want `func deferred: named return variable "err" is declared but not used in return statement`
+	defer func() {
+		err = errors.New("deferred")
+	}()
+	return n, nil
+}
@@ -0,0 +1,106 @@
+package panicrecover
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+func mustParse(s string) (n int, err error) {
+	n, err = strconv.Atoi(s)
+	if n < 0 {
+		panic("negative") // want `mustParse: panics without a deferred recover converting the panic into "err"`
+	}
+	return n, err
+}
+
+func converted(s string) (n int, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("parsing %q: %v", s, r)
+		}
+	}()
+
+	n, err = strconv.Atoi(s)
+	if n < 0 {
+		panic("negative")
+	}
+	return n, err
+}
+
+// recoverInto converts a recovered panic into the error at errp.
+func recoverInto(errp *error) {
+	if r := recover(); r != nil {
+		*errp = fmt.Errorf("recovered: %v", r)
+	}
+}
+
+func helper(s string) (n int, err error) {
+	defer recoverInto(&err)
+
+	n, err = strconv.Atoi(s)
+	if n < 0 {
+		panic("negative")
+	}
+	return n, err
+}
+
+func recoveredOnly(s string) (n int, err error) {
+	defer func() {
+		_ = recover()
+	}()
+
+	n, err = strconv.Atoi(s)
+	if n < 0 {
+		panic("negative") // want `recoveredOnly: panics without a deferred recover converting the panic into "err"`
+	}
+	return n, err
+}
+
+func redeclared(s string) (n int, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err := fmt.Errorf("recovered: %v", r) // want `redeclared: named return variable "err" is shadowed by variable declared in a deferred function`
+			fmt.Println(err)
+		}
+	}()
+
+	n, err = strconv.Atoi(s)
+	if n < 0 {
+		panic(errors.New("negative")) // want `redeclared: panics without a deferred recover converting the panic into "err"`
+	}
+	return n, err
+}
+
+func assignedOnly(s string) (n int, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("parsing %q: %w", s, err)
+		}
+	}()
+
+	n, err = strconv.Atoi(s)
+	if n < 0 {
+		panic("negative") // want `assignedOnly: panics without a deferred recover converting the panic into "err"`
+	}
+	return n, err
+}
+
+func nested(values []string) (err error) {
+	for _, v := range values {
+		func() {
+			if v == "" {
+				panic("empty")
+			}
+		}()
+	}
+	return err
+}
+
+func noError(s string) (n int) {
+	if s == "" {
+		panic("empty")
+	}
+	n = len(s)
+	return n
+}
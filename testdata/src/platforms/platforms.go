@@ -0,0 +1,6 @@
+package platforms
+
+// Shared by all platforms, reported once
+func greeting() string {
+	return "hello " + platformName()
+}
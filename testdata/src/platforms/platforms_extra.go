@@ -0,0 +1,7 @@
+//go:build extra
+
+package platforms
+
+func extra() int {
+	return 1
+}
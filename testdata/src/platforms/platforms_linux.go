@@ -0,0 +1,5 @@
+package platforms
+
+func platformName() string {
+	return "linux"
+}
@@ -0,0 +1,47 @@
+package bare
+
+import (
+	"errors"
+	"strconv"
+)
+
+func total(xs []int) (sum int, err error) {
+	for _, x := range xs {
+		sum += x
+	}
+	return sum, nil // want `total: restates the named results with nil for "err", which is never assigned, rather than returning bare`
+}
+
+func pair(s string) (key string, value string, err error) {
+	key, value = s, s
+	return (key), value, nil // want `pair: restates the named results with nil for "err", which is never assigned, rather than returning bare`
+}
+
+func alreadyBare(xs []int) (n int, err error) {
+	n = len(xs)
+	return
+}
+
+// The error is assigned, so nil differs from what a bare return returns
+func assigned(s string) (n int, err error) { // want `named return variable "err" is declared but not used in return statement`
+	n, err = strconv.Atoi(s)
+	if err != nil {
+		return n, err
+	}
+	return n, nil
+}
+
+func withoutError(x int) (a int, b int) {
+	a, b = x, x
+	return a, b
+}
+
+func deferred(s string) (n int, err error) {
+	defer func() {
+		if n < 0 {
+			err = errors.New("negative")
+		}
+	}()
+	n = len(s)
+	return n, nil
+}
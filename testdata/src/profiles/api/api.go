@@ -0,0 +1,14 @@
+package api
+
+import "strconv"
+
+// Parse parses s into n, or fails.
+func Parse(s string) (n int, e error) { // want `Parse: error result "e" should be named "err"`
+	n, e = strconv.Atoi(s)
+	return n, e
+}
+
+// Double doubles x.
+func Double(x int) int { // want `Double: unnamed return with type "int" found`
+	return 2 * x
+}
@@ -0,0 +1,16 @@
+package helpers
+
+import "strconv"
+
+// Internal helpers only name the results they must tell apart
+func parse(s string) (int, error) {
+	return strconv.Atoi(s)
+}
+
+func double(x int) int {
+	return 2 * x
+}
+
+func bounds(xs []int) (int, int) { // want `bounds: unnamed return with type "int" found` `bounds: unnamed return with type "int" found`
+	return xs[0], xs[len(xs)-1]
+}
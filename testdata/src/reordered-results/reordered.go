@@ -0,0 +1,55 @@
+package reordered
+
+import "errors"
+
+func bounds(xs []int) (lo, hi int) {
+	for _, x := range xs {
+		lo, hi = min(lo, x), max(hi, x)
+	}
+	return hi, lo // want `bounds: returns hi, lo in the place of lo, hi, unlike the order the results are declared in`
+}
+
+func inOrder(xs []int) (lo, hi int) {
+	lo, hi = xs[0], xs[len(xs)-1]
+	return lo, hi
+}
+
+func withError(a, b string) (first string, second string, err error) {
+	if a == "" {
+		err = errors.New("empty")
+		return first, second, err
+	}
+	first, second = a, b
+	return second, first, err // want `withError: returns second, first in the place of first, second, unlike the order the results are declared in`
+}
+
+type reader interface{ Read() }
+
+type scanner interface{ Read() }
+
+// Results of types only assignable to each other are swapped on purpose.
+func differentTypes(r reader, s scanner) (rr reader, ss scanner) {
+	rr, ss = r, s
+	return ss, rr
+}
+
+func shadowed(x int) (a, b int) {
+	a, b = x, x
+	if x > 0 {
+		b := a + 1 // want `named return variable "b" is shadowed`
+		return a, b
+	}
+	return a, b
+}
+
+func swapLocals(x, y int) (a, b int) {
+	{
+		a, b := y, x // want `named return variable "a" is shadowed` `named return variable "b" is shadowed`
+		return b, a
+	}
+}
+
+func bare(x int) (a, b int) {
+	a, b = x, -x
+	return
+}
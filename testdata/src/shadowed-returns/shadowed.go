@@ -0,0 +1,51 @@
+package shadowed
+
+import (
+	"errors"
+	"strconv"
+)
+
+func parse(s string) (n int, err error) {
+	if n, err := strconv.Atoi(s); err != nil { // want `named return variable "n" is shadowed` `named return variable "err" is shadowed`
+		return n, err // want `parse: returns the local variable "n" shadowing the named result, not the result itself` `parse: returns the local variable "err" shadowing the named result, not the result itself`
+	}
+	n, err = strconv.Atoi(s)
+	return n, err
+}
+
+// The error handled by the deferred function isn't reported as shadowed,
+// but the one returned still bypasses it
+func deferred(s string) (n int, err error) {
+	defer func() {
+		if err != nil {
+			err = errors.Join(errors.New("parsing"), err)
+		}
+	}()
+
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			err := errors.New("not a digit")
+			return n, (err) // want `deferred: returns the local variable "err" shadowing the named result, not the result itself`
+		}
+	}
+	n, err = strconv.Atoi(s)
+	return n, err
+}
+
+func resolved(s string) (n int, err error) {
+	if len(s) == 0 {
+		err = errors.New("empty")
+		return n, err
+	}
+	n, err = strconv.Atoi(s)
+	return n, err
+}
+
+func nested(s string) (err error) {
+	check := func() (n int, err error) {
+		n, err = strconv.Atoi(s)
+		return n, err
+	}
+	_, err = check()
+	return err
+}
@@ -0,0 +1,81 @@
+package fixes
+
+import (
+	"errors"
+	"io"
+	"strconv"
+)
+
+type Config struct{}
+
+func unnamed() (int, error) { // want `unnamed return with type "int"` `unnamed return with type "error"`
+	return 42, nil
+}
+
+func single() *Config { // want `unnamed return with type "\*Config"`
+	return nil
+}
+
+// Names in use in the function are not taken
+func clash(n int) (int, error) { // want `unnamed return with type "int"` `unnamed return with type "error"`
+	err := errors.New("failed")
+	return n, err
+}
+
+func withComments() ( /* count */ int, // want `unnamed return with type "int"` `unnamed return with type "io.Reader"`
+	io.Reader, // the input
+) {
+	return 0, nil
+}
+
+func underscores() (_, _ int) { // want `underscore as a return variable name is unacceptable for type "int"` `underscore as a return variable name is unacceptable for type "int"`
+	return
+}
+
+func (c *Config) underscore() (_ string, err error) { // want `method \(\*Config\)\.underscore: underscore as a return variable name`
+	return
+}
+
+func literals() (result int, err error) { // want `named return variable "result" is declared but not used in return statement` `named return variable "err" is declared but not used in return statement`
+	if result > 0 {
+		return 1, errors.New("positive")
+	}
+	return 0, err
+}
+
+func call() (value int, err error) { // want `named return variable "value" is declared but not used in return statement` `named return variable "err" is declared but not used in return statement`
+	return strconv.Atoi("42")
+}
+
+func shadowed(s string) (value int, err error) {
+	if value, err := strconv.Atoi(s); err != nil { // want `named return variable "value" is shadowed` `named return variable "err" is shadowed`
+		return value, err
+	}
+	return value, err
+}
+
+func shadowedInLoop() (result int) {
+	for result := 0; result < 3; result++ { // want `shadowed by for loop variable` `shadowed by local variable declaration`
+		_ = result
+	}
+	return result
+}
+
+func shadowedUnicode() (ärger int) {
+	if ärger := strconv.IntSize; ärger > 0 { // want `named return variable "ärger" is shadowed`
+		return ärger
+	}
+	return ärger
+}
+
+type Box[T any] struct {
+	value T
+}
+
+func generic[T any, K comparable](m map[K]T, key K) (T, Box[T]) { // want `unnamed return with type "T"` `unnamed return with type "Box\[T\]"`
+	return m[key], Box[T]{value: m[key]}
+}
+
+func (b *Box[T]) get() (T, bool) { // want `method \(\*Box\[T\]\)\.get: unnamed return with type "T"` `unnamed return with type "bool"`
+	return b.value, true
+}
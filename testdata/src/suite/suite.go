@@ -0,0 +1,18 @@
+package suite
+
+// Findings of the other sub-checks must not be reported by the shadowing analyzer
+func unnamed() int {
+	return 1
+}
+
+func unusedInReturn() (result int) {
+	return 1
+}
+
+func shadowed() (result int) {
+	if true {
+		result := 2 // want `named return variable "result" is shadowed by local variable declaration`
+		_ = result
+	}
+	return result
+}
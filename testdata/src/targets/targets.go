@@ -0,0 +1,26 @@
+package targets
+
+type server struct{}
+
+func parse() int { // want `func parse: unnamed return with type "int" found - named returns are required`
+	return 0
+}
+
+func (s *server) Start() error { // want `method \(\*server\).Start: unnamed return with type "error" found - named returns are required`
+	handle := func() error {
+		return nil
+	}
+	return handle()
+}
+
+var handler = func() (int, error) {
+	return 0, nil
+}
+
+func run() (err error) {
+	do := func(n int) bool {
+		return n > 0
+	}
+	_ = do(1)
+	return err
+}
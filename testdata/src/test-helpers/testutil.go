@@ -0,0 +1,38 @@
+package testutil
+
+import (
+	"os"
+	"testing"
+)
+
+func TempFile(t *testing.T, content string) string {
+	t.Helper()
+	f, err := os.CreateTemp(t.TempDir(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = f.WriteString(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f.Name()
+}
+
+func Fixture(tb testing.TB, name string) ([]byte, error) {
+	tb.Helper()
+	return os.ReadFile(name)
+}
+
+func Payload(b *testing.B, size int) []byte {
+	b.Helper()
+	return make([]byte, size)
+}
+
+// Only the first parameter makes a helper
+func Compare(want string, t *testing.T) bool { // want `unnamed return with type "bool" found`
+	return want == t.Name()
+}
+
+func Load(name string) ([]byte, error) { // want `unnamed return with type "\[\]byte" found` `unnamed return with type "error" found`
+	return os.ReadFile(name)
+}
@@ -0,0 +1,19 @@
+package profile
+
+import "strconv"
+
+// =============================================================================
+// TESTING THE SETTINGS FOR TEST FILES
+// =============================================================================
+
+// Outside of test files, every function must name its results
+func count() int { // want `func count: unnamed return with type "int" found - named returns are required`
+	return 1
+}
+
+func parse(s string) (n int, err error) {
+	if n, err := strconv.Atoi(s); err == nil { // want `func parse: named return variable "n" is shadowed by local variable declaration` `func parse: named return variable "err" is shadowed by local variable declaration`
+		return n, err
+	}
+	return n, err
+}
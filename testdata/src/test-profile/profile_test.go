@@ -0,0 +1,30 @@
+package profile
+
+import (
+	"strconv"
+	"testing"
+)
+
+// In test files, only functions returning an error are checked
+func fixture() int {
+	return 1
+}
+
+func load(s string) error { // want `func load: unnamed return with type "error" found - named returns are required`
+	_, err := strconv.Atoi(s)
+	return err
+}
+
+// and shadowing isn't reported
+func parseFixture(s string) (n int, err error) {
+	if n, err := strconv.Atoi(s); err == nil {
+		return n, err
+	}
+	return n, err
+}
+
+func TestParse(t *testing.T) {
+	if n, err := parse("1"); n != 1 || err != nil {
+		t.Errorf("parse: %d, %v", n, err)
+	}
+}
@@ -0,0 +1,58 @@
+package unusednames
+
+import (
+	"errors"
+	"strconv"
+)
+
+// =============================================================================
+// GOOD EXAMPLES - These should NOT trigger any reports
+// =============================================================================
+
+// The names are assigned and returned
+func parse(s string) (n int, err error) {
+	n, err = strconv.Atoi(s)
+	return n, err
+}
+
+// A bare return uses the names
+func bare(s string) (n int, err error) {
+	n, err = strconv.Atoi(s)
+	return
+}
+
+// Using one of the names is enough
+func half(s string) (n int, err error) { // want `func half: named return variable "n" is declared but not used in return statement`
+	if s == "" {
+		err = errors.New("empty")
+	}
+	return 0, err
+}
+
+// A deferred function literal using the names counts
+func deferred() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.New("panicked")
+		}
+	}()
+	return nil
+}
+
+// =============================================================================
+// BAD EXAMPLES - These SHOULD trigger reports
+// =============================================================================
+
+// The names are never used
+func cargo(s string) (n int, err error) { // want `func cargo: named results are never used - assign and return them, or use a bare return` `func cargo: named return variable "n" is declared but not used in return statement` `func cargo: named return variable "err" is declared but not used in return statement`
+	return strconv.Atoi(s)
+}
+
+// A variable of the same name in a literal is a different one
+func literal() (err error) { // want `func literal: named results are never used - assign and return them, or use a bare return` `func literal: named return variable "err" is declared but not used in return statement`
+	describe := func(err error) (msg string) {
+		msg = "wrapped: " + err.Error()
+		return msg
+	}
+	return errors.New(describe(errors.New("failed")))
+}